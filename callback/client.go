@@ -0,0 +1,30 @@
+package callback
+
+import (
+	"sync"
+
+	"DomainC/cfclient"
+)
+
+var sharedClient struct {
+	mu     sync.RWMutex
+	client cfclient.Client
+}
+
+// SetCFClient 设置回调共用的 Cloudflare 客户端，启动时传入与命令处理器相同的实例。
+func SetCFClient(client cfclient.Client) {
+	sharedClient.mu.Lock()
+	sharedClient.client = client
+	sharedClient.mu.Unlock()
+}
+
+// cloudflareClient 返回共用客户端；未设置时新建一个。
+func cloudflareClient() cfclient.Client {
+	sharedClient.mu.RLock()
+	client := sharedClient.client
+	sharedClient.mu.RUnlock()
+	if client == nil {
+		return cfclient.NewClient()
+	}
+	return client
+}
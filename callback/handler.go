@@ -2,6 +2,7 @@ package callback
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
@@ -9,7 +10,6 @@ import (
 
 	"DomainC/cfclient"
 	"DomainC/config"
-	"DomainC/reminder"
 	"DomainC/telegram"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -62,6 +62,30 @@ func HandleCallback(cb *tgbotapi.CallbackQuery) {
 		handleCFRulesCallback(action, parts, user, cb)
 		return
 	}
+	if strings.HasPrefix(action, "wafevents_") {
+		handleWAFEventsCallback(action, parts, user, cb)
+		return
+	}
+	if strings.HasPrefix(action, "move_") {
+		handleMoveCallback(action, parts, user, cb)
+		return
+	}
+	if action == "dnsundo" {
+		handleDNSUndoCallback(parts, user, cb)
+		return
+	}
+	if strings.HasPrefix(action, "snapshot_") {
+		handleSnapshotCallback(action, parts, user, cb)
+		return
+	}
+	if strings.HasPrefix(action, "approval_") {
+		handleApprovalCallback(action, parts, user, cb)
+		return
+	}
+	if strings.HasPrefix(action, "abuse_") {
+		handleAbuseCallback(action, parts, user)
+		return
+	}
 	if len(parts) < 3 {
 		log.Printf("无效的回调数据: %s", callbackData)
 		return
@@ -77,8 +101,7 @@ func HandleCallback(cb *tgbotapi.CallbackQuery) {
 
 	log.Printf("处理回调: action=%s, account=%s, domain=%s, user=%s", action, accountLabel, domain, user.UserName)
 
-	// 创建统一的 Cloudflare 客户端实例
-	client := cfclient.NewClient()
+	client := cloudflareClient()
 
 	account := cfclient.GetAccountByLabel(accountLabel)
 	if account == nil {
@@ -99,10 +122,18 @@ func HandleCallback(cb *tgbotapi.CallbackQuery) {
 				failMsg = fmt.Sprintf("%s 解除禁用失败: %s --- %s (%%v)", user.UserName, domain, accountLabel)
 			}
 
-			err := client.PauseDomain(context.Background(), *account, domain, paused == "yes")
+			runClient, recorder := telegram.BeginDryRun(client, false)
+			err := runClient.PauseDomain(context.Background(), *account, domain, paused == "yes")
 			if err != nil {
 				telegram.SendTelegramAlert(fmt.Sprintf(failMsg, err))
+			} else if recorder != nil {
+				telegram.FinishDryRun(context.Background(), nil, recorder, "pause "+domain, successMsg)
 			} else {
+				operation := "unpause"
+				if paused == "yes" {
+					operation = "pause"
+				}
+				telegram.RecordOperation(telegram.OperationEntry{Operation: operation, Account: accountLabel, Zone: domain, Operator: user.UserName})
 				telegram.SendTelegramAlert(successMsg)
 			}
 		}()
@@ -151,15 +182,9 @@ func HandleCallback(cb *tgbotapi.CallbackQuery) {
 
 	case "delete_confirm":
 		go func() {
-			err := client.DeleteDomain(context.Background(), *account, domain)
-			if err != nil {
-				telegram.SendTelegramAlert(fmt.Sprintf("删除域名失败: %s --- %s (%v)", domain, accountLabel, err))
-				return
+			if err := telegram.ConfirmDeleteZone(context.Background(), client, telegram.DefaultSender(), *account, domain, user); err != nil {
+				telegram.SendTelegramAlert(fmt.Sprintf("发送审批请求失败: %v", err))
 			}
-			if rt := reminder.DefaultRuntime(); rt != nil {
-				rt.RecordDomainDeletion(context.Background(), domain, accountLabel)
-			}
-			telegram.SendTelegramAlert(fmt.Sprintf("✅ 删除域名成功: %s --- %s (操作人: %s)", domain, accountLabel, user.UserName))
 		}()
 
 	case "delete_cancel":
@@ -181,7 +206,7 @@ func handleIPListCallback(action string, parts []string, user *tgbotapi.User, cb
 	}
 
 	accountLabel := payload.AccountLabel
-	client := cfclient.NewClient()
+	client := cloudflareClient()
 	sender := telegram.DefaultSender()
 	switch action {
 	case "iplist_account":
@@ -199,35 +224,12 @@ func handleIPListCallback(action string, parts []string, user *tgbotapi.User, cb
 				return
 			}
 			if len(lists) == 0 {
-				telegram.SendTelegramAlert(fmt.Sprintf("账号 %s 暂无 IP 白名单。", accountLabel))
+				telegram.SendTelegramAlert(fmt.Sprintf("账号 %s 暂无 Custom List。", accountLabel))
 				return
 			}
 
-			var sb strings.Builder
-			sb.WriteString(fmt.Sprintf("【IP 白名单】\n账号: %s\n请选择要操作的名单，或直接进入全部 IP 删除选择：\n", accountLabel))
-
-			var buttons [][]telegram.Button
-			accountToken := telegram.SetIPListCallbackPayload(telegram.IPListCallbackPayload{
-				AccountLabel: accountLabel,
-			})
-			buttons = append(buttons, []telegram.Button{{
-				Text:         "删除IP（全部名单）",
-				CallbackData: fmt.Sprintf("iplist_delete_account|%s", accountToken),
-			}})
-			for i, list := range lists {
-				sb.WriteString(fmt.Sprintf("%d. %s (%d)\n", i+1, list.Name, list.NumItems))
-				listToken := telegram.SetIPListCallbackPayload(telegram.IPListCallbackPayload{
-					AccountLabel: accountLabel,
-					ListID:       list.ID,
-					ListName:     list.Name,
-				})
-				buttons = append(buttons, []telegram.Button{
-					{Text: "添加 " + list.Name, CallbackData: fmt.Sprintf("iplist_add|%s", listToken)},
-					{Text: "删除 " + list.Name, CallbackData: fmt.Sprintf("iplist_delete|%s", listToken)},
-				})
-			}
-
-			if err := sender.SendWithButtons(context.Background(), sb.String(), buttons); err != nil {
+			page := telegram.BuildIPListListSelector(accountLabel, lists)
+			if err := sender.SendWithButtons(context.Background(), page.Message, page.Buttons); err != nil {
 				log.Printf("发送 IP 白名单列表失败: %v", err)
 			}
 		}()
@@ -425,18 +427,68 @@ func handleIPListCallback(action string, parts []string, user *tgbotapi.User, cb
 		}
 		telegram.SendTelegramAlert(fmt.Sprintf("已取消 IP 删除选择（操作人: %s）", user.UserName))
 
+	case "iplist_sync_confirm":
+		plan, ok := telegram.TakeIPListSyncPlan(payload.SessionID)
+		if !ok {
+			telegram.SendTelegramAlert("名单同步计划已过期，请重新执行 /iplist sync。")
+			return
+		}
+		account := cfclient.GetAccountByLabel(plan.AccountLabel)
+		if account == nil {
+			telegram.SendTelegramAlert(fmt.Sprintf("操作失败：未找到账号 %s", plan.AccountLabel))
+			return
+		}
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: "✅ 已确认，后台同步中…", CallbackData: "noop"},
+			}})
+		}
+		go func() {
+			runClient, recorder := telegram.BeginDryRun(client, false)
+			summary := telegram.ProcessIPListSyncPlan(context.Background(), runClient, *account, plan)
+			if recorder != nil {
+				telegram.FinishDryRun(context.Background(), sender, recorder, "iplist sync "+plan.AccountLabel, summary)
+				return
+			}
+			telegram.SendTelegramAlert(fmt.Sprintf("%s\n操作人: %s", summary, user.UserName))
+		}()
+
+	case "iplist_sync_cancel":
+		telegram.ClearIPListSyncPlan(payload.SessionID)
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: "已取消", CallbackData: "noop"},
+			}})
+		}
+		telegram.SendTelegramAlert(fmt.Sprintf("已取消名单同步（操作人: %s）", user.UserName))
+
 	case "iplist_add":
-		listName := payload.ListName
-		if strings.TrimSpace(listName) == "" {
-			listName = payload.ListID
-		}
-		telegram.SetPendingIPListInput(user.ID, telegram.IPListInputRequest{
-			AccountLabel: accountLabel,
-			ListID:       payload.ListID,
-			ListName:     listName,
-			Action:       telegram.IPListActionAdd,
-		})
-		telegram.SendTelegramAlert(fmt.Sprintf("已选择白名单 %s（账号: %s）。\n请直接发送要添加的地址，每行一条，格式：IP 或 CIDR，备注可选。\n示例：\n1.2.3.4 办公网\n2407:cdc0:b010::/112 香港", listName, accountLabel))
+		go func() {
+			listName := payload.ListName
+			listKind := payload.ListKind
+			if strings.TrimSpace(listName) == "" || strings.TrimSpace(listKind) == "" {
+				if account := cfclient.GetAccountByLabel(accountLabel); account != nil {
+					if list, err := client.GetCustomList(context.Background(), *account, payload.ListID); err == nil {
+						if strings.TrimSpace(listName) == "" {
+							listName = list.Name
+						}
+						listKind = list.Kind
+					}
+				}
+			}
+			if strings.TrimSpace(listName) == "" {
+				listName = payload.ListID
+			}
+			req := telegram.IPListInputRequest{
+				AccountLabel: accountLabel,
+				ListID:       payload.ListID,
+				ListName:     listName,
+				ListKind:     listKind,
+				Action:       telegram.IPListActionAdd,
+			}
+			telegram.SetPendingIPListInput(user.ID, req)
+			telegram.SendTelegramAlert(telegram.BuildIPListInputPrompt(req))
+		}()
 	}
 }
 
@@ -459,7 +511,8 @@ func beginIPListDeleteSelection(client cfclient.Client, sender telegram.Sender,
 			return
 		}
 		for _, item := range listItems {
-			if item.ID == "" || item.IP == nil || strings.TrimSpace(*item.IP) == "" {
+			value := cfclient.CustomListItemValue(item)
+			if item.ID == "" || value == "" {
 				continue
 			}
 			key := list.ID + ":" + item.ID
@@ -469,7 +522,8 @@ func beginIPListDeleteSelection(client cfclient.Client, sender telegram.Sender,
 				ListID:       list.ID,
 				ListName:     list.Name,
 				ItemID:       item.ID,
-				IP:           *item.IP,
+				Kind:         list.Kind,
+				Value:        value,
 				Comment:      item.Comment,
 			})
 		}
@@ -480,13 +534,13 @@ func beginIPListDeleteSelection(client cfclient.Client, sender telegram.Sender,
 		if strings.TrimSpace(onlyListID) != "" {
 			scope = onlyListID
 		}
-		telegram.SendTelegramAlert(fmt.Sprintf("账号 %s 的 %s 暂无可删除 IP 记录。", accountLabel, scope))
+		telegram.SendTelegramAlert(fmt.Sprintf("账号 %s 的 %s 暂无可删除条目。", accountLabel, scope))
 		return
 	}
 
 	sort.Slice(items, func(i, j int) bool {
 		if items[i].ListName == items[j].ListName {
-			return items[i].IP < items[j].IP
+			return items[i].Value < items[j].Value
 		}
 		return items[i].ListName < items[j].ListName
 	})
@@ -612,6 +666,32 @@ func handleDeleteCommandCallback(action string, parts []string, user *tgbotapi.U
 		telegram.SendTelegramAlert(telegram.BuildDeleteInputPrompt(""))
 
 	case "deletecmd_confirm":
+		// 只读取一次 dry-run 开关，审批判断和执行共用同一个客户端。
+		client, recorder := telegram.BeginDryRun(cloudflareClient(), false)
+		if telegram.ApprovalRequired(telegram.ApprovalActionDelete, recorder) {
+			if cb.Message != nil {
+				_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+					{Text: "⏳ 等待第二人批准", CallbackData: "noop"},
+				}})
+			}
+			preview := payload.Domains
+			if len(preview) > 20 {
+				preview = preview[:20]
+			}
+			description := fmt.Sprintf("批量删除 %d 个域名: %s", len(payload.Domains), strings.Join(preview, ", "))
+			if len(payload.Domains) > len(preview) {
+				description += " 等"
+			}
+			_, err := telegram.RequestApproval(context.Background(), sender, telegram.ApprovalActionDelete, description, user, func(requester, approver string) {
+				result := telegram.ProcessDeleteBatch(client, config.Cfg().CloudflareAccounts, payload.Domains)
+				result.ParseErrors = append(result.ParseErrors, payload.ParseErrors...)
+				telegram.SendTelegramAlert(result.Summary() + "\n\n" + telegram.FormatApprovalFooter(requester, approver))
+			})
+			if err != nil {
+				telegram.SendTelegramAlert(fmt.Sprintf("发送审批请求失败: %v", err))
+			}
+			return
+		}
 		if cb.Message != nil {
 			_ = sender.EditButtons(context.Background(),
 				cb.Message.Chat.ID,
@@ -623,9 +703,9 @@ func handleDeleteCommandCallback(action string, parts []string, user *tgbotapi.U
 		}
 
 		go func() {
-			result := telegram.ProcessDeleteBatch(cfclient.NewClient(), config.Cfg.CloudflareAccounts, payload.Domains)
+			result := telegram.ProcessDeleteBatch(client, config.Cfg().CloudflareAccounts, payload.Domains)
 			result.ParseErrors = append(result.ParseErrors, payload.ParseErrors...)
-			telegram.SendTelegramAlert(result.Summary())
+			telegram.FinishDryRun(context.Background(), sender, recorder, fmt.Sprintf("delete %d domains", len(payload.Domains)), result.Summary())
 
 			if cb.Message != nil {
 				_ = sender.EditButtons(context.Background(),
@@ -799,7 +879,7 @@ func handleOriginSSLCallback(action string, parts []string, user *tgbotapi.User,
 		}
 		telegram.SendTelegramAlert(fmt.Sprintf("正在读取账号 %s 下所有域名，请稍候。", payload.AccountLabel))
 		go func() {
-			if err := telegram.BeginOriginSSLDomainSelection(context.Background(), cfclient.NewClient(), sender, *account); err != nil {
+			if err := telegram.BeginOriginSSLDomainSelection(context.Background(), cloudflareClient(), sender, *account); err != nil {
 				telegram.SendTelegramAlert(fmt.Sprintf("读取 /ssl 域名列表失败: %v", err))
 			}
 		}()
@@ -817,7 +897,7 @@ func handleOriginSSLCallback(action string, parts []string, user *tgbotapi.User,
 			}})
 		}
 		go func() {
-			if err := telegram.BeginOriginSSLDomainSelection(context.Background(), cfclient.NewClient(), sender, *account); err != nil {
+			if err := telegram.BeginOriginSSLDomainSelection(context.Background(), cloudflareClient(), sender, *account); err != nil {
 				telegram.SendTelegramAlert(fmt.Sprintf("读取 /ssl 域名列表失败: %v", err))
 			}
 		}()
@@ -854,7 +934,7 @@ func handleOriginSSLCallback(action string, parts []string, user *tgbotapi.User,
 			telegram.SendTelegramAlert("/ssl 域名选择已过期，请重新执行 /ssl。")
 			return
 		}
-		if len(config.Cfg.AWSTargets) > 0 {
+		if len(config.Cfg().AWSTargets) > 0 {
 			page := telegram.BuildOriginSSLAWSSelectionView(payload.SessionID, selection)
 			editOrSendPage(sender, cb, page)
 		} else {
@@ -929,7 +1009,7 @@ func handleOriginSSLCallback(action string, parts []string, user *tgbotapi.User,
 		awsAliases := sortedSelectedKeys(selection.AWSAliases)
 		blockCountries := append([]string(nil), selection.BlockCountries...)
 		go func() {
-			result := telegram.ProcessOriginSSLDomainItems(context.Background(), cfclient.NewClient(), *account, items, awsAliases, blockCountries)
+			result := telegram.ProcessOriginSSLDomainItems(context.Background(), cloudflareClient(), *account, items, awsAliases, blockCountries)
 			telegram.SendTelegramAlert(result.Summary())
 			telegram.SendOriginSSLInteractiveARNOutputs(sender, result)
 		}()
@@ -998,7 +1078,7 @@ func handleOriginSSLCallback(action string, parts []string, user *tgbotapi.User,
 			return
 		}
 		go func() {
-			result := telegram.ProcessOriginSSLDNSPlan(context.Background(), cfclient.NewClient(), *account, plan)
+			result := telegram.ProcessOriginSSLDNSPlan(context.Background(), cloudflareClient(), *account, plan)
 			telegram.ClearOriginSSLDNSPlan(payload.SessionID)
 			telegram.SendTelegramAlert(result.Summary())
 		}()
@@ -1173,7 +1253,7 @@ func handleCFRulesCallback(action string, parts []string, user *tgbotapi.User, c
 	}
 
 	sender := telegram.DefaultSender()
-	client := cfclient.NewClient()
+	client := cloudflareClient()
 	switch action {
 	case "cfrules_account":
 		account := cfclient.GetAccountByLabel(payload.AccountLabel)
@@ -1262,6 +1342,26 @@ func handleCFRulesCallback(action string, parts []string, user *tgbotapi.User, c
 		if feature == "" {
 			feature = "all"
 		}
+		if feature == "ratelimit" && runAction == "enable" {
+			if user == nil {
+				telegram.SendTelegramAlert("无法识别操作用户，不能继续输入限速参数。")
+				return
+			}
+			req := telegram.CFRulesInputRequest{
+				AccountLabel: payload.AccountLabel,
+				SessionID:    payload.SessionID,
+				Action:       runAction,
+				Feature:      feature,
+			}
+			telegram.SetPendingCFRulesInput(user.ID, req)
+			if cb.Message != nil {
+				_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+					{Text: "等待限速参数输入", CallbackData: "noop"},
+				}})
+			}
+			telegram.SendTelegramAlert(telegram.BuildCFRulesRateLimitPrompt(req, ""))
+			return
+		}
 		blockCountries := config.DefaultBlockCountries()
 		if telegram.CFRulesNeedsBlockCountries(runAction, feature) && len(blockCountries) == 0 {
 			if user == nil {
@@ -1289,9 +1389,10 @@ func handleCFRulesCallback(action string, parts []string, user *tgbotapi.User, c
 			}})
 		}
 		go func() {
-			result := telegram.ProcessCFRulesItems(context.Background(), client, *account, items, runAction, feature, blockCountries)
+			runClient, recorder := telegram.BeginDryRun(client, false)
+			result := telegram.ProcessCFRulesItems(context.Background(), runClient, *account, items, runAction, feature, blockCountries, cfclient.RateLimitRuleOptions{})
 			telegram.ClearCFRulesSelection(payload.SessionID)
-			telegram.SendTelegramAlert(result.Summary())
+			telegram.FinishDryRun(context.Background(), sender, recorder, fmt.Sprintf("cf_rules %s %s %s", account.Label, runAction, feature), result.Summary())
 		}()
 
 	case "cfrules_cancel":
@@ -1313,6 +1414,181 @@ func handleCFRulesCallback(action string, parts []string, user *tgbotapi.User, c
 	}
 }
 
+func handleWAFEventsCallback(action string, parts []string, user *tgbotapi.User, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 || action != "wafevents_block" {
+		log.Printf("invalid wafevents callback data: %v", parts)
+		return
+	}
+	payload, ok := telegram.GetWAFEventsCallbackPayload(parts[1])
+	if !ok {
+		telegram.SendTelegramAlert("WAF 事件按钮已过期，请重新执行 /waf_events。")
+		return
+	}
+	account := cfclient.GetAccountByLabel(payload.AccountLabel)
+	if account == nil {
+		telegram.SendTelegramAlert(fmt.Sprintf("操作失败：未找到账号 %s", payload.AccountLabel))
+		return
+	}
+	telegram.SendTelegramAlert(fmt.Sprintf("正在把 %s 加入账号 %s 下 %d 个域名的封禁规则，请稍候。", payload.Value, payload.AccountLabel, len(payload.ZoneIDs)))
+	operator := "unknown"
+	if user != nil {
+		operator = user.UserName
+	}
+	go func() {
+		telegram.SendTelegramAlert(telegram.ApplyWAFEventsBlock(context.Background(), cloudflareClient(), *account, payload, operator))
+	}()
+}
+
+func handleMoveCallback(action string, parts []string, user *tgbotapi.User, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		log.Printf("invalid move callback data: %v", parts)
+		return
+	}
+	token := parts[1]
+	operator := "unknown"
+	if user != nil {
+		operator = user.UserName
+	}
+	sender := telegram.DefaultSender()
+	markButtons := func(text string) {
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: text, CallbackData: "noop"},
+			}})
+		}
+	}
+	switch action {
+	case "move_confirm":
+		markButtons("迁移执行中…")
+		go telegram.ConfirmZoneMove(token, operator)
+	case "move_cancel":
+		markButtons("已取消")
+		if telegram.CancelZoneMove(token) {
+			telegram.SendTelegramAlert(fmt.Sprintf("已取消 Zone 迁移（操作人: %s）", operator))
+		}
+	case "move_delete_old":
+		markButtons("正在删除旧 Zone…")
+		go telegram.DeleteOldZoneAfterMove(token, user)
+	case "move_keep_old":
+		markButtons("已保留旧 Zone")
+		telegram.KeepOldZoneAfterMove(token, operator)
+	case "move_delete_target":
+		markButtons("正在删除新 Zone…")
+		go telegram.DeleteTargetZoneAfterMove(token, user)
+	case "move_keep_target":
+		markButtons("已保留新 Zone")
+		telegram.KeepTargetZoneAfterMove(token, operator)
+	}
+}
+
+func handleDNSUndoCallback(parts []string, user *tgbotapi.User, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		log.Printf("invalid dnsundo callback data: %v", parts)
+		return
+	}
+	operator := "unknown"
+	if user != nil {
+		operator = user.UserName
+	}
+	sender := telegram.DefaultSender()
+	if cb.Message != nil {
+		_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+			{Text: "撤销处理中…", CallbackData: "noop"},
+		}})
+	}
+	go func() {
+		client, recorder := telegram.BeginDryRun(cloudflareClient(), false)
+		msg := telegram.UndoDNSMutation(context.Background(), client, parts[1], operator)
+		if recorder != nil {
+			// dry-run 不占用撤销记录，恢复按钮保持可用。
+			telegram.FinishDryRun(context.Background(), sender, recorder, "dnsundo", msg)
+			if cb.Message != nil {
+				_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, telegram.DNSUndoButtons(parts[1]))
+			}
+			return
+		}
+		telegram.SendTelegramAlert(msg)
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: "已处理撤销", CallbackData: "noop"},
+			}})
+		}
+	}()
+}
+
+func handleSnapshotCallback(action string, parts []string, user *tgbotapi.User, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		log.Printf("invalid snapshot callback data: %v", parts)
+		return
+	}
+	token := parts[1]
+	operator := "unknown"
+	if user != nil {
+		operator = user.UserName
+	}
+	sender := telegram.DefaultSender()
+	markButtons := func(text string) {
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: text, CallbackData: "noop"},
+			}})
+		}
+	}
+	switch action {
+	case "snapshot_restore":
+		markButtons("恢复执行中…")
+		go telegram.ConfirmZoneRestore(token, operator)
+	case "snapshot_cancel":
+		markButtons("已取消")
+		if telegram.CancelZoneRestore(token) {
+			telegram.SendTelegramAlert(fmt.Sprintf("已取消快照恢复（操作人: %s）", operator))
+		}
+	}
+}
+
+func handleApprovalCallback(action string, parts []string, user *tgbotapi.User, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		log.Printf("invalid approval callback data: %v", parts)
+		return
+	}
+	operator := "unknown"
+	if user != nil {
+		operator = user.UserName
+	}
+	sender := telegram.DefaultSender()
+	markButtons := func(text string) {
+		if cb.Message != nil {
+			_ = sender.EditButtons(context.Background(), cb.Message.Chat.ID, cb.Message.MessageID, [][]telegram.Button{{
+				{Text: text, CallbackData: "noop"},
+			}})
+		}
+	}
+	switch action {
+	case "approval_approve":
+		item, err := telegram.ApproveApproval(parts[1], user)
+		if err != nil {
+			if errors.Is(err, telegram.ErrApprovalNotFound) {
+				markButtons("已失效")
+			}
+			telegram.SendTelegramAlert(fmt.Sprintf("批准失败（%s）: %v", operator, err))
+			return
+		}
+		markButtons("✅ 已批准，执行中…")
+		telegram.SendTelegramAlert(fmt.Sprintf("已批准 %s，开始执行。\n%s", item.Action, telegram.FormatApprovalFooter(item.Requester, item.Approver)))
+	case "approval_reject":
+		item, err := telegram.RejectApproval(parts[1], user)
+		if err != nil {
+			if errors.Is(err, telegram.ErrApprovalNotFound) {
+				markButtons("已失效")
+			}
+			telegram.SendTelegramAlert(fmt.Sprintf("拒绝失败（%s）: %v", operator, err))
+			return
+		}
+		markButtons("❌ 已拒绝")
+		telegram.SendTelegramAlert(fmt.Sprintf("已拒绝 %s（申请人: %s，操作人: %s）", item.Action, item.Requester, operator))
+	}
+}
+
 func renderCFRulesDomainSelection(sender telegram.Sender, cb *tgbotapi.CallbackQuery, sessionID string, selection telegram.CFRulesSelection) {
 	page := telegram.BuildCFRulesDomainSelectionView(sessionID, selection)
 	editOrSendPage(sender, cb, page)
@@ -1352,3 +1628,69 @@ func remainingStringItems(all []string, selected []string) []string {
 	}
 	return out
 }
+
+// handleAbuseCallback 处理滥用报告快捷按钮，回调数据格式：abuse_xxx|accountLabel|domain。
+func handleAbuseCallback(action string, parts []string, user *tgbotapi.User) {
+	if len(parts) < 3 {
+		log.Printf("无效的滥用报告回调数据: %v", parts)
+		return
+	}
+	accountLabel := parts[1]
+	domain := strings.ToLower(parts[2])
+	account := cfclient.GetAccountByLabel(accountLabel)
+	if account == nil {
+		telegram.SendTelegramAlert(fmt.Sprintf("操作失败：未找到账号 %s", accountLabel))
+		return
+	}
+	client := cloudflareClient()
+
+	switch action {
+	case "abuse_purge":
+		go func() {
+			ctx := context.Background()
+			zone, err := telegram.ResolveAccountZone(ctx, client, *account, domain)
+			if err != nil {
+				telegram.SendTelegramAlert(fmt.Sprintf("清理缓存失败: %s --- %s (%v)", domain, accountLabel, err))
+				return
+			}
+			successMsg := fmt.Sprintf("✅ 已清理缓存：%s (账号: %s，操作人: %s)", zone.Name, accountLabel, user.UserName)
+			runClient, recorder := telegram.BeginDryRun(client, false)
+			if err := runClient.PurgeZoneCache(ctx, *account, zone.ID); err != nil {
+				telegram.SendTelegramAlert(fmt.Sprintf("清理缓存失败: %s --- %s (%v)", zone.Name, accountLabel, err))
+				return
+			}
+			if recorder != nil {
+				telegram.FinishDryRun(ctx, nil, recorder, "cls "+zone.Name, successMsg)
+				return
+			}
+			telegram.RecordOperation(telegram.OperationEntry{Operation: "cls", Account: accountLabel, Zone: zone.Name, Operator: user.UserName, Detail: "滥用报告快捷处理"})
+			telegram.SendTelegramAlert(successMsg)
+		}()
+
+	case "abuse_pause":
+		zone, err := telegram.ResolveAccountZone(context.Background(), client, *account, domain)
+		if err != nil {
+			telegram.SendTelegramAlert(fmt.Sprintf("查询 %s 所属 Zone 失败: %v", domain, err))
+			return
+		}
+		// 暂停会让整个 Zone 停止代理，复用已有的 pause 回调做二次确认。
+		telegram.SendTelegramAlertWithButtons(
+			fmt.Sprintf("确认暂停 %s（账号 %s）？暂停后该 Zone 的流量将不再经过 Cloudflare 代理。", zone.Name, accountLabel),
+			[][]telegram.Button{{
+				{Text: "⏸ 确认暂停", CallbackData: fmt.Sprintf("pause|%s|%s|yes", accountLabel, zone.Name)},
+				{Text: "❌ 取消", CallbackData: "noop"},
+			}},
+		)
+
+	case "abuse_repoint":
+		prompt, err := telegram.BeginAbuseRepoint(context.Background(), client, *account, domain, user.ID)
+		if err != nil {
+			telegram.SendTelegramAlert(fmt.Sprintf("无法修改 %s 的解析: %v", domain, err))
+			return
+		}
+		telegram.SendTelegramAlert(prompt)
+
+	default:
+		log.Printf("未知的滥用报告回调: %s", action)
+	}
+}
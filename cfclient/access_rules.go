@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"DomainC/config"
 )
@@ -34,6 +35,23 @@ type accountIPAccessRule struct {
 		Target string `json:"target"`
 		Value  string `json:"value"`
 	} `json:"configuration"`
+	Scope struct {
+		Type string `json:"type"`
+	} `json:"scope"`
+	CreatedOn  time.Time `json:"created_on"`
+	ModifiedOn time.Time `json:"modified_on"`
+}
+
+// IPAccessRule 是 Zone/账号级 IP Access Rule 的展示视图，Scope 为 zone/account/organization。
+type IPAccessRule struct {
+	ID         string
+	Mode       string
+	Notes      string
+	Target     string
+	Value      string
+	Scope      string
+	CreatedOn  time.Time
+	ModifiedOn time.Time
 }
 
 func NormalizeIPAccessRuleValues(values []string) ([]string, error) {
@@ -244,6 +262,64 @@ func (c *apiClient) ClearAccountIPAccessRulesByNote(ctx context.Context, account
 	return result, nil
 }
 
+// ListAccountIPAccessRules 列出账号级全部 IP Access Rules（不限于机器人创建的规则）。
+func (c *apiClient) ListAccountIPAccessRules(ctx context.Context, account config.CF) ([]IPAccessRule, error) {
+	accountID, err := c.GetAccountID(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := c.listAccountIPAccessRules(ctx, account, accountID, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return toIPAccessRules(rules, "account"), nil
+}
+
+// ListZoneIPAccessRules 列出 Zone 的 IP Access Rules，结果包含从账号继承的规则，可按 Scope 区分。
+func (c *apiClient) ListZoneIPAccessRules(ctx context.Context, account config.CF, zoneID string) ([]IPAccessRule, error) {
+	if strings.TrimSpace(zoneID) == "" {
+		return nil, errors.New("zoneID is empty")
+	}
+	const perPage = 100
+	var out []accountIPAccessRule
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(perPage))
+		var rules []accountIPAccessRule
+		path := fmt.Sprintf("/zones/%s/firewall/access_rules/rules?%s", zoneID, q.Encode())
+		if err := c.Do(ctx, account, "GET", path, nil, &rules); err != nil {
+			return nil, err
+		}
+		out = append(out, rules...)
+		if len(rules) < perPage {
+			break
+		}
+	}
+	return toIPAccessRules(out, "zone"), nil
+}
+
+func toIPAccessRules(rules []accountIPAccessRule, defaultScope string) []IPAccessRule {
+	out := make([]IPAccessRule, 0, len(rules))
+	for _, rule := range rules {
+		scope := strings.ToLower(strings.TrimSpace(rule.Scope.Type))
+		if scope == "" {
+			scope = defaultScope
+		}
+		out = append(out, IPAccessRule{
+			ID:         rule.ID,
+			Mode:       rule.Mode,
+			Notes:      rule.Notes,
+			Target:     rule.Configuration.Target,
+			Value:      rule.Configuration.Value,
+			Scope:      scope,
+			CreatedOn:  rule.CreatedOn,
+			ModifiedOn: rule.ModifiedOn,
+		})
+	}
+	return out
+}
+
 func (c *apiClient) listAccountIPAccessRules(ctx context.Context, account config.CF, accountID string, query url.Values) ([]accountIPAccessRule, error) {
 	const perPage = 100
 	var out []accountIPAccessRule
@@ -359,8 +435,15 @@ func removeIPAccessRuleValues(existing []string, remove []string) []string {
 }
 
 func (c *apiClient) ensureFirewallCustomRuleByDescription(ctx context.Context, account config.CF, zoneID string, rulesetName string, description string, rule rulesetRule) (string, error) {
+	return c.ensureRulesetRuleByDescription(ctx, account, zoneID, firewallCustomPhase, rulesetName, description, rule, func(existing rulesetRule, want rulesetRule) bool {
+		return firewallRuleMatches(existing, want.Expression, want.Action, want.Enabled)
+	})
+}
+
+// ensureRulesetRuleByDescription 在指定 phase 的入口规则集中按描述幂等创建/更新规则，并清理同描述的重复规则。
+func (c *apiClient) ensureRulesetRuleByDescription(ctx context.Context, account config.CF, zoneID string, phase string, rulesetName string, description string, rule rulesetRule, matches func(existing rulesetRule, want rulesetRule) bool) (string, error) {
 	var entry rulesetEntryPoint
-	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, firewallCustomPhase)
+	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
 	err := c.Do(ctx, account, "GET", path, nil, &entry)
 	if err != nil {
 		var apiErr *CloudflareAPIError
@@ -368,7 +451,7 @@ func (c *apiClient) ensureFirewallCustomRuleByDescription(ctx context.Context, a
 			req := map[string]any{
 				"name":  rulesetName,
 				"kind":  "zone",
-				"phase": firewallCustomPhase,
+				"phase": phase,
 				"rules": []rulesetRule{rule},
 			}
 			if err := c.Do(ctx, account, "POST", fmt.Sprintf("/zones/%s/rulesets", zoneID), req, nil); err != nil {
@@ -399,7 +482,7 @@ func (c *apiClient) ensureFirewallCustomRuleByDescription(ctx context.Context, a
 			return "", errors.New("Cloudflare firewall rule id is empty")
 		}
 		status := statusAlreadyExists
-		if !firewallRuleMatches(target, rule.Expression, rule.Action, rule.Enabled) {
+		if !matches(target, rule) {
 			rule.ID = target.ID
 			updatePath := fmt.Sprintf("/zones/%s/rulesets/%s/rules/%s", zoneID, entry.ID, target.ID)
 			if err := c.Do(ctx, account, "PATCH", updatePath, rule, nil); err != nil {
@@ -426,6 +509,9 @@ func (c *apiClient) ensureFirewallCustomRuleByDescription(ctx context.Context, a
 }
 
 func (c *apiClient) verifyFirewallCustomRule(ctx context.Context, account config.CF, zoneID string, description string, expression string) error {
+	if c.dryRun() {
+		return nil
+	}
 	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, firewallCustomPhase)
 	var lastErr error
 	for attempt := 0; attempt < countryBlockVerifyAttempts; attempt++ {
@@ -0,0 +1,52 @@
+package cfclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestListZoneIPAccessRulesKeepsScopeAndCreatedOn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/zones/zone1/firewall/access_rules/rules" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+		if r.URL.Query().Get("page") != "1" {
+			t.Fatalf("unexpected page: %s", r.URL.RawQuery)
+		}
+		writeCFResponse(t, w, http.StatusOK, true, []map[string]any{
+			{
+				"id":            "r1",
+				"mode":          "block",
+				"notes":         "manual",
+				"configuration": map[string]any{"target": "ip", "value": "1.2.3.4"},
+				"scope":         map[string]any{"type": "account"},
+				"created_on":    "2024-05-01T10:00:00Z",
+			},
+			{
+				"id":            "r2",
+				"mode":          "challenge",
+				"configuration": map[string]any{"target": "country", "value": "CN"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	rules, err := client.ListZoneIPAccessRules(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "zone1")
+	if err != nil {
+		t.Fatalf("ListZoneIPAccessRules returned error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if rules[0].Scope != "account" || rules[0].Value != "1.2.3.4" || rules[0].CreatedOn.IsZero() {
+		t.Fatalf("unexpected first rule: %+v", rules[0])
+	}
+	if rules[1].Scope != "zone" || rules[1].Target != "country" {
+		t.Fatalf("unexpected second rule: %+v", rules[1])
+	}
+}
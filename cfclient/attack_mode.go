@@ -0,0 +1,100 @@
+package cfclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DomainC/config"
+)
+
+const (
+	securityLevelSetting     = "security_level"
+	SecurityLevelUnderAttack = "under_attack"
+)
+
+// AttackModePrevious 记录开启攻击模式前的原始设置，用于之后恢复。
+// BotFightMode 为 nil 表示本次没有修改 Bot Fight Mode。
+type AttackModePrevious struct {
+	SecurityLevel string `json:"security_level"`
+	BotFightMode  *bool  `json:"bot_fight_mode,omitempty"`
+}
+
+type botManagementSettings struct {
+	FightMode bool `json:"fight_mode"`
+}
+
+// GetZoneSecurityLevel 读取 Zone 当前的 security_level。
+func (c *apiClient) GetZoneSecurityLevel(ctx context.Context, account config.CF, zoneID string) (string, error) {
+	var raw map[string]any
+	if err := c.Do(ctx, account, http.MethodGet, fmt.Sprintf("/zones/%s/settings/%s", zoneID, securityLevelSetting), nil, &raw); err != nil {
+		return "", err
+	}
+	value, _ := raw["value"].(string)
+	return strings.TrimSpace(value), nil
+}
+
+// GetBotFightMode 读取 Zone 的 Bot Fight Mode 开关。
+func (c *apiClient) GetBotFightMode(ctx context.Context, account config.CF, zoneID string) (bool, error) {
+	var settings botManagementSettings
+	if err := c.Do(ctx, account, http.MethodGet, fmt.Sprintf("/zones/%s/bot_management", zoneID), nil, &settings); err != nil {
+		return false, err
+	}
+	return settings.FightMode, nil
+}
+
+// SetBotFightMode 开启或关闭 Zone 的 Bot Fight Mode。
+func (c *apiClient) SetBotFightMode(ctx context.Context, account config.CF, zoneID string, enabled bool) error {
+	return c.Do(ctx, account, http.MethodPut, fmt.Sprintf("/zones/%s/bot_management", zoneID), map[string]any{"fight_mode": enabled}, nil)
+}
+
+// EnableAttackMode 把 security_level 设为 under_attack，可选同时开启 Bot Fight Mode，返回修改前的设置。
+// security_level 切换失败时返回空的 AttackModePrevious；仅 Bot Fight Mode 失败时仍返回已读到的原值。
+func (c *apiClient) EnableAttackMode(ctx context.Context, account config.CF, zoneID string, botFight bool) (AttackModePrevious, error) {
+	var previous AttackModePrevious
+	level, err := c.GetZoneSecurityLevel(ctx, account, zoneID)
+	if err != nil {
+		return previous, fmt.Errorf("读取 security_level 失败: %w", err)
+	}
+	previous.SecurityLevel = level
+	if level != SecurityLevelUnderAttack {
+		if err := c.updateZoneSettingValueWithRetry(ctx, account, zoneID, securityLevelSetting, SecurityLevelUnderAttack); err != nil {
+			return AttackModePrevious{}, err
+		}
+	}
+	if !botFight {
+		return previous, nil
+	}
+	fightMode, err := c.GetBotFightMode(ctx, account, zoneID)
+	if err != nil {
+		return previous, fmt.Errorf("security_level 已切换，但读取 Bot Fight Mode 失败: %w", err)
+	}
+	previous.BotFightMode = &fightMode
+	if !fightMode {
+		if err := c.SetBotFightMode(ctx, account, zoneID, true); err != nil {
+			return previous, fmt.Errorf("security_level 已切换，但开启 Bot Fight Mode 失败: %w", err)
+		}
+	}
+	return previous, nil
+}
+
+// RestoreAttackMode 恢复 EnableAttackMode 记录的原始设置。
+func (c *apiClient) RestoreAttackMode(ctx context.Context, account config.CF, zoneID string, previous AttackModePrevious) error {
+	level := strings.TrimSpace(previous.SecurityLevel)
+	if level == "" || level == SecurityLevelUnderAttack {
+		// 开启前已经是 under_attack 或未读到原值时，不主动降级。
+		level = ""
+	}
+	if level != "" {
+		if err := c.updateZoneSettingValueWithRetry(ctx, account, zoneID, securityLevelSetting, level); err != nil {
+			return err
+		}
+	}
+	if previous.BotFightMode != nil && !*previous.BotFightMode {
+		if err := c.SetBotFightMode(ctx, account, zoneID, false); err != nil {
+			return fmt.Errorf("恢复 Bot Fight Mode 失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -117,7 +117,7 @@ type Client interface {
 	ListCustomLists(ctx context.Context, account config.CF) ([]cloudflare.List, error)
 	GetCustomList(ctx context.Context, account config.CF, listID string) (cloudflare.List, error)
 	ListCustomListItems(ctx context.Context, account config.CF, listID string) ([]cloudflare.ListItem, error)
-	CreateCustomListItem(ctx context.Context, account config.CF, listID string, ip string, comment string) ([]cloudflare.ListItem, error)
+	CreateCustomListItem(ctx context.Context, account config.CF, listID string, item cloudflare.ListItemCreateRequest) ([]cloudflare.ListItem, error)
 	DeleteCustomListItem(ctx context.Context, account config.CF, listID string, itemID string) ([]cloudflare.ListItem, error)
 	SetZoneSSLFullStrict(ctx context.Context, account config.CF, domain string) error
 	GetAbuseReportCount(ctx context.Context, account config.CF) (int, error)
@@ -129,6 +129,7 @@ type apiClient struct {
 	accountIDCache map[string]string
 	baseURL        string
 	httpClient     *http.Client
+	recorder       *DryRunRecorder
 }
 
 type abuseReportsResponse struct {
@@ -198,7 +199,7 @@ func NewClient() Client {
 	return &apiClient{
 		accountIDCache: make(map[string]string),
 		baseURL:        "https://api.cloudflare.com/client/v4",
-		httpClient:     http.DefaultClient,
+		httpClient:     &http.Client{Transport: defaultCloudflareTransport},
 	}
 }
 
@@ -257,7 +258,7 @@ func (c *apiClient) GetAbuseReportCount(ctx context.Context, account config.CF)
 		req.Header.Set("Authorization", "Bearer "+account.APIToken)
 		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := c.cloudflareHTTPClient().Do(req)
 		if err != nil {
 			lastErr = fmt.Errorf("查询滥用报告失败 [%s]: %v", account.Label, err)
 			continue
@@ -673,7 +674,7 @@ func (c *apiClient) GetAccountID(ctx context.Context, account config.CF) (string
 		return accountID, nil
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return "", fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -695,7 +696,7 @@ func (c *apiClient) CreateZone(ctx context.Context, account config.CF, domain st
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return ZoneDetail{}, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -803,7 +804,7 @@ func (c *apiClient) DeleteDNSRecord(ctx context.Context, account config.CF, doma
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return 0, fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -842,7 +843,7 @@ func (c *apiClient) PurgeZoneCache(ctx context.Context, account config.CF, zoneI
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -858,7 +859,7 @@ func (c *apiClient) GetZoneDetails(ctx context.Context, account config.CF, domai
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return ZoneDetail{}, fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -891,7 +892,7 @@ func (c *apiClient) DeleteDomain(ctx context.Context, account config.CF, domain
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -917,7 +918,7 @@ func (c *apiClient) PauseDomain(ctx context.Context, account config.CF, domain s
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -952,7 +953,7 @@ func (c *apiClient) ListDNSRecords(ctx context.Context, account config.CF, domai
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1041,7 +1042,7 @@ func (c *apiClient) UpsertDNSRecord(ctx context.Context, account config.CF, doma
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return cloudflare.DNSRecord{}, fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1131,7 +1132,7 @@ func (c *apiClient) UpdateDNSRecord(ctx context.Context, account config.CF, doma
 		return cloudflare.DNSRecord{}, errors.New("record content is empty")
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return cloudflare.DNSRecord{}, fmt.Errorf("初始化客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1168,7 +1169,7 @@ func (c *apiClient) FetchAllDomains(ctx context.Context, account config.CF) ([]D
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"初始化 Cloudflare 客户端失败 [%s]: %v",
@@ -1206,9 +1207,9 @@ func FetchAllDomains(account config.CF) ([]DomainInfo, error) {
 
 // GetAccountByLabel 返回配置中与 label 匹配的 Cloudflare 账号指针，找不到则返回 nil
 func GetAccountByLabel(label string) *config.CF {
-	for i := range config.Cfg.CloudflareAccounts {
-		if config.Cfg.CloudflareAccounts[i].Label == label {
-			return &config.Cfg.CloudflareAccounts[i]
+	for i := range config.Cfg().CloudflareAccounts {
+		if config.Cfg().CloudflareAccounts[i].Label == label {
+			return &config.Cfg().CloudflareAccounts[i]
 		}
 	}
 	return nil
@@ -1224,7 +1225,7 @@ func (c *apiClient) ListZones(ctx context.Context, account config.CF) ([]ZoneDet
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1281,7 +1282,7 @@ func (c *apiClient) ListZoneSummaries(ctx context.Context, account config.CF) ([
 		req.Header.Set("Authorization", "Bearer "+account.APIToken)
 		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := c.cloudflareHTTPClient().Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("列出 Zone 失败 [%s]: %v", account.Label, err)
 		}
@@ -1458,14 +1459,14 @@ func (c *apiClient) doCloudflareGraphQL(ctx context.Context, account config.CF,
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.cloudflare.com/client/v4/graphql", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cloudflareBaseURL()+"/graphql", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+account.APIToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.cloudflareHTTPClient().Do(req)
 	if err != nil {
 		return err
 	}
@@ -1537,7 +1538,7 @@ func (c *apiClient) updateZoneSettingValue(ctx context.Context, account config.C
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1567,7 +1568,7 @@ func (c *apiClient) CreateOriginCertificate(ctx context.Context, account config.
 		return OriginCert{}, fmt.Errorf("hostnames 不能为空")
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return OriginCert{}, fmt.Errorf(
 			"初始化 Cloudflare 客户端失败 [%s]: %v",
@@ -1663,7 +1664,7 @@ func (c *apiClient) SetZoneSSLFullStrict(ctx context.Context, account config.CF,
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1691,7 +1692,7 @@ func (c *apiClient) ListOriginCACertificates(ctx context.Context, account config
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1724,7 +1725,7 @@ func (c *apiClient) ListCustomLists(ctx context.Context, account config.CF) ([]c
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1741,7 +1742,7 @@ func (c *apiClient) ListCustomLists(ctx context.Context, account config.CF) ([]c
 
 	var out []cloudflare.List
 	for _, list := range lists {
-		if list.Kind == cloudflare.ListTypeIP {
+		if NormalizeCustomListKind(list.Kind) != "" {
 			out = append(out, list)
 		}
 	}
@@ -1752,7 +1753,7 @@ func (c *apiClient) GetCustomList(ctx context.Context, account config.CF, listID
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return cloudflare.List{}, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1776,7 +1777,7 @@ func (c *apiClient) ListCustomListItems(ctx context.Context, account config.CF,
 		return nil, errors.New("listID is empty")
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1796,19 +1797,18 @@ func (c *apiClient) ListCustomListItems(ctx context.Context, account config.CF,
 	return items, nil
 }
 
-func (c *apiClient) CreateCustomListItem(ctx context.Context, account config.CF, listID string, ip string, comment string) ([]cloudflare.ListItem, error) {
+func (c *apiClient) CreateCustomListItem(ctx context.Context, account config.CF, listID string, item cloudflare.ListItemCreateRequest) ([]cloudflare.ListItem, error) {
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
 	if strings.TrimSpace(listID) == "" {
 		return nil, errors.New("listID is empty")
 	}
-	ip = strings.TrimSpace(ip)
-	if ip == "" {
-		return nil, errors.New("ip is empty")
+	if err := validateCustomListCreateRequest(item); err != nil {
+		return nil, err
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -1819,11 +1819,8 @@ func (c *apiClient) CreateCustomListItem(ctx context.Context, account config.CF,
 	}
 
 	items, err := api.CreateListItem(ctx, cloudflare.AccountIdentifier(accountID), cloudflare.ListCreateItemParams{
-		ID: listID,
-		Item: cloudflare.ListItemCreateRequest{
-			IP:      &ip,
-			Comment: comment,
-		},
+		ID:   listID,
+		Item: item,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("添加 Custom List 条目失败 [%s]: %v", account.Label, err)
@@ -1842,7 +1839,7 @@ func (c *apiClient) DeleteCustomListItem(ctx context.Context, account config.CF,
 		return nil, errors.New("itemID is empty")
 	}
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.newCloudflareAPI(account)
 	if err != nil {
 		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
 	}
@@ -0,0 +1,575 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+// CustomListKinds 是 /iplist 支持的 Cloudflare Custom List 类型，顺序即展示顺序。
+var CustomListKinds = []string{
+	cloudflare.ListTypeIP,
+	cloudflare.ListTypeHostname,
+	cloudflare.ListTypeASN,
+	cloudflare.ListTypeRedirect,
+}
+
+var errCustomListValueEmpty = errors.New("条目不能为空")
+
+// NormalizeCustomListKind 把 Cloudflare 返回或用户输入的列表类型统一为小写常量，
+// 无法识别时返回空字符串。旧数据没有类型时按 IP 列表处理。
+func NormalizeCustomListKind(kind string) string {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", cloudflare.ListTypeIP:
+		return cloudflare.ListTypeIP
+	case cloudflare.ListTypeHostname, "host":
+		return cloudflare.ListTypeHostname
+	case cloudflare.ListTypeASN:
+		return cloudflare.ListTypeASN
+	case cloudflare.ListTypeRedirect:
+		return cloudflare.ListTypeRedirect
+	default:
+		return ""
+	}
+}
+
+// CustomListKindLabel 返回列表类型的中文展示名。
+func CustomListKindLabel(kind string) string {
+	switch NormalizeCustomListKind(kind) {
+	case cloudflare.ListTypeIP:
+		return "IP"
+	case cloudflare.ListTypeHostname:
+		return "主机名"
+	case cloudflare.ListTypeASN:
+		return "ASN"
+	case cloudflare.ListTypeRedirect:
+		return "重定向"
+	default:
+		return strings.TrimSpace(kind)
+	}
+}
+
+// CustomListItemValue 返回条目在所属列表类型下的展示值。
+func CustomListItemValue(item cloudflare.ListItem) string {
+	switch {
+	case item.IP != nil:
+		return strings.TrimSpace(*item.IP)
+	case item.Hostname != nil:
+		return strings.TrimSpace(item.Hostname.UrlHostname)
+	case item.ASN != nil:
+		return "AS" + strconv.FormatUint(uint64(*item.ASN), 10)
+	case item.Redirect != nil:
+		status := 301
+		if item.Redirect.StatusCode != nil {
+			status = *item.Redirect.StatusCode
+		}
+		return fmt.Sprintf("%s -> %s (%d)", strings.TrimSpace(item.Redirect.SourceUrl), strings.TrimSpace(item.Redirect.TargetUrl), status)
+	default:
+		return ""
+	}
+}
+
+// CustomListItemMatchKey 返回用于删除匹配的规范化值：IP 原样返回，由调用方展开 CIDR；
+// 主机名小写，ASN 为纯数字，重定向使用 CustomListRedirectKey。
+func CustomListItemMatchKey(item cloudflare.ListItem) string {
+	switch {
+	case item.IP != nil:
+		return strings.TrimSpace(*item.IP)
+	case item.Hostname != nil:
+		return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(item.Hostname.UrlHostname), "."))
+	case item.ASN != nil:
+		return strconv.FormatUint(uint64(*item.ASN), 10)
+	case item.Redirect != nil:
+		return CustomListRedirectKey(item.Redirect.SourceUrl)
+	default:
+		return ""
+	}
+}
+
+// CustomListRedirectKey 把重定向 source URL 规范为匹配键：去掉协议和末尾斜杠并转小写。
+func CustomListRedirectKey(source string) string {
+	value := strings.ToLower(strings.TrimSpace(source))
+	value = strings.TrimPrefix(value, "https://")
+	value = strings.TrimPrefix(value, "http://")
+	return strings.TrimSuffix(value, "/")
+}
+
+// ParseCustomListItem 按列表类型校验一行输入并生成创建请求。
+// IP/主机名/ASN 的格式为 “值 [备注]”；重定向的格式为 “source target [状态码] [备注]”。
+func ParseCustomListItem(kind string, input string) (cloudflare.ListItemCreateRequest, error) {
+	fields := strings.Fields(strings.TrimSpace(input))
+	if len(fields) == 0 {
+		return cloudflare.ListItemCreateRequest{}, errCustomListValueEmpty
+	}
+
+	switch NormalizeCustomListKind(kind) {
+	case cloudflare.ListTypeIP:
+		value, err := NormalizeCustomListIP(fields[0])
+		if err != nil {
+			return cloudflare.ListItemCreateRequest{}, err
+		}
+		return cloudflare.ListItemCreateRequest{IP: &value, Comment: joinComment(fields[1:])}, nil
+	case cloudflare.ListTypeHostname:
+		value, err := NormalizeCustomListHostname(fields[0])
+		if err != nil {
+			return cloudflare.ListItemCreateRequest{}, err
+		}
+		return cloudflare.ListItemCreateRequest{Hostname: &cloudflare.Hostname{UrlHostname: value}, Comment: joinComment(fields[1:])}, nil
+	case cloudflare.ListTypeASN:
+		value, err := NormalizeCustomListASN(fields[0])
+		if err != nil {
+			return cloudflare.ListItemCreateRequest{}, err
+		}
+		return cloudflare.ListItemCreateRequest{ASN: &value, Comment: joinComment(fields[1:])}, nil
+	case cloudflare.ListTypeRedirect:
+		return parseCustomListRedirect(fields)
+	default:
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("不支持的列表类型 %q", kind)
+	}
+}
+
+// CustomListCreateValue 返回创建请求对应的展示值，用于结果汇总。
+func CustomListCreateValue(item cloudflare.ListItemCreateRequest) string {
+	return CustomListItemValue(cloudflare.ListItem{
+		IP:       item.IP,
+		Hostname: item.Hostname,
+		ASN:      item.ASN,
+		Redirect: item.Redirect,
+	})
+}
+
+// CustomListCreateFromItem 把已有条目转换为创建请求，保留重定向选项和备注。
+func CustomListCreateFromItem(item cloudflare.ListItem) cloudflare.ListItemCreateRequest {
+	req := cloudflare.ListItemCreateRequest{IP: item.IP, ASN: item.ASN, Comment: item.Comment}
+	if item.Hostname != nil {
+		hostname := *item.Hostname
+		req.Hostname = &hostname
+	}
+	if item.Redirect != nil {
+		redirect := *item.Redirect
+		req.Redirect = &redirect
+	}
+	return req
+}
+
+// CustomListCreateMatchKey 返回创建请求对应的删除匹配键，规则同 CustomListItemMatchKey。
+func CustomListCreateMatchKey(item cloudflare.ListItemCreateRequest) string {
+	return CustomListItemMatchKey(cloudflare.ListItem{
+		IP:       item.IP,
+		Hostname: item.Hostname,
+		ASN:      item.ASN,
+		Redirect: item.Redirect,
+	})
+}
+
+// NormalizeCustomListIP 校验 IP 或 CIDR，CIDR 统一为网络地址形式。
+func NormalizeCustomListIP(input string) (string, error) {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return "", fmt.Errorf("IP 不能为空")
+	}
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", err
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return "", fmt.Errorf("IP 无法解析")
+	}
+	return ip.String(), nil
+}
+
+// NormalizeCustomListHostname 校验主机名语法，允许最左侧一个 “*.” 通配。
+func NormalizeCustomListHostname(input string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	value = strings.TrimSuffix(value, ".")
+	if value == "" {
+		return "", fmt.Errorf("主机名不能为空")
+	}
+	if strings.Contains(value, "://") || strings.ContainsAny(value, "/?#:@ ") {
+		return "", fmt.Errorf("主机名 %q 不能包含协议、端口或路径", input)
+	}
+	host := strings.TrimPrefix(value, "*.")
+	if len(host) > 253 {
+		return "", fmt.Errorf("主机名 %q 超过 253 个字符", input)
+	}
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%q 是 IP 地址，请使用 IP 列表", input)
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("主机名 %q 至少需要两级域名", input)
+	}
+	for _, label := range labels {
+		if !validHostnameLabel(label) {
+			return "", fmt.Errorf("主机名 %q 含非法标签 %q", input, label)
+		}
+	}
+	return value, nil
+}
+
+func validHostnameLabel(label string) bool {
+	if len(label) == 0 || len(label) > 63 {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeCustomListASN 接受 13335 或 AS13335 两种写法。
+func NormalizeCustomListASN(input string) (uint32, error) {
+	value := strings.TrimSpace(input)
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = value[2:]
+	}
+	if value == "" {
+		return 0, fmt.Errorf("ASN 不能为空")
+	}
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("ASN %q 必须是 1-4294967295 之间的整数", input)
+	}
+	if asn == 0 {
+		return 0, fmt.Errorf("ASN %q 必须是 1-4294967295 之间的整数", input)
+	}
+	return uint32(asn), nil
+}
+
+func parseCustomListRedirect(fields []string) (cloudflare.ListItemCreateRequest, error) {
+	if len(fields) < 2 {
+		return cloudflare.ListItemCreateRequest{}, fmt.Errorf("重定向格式为：source target [301|302|307|308] [备注]")
+	}
+	source, err := NormalizeCustomListRedirectSource(fields[0])
+	if err != nil {
+		return cloudflare.ListItemCreateRequest{}, err
+	}
+	target, err := normalizeRedirectTarget(fields[1])
+	if err != nil {
+		return cloudflare.ListItemCreateRequest{}, err
+	}
+
+	status := 301
+	rest := fields[2:]
+	if len(rest) > 0 {
+		if code, convErr := strconv.Atoi(rest[0]); convErr == nil {
+			switch code {
+			case 301, 302, 307, 308:
+				status = code
+			default:
+				return cloudflare.ListItemCreateRequest{}, fmt.Errorf("重定向状态码 %d 不支持，只能是 301/302/307/308", code)
+			}
+			rest = rest[1:]
+		}
+	}
+	return cloudflare.ListItemCreateRequest{
+		Redirect: &cloudflare.Redirect{
+			SourceUrl:  source,
+			TargetUrl:  target,
+			StatusCode: &status,
+		},
+		Comment: joinComment(rest),
+	}, nil
+}
+
+// NormalizeCustomListRedirectSource 校验 source URL：可省略协议，不能带查询串或锚点。
+func NormalizeCustomListRedirectSource(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", fmt.Errorf("source URL 不能为空")
+	}
+	if strings.ContainsAny(value, "?#") {
+		return "", fmt.Errorf("source URL %q 不能包含查询参数或锚点", raw)
+	}
+	probe := value
+	if !strings.Contains(probe, "://") {
+		probe = "https://" + probe
+	}
+	parsed, err := url.Parse(probe)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("source URL %q 无法解析", raw)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("source URL %q 只支持 http/https", raw)
+	}
+	if _, err := NormalizeCustomListHostname(parsed.Hostname()); err != nil {
+		return "", fmt.Errorf("source URL %q 主机名不合法: %v", raw, err)
+	}
+	return value, nil
+}
+
+func normalizeRedirectTarget(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("target URL %q 必须是完整的 http/https 地址", raw)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("target URL %q 必须是完整的 http/https 地址", raw)
+	}
+	return value, nil
+}
+
+func joinComment(fields []string) string {
+	return strings.TrimSpace(strings.Join(fields, " "))
+}
+
+// validateCustomListCreateRequest 确保创建请求恰好设置了一种条目值。
+func validateCustomListCreateRequest(item cloudflare.ListItemCreateRequest) error {
+	set := 0
+	if item.IP != nil {
+		if strings.TrimSpace(*item.IP) == "" {
+			return errors.New("ip is empty")
+		}
+		set++
+	}
+	if item.Hostname != nil {
+		if strings.TrimSpace(item.Hostname.UrlHostname) == "" {
+			return errors.New("hostname is empty")
+		}
+		set++
+	}
+	if item.ASN != nil {
+		if *item.ASN == 0 {
+			return errors.New("asn is empty")
+		}
+		set++
+	}
+	if item.Redirect != nil {
+		if strings.TrimSpace(item.Redirect.SourceUrl) == "" || strings.TrimSpace(item.Redirect.TargetUrl) == "" {
+			return errors.New("redirect source/target is empty")
+		}
+		set++
+	}
+	switch set {
+	case 0:
+		return errCustomListValueEmpty
+	case 1:
+		return nil
+	default:
+		return errors.New("list item must set exactly one of ip/hostname/asn/redirect")
+	}
+}
+
+const customListBulkTimeout = 10 * time.Minute
+
+// customListBulkPollInterval 是轮询批量操作状态的间隔，测试中会调小。
+var customListBulkPollInterval = 2 * time.Second
+
+// CustomListBulkResult 描述一次已完成的 Custom List 批量操作。
+type CustomListBulkResult struct {
+	OperationID string
+	Status      string
+	Items       int
+	Completed   *time.Time
+}
+
+type customListBulkOperationResult struct {
+	OperationID string `json:"operation_id"`
+}
+
+// AppendCustomListItems 通过异步批量接口一次性追加条目，并等待操作完成。
+func (c *apiClient) AppendCustomListItems(ctx context.Context, account config.CF, listID string, items []cloudflare.ListItemCreateRequest) (CustomListBulkResult, error) {
+	if len(items) == 0 {
+		return CustomListBulkResult{Status: "completed"}, nil
+	}
+	return c.runCustomListBulkOperation(ctx, account, listID, http.MethodPost, items)
+}
+
+// ReplaceCustomListItems 用给定条目整体替换名单内容，items 为空时清空名单。
+func (c *apiClient) ReplaceCustomListItems(ctx context.Context, account config.CF, listID string, items []cloudflare.ListItemCreateRequest) (CustomListBulkResult, error) {
+	if items == nil {
+		items = []cloudflare.ListItemCreateRequest{}
+	}
+	return c.runCustomListBulkOperation(ctx, account, listID, http.MethodPut, items)
+}
+
+func (c *apiClient) runCustomListBulkOperation(ctx context.Context, account config.CF, listID string, method string, items []cloudflare.ListItemCreateRequest) (CustomListBulkResult, error) {
+	if strings.TrimSpace(listID) == "" {
+		return CustomListBulkResult{}, errors.New("listID is empty")
+	}
+	for i, item := range items {
+		if err := validateCustomListCreateRequest(item); err != nil {
+			return CustomListBulkResult{}, fmt.Errorf("第 %d 条: %w", i+1, err)
+		}
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, customListBulkTimeout)
+		defer cancel()
+	}
+
+	accountID, err := c.GetAccountID(ctx, account)
+	if err != nil {
+		return CustomListBulkResult{}, err
+	}
+
+	var payload any = items
+	if method == http.MethodPut {
+		if payload, err = c.customListReplacePayload(ctx, account, accountID, listID, items); err != nil {
+			return CustomListBulkResult{}, err
+		}
+	}
+
+	path := fmt.Sprintf("/accounts/%s/rules/lists/%s/items", accountID, listID)
+	if c.dryRun() {
+		// dry-run 只记录批量写请求，没有真实的 operation_id 可轮询，直接视为已完成。
+		if err := c.Do(ctx, account, method, path, payload, nil); err != nil {
+			return CustomListBulkResult{}, fmt.Errorf("提交 Custom List 批量操作失败 [%s]: %w", account.Label, err)
+		}
+		now := time.Now().UTC()
+		return CustomListBulkResult{OperationID: "dryrun", Status: "completed", Items: len(items), Completed: &now}, nil
+	}
+
+	var started customListBulkOperationResult
+	if err := c.Do(ctx, account, method, path, payload, &started); err != nil {
+		return CustomListBulkResult{}, fmt.Errorf("提交 Custom List 批量操作失败 [%s]: %w", account.Label, err)
+	}
+	if strings.TrimSpace(started.OperationID) == "" {
+		return CustomListBulkResult{}, fmt.Errorf("提交 Custom List 批量操作失败 [%s]: 未返回 operation_id", account.Label)
+	}
+
+	op, err := c.WaitCustomListBulkOperation(ctx, account, started.OperationID)
+	if err != nil {
+		return CustomListBulkResult{OperationID: started.OperationID, Status: op.Status}, err
+	}
+	return CustomListBulkResult{
+		OperationID: started.OperationID,
+		Status:      op.Status,
+		Items:       len(items),
+		Completed:   op.Completed,
+	}, nil
+}
+
+// customListHostname 补上 cloudflare-go 的 Hostname 缺少的 exclude_exact_hostname 字段。
+type customListHostname struct {
+	UrlHostname          string `json:"url_hostname"`
+	ExcludeExactHostname *bool  `json:"exclude_exact_hostname,omitempty"`
+}
+
+// customListBulkItem 是整体替换时提交的条目，hostname 字段覆盖内嵌请求中的同名字段。
+type customListBulkItem struct {
+	cloudflare.ListItemCreateRequest
+	Hostname *customListHostname `json:"hostname,omitempty"`
+}
+
+// customListReplacePayload 生成整体替换的请求体：主机名条目沿用名单中已有的 exclude_exact_hostname，
+// 避免替换后丢失 SDK 读不到的选项。
+func (c *apiClient) customListReplacePayload(ctx context.Context, account config.CF, accountID string, listID string, items []cloudflare.ListItemCreateRequest) ([]customListBulkItem, error) {
+	payload := make([]customListBulkItem, 0, len(items))
+	var exclusions map[string]bool
+	for _, item := range items {
+		entry := customListBulkItem{ListItemCreateRequest: item}
+		if item.Hostname != nil {
+			if exclusions == nil {
+				var err error
+				if exclusions, err = c.customListHostnameExclusions(ctx, account, accountID, listID); err != nil {
+					return nil, err
+				}
+			}
+			entry.Hostname = &customListHostname{UrlHostname: item.Hostname.UrlHostname}
+			if exclude, ok := exclusions[CustomListCreateMatchKey(item)]; ok {
+				entry.Hostname.ExcludeExactHostname = &exclude
+			}
+		}
+		payload = append(payload, entry)
+	}
+	return payload, nil
+}
+
+// customListHostnameExclusions 按游标分页读取名单中设置了 exclude_exact_hostname 的主机名。
+func (c *apiClient) customListHostnameExclusions(ctx context.Context, account config.CF, accountID string, listID string) (map[string]bool, error) {
+	out := make(map[string]bool)
+	cursor := ""
+	for {
+		path := fmt.Sprintf("/accounts/%s/rules/lists/%s/items?per_page=500", accountID, listID)
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		envelope, err := c.doEnvelope(ctx, account, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Custom List 条目失败 [%s]: %w", account.Label, err)
+		}
+		var items []struct {
+			Hostname *customListHostname `json:"hostname"`
+		}
+		if len(envelope.Result) > 0 {
+			if err := json.Unmarshal(envelope.Result, &items); err != nil {
+				return nil, fmt.Errorf("解析 Custom List 条目失败 [%s]: %w", account.Label, err)
+			}
+		}
+		for _, item := range items {
+			if item.Hostname != nil && item.Hostname.ExcludeExactHostname != nil {
+				key := CustomListItemMatchKey(cloudflare.ListItem{Hostname: &cloudflare.Hostname{UrlHostname: item.Hostname.UrlHostname}})
+				out[key] = *item.Hostname.ExcludeExactHostname
+			}
+		}
+		var info struct {
+			Cursors struct {
+				After string `json:"after"`
+			} `json:"cursors"`
+		}
+		if len(envelope.ResultInfo) > 0 {
+			_ = json.Unmarshal(envelope.ResultInfo, &info)
+		}
+		if len(items) == 0 || strings.TrimSpace(info.Cursors.After) == "" {
+			return out, nil
+		}
+		cursor = info.Cursors.After
+	}
+}
+
+// WaitCustomListBulkOperation 轮询批量操作直到 completed 或 failed。
+func (c *apiClient) WaitCustomListBulkOperation(ctx context.Context, account config.CF, operationID string) (cloudflare.ListBulkOperation, error) {
+	accountID, err := c.GetAccountID(ctx, account)
+	if err != nil {
+		return cloudflare.ListBulkOperation{}, err
+	}
+
+	path := fmt.Sprintf("/accounts/%s/rules/lists/bulk_operations/%s", accountID, operationID)
+	for {
+		var op cloudflare.ListBulkOperation
+		if err := c.Do(ctx, account, http.MethodGet, path, nil, &op); err != nil {
+			return op, fmt.Errorf("查询 Custom List 批量操作 %s 失败 [%s]: %w", operationID, account.Label, err)
+		}
+		switch strings.ToLower(op.Status) {
+		case "completed":
+			return op, nil
+		case "failed":
+			msg := strings.TrimSpace(op.Error)
+			if msg == "" {
+				msg = "unknown error"
+			}
+			return op, fmt.Errorf("Custom List 批量操作 %s 失败 [%s]: %s", operationID, account.Label, msg)
+		}
+
+		timer := time.NewTimer(customListBulkPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return op, fmt.Errorf("等待 Custom List 批量操作 %s 超时 [%s]（最后状态 %s）: %w", operationID, account.Label, op.Status, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+func TestParseCustomListItemPerKind(t *testing.T) {
+	item, err := ParseCustomListItem(cloudflare.ListTypeIP, "10.0.0.7/24 办公网")
+	if err != nil || item.IP == nil || *item.IP != "10.0.0.0/24" || item.Comment != "办公网" {
+		t.Fatalf("ip item = %+v, err = %v", item, err)
+	}
+
+	item, err = ParseCustomListItem(cloudflare.ListTypeHostname, "*.CDN.Example.com.")
+	if err != nil || item.Hostname == nil || item.Hostname.UrlHostname != "*.cdn.example.com" {
+		t.Fatalf("hostname item = %+v, err = %v", item, err)
+	}
+
+	item, err = ParseCustomListItem(cloudflare.ListTypeASN, "AS13335 cloudflare")
+	if err != nil || item.ASN == nil || *item.ASN != 13335 {
+		t.Fatalf("asn item = %+v, err = %v", item, err)
+	}
+
+	item, err = ParseCustomListItem(cloudflare.ListTypeRedirect, "old.example.com/promo https://www.example.com/sale 302 活动")
+	if err != nil || item.Redirect == nil {
+		t.Fatalf("redirect item = %+v, err = %v", item, err)
+	}
+	if item.Redirect.StatusCode == nil || *item.Redirect.StatusCode != 302 || item.Comment != "活动" {
+		t.Fatalf("redirect status/comment = %+v", item)
+	}
+	if got := CustomListCreateMatchKey(item); got != "old.example.com/promo" {
+		t.Fatalf("redirect match key = %q", got)
+	}
+}
+
+func TestParseCustomListItemRejectsInvalidValues(t *testing.T) {
+	cases := []struct {
+		kind  string
+		input string
+	}{
+		{cloudflare.ListTypeIP, "example.com"},
+		{cloudflare.ListTypeHostname, "-bad.example.com"},
+		{cloudflare.ListTypeHostname, "example.com:8080"},
+		{cloudflare.ListTypeHostname, "localhost"},
+		{cloudflare.ListTypeASN, "AS0"},
+		{cloudflare.ListTypeASN, "AS99999999999"},
+		{cloudflare.ListTypeRedirect, "example.com/a"},
+		{cloudflare.ListTypeRedirect, "example.com/a?x=1 https://example.net"},
+		{cloudflare.ListTypeRedirect, "example.com/a example.net/b"},
+		{cloudflare.ListTypeRedirect, "example.com/a https://example.net 303"},
+	}
+	for _, tc := range cases {
+		if _, err := ParseCustomListItem(tc.kind, tc.input); err == nil {
+			t.Errorf("ParseCustomListItem(%q, %q) expected error", tc.kind, tc.input)
+		}
+	}
+}
+
+func TestReplaceCustomListItemsPollsUntilCompleted(t *testing.T) {
+	oldInterval := customListBulkPollInterval
+	customListBulkPollInterval = time.Millisecond
+	defer func() { customListBulkPollInterval = oldInterval }()
+
+	polls := 0
+	var replaced []cloudflare.ListItemCreateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/accounts/acct/rules/lists/list1/items":
+			if err := json.NewDecoder(r.Body).Decode(&replaced); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"operation_id": "op1"})
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts/acct/rules/lists/bulk_operations/op1":
+			polls++
+			status := "running"
+			if polls >= 3 {
+				status = "completed"
+			}
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "op1", "status": status})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	ip := "1.2.3.4"
+	result, err := client.ReplaceCustomListItems(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "list1", []cloudflare.ListItemCreateRequest{{IP: &ip}})
+	if err != nil {
+		t.Fatalf("ReplaceCustomListItems returned error: %v", err)
+	}
+	if result.OperationID != "op1" || result.Status != "completed" || result.Items != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if polls != 3 {
+		t.Fatalf("polls = %d, want 3", polls)
+	}
+	if len(replaced) != 1 || replaced[0].IP == nil || *replaced[0].IP != ip {
+		t.Fatalf("unexpected replace body: %+v", replaced)
+	}
+}
+
+func TestAppendCustomListItemsReportsFailedOperation(t *testing.T) {
+	oldInterval := customListBulkPollInterval
+	customListBulkPollInterval = time.Millisecond
+	defer func() { customListBulkPollInterval = oldInterval }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/accounts/acct/rules/lists/list1/items":
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"operation_id": "op2"})
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts/acct/rules/lists/bulk_operations/op2":
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "op2", "status": "failed", "error": "duplicate item"})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	asn := uint32(13335)
+	_, err := client.AppendCustomListItems(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "list1", []cloudflare.ListItemCreateRequest{{ASN: &asn}})
+	if err == nil || !strings.Contains(err.Error(), "duplicate item") {
+		t.Fatalf("expected failed operation error, got %v", err)
+	}
+}
+
+func TestCustomListBulkOperationsUnderDryRunRecordOnceWithoutPolling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("request reached Cloudflare in dry-run: %s %s", r.Method, r.URL.String())
+	}))
+	defer server.Close()
+
+	recorder := NewDryRunRecorder()
+	recorder.base = server.Client().Transport
+	client := &apiClient{
+		accountIDCache: make(map[string]string),
+		baseURL:        server.URL,
+		httpClient:     &http.Client{Transport: recorder},
+		recorder:       recorder,
+	}
+	account := config.CF{Label: "main", APIToken: "secret", AccountID: "acct"}
+	ip1, ip2 := "1.2.3.4", "5.6.7.8"
+	items := []cloudflare.ListItemCreateRequest{{IP: &ip1}, {IP: &ip2}}
+
+	appended, err := client.AppendCustomListItems(context.Background(), account, "list1", items)
+	if err != nil {
+		t.Fatalf("AppendCustomListItems returned error: %v", err)
+	}
+	replaced, err := client.ReplaceCustomListItems(context.Background(), account, "list1", items[:1])
+	if err != nil {
+		t.Fatalf("ReplaceCustomListItems returned error: %v", err)
+	}
+	if appended.Status != "completed" || appended.Items != 2 || appended.Completed == nil || replaced.Status != "completed" || replaced.Items != 1 {
+		t.Fatalf("unexpected synthetic results: %+v %+v", appended, replaced)
+	}
+
+	plan := recorder.Plan("test")
+	if plan.Reads != 0 || len(plan.Writes) != 2 {
+		t.Fatalf("expected exactly one write per bulk operation and no polling, got %+v", plan)
+	}
+	if plan.Writes[0].Method != http.MethodPost || plan.Writes[1].Method != http.MethodPut || plan.Writes[1].Path != "/accounts/acct/rules/lists/list1/items" {
+		t.Fatalf("unexpected writes: %+v", plan.Writes)
+	}
+	var body []cloudflare.ListItemCreateRequest
+	if err := json.Unmarshal(plan.Writes[0].Body, &body); err != nil || len(body) != 2 {
+		t.Fatalf("append body not recorded: %s (%v)", plan.Writes[0].Body, err)
+	}
+}
+
+func TestReplaceCustomListItemsKeepsHostnameExclusions(t *testing.T) {
+	oldInterval := customListBulkPollInterval
+	customListBulkPollInterval = time.Millisecond
+	defer func() { customListBulkPollInterval = oldInterval }()
+
+	var replaced []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts/acct/rules/lists/list1/items":
+			if r.URL.Query().Get("cursor") == "" {
+				writeCFResponseWithInfo(t, w, []map[string]any{
+					{"id": "1", "hostname": map[string]any{"url_hostname": "a.example.com", "exclude_exact_hostname": true}},
+				}, map[string]any{"cursors": map[string]any{"after": "next"}})
+				return
+			}
+			writeCFResponseWithInfo(t, w, []map[string]any{
+				{"id": "2", "hostname": map[string]any{"url_hostname": "b.example.com", "exclude_exact_hostname": false}},
+			}, map[string]any{"cursors": map[string]any{}})
+		case r.Method == http.MethodPut && r.URL.Path == "/accounts/acct/rules/lists/list1/items":
+			if err := json.NewDecoder(r.Body).Decode(&replaced); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"operation_id": "op3"})
+		case r.Method == http.MethodGet && r.URL.Path == "/accounts/acct/rules/lists/bulk_operations/op3":
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "op3", "status": "completed"})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	items := []cloudflare.ListItemCreateRequest{
+		{Hostname: &cloudflare.Hostname{UrlHostname: "a.example.com"}},
+		{Hostname: &cloudflare.Hostname{UrlHostname: "b.example.com"}},
+		{Hostname: &cloudflare.Hostname{UrlHostname: "c.example.com"}},
+	}
+	if _, err := client.ReplaceCustomListItems(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "list1", items); err != nil {
+		t.Fatalf("ReplaceCustomListItems returned error: %v", err)
+	}
+	if len(replaced) != 3 {
+		t.Fatalf("unexpected replace body: %+v", replaced)
+	}
+	want := []any{true, false, nil}
+	for i, item := range replaced {
+		hostname, _ := item["hostname"].(map[string]any)
+		if hostname["exclude_exact_hostname"] != want[i] {
+			t.Fatalf("item %d: exclude_exact_hostname = %v, want %v (%+v)", i, hostname["exclude_exact_hostname"], want[i], item)
+		}
+	}
+}
+
+func writeCFResponseWithInfo(t *testing.T, w http.ResponseWriter, result any, info any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cfAPIEnvelope{Success: true, Result: mustJSONRaw(t, result), ResultInfo: mustJSONRaw(t, info)})
+}
@@ -0,0 +1,58 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DomainC/config"
+)
+
+// ListZoneDNSRecords 按 zone_id 列出全部 DNS 记录。
+func (c *apiClient) ListZoneDNSRecords(ctx context.Context, account config.CF, zoneID string) ([]SnapshotDNSRecord, error) {
+	if strings.TrimSpace(zoneID) == "" {
+		return nil, errors.New("zoneID is empty")
+	}
+	return c.listSnapshotDNSRecords(ctx, account, zoneID)
+}
+
+// CreateZoneDNSRecord 按记录原样（类型、名称、内容、TTL、代理、优先级、备注）在 zoneID 中创建。
+func (c *apiClient) CreateZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, record SnapshotDNSRecord) (SnapshotDNSRecord, error) {
+	if strings.TrimSpace(zoneID) == "" {
+		return SnapshotDNSRecord{}, errors.New("zoneID is empty")
+	}
+	var out SnapshotDNSRecord
+	if err := c.Do(ctx, account, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", zoneID), snapshotDNSRecordBody(record), &out); err != nil {
+		return SnapshotDNSRecord{}, err
+	}
+	return out, nil
+}
+
+// PatchZoneDNSRecord 把 recordID 覆盖为 record 描述的内容、TTL 和代理状态。
+func (c *apiClient) PatchZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, recordID string, record SnapshotDNSRecord) (SnapshotDNSRecord, error) {
+	if strings.TrimSpace(zoneID) == "" || strings.TrimSpace(recordID) == "" {
+		return SnapshotDNSRecord{}, errors.New("zoneID or recordID is empty")
+	}
+	var out SnapshotDNSRecord
+	if err := c.Do(ctx, account, http.MethodPatch, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, recordID), snapshotDNSRecordBody(record), &out); err != nil {
+		return SnapshotDNSRecord{}, err
+	}
+	return out, nil
+}
+
+// SameDNSRecordState 判断两条记录的内容、TTL 和代理状态是否一致，用于撤销前确认记录未被再次修改。
+func SameDNSRecordState(a, b SnapshotDNSRecord) bool {
+	if !strings.EqualFold(a.Type, b.Type) || !strings.EqualFold(strings.TrimSuffix(a.Name, "."), strings.TrimSuffix(b.Name, ".")) {
+		return false
+	}
+	if a.Content != b.Content || a.TTL != b.TTL {
+		return false
+	}
+	return boolValue(a.Proxied) == boolValue(b.Proxied)
+}
+
+func boolValue(v *bool) bool {
+	return v != nil && *v
+}
@@ -0,0 +1,50 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestPatchAndCreateZoneDNSRecordSendPriorState(t *testing.T) {
+	var patched, created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/zones/zone1/dns_records/rec1":
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "rec1", "type": "A", "name": "www.example.com", "content": "203.0.113.1", "ttl": 300, "proxied": false})
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone1/dns_records":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "rec2", "type": "CNAME", "name": "cdn.example.com", "content": "origin.example.net", "ttl": 1})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	account := config.CF{Label: "main", APIToken: "secret"}
+	proxied := false
+	restored, err := client.PatchZoneDNSRecord(context.Background(), account, "zone1", "rec1", SnapshotDNSRecord{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 300, Proxied: &proxied})
+	if err != nil {
+		t.Fatalf("PatchZoneDNSRecord returned error: %v", err)
+	}
+	if patched["content"] != "203.0.113.1" || patched["proxied"] != false || patched["ttl"] != float64(300) {
+		t.Fatalf("unexpected patch body: %+v", patched)
+	}
+	if !SameDNSRecordState(restored, SnapshotDNSRecord{Type: "A", Name: "www.example.com.", Content: "203.0.113.1", TTL: 300}) {
+		t.Fatalf("expected restored record to match prior state: %+v", restored)
+	}
+
+	recreated, err := client.CreateZoneDNSRecord(context.Background(), account, "zone1", SnapshotDNSRecord{ID: "old", Type: "CNAME", Name: "cdn.example.com", Content: "origin.example.net", TTL: 1})
+	if err != nil {
+		t.Fatalf("CreateZoneDNSRecord returned error: %v", err)
+	}
+	if created["id"] != nil || created["content"] != "origin.example.net" || recreated.ID != "rec2" {
+		t.Fatalf("unexpected create body=%+v result=%+v", created, recreated)
+	}
+}
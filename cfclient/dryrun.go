@@ -0,0 +1,244 @@
+package cfclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// PlannedWrite 是 dry-run 期间被拦截的一次写请求。
+type PlannedWrite struct {
+	Seq     int             `json:"seq"`
+	At      time.Time       `json:"at"`
+	Account string          `json:"account,omitempty"`
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// DryRunPlan 是写入计划文件的内容。
+type DryRunPlan struct {
+	Title     string         `json:"title"`
+	Summary   string         `json:"summary,omitempty"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+	Reads     int            `json:"reads"`
+	Writes    []PlannedWrite `json:"writes"`
+}
+
+// DryRunRecorder 放行读请求（GET/HEAD 以及 GraphQL 查询），记录其余写请求并返回伪造的成功响应。
+type DryRunRecorder struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	reads     int
+	writes    []PlannedWrite
+	base      http.RoundTripper
+}
+
+func NewDryRunRecorder() *DryRunRecorder {
+	return &DryRunRecorder{startedAt: time.Now().UTC()}
+}
+
+// NewDryRunClient 返回与 NewClient 相同实现的客户端，但所有写请求只会被 recorder 记录。
+func NewDryRunClient(recorder *DryRunRecorder) Client {
+	return &apiClient{
+		accountIDCache: make(map[string]string),
+		baseURL:        cfAPIBaseURL,
+		httpClient:     &http.Client{Transport: recorder},
+		recorder:       recorder,
+	}
+}
+
+// WithDryRun 返回 client 的 dry-run 副本：沿用 client 的 baseURL 和传输层（含指标统计），写请求交给 recorder 记录。
+// client 不是 NewClient 创建的实现时退回 NewDryRunClient。
+func WithDryRun(client Client, recorder *DryRunRecorder) Client {
+	c, ok := client.(*apiClient)
+	if !ok {
+		return NewDryRunClient(recorder)
+	}
+	httpClient := &http.Client{Transport: recorder}
+	if c.httpClient != nil {
+		recorder.base = c.httpClient.Transport
+		httpClient.Timeout = c.httpClient.Timeout
+	}
+	c.accountIDMu.RLock()
+	cache := make(map[string]string, len(c.accountIDCache))
+	for label, id := range c.accountIDCache {
+		cache[label] = id
+	}
+	c.accountIDMu.RUnlock()
+	return &apiClient{
+		accountIDCache: cache,
+		baseURL:        c.baseURL,
+		httpClient:     httpClient,
+		recorder:       recorder,
+	}
+}
+
+// DryRunRecorderOf 返回客户端关联的 recorder；非 dry-run 客户端返回 nil, false。
+func DryRunRecorderOf(client any) (*DryRunRecorder, bool) {
+	c, ok := client.(*apiClient)
+	if !ok || c.recorder == nil {
+		return nil, false
+	}
+	return c.recorder, true
+}
+
+func (r *DryRunRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isDryRunRead(req) {
+		r.mu.Lock()
+		r.reads++
+		r.mu.Unlock()
+		base := r.base
+		if base == nil {
+			base = defaultCloudflareTransport
+		}
+		return base.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	r.mu.Lock()
+	seq := len(r.writes) + 1
+	write := PlannedWrite{
+		Seq:     seq,
+		At:      time.Now().UTC(),
+		Account: dryRunAccountLabel(req.Header.Get("Authorization")),
+		Method:  req.Method,
+		Path:    dryRunPath(req),
+	}
+	if json.Valid(body) {
+		write.Body = json.RawMessage(body)
+	} else if len(body) > 0 {
+		quoted, _ := json.Marshal(string(body))
+		write.Body = quoted
+	}
+	r.writes = append(r.writes, write)
+	r.mu.Unlock()
+
+	envelope := map[string]any{
+		"success":  true,
+		"errors":   []any{},
+		"messages": []any{},
+		"result":   dryRunResult(req.Method, req.URL.Path, body, seq),
+	}
+	data, _ := json.Marshal(envelope)
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}, nil
+}
+
+// Writes 返回目前记录的写请求副本。
+func (r *DryRunRecorder) Writes() []PlannedWrite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlannedWrite(nil), r.writes...)
+}
+
+// Plan 生成计划内容。
+func (r *DryRunRecorder) Plan(title string) DryRunPlan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return DryRunPlan{
+		Title:     title,
+		StartedAt: r.startedAt,
+		EndedAt:   time.Now().UTC(),
+		Reads:     r.reads,
+		Writes:    append([]PlannedWrite{}, r.writes...),
+	}
+}
+
+func isDryRunRead(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		// GraphQL Analytics 只有查询，没有写操作。
+		return strings.HasSuffix(strings.TrimRight(req.URL.Path, "/"), "/graphql")
+	}
+	return false
+}
+
+// dryRunResult 伪造写请求的 result：对象请求体原样回显并补上 id，其余返回只含 id 的对象。
+func dryRunResult(method, urlPath string, body []byte, seq int) any {
+	id := fmt.Sprintf("dryrun-%d", seq)
+	if method == http.MethodDelete {
+		return map[string]any{"id": path.Base(urlPath)}
+	}
+	var obj map[string]any
+	if len(body) > 0 && json.Unmarshal(body, &obj) == nil {
+		if _, ok := obj["id"]; !ok {
+			obj["id"] = id
+		}
+		return obj
+	}
+	var arr []any
+	if len(body) > 0 && json.Unmarshal(body, &arr) == nil {
+		return arr
+	}
+	return map[string]any{"id": id}
+}
+
+func dryRunPath(req *http.Request) string {
+	p := req.URL.Path
+	if idx := strings.Index(p, "/client/v4"); idx >= 0 {
+		p = p[idx+len("/client/v4"):]
+	}
+	if req.URL.RawQuery != "" {
+		p += "?" + req.URL.RawQuery
+	}
+	return p
+}
+
+// dryRunAccountLabel 把 Authorization 中的 token 映射回账号标签，计划文件里不出现 token。
+func dryRunAccountLabel(auth string) string {
+	token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	if token == "" {
+		return ""
+	}
+	for _, acc := range config.Cfg().CloudflareAccounts {
+		if acc.APIToken == token {
+			return acc.Label
+		}
+	}
+	return "unknown"
+}
+
+// newCloudflareAPI 创建 cloudflare-go 客户端，并复用 c 的 HTTP 客户端，使 dry-run/测试也能拦截 SDK 请求。
+func (c *apiClient) newCloudflareAPI(account config.CF) (*cloudflare.API, error) {
+	if c.httpClient != nil && c.httpClient != http.DefaultClient {
+		return cloudflare.NewWithAPIToken(account.APIToken, cloudflare.HTTPClient(c.httpClient), cloudflare.UsingLogger(sdkRetryLogger{}))
+	}
+	return cloudflare.NewWithAPIToken(account.APIToken, cloudflare.UsingLogger(sdkRetryLogger{}))
+}
+
+// dryRun 表示写请求不会真正执行，写后校验需要跳过。
+func (c *apiClient) dryRun() bool {
+	return c.recorder != nil
+}
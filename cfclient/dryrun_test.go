@@ -0,0 +1,111 @@
+package cfclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestDryRunClientPassesReadsAndRecordsWrites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("write reached Cloudflare in dry-run: %s %s", r.Method, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/zones/zone1/dns_records":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{
+				{"id": "rec1", "type": "A", "name": "www.example.com", "content": "203.0.113.1", "ttl": 1},
+			})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	prev := *config.Cfg()
+	defer config.Set(prev)
+	cfg := prev
+	cfg.CloudflareAccounts = []config.CF{{Label: "main", APIToken: "secret"}}
+	config.Set(cfg)
+
+	recorder := NewDryRunRecorder()
+	recorder.base = server.Client().Transport
+	client := &apiClient{
+		accountIDCache: make(map[string]string),
+		baseURL:        server.URL,
+		httpClient:     &http.Client{Transport: recorder},
+		recorder:       recorder,
+	}
+	account := config.CF{Label: "main", APIToken: "secret"}
+
+	records, err := client.ListZoneDNSRecords(context.Background(), account, "zone1")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("expected read to pass through, records=%+v err=%v", records, err)
+	}
+	created, err := client.CreateZoneDNSRecord(context.Background(), account, "zone1", SnapshotDNSRecord{Type: "A", Name: "api.example.com", Content: "203.0.113.9", TTL: 1})
+	if err != nil {
+		t.Fatalf("CreateZoneDNSRecord returned error: %v", err)
+	}
+	if created.ID != "dryrun-1" || created.Content != "203.0.113.9" {
+		t.Fatalf("unexpected synthetic result: %+v", created)
+	}
+	if err := client.DeleteZoneByID(context.Background(), account, "zone1"); err != nil {
+		t.Fatalf("DeleteZoneByID returned error: %v", err)
+	}
+
+	plan := recorder.Plan("test")
+	if plan.Reads != 1 || len(plan.Writes) != 2 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan.Writes[0].Method != http.MethodPost || plan.Writes[0].Path != "/zones/zone1/dns_records" || plan.Writes[0].Account != "main" {
+		t.Fatalf("unexpected first write: %+v", plan.Writes[0])
+	}
+	if plan.Writes[1].Method != http.MethodDelete || plan.Writes[1].Path != "/zones/zone1" {
+		t.Fatalf("unexpected second write: %+v", plan.Writes[1])
+	}
+	if rec, ok := DryRunRecorderOf(client); !ok || rec != recorder {
+		t.Fatalf("expected DryRunRecorderOf to return recorder")
+	}
+	if _, ok := DryRunRecorderOf(NewClient()); ok {
+		t.Fatalf("regular client must not be reported as dry-run")
+	}
+}
+
+func TestWithDryRunKeepsBaseClientTransport(t *testing.T) {
+	var reads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("write reached Cloudflare in dry-run: %s %s", r.Method, r.URL.Path)
+		}
+		reads++
+		writeCFResponse(t, w, http.StatusOK, true, []map[string]any{})
+	}))
+	defer server.Close()
+
+	base := &apiClient{
+		accountIDCache: map[string]string{"main": "acc1"},
+		baseURL:        server.URL,
+		httpClient:     server.Client(),
+	}
+	recorder := NewDryRunRecorder()
+	client := WithDryRun(base, recorder)
+	if rec, ok := DryRunRecorderOf(client); !ok || rec != recorder {
+		t.Fatalf("expected wrapped client to report recorder")
+	}
+	if _, ok := DryRunRecorderOf(base); ok {
+		t.Fatalf("wrapping must not modify the base client")
+	}
+	account := config.CF{Label: "main", APIToken: "secret"}
+	if _, err := client.(*apiClient).ListZoneDNSRecords(context.Background(), account, "zone1"); err != nil {
+		t.Fatalf("read returned error: %v", err)
+	}
+	if err := client.(*apiClient).DeleteZoneByID(context.Background(), account, "zone1"); err != nil {
+		t.Fatalf("DeleteZoneByID returned error: %v", err)
+	}
+	if plan := recorder.Plan("wrap"); reads != 1 || plan.Reads != 1 || len(plan.Writes) != 1 {
+		t.Fatalf("unexpected plan reads=%d plan=%+v", reads, plan)
+	}
+}
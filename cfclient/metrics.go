@@ -0,0 +1,102 @@
+package cfclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/metrics"
+)
+
+var (
+	cfAPIRequests = metrics.NewCounterVec("domainc_cloudflare_api_requests_total",
+		"Cloudflare API HTTP 请求次数（每次重试单独计数），code 为 HTTP 状态码或 error", "method", "endpoint", "code")
+	cfAPILatency = metrics.NewHistogramVec("domainc_cloudflare_api_request_duration_seconds",
+		"Cloudflare API HTTP 请求耗时", nil, "method", "endpoint")
+	cfAPIRetries = metrics.NewCounterVec("domainc_cloudflare_api_retries_total",
+		"Cloudflare API 重试次数，client 为 api（apiClient.Do）或 sdk（cloudflare-go）", "client")
+	cfAPIErrorCodes = metrics.NewCounterVec("domainc_cloudflare_api_error_codes_total",
+		"Cloudflare API 响应中 errors[].code 出现次数", "code")
+)
+
+// defaultCloudflareTransport 统计经过的全部 Cloudflare 请求，Do、SDK、GraphQL 和滥用报告接口共用。
+var defaultCloudflareTransport http.RoundTripper = &metricsTransport{base: http.DefaultTransport}
+
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := cloudflareEndpoint(req.URL.Path)
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	cfAPILatency.ObserveDuration(time.Since(started), req.Method, endpoint)
+	if err != nil {
+		cfAPIRequests.Inc(req.Method, endpoint, "error")
+		return resp, err
+	}
+	cfAPIRequests.Inc(req.Method, endpoint, strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest && resp.Body != nil {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if readErr == nil {
+			recordCloudflareErrorCodes(body)
+		}
+	}
+	return resp, nil
+}
+
+func recordCloudflareErrorCodes(body []byte) {
+	var envelope struct {
+		Errors []cfAPIMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return
+	}
+	for _, item := range envelope.Errors {
+		cfAPIErrorCodes.Inc(strconv.Itoa(item.Code))
+	}
+}
+
+// cloudflareEndpoint 把 URL 路径中的 ID 替换为 :id，避免指标基数随 zone/记录数量膨胀。
+func cloudflareEndpoint(path string) string {
+	path = strings.TrimPrefix(path, "/client/v4")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if isCloudflareID(part) {
+			parts[i] = ":id"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func isCloudflareID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	hex, digits := true, true
+	for _, r := range segment {
+		isDigit := r >= '0' && r <= '9'
+		if !isDigit {
+			digits = false
+		}
+		if !isDigit && (r < 'a' || r > 'f') && (r < 'A' || r > 'F') {
+			hex = false
+		}
+	}
+	return digits || (hex && len(segment) >= 16) || (len(segment) == 36 && strings.Count(segment, "-") == 4)
+}
+
+// sdkRetryLogger 接收 cloudflare-go 的重试日志，用于统计 SDK 内部重试次数。
+type sdkRetryLogger struct{}
+
+func (sdkRetryLogger) Printf(format string, v ...interface{}) {
+	if strings.Contains(format, "before retry attempt") {
+		cfAPIRetries.Inc("sdk")
+	}
+}
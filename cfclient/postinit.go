@@ -71,6 +71,8 @@ type FeatureManageOptions struct {
 	SQLi           bool
 	Speed          bool
 	Cache          bool
+	RateLimit      bool
+	RateLimitRule  RateLimitRuleOptions
 }
 
 type FeatureManageResult struct {
@@ -80,6 +82,7 @@ type FeatureManageResult struct {
 	SQLiRuleStatus     string
 	SpeedStatus        map[string]string
 	CacheRuleStatus    string
+	RateLimitStatus    string
 	Warnings           []string
 	Errors             []string
 }
@@ -912,6 +915,12 @@ func (c *apiClient) DeleteDefaultStaticFileCacheRule(ctx context.Context, accoun
 }
 
 func (c *apiClient) deleteRulesetRuleByDescription(ctx context.Context, account config.CF, zoneID string, phase string, description string) (string, error) {
+	return c.deleteRulesetRulesMatching(ctx, account, zoneID, phase, func(ruleDescription string) bool {
+		return ruleDescription == description
+	})
+}
+
+func (c *apiClient) deleteRulesetRulesMatching(ctx context.Context, account config.CF, zoneID string, phase string, match func(description string) bool) (string, error) {
 	var entry rulesetEntryPoint
 	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
 	err := c.Do(ctx, account, http.MethodGet, path, nil, &entry)
@@ -924,7 +933,7 @@ func (c *apiClient) deleteRulesetRuleByDescription(ctx context.Context, account
 	}
 	deleted := false
 	for _, rule := range entry.Rules {
-		if rule.Description != description {
+		if !match(rule.Description) {
 			continue
 		}
 		if strings.TrimSpace(rule.ID) == "" {
@@ -950,6 +959,7 @@ func (c *apiClient) ManageZoneFeatures(ctx context.Context, account config.CF, d
 		SQLiRuleStatus:     statusSkipped,
 		SpeedStatus:        map[string]string{},
 		CacheRuleStatus:    statusSkipped,
+		RateLimitStatus:    statusSkipped,
 	}
 	action := strings.ToLower(strings.TrimSpace(opts.Action))
 	if action == "" {
@@ -1022,6 +1032,22 @@ func (c *apiClient) ManageZoneFeatures(ctx context.Context, account config.CF, d
 		}
 	}
 
+	if opts.RateLimit {
+		if action == "disable" || action == "delete" || action == "off" {
+			status, err := c.DeleteRateLimitRule(ctx, account, zoneID, opts.RateLimitRule.Path, opts.RateLimitRule.Method)
+			result.RateLimitStatus = statusOrFailed(status, err)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		} else {
+			status, err := c.EnsureRateLimitRule(ctx, account, zoneID, opts.RateLimitRule)
+			result.RateLimitStatus = statusOrFailed(status, err)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+	}
+
 	return result
 }
 
@@ -92,10 +92,11 @@ type cfAPIMessage struct {
 }
 
 type cfAPIEnvelope struct {
-	Success  bool            `json:"success"`
-	Errors   []cfAPIMessage  `json:"errors"`
-	Messages []cfAPIMessage  `json:"messages"`
-	Result   json.RawMessage `json:"result"`
+	Success    bool            `json:"success"`
+	Errors     []cfAPIMessage  `json:"errors"`
+	Messages   []cfAPIMessage  `json:"messages"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo json.RawMessage `json:"result_info"`
 }
 
 func (c *apiClient) cloudflareBaseURL() string {
@@ -113,6 +114,25 @@ func (c *apiClient) cloudflareHTTPClient() *http.Client {
 }
 
 func (c *apiClient) Do(ctx context.Context, account config.CF, method, path string, body any, out any) error {
+	envelope, err := c.doEnvelope(ctx, account, method, path, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(envelope.Result) == 0 || string(envelope.Result) == "null" {
+		return nil
+	}
+	if raw, ok := out.(*json.RawMessage); ok {
+		*raw = append((*raw)[:0], envelope.Result...)
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("decode Cloudflare result failed: %w", err)
+	}
+	return nil
+}
+
+// doEnvelope 发送请求并返回完整响应，需要 result_info（分页游标）的调用方使用。
+func (c *apiClient) doEnvelope(ctx context.Context, account config.CF, method, path string, body any) (cfAPIEnvelope, error) {
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
@@ -121,7 +141,7 @@ func (c *apiClient) Do(ctx context.Context, account config.CF, method, path stri
 		var err error
 		bodyBytes, err = json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("marshal Cloudflare request body failed: %w", err)
+			return cfAPIEnvelope{}, fmt.Errorf("marshal Cloudflare request body failed: %w", err)
 		}
 	}
 
@@ -133,11 +153,12 @@ func (c *apiClient) Do(ctx context.Context, account config.CF, method, path stri
 	var lastErr error
 	for attempt := 0; attempt <= maxCloudflareHTTPRetries; attempt++ {
 		if attempt > 0 {
+			cfAPIRetries.Inc("api")
 			timer := time.NewTimer(time.Duration(1<<uint(attempt-1)) * defaultHTTPRetryBaseDelay)
 			select {
 			case <-ctx.Done():
 				timer.Stop()
-				return ctx.Err()
+				return cfAPIEnvelope{}, ctx.Err()
 			case <-timer.C:
 			}
 		}
@@ -148,7 +169,7 @@ func (c *apiClient) Do(ctx context.Context, account config.CF, method, path stri
 		}
 		req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
 		if err != nil {
-			return err
+			return cfAPIEnvelope{}, err
 		}
 		req.Header.Set("Authorization", "Bearer "+account.APIToken)
 		req.Header.Set("Content-Type", "application/json")
@@ -173,7 +194,7 @@ func (c *apiClient) Do(ctx context.Context, account config.CF, method, path stri
 				if shouldRetryHTTPStatus(resp.StatusCode) {
 					continue
 				}
-				return lastErr
+				return cfAPIEnvelope{}, lastErr
 			}
 		}
 
@@ -188,26 +209,16 @@ func (c *apiClient) Do(ctx context.Context, account config.CF, method, path stri
 			if shouldRetryHTTPStatus(resp.StatusCode) && attempt < maxCloudflareHTTPRetries {
 				continue
 			}
-			return apiErr
+			return cfAPIEnvelope{}, apiErr
 		}
 
-		if out == nil || len(envelope.Result) == 0 || string(envelope.Result) == "null" {
-			return nil
-		}
-		if raw, ok := out.(*json.RawMessage); ok {
-			*raw = append((*raw)[:0], envelope.Result...)
-			return nil
-		}
-		if err := json.Unmarshal(envelope.Result, out); err != nil {
-			return fmt.Errorf("decode Cloudflare result failed: %w", err)
-		}
-		return nil
+		return envelope, nil
 	}
 
 	if lastErr != nil {
-		return lastErr
+		return cfAPIEnvelope{}, lastErr
 	}
-	return errors.New("Cloudflare request failed")
+	return cfAPIEnvelope{}, errors.New("Cloudflare request failed")
 }
 
 func shouldRetryHTTPStatus(status int) bool {
@@ -363,12 +374,13 @@ func buildCountryBlockExpression(countries []string) string {
 }
 
 type rulesetRule struct {
-	ID               string `json:"id,omitempty"`
-	Description      string `json:"description"`
-	Expression       string `json:"expression"`
-	Action           string `json:"action"`
-	Enabled          bool   `json:"enabled"`
-	ActionParameters any    `json:"action_parameters,omitempty"`
+	ID               string            `json:"id,omitempty"`
+	Description      string            `json:"description"`
+	Expression       string            `json:"expression"`
+	Action           string            `json:"action"`
+	Enabled          bool              `json:"enabled"`
+	ActionParameters any               `json:"action_parameters,omitempty"`
+	Ratelimit        *rulesetRateLimit `json:"ratelimit,omitempty"`
 }
 
 type rulesetEntryPoint struct {
@@ -472,6 +484,9 @@ func (c *apiClient) EnsureCountryBlockRule(ctx context.Context, account config.C
 }
 
 func (c *apiClient) verifyCountryBlockRule(ctx context.Context, account config.CF, zoneID string, expression string) error {
+	if c.dryRun() {
+		return nil
+	}
 	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, firewallCustomPhase)
 	var lastErr error
 	for attempt := 0; attempt < countryBlockVerifyAttempts; attempt++ {
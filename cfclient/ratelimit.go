@@ -0,0 +1,222 @@
+package cfclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"DomainC/config"
+)
+
+const (
+	rateLimitPhase          = "http_ratelimit"
+	rateLimitRulesetName    = "telegram-auto-ratelimit"
+	rateLimitRuleDescPrefix = "telegram-auto-ratelimit"
+	defaultRateLimitPeriod  = 60
+	defaultRateLimitAction  = "block"
+	maxRateLimitRequests    = 1000000
+	maxRateLimitPathLength  = 512
+)
+
+var (
+	rateLimitCharacteristics    = []string{"cf.colo.id", "ip.src"}
+	rateLimitPeriods            = []int{10, 60, 120, 300, 600, 3600}
+	rateLimitMitigationTimeouts = []int{0, 10, 60, 120, 300, 600, 3600, 86400}
+	rateLimitActions            = []string{"block", "managed_challenge", "js_challenge", "challenge", "log"}
+	rateLimitMethods            = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+)
+
+type rulesetRateLimit struct {
+	Characteristics   []string `json:"characteristics"`
+	Period            int      `json:"period"`
+	RequestsPerPeriod int      `json:"requests_per_period"`
+	MitigationTimeout int      `json:"mitigation_timeout"`
+}
+
+// RateLimitRuleOptions 描述一条按路径的限速规则；Path 以 * 结尾时按前缀匹配。
+// RequestsPerSecond 与 RequestsPerPeriod 二选一，前者会乘以 Period 换算。
+type RateLimitRuleOptions struct {
+	Path              string
+	Method            string
+	RequestsPerSecond int
+	RequestsPerPeriod int
+	Period            int
+	Action            string
+	MitigationTimeout int
+}
+
+// NormalizeRateLimitRuleOptions 校验并补全限速参数，返回的 RequestsPerPeriod 为最终下发值。
+func NormalizeRateLimitRuleOptions(opts RateLimitRuleOptions) (RateLimitRuleOptions, error) {
+	path := strings.TrimSpace(opts.Path)
+	if path == "" {
+		return opts, fmt.Errorf("限速规则需要 path，例如 path=/login")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return opts, fmt.Errorf("path 必须以 / 开头: %s", path)
+	}
+	if len(path) > maxRateLimitPathLength || strings.ContainsAny(path, "\"\\ ?#") {
+		return opts, fmt.Errorf("path 格式错误: %s", path)
+	}
+	if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+		return opts, fmt.Errorf("path 只支持结尾使用 * 表示前缀匹配: %s", path)
+	}
+	opts.Path = path
+
+	opts.Method = strings.ToUpper(strings.TrimSpace(opts.Method))
+	if opts.Method != "" && !containsString(rateLimitMethods, opts.Method) {
+		return opts, fmt.Errorf("method 不支持: %s", opts.Method)
+	}
+
+	if opts.Period == 0 {
+		opts.Period = defaultRateLimitPeriod
+	}
+	if !containsInt(rateLimitPeriods, opts.Period) {
+		return opts, fmt.Errorf("period 必须是 %s 秒之一", joinInts(rateLimitPeriods))
+	}
+
+	if opts.RequestsPerPeriod <= 0 {
+		if opts.RequestsPerSecond <= 0 {
+			return opts, fmt.Errorf("需要 rps（每秒请求数）或 requests（每个周期请求数）且大于 0")
+		}
+		opts.RequestsPerPeriod = opts.RequestsPerSecond * opts.Period
+	}
+	if opts.RequestsPerPeriod > maxRateLimitRequests {
+		return opts, fmt.Errorf("请求数过大: %d", opts.RequestsPerPeriod)
+	}
+
+	opts.Action = strings.ToLower(strings.TrimSpace(opts.Action))
+	if opts.Action == "" {
+		opts.Action = defaultRateLimitAction
+	}
+	if !IsRateLimitAction(opts.Action) {
+		return opts, fmt.Errorf("限速动作必须是 %s 之一", strings.Join(rateLimitActions, "/"))
+	}
+
+	if opts.Action == "block" || opts.Action == "log" {
+		if opts.MitigationTimeout == 0 {
+			opts.MitigationTimeout = opts.Period
+		}
+		if !containsInt(rateLimitMitigationTimeouts, opts.MitigationTimeout) {
+			return opts, fmt.Errorf("timeout 必须是 %s 秒之一", joinInts(rateLimitMitigationTimeouts))
+		}
+	} else {
+		// Cloudflare 要求挑战类动作的 mitigation_timeout 为 0。
+		opts.MitigationTimeout = 0
+	}
+	return opts, nil
+}
+
+// IsRateLimitAction 判断是否为限速规则支持的动作。
+func IsRateLimitAction(action string) bool {
+	return containsString(rateLimitActions, strings.ToLower(strings.TrimSpace(action)))
+}
+
+// RateLimitRuleDescription 返回限速规则描述，每个 path+method 对应一条规则。
+func RateLimitRuleDescription(path string, method string) string {
+	desc := rateLimitRuleDescPrefix + " " + strings.TrimSpace(path)
+	if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+		desc += " " + method
+	}
+	return desc
+}
+
+// FormatRateLimitRule 返回限速规则的简短描述，用于结果展示。
+func FormatRateLimitRule(opts RateLimitRuleOptions) string {
+	method := opts.Method
+	if method == "" {
+		method = "ANY"
+	}
+	return fmt.Sprintf("%s %s %d/%ds %s", method, opts.Path, opts.RequestsPerPeriod, opts.Period, opts.Action)
+}
+
+func buildRateLimitExpression(path string, method string) string {
+	var expression string
+	if prefix, ok := strings.CutSuffix(path, "*"); ok {
+		expression = fmt.Sprintf("starts_with(http.request.uri.path, %s)", quoteRuleLiteral(prefix))
+	} else {
+		expression = fmt.Sprintf("http.request.uri.path eq %s", quoteRuleLiteral(path))
+	}
+	if method != "" {
+		expression += fmt.Sprintf(" and http.request.method eq %s", quoteRuleLiteral(method))
+	}
+	return "(" + expression + ")"
+}
+
+func buildRateLimitRule(opts RateLimitRuleOptions) rulesetRule {
+	return rulesetRule{
+		Description: RateLimitRuleDescription(opts.Path, opts.Method),
+		Expression:  buildRateLimitExpression(opts.Path, opts.Method),
+		Action:      opts.Action,
+		Enabled:     true,
+		Ratelimit: &rulesetRateLimit{
+			Characteristics:   append([]string(nil), rateLimitCharacteristics...),
+			Period:            opts.Period,
+			RequestsPerPeriod: opts.RequestsPerPeriod,
+			MitigationTimeout: opts.MitigationTimeout,
+		},
+	}
+}
+
+// EnsureRateLimitRule 在 http_ratelimit 入口规则集中按描述幂等创建/更新限速规则。
+func (c *apiClient) EnsureRateLimitRule(ctx context.Context, account config.CF, zoneID string, opts RateLimitRuleOptions) (string, error) {
+	normalized, err := NormalizeRateLimitRuleOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	rule := buildRateLimitRule(normalized)
+	return c.ensureRulesetRuleByDescription(ctx, account, zoneID, rateLimitPhase, rateLimitRulesetName, rule.Description, rule, rateLimitRuleMatches)
+}
+
+// DeleteRateLimitRule 删除指定 path 的限速规则；path 为空时删除机器人创建的全部限速规则。
+func (c *apiClient) DeleteRateLimitRule(ctx context.Context, account config.CF, zoneID string, path string, method string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return c.deleteRulesetRulesMatching(ctx, account, zoneID, rateLimitPhase, func(description string) bool {
+			return description == rateLimitRuleDescPrefix || strings.HasPrefix(description, rateLimitRuleDescPrefix+" ")
+		})
+	}
+	return c.deleteRulesetRuleByDescription(ctx, account, zoneID, rateLimitPhase, RateLimitRuleDescription(path, method))
+}
+
+func rateLimitRuleMatches(existing rulesetRule, want rulesetRule) bool {
+	if !firewallRuleMatches(existing, want.Expression, want.Action, want.Enabled) {
+		return false
+	}
+	if existing.Ratelimit == nil || want.Ratelimit == nil {
+		return existing.Ratelimit == want.Ratelimit
+	}
+	got := *existing.Ratelimit
+	got.Characteristics = append([]string(nil), got.Characteristics...)
+	sort.Strings(got.Characteristics)
+	expected := *want.Ratelimit
+	expected.Characteristics = append([]string(nil), expected.Characteristics...)
+	sort.Strings(expected.Characteristics)
+	return jsonEqual(got, expected)
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, target int) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,106 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestNormalizeRateLimitRuleOptions(t *testing.T) {
+	opts, err := NormalizeRateLimitRuleOptions(RateLimitRuleOptions{Path: "/login", RequestsPerSecond: 10, Period: 60})
+	if err != nil {
+		t.Fatalf("NormalizeRateLimitRuleOptions returned error: %v", err)
+	}
+	if opts.RequestsPerPeriod != 600 || opts.Action != "block" || opts.MitigationTimeout != 60 {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	opts, err = NormalizeRateLimitRuleOptions(RateLimitRuleOptions{Path: "/api/*", RequestsPerPeriod: 50, Period: 10, Action: "managed_challenge", MitigationTimeout: 600})
+	if err != nil || opts.MitigationTimeout != 0 {
+		t.Fatalf("challenge options = %+v, err = %v", opts, err)
+	}
+	if got := buildRateLimitExpression(opts.Path, "POST"); got != `(starts_with(http.request.uri.path, "/api/") and http.request.method eq "POST")` {
+		t.Fatalf("unexpected expression: %s", got)
+	}
+
+	invalid := []RateLimitRuleOptions{
+		{RequestsPerSecond: 1},
+		{Path: "login", RequestsPerSecond: 1},
+		{Path: "/login", RequestsPerSecond: 1, Period: 30},
+		{Path: "/login"},
+		{Path: "/login", RequestsPerSecond: 1, Action: "skip"},
+		{Path: "/a*b", RequestsPerSecond: 1},
+	}
+	for _, item := range invalid {
+		if _, err := NormalizeRateLimitRuleOptions(item); err == nil {
+			t.Errorf("expected error for %+v", item)
+		}
+	}
+}
+
+func TestEnsureRateLimitRuleCreatesRulesetWhenEntrypointMissing(t *testing.T) {
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/rulesets/phases/http_ratelimit/entrypoint":
+			writeCFResponse(t, w, http.StatusNotFound, false, nil, "not found")
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone1/rulesets":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "rs1"})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	status, err := client.EnsureRateLimitRule(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "zone1", RateLimitRuleOptions{Path: "/login", RequestsPerSecond: 10, Period: 60})
+	if err != nil {
+		t.Fatalf("EnsureRateLimitRule returned error: %v", err)
+	}
+	if status != statusCreated {
+		t.Fatalf("status = %q", status)
+	}
+	if created["phase"] != "http_ratelimit" {
+		t.Fatalf("unexpected ruleset body: %+v", created)
+	}
+	rules, _ := created["rules"].([]any)
+	if len(rules) != 1 {
+		t.Fatalf("unexpected rules: %+v", created["rules"])
+	}
+	rule := rules[0].(map[string]any)
+	ratelimit, _ := rule["ratelimit"].(map[string]any)
+	if rule["description"] != "telegram-auto-ratelimit /login" || ratelimit["requests_per_period"] != float64(600) || ratelimit["period"] != float64(60) {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+}
+
+func TestEnsureRateLimitRuleKeepsMatchingRule(t *testing.T) {
+	opts, err := NormalizeRateLimitRuleOptions(RateLimitRuleOptions{Path: "/login", RequestsPerSecond: 10, Period: 60})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	existing := buildRateLimitRule(opts)
+	existing.ID = "rule1"
+	existing.Ratelimit.Characteristics = []string{"ip.src", "cf.colo.id"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/rulesets/phases/http_ratelimit/entrypoint" {
+			writeCFResponse(t, w, http.StatusOK, true, rulesetEntryPoint{ID: "rs1", Rules: []rulesetRule{existing}})
+			return
+		}
+		t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	status, err := client.EnsureRateLimitRule(context.Background(), config.CF{APIToken: "secret", AccountID: "acct"}, "zone1", opts)
+	if err != nil || status != statusAlreadyExists {
+		t.Fatalf("status = %q, err = %v", status, err)
+	}
+}
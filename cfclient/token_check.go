@@ -0,0 +1,172 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+// TokenCapability 描述机器人依赖的一项 Cloudflare 权限及依赖它的功能。
+// 探测只做只读请求，编辑类权限只能确认对应资源可读。
+type TokenCapability struct {
+	Key      string
+	Name     string
+	Scope    string // zone 或 account
+	Path     string // 相对路径，{zone}、{account} 会被替换
+	Commands []string
+}
+
+// TokenCapabilities 是 /tokens check 依次探测的能力列表。
+var TokenCapabilities = []TokenCapability{
+	{Key: "zone", Name: "Zone 读取/编辑", Scope: "zone", Path: "zones/{zone}", Commands: []string{"/status", "/getns", "/delete", "/checkcf", "资产同步"}},
+	{Key: "dns", Name: "DNS 编辑", Scope: "zone", Path: "zones/{zone}/dns_records?per_page=1", Commands: []string{"/dns", "/setdns", "/deldns", "/csv", "/move"}},
+	{Key: "zone_settings", Name: "Zone 设置", Scope: "zone", Path: "zones/{zone}/settings/security_level", Commands: []string{"/attack", "/cf_init", "/snapshot"}},
+	{Key: "rulesets", Name: "WAF 规则集", Scope: "zone", Path: "zones/{zone}/rulesets", Commands: []string{"/cf_rules", "/cf_ipblock", "/snapshot", "/move"}},
+	{Key: "access_rules", Name: "IP 访问规则", Scope: "zone", Path: "zones/{zone}/firewall/access_rules/rules?per_page=1", Commands: []string{"/ipaccess", "/cf_ipblock"}},
+	{Key: "ssl", Name: "SSL/证书", Scope: "zone", Path: "zones/{zone}/settings/ssl", Commands: []string{"/ssl", "/originssl", "证书提醒"}},
+	{Key: "origin_ca", Name: "Origin CA 证书", Scope: "zone", Path: "certificates?zone_id={zone}", Commands: []string{"/ssl", "/originssl"}},
+	{Key: "lists", Name: "自定义列表", Scope: "account", Path: "accounts/{account}/rules/lists", Commands: []string{"/iplist", "/cf_ipblock"}},
+	{Key: "abuse_reports", Name: "滥用报告", Scope: "account", Path: "accounts/{account}/abuse-reports?per_page=1", Commands: []string{"滥用报告日报"}},
+	{Key: "rum", Name: "Web Analytics (RUM)", Scope: "account", Path: "accounts/{account}/rum/site_info/list?per_page=1", Commands: []string{"/cf_add", "/cf_init"}},
+}
+
+const (
+	TokenProbeOK      = "ok"
+	TokenProbeDenied  = "denied"
+	TokenProbeError   = "error"
+	TokenProbeSkipped = "skipped"
+)
+
+// TokenProbeResult 是单项能力的探测结果。
+type TokenProbeResult struct {
+	Capability TokenCapability
+	Status     string
+	Detail     string
+}
+
+// TokenCheckResult 汇总一个账号的 token 校验和能力探测。
+type TokenCheckResult struct {
+	AccountLabel string
+	TokenID      string
+	TokenStatus  string
+	ExpiresOn    time.Time
+	NotBefore    time.Time
+	VerifyError  string
+	ProbeZone    string
+	Probes       []TokenProbeResult
+}
+
+// Valid 表示 verify 接口确认 token 处于 active 状态。
+func (r TokenCheckResult) Valid() bool {
+	return r.VerifyError == "" && strings.EqualFold(r.TokenStatus, "active")
+}
+
+// TokenChecker 校验 API token 并探测各项权限。
+type TokenChecker interface {
+	CheckToken(ctx context.Context, account config.CF) (TokenCheckResult, error)
+}
+
+type tokenVerifyResult struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	ExpiresOn string `json:"expires_on"`
+	NotBefore string `json:"not_before"`
+}
+
+// CheckToken 调用 token verify 接口，再用无副作用的 GET 请求逐项探测权限。
+// Zone 级探测使用账号下第一个 Zone；账号没有 Zone 时这些项标记为跳过。
+func (c *apiClient) CheckToken(ctx context.Context, account config.CF) (TokenCheckResult, error) {
+	result := TokenCheckResult{AccountLabel: account.Label}
+
+	var verify tokenVerifyResult
+	err := c.Do(ctx, account, http.MethodGet, "user/tokens/verify", nil, &verify)
+	if err != nil && strings.TrimSpace(account.AccountID) != "" {
+		// 账号级 token 只能通过账号下的 verify 接口校验。
+		accountPath := fmt.Sprintf("accounts/%s/tokens/verify", url.PathEscape(strings.TrimSpace(account.AccountID)))
+		if accErr := c.Do(ctx, account, http.MethodGet, accountPath, nil, &verify); accErr == nil {
+			err = nil
+		}
+	}
+	if err != nil {
+		result.VerifyError = err.Error()
+		return result, nil
+	}
+	result.TokenID = verify.ID
+	result.TokenStatus = verify.Status
+	result.ExpiresOn = parseTokenTime(verify.ExpiresOn)
+	result.NotBefore = parseTokenTime(verify.NotBefore)
+	if !result.Valid() {
+		return result, nil
+	}
+
+	var zones []provisionZoneResult
+	zoneErr := c.Do(ctx, account, http.MethodGet, "zones?per_page=1", nil, &zones)
+	zoneID := ""
+	if zoneErr == nil && len(zones) > 0 {
+		zoneID = zones[0].ID
+		result.ProbeZone = zones[0].Name
+	}
+	accountID, accountErr := c.GetAccountID(ctx, account)
+
+	for _, capability := range TokenCapabilities {
+		probe := TokenProbeResult{Capability: capability}
+		path := capability.Path
+		switch capability.Scope {
+		case "zone":
+			if zoneErr != nil {
+				probe.Status, probe.Detail = classifyTokenProbeError(zoneErr)
+				result.Probes = append(result.Probes, probe)
+				continue
+			}
+			if zoneID == "" {
+				probe.Status, probe.Detail = TokenProbeSkipped, "账号下没有 Zone"
+				result.Probes = append(result.Probes, probe)
+				continue
+			}
+			path = strings.ReplaceAll(path, "{zone}", url.PathEscape(zoneID))
+		case "account":
+			if accountErr != nil {
+				probe.Status, probe.Detail = classifyTokenProbeError(accountErr)
+				result.Probes = append(result.Probes, probe)
+				continue
+			}
+			path = strings.ReplaceAll(path, "{account}", url.PathEscape(accountID))
+		}
+		if err := c.Do(ctx, account, http.MethodGet, path, nil, nil); err != nil {
+			probe.Status, probe.Detail = classifyTokenProbeError(err)
+		} else {
+			probe.Status = TokenProbeOK
+		}
+		result.Probes = append(result.Probes, probe)
+	}
+	return result, nil
+}
+
+func classifyTokenProbeError(err error) (string, string) {
+	var apiErr *CloudflareAPIError
+	if errors.As(err, &apiErr) && (apiErr.IsStatus(http.StatusForbidden) || apiErr.IsStatus(http.StatusUnauthorized)) {
+		return TokenProbeDenied, strings.Join(apiErr.Messages, "; ")
+	}
+	if isCloudflarePermissionError(err) {
+		return TokenProbeDenied, err.Error()
+	}
+	return TokenProbeError, err.Error()
+}
+
+func parseTokenTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
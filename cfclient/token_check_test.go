@@ -0,0 +1,51 @@
+package cfclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestCheckTokenBuildsCapabilityMatrix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("token check must only send GET requests, got %s %s", r.Method, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/user/tokens/verify":
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "tok1", "status": "active", "expires_on": "2030-01-02T00:00:00Z"})
+		case "/zones":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{{"id": "zone1", "name": "example.com"}})
+		case "/zones/zone1/rulesets", "/accounts/acct/rum/site_info/list":
+			writeCFResponse(t, w, http.StatusForbidden, false, nil, "Authentication error")
+		default:
+			writeCFResponse(t, w, http.StatusOK, true, []any{})
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	result, err := client.CheckToken(context.Background(), config.CF{Label: "main", APIToken: "secret", AccountID: "acct"})
+	if err != nil {
+		t.Fatalf("CheckToken returned error: %v", err)
+	}
+	if !result.Valid() || result.TokenID != "tok1" || result.ExpiresOn.Year() != 2030 || result.ProbeZone != "example.com" {
+		t.Fatalf("unexpected verify result: %+v", result)
+	}
+	if len(result.Probes) != len(TokenCapabilities) {
+		t.Fatalf("probes = %d, want %d", len(result.Probes), len(TokenCapabilities))
+	}
+	statuses := make(map[string]string)
+	for _, probe := range result.Probes {
+		statuses[probe.Capability.Key] = probe.Status
+	}
+	if statuses["rulesets"] != TokenProbeDenied || statuses["rum"] != TokenProbeDenied {
+		t.Fatalf("expected denied rulesets and rum, got %+v", statuses)
+	}
+	if statuses["dns"] != TokenProbeOK || statuses["lists"] != TokenProbeOK {
+		t.Fatalf("expected dns and lists ok, got %+v", statuses)
+	}
+}
@@ -0,0 +1,137 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+// ZoneTrafficStats 是一个 Zone 在时间窗口内的请求量和状态码分布。
+// Origin* 只统计回源请求（originResponseStatus > 0），用于判断源站是否异常。
+type ZoneTrafficStats struct {
+	ZoneID         string
+	Requests       int
+	Status2xx      int
+	Status3xx      int
+	Status4xx      int
+	Status5xx      int
+	OriginRequests int
+	OriginErrors   int
+}
+
+// Rate5xx 返回边缘 5xx 占比。
+func (s ZoneTrafficStats) Rate5xx() float64 {
+	if s.Requests <= 0 {
+		return 0
+	}
+	return float64(s.Status5xx) / float64(s.Requests)
+}
+
+// OriginErrorRate 返回回源请求中源站 5xx 的占比。
+func (s ZoneTrafficStats) OriginErrorRate() float64 {
+	if s.OriginRequests <= 0 {
+		return 0
+	}
+	return float64(s.OriginErrors) / float64(s.OriginRequests)
+}
+
+type zoneTrafficGraphQLResponse struct {
+	Data struct {
+		Viewer struct {
+			Zones []struct {
+				ZoneTag string `json:"zoneTag"`
+				Edge    []struct {
+					Count      int `json:"count"`
+					Dimensions struct {
+						EdgeResponseStatus int `json:"edgeResponseStatus"`
+					} `json:"dimensions"`
+				} `json:"edge"`
+				Origin []struct {
+					Count      int `json:"count"`
+					Dimensions struct {
+						OriginResponseStatus int `json:"originResponseStatus"`
+					} `json:"dimensions"`
+				} `json:"origin"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// FetchZoneTrafficStats 通过 GraphQL httpRequestsAdaptiveGroups 按 10 个 Zone 一组查询请求量和状态码分布。
+func (c *apiClient) FetchZoneTrafficStats(ctx context.Context, account config.CF, zoneIDs []string, since, until time.Time) (map[string]ZoneTrafficStats, error) {
+	zoneTags := make([]string, 0, len(zoneIDs))
+	for _, zoneID := range zoneIDs {
+		if strings.TrimSpace(zoneID) != "" {
+			zoneTags = append(zoneTags, strings.TrimSpace(zoneID))
+		}
+	}
+	out := make(map[string]ZoneTrafficStats, len(zoneTags))
+	for start := 0; start < len(zoneTags); start += 10 {
+		end := start + 10
+		if end > len(zoneTags) {
+			end = len(zoneTags)
+		}
+		if err := c.fetchZoneTrafficStatsChunk(ctx, account, zoneTags[start:end], since, until, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (c *apiClient) fetchZoneTrafficStatsChunk(ctx context.Context, account config.CF, zoneTags []string, since, until time.Time, out map[string]ZoneTrafficStats) error {
+	query := `query($zoneTags: [string], $start: Time, $end: Time) {
+viewer {
+zones(filter: {zoneTag_in: $zoneTags}) {
+zoneTag
+edge: httpRequestsAdaptiveGroups(limit: 100, filter: {datetime_geq: $start, datetime_lt: $end}, orderBy: [count_DESC]) { count dimensions { edgeResponseStatus } }
+origin: httpRequestsAdaptiveGroups(limit: 100, filter: {datetime_geq: $start, datetime_lt: $end, originResponseStatus_gt: 0}, orderBy: [count_DESC]) { count dimensions { originResponseStatus } }
+}
+}
+}`
+	vars := map[string]interface{}{
+		"zoneTags": zoneTags,
+		"start":    since.UTC().Format(time.RFC3339),
+		"end":      until.UTC().Format(time.RFC3339),
+	}
+
+	var resp zoneTrafficGraphQLResponse
+	if err := c.doCloudflareGraphQL(ctx, account, query, vars, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+	for _, zone := range resp.Data.Viewer.Zones {
+		if strings.TrimSpace(zone.ZoneTag) == "" {
+			continue
+		}
+		stats := ZoneTrafficStats{ZoneID: zone.ZoneTag}
+		for _, group := range zone.Edge {
+			stats.Requests += group.Count
+			switch status := group.Dimensions.EdgeResponseStatus; {
+			case status >= 500:
+				stats.Status5xx += group.Count
+			case status >= 400:
+				stats.Status4xx += group.Count
+			case status >= 300:
+				stats.Status3xx += group.Count
+			case status >= 200:
+				stats.Status2xx += group.Count
+			}
+		}
+		for _, group := range zone.Origin {
+			stats.OriginRequests += group.Count
+			if group.Dimensions.OriginResponseStatus >= 500 {
+				stats.OriginErrors += group.Count
+			}
+		}
+		out[zone.ZoneTag] = stats
+	}
+	return nil
+}
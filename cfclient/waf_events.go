@@ -0,0 +1,278 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+const (
+	asnBlockRuleDesc = "telegram-auto-block-asn"
+
+	wafEventsDefaultLimit = 10
+	wafEventsMaxLimit     = 50
+)
+
+// wafEventsBlockingActions 只统计真正拦截/质询的事件，log/skip 之类的记录不算入报表。
+var wafEventsBlockingActions = []string{"block", "managed_challenge", "jschallenge", "challenge"}
+
+// WAFEventCount 是 WAF 事件按单个维度聚合后的计数。
+type WAFEventCount struct {
+	Value string
+	Label string
+	Count int
+}
+
+// WAFRuleHit 是单条规则的命中次数，Owner 非空表示是本工具创建的规则。
+type WAFRuleHit struct {
+	RuleID      string
+	Description string
+	Source      string
+	Action      string
+	Owner       string
+	Count       int
+}
+
+// WAFEventsReport 汇总一个 Zone 在时间窗口内被拦截的请求。
+type WAFEventsReport struct {
+	ZoneID       string
+	ZoneName     string
+	Since        time.Time
+	Until        time.Time
+	Total        int
+	TopIPs       []WAFEventCount
+	TopASNs      []WAFEventCount
+	TopCountries []WAFEventCount
+	TopPaths     []WAFEventCount
+	Rules        []WAFRuleHit
+}
+
+type wafEventsGroup struct {
+	Count      int `json:"count"`
+	Dimensions struct {
+		ClientIP             string `json:"clientIP"`
+		ClientAsn            string `json:"clientAsn"`
+		ClientASNDescription string `json:"clientASNDescription"`
+		ClientCountryName    string `json:"clientCountryName"`
+		ClientRequestPath    string `json:"clientRequestPath"`
+		RuleID               string `json:"ruleId"`
+		Description          string `json:"description"`
+		Source               string `json:"source"`
+		Action               string `json:"action"`
+	} `json:"dimensions"`
+}
+
+type wafEventsGraphQLResponse struct {
+	Data struct {
+		Viewer struct {
+			Zones []struct {
+				Total     []wafEventsGroup `json:"total"`
+				IPs       []wafEventsGroup `json:"ips"`
+				ASNs      []wafEventsGroup `json:"asns"`
+				Countries []wafEventsGroup `json:"countries"`
+				Paths     []wafEventsGroup `json:"paths"`
+				Rules     []wafEventsGroup `json:"rules"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// WAFRuleOwner 根据规则描述判断是否为本工具创建的规则，返回中文名称；不是则返回空字符串。
+func WAFRuleOwner(description string) string {
+	desc := strings.TrimSpace(description)
+	switch {
+	case desc == sqlBlockRuleDesc:
+		return "SQL 注入拦截"
+	case desc == countryBlockRuleDesc:
+		return "国家/地区拦截"
+	case desc == ipBlockRuleDesc:
+		return "IP 封禁"
+	case desc == asnBlockRuleDesc:
+		return "ASN 封禁"
+	case strings.HasPrefix(desc, rateLimitRuleDescPrefix):
+		return "限速"
+	}
+	return ""
+}
+
+// FetchWAFEvents 通过 GraphQL firewallEventsAdaptiveGroups 汇总 Zone 在 [since, until] 内被拦截的请求。
+func (c *apiClient) FetchWAFEvents(ctx context.Context, account config.CF, zoneID string, since, until time.Time, limit int) (WAFEventsReport, error) {
+	report := WAFEventsReport{ZoneID: zoneID, Since: since.UTC(), Until: until.UTC()}
+	if strings.TrimSpace(zoneID) == "" {
+		return report, fmt.Errorf("zone_id 不能为空")
+	}
+	if !until.After(since) {
+		return report, fmt.Errorf("时间窗口无效: %s - %s", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+	if limit <= 0 {
+		limit = wafEventsDefaultLimit
+	}
+	if limit > wafEventsMaxLimit {
+		limit = wafEventsMaxLimit
+	}
+
+	query := `query($zoneTag: string, $filter: ZoneFirewallEventsAdaptiveGroupsFilter_InputObject, $limit: Int!) {
+viewer {
+zones(filter: {zoneTag: $zoneTag}) {
+total: firewallEventsAdaptiveGroups(limit: 1, filter: $filter) { count }
+ips: firewallEventsAdaptiveGroups(limit: $limit, filter: $filter, orderBy: [count_DESC]) { count dimensions { clientIP } }
+asns: firewallEventsAdaptiveGroups(limit: $limit, filter: $filter, orderBy: [count_DESC]) { count dimensions { clientAsn clientASNDescription } }
+countries: firewallEventsAdaptiveGroups(limit: $limit, filter: $filter, orderBy: [count_DESC]) { count dimensions { clientCountryName } }
+paths: firewallEventsAdaptiveGroups(limit: $limit, filter: $filter, orderBy: [count_DESC]) { count dimensions { clientRequestPath } }
+rules: firewallEventsAdaptiveGroups(limit: $limit, filter: $filter, orderBy: [count_DESC]) { count dimensions { ruleId description source action } }
+}
+}
+}`
+	vars := map[string]interface{}{
+		"zoneTag": zoneID,
+		"limit":   limit,
+		"filter": map[string]interface{}{
+			"datetime_geq": report.Since.Format(time.RFC3339),
+			"datetime_leq": report.Until.Format(time.RFC3339),
+			"action_in":    wafEventsBlockingActions,
+		},
+	}
+
+	var resp wafEventsGraphQLResponse
+	if err := c.doCloudflareGraphQL(ctx, account, query, vars, &resp); err != nil {
+		return report, err
+	}
+	if len(resp.Errors) > 0 {
+		return report, errors.New(resp.Errors[0].Message)
+	}
+	if len(resp.Data.Viewer.Zones) == 0 {
+		return report, nil
+	}
+	zone := resp.Data.Viewer.Zones[0]
+	if len(zone.Total) > 0 {
+		report.Total = zone.Total[0].Count
+	}
+	for _, group := range zone.IPs {
+		report.TopIPs = appendWAFEventCount(report.TopIPs, group.Dimensions.ClientIP, "", group.Count)
+	}
+	for _, group := range zone.ASNs {
+		report.TopASNs = appendWAFEventCount(report.TopASNs, group.Dimensions.ClientAsn, group.Dimensions.ClientASNDescription, group.Count)
+	}
+	for _, group := range zone.Countries {
+		report.TopCountries = appendWAFEventCount(report.TopCountries, group.Dimensions.ClientCountryName, "", group.Count)
+	}
+	for _, group := range zone.Paths {
+		report.TopPaths = appendWAFEventCount(report.TopPaths, group.Dimensions.ClientRequestPath, "", group.Count)
+	}
+	for _, group := range zone.Rules {
+		d := group.Dimensions
+		report.Rules = append(report.Rules, WAFRuleHit{
+			RuleID:      strings.TrimSpace(d.RuleID),
+			Description: strings.TrimSpace(d.Description),
+			Source:      strings.TrimSpace(d.Source),
+			Action:      strings.TrimSpace(d.Action),
+			Owner:       WAFRuleOwner(d.Description),
+			Count:       group.Count,
+		})
+	}
+	return report, nil
+}
+
+func appendWAFEventCount(items []WAFEventCount, value, label string, count int) []WAFEventCount {
+	value = strings.TrimSpace(value)
+	if value == "" || count <= 0 {
+		return items
+	}
+	return append(items, WAFEventCount{Value: value, Label: strings.TrimSpace(label), Count: count})
+}
+
+func buildASNBlockExpression(asns []uint32) string {
+	values := make([]string, 0, len(asns))
+	for _, asn := range asns {
+		values = append(values, strconv.FormatUint(uint64(asn), 10))
+	}
+	return fmt.Sprintf("ip.src.asnum in {%s}", strings.Join(values, " "))
+}
+
+func parseASNBlockExpression(expression string) []uint32 {
+	start := strings.Index(expression, "{")
+	end := strings.LastIndex(expression, "}")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var out []uint32
+	for _, field := range strings.Fields(expression[start+1 : end]) {
+		asn, err := NormalizeCustomListASN(field)
+		if err != nil {
+			continue
+		}
+		out = append(out, asn)
+	}
+	return out
+}
+
+// EnsureASNBlockRule 把 ASN 合并进 Zone 的 telegram-auto-block-asn 自定义规则，已存在的 ASN 会保留。
+func (c *apiClient) EnsureASNBlockRule(ctx context.Context, account config.CF, zoneID string, asns []uint32) (string, error) {
+	if len(asns) == 0 {
+		return statusSkipped, nil
+	}
+	existing, err := c.currentASNBlockValues(ctx, account, zoneID)
+	if err != nil {
+		return "", err
+	}
+	merged := mergeASNValues(existing, asns)
+	expression := buildASNBlockExpression(merged)
+	rule := rulesetRule{
+		Description: asnBlockRuleDesc,
+		Expression:  expression,
+		Action:      "block",
+		Enabled:     true,
+	}
+	status, err := c.ensureFirewallCustomRuleByDescription(ctx, account, zoneID, ipBlockRulesetName, asnBlockRuleDesc, rule)
+	if err != nil {
+		return "", err
+	}
+	if err := c.verifyFirewallCustomRule(ctx, account, zoneID, asnBlockRuleDesc, expression); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func (c *apiClient) currentASNBlockValues(ctx context.Context, account config.CF, zoneID string) ([]uint32, error) {
+	var entry rulesetEntryPoint
+	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, firewallCustomPhase)
+	if err := c.Do(ctx, account, "GET", path, nil, &entry); err != nil {
+		var apiErr *CloudflareAPIError
+		if errors.As(err, &apiErr) && apiErr.IsStatus(404) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, rule := range entry.Rules {
+		if rule.Description == asnBlockRuleDesc {
+			return parseASNBlockExpression(rule.Expression), nil
+		}
+	}
+	return nil, nil
+}
+
+func mergeASNValues(existing []uint32, add []uint32) []uint32 {
+	seen := make(map[uint32]struct{}, len(existing)+len(add))
+	out := make([]uint32, 0, len(existing)+len(add))
+	for _, asn := range append(append([]uint32(nil), existing...), add...) {
+		if asn == 0 {
+			continue
+		}
+		if _, ok := seen[asn]; ok {
+			continue
+		}
+		seen[asn] = struct{}{}
+		out = append(out, asn)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,71 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"DomainC/config"
+)
+
+func TestFetchWAFEventsParsesGroupsAndOwnedRules(t *testing.T) {
+	var gotVars map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+		var body struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		gotVars = body.Variables
+		_, _ = w.Write([]byte(`{"data":{"viewer":{"zones":[{
+"total":[{"count":42}],
+"ips":[{"count":30,"dimensions":{"clientIP":"203.0.113.9"}},{"count":0,"dimensions":{"clientIP":"198.51.100.1"}}],
+"asns":[{"count":25,"dimensions":{"clientAsn":"64500","clientASNDescription":"EXAMPLE-NET"}}],
+"countries":[{"count":20,"dimensions":{"clientCountryName":"CN"}}],
+"paths":[{"count":12,"dimensions":{"clientRequestPath":"/wp-login.php"}}],
+"rules":[
+ {"count":18,"dimensions":{"ruleId":"r1","description":"telegram-auto-sqli-block","source":"firewallCustom","action":"block"}},
+ {"count":5,"dimensions":{"ruleId":"r2","description":"telegram-auto-ratelimit /login","source":"ratelimit","action":"block"}},
+ {"count":3,"dimensions":{"ruleId":"r3","description":"Managed rule","source":"firewallManaged","action":"managed_challenge"}}
+]}]}}}`))
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	until := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	report, err := client.FetchWAFEvents(context.Background(), config.CF{APIToken: "secret"}, "zone1", until.Add(-24*time.Hour), until, 0)
+	if err != nil {
+		t.Fatalf("FetchWAFEvents returned error: %v", err)
+	}
+	if gotVars["zoneTag"] != "zone1" || gotVars["limit"] != float64(wafEventsDefaultLimit) {
+		t.Fatalf("unexpected variables: %+v", gotVars)
+	}
+	filter, _ := gotVars["filter"].(map[string]any)
+	if filter["datetime_geq"] != "2026-01-01T00:00:00Z" || filter["datetime_leq"] != "2026-01-02T00:00:00Z" {
+		t.Fatalf("unexpected filter: %+v", filter)
+	}
+	if report.Total != 42 || len(report.TopIPs) != 1 || report.TopIPs[0].Value != "203.0.113.9" {
+		t.Fatalf("unexpected totals/ips: %+v", report)
+	}
+	if len(report.TopASNs) != 1 || report.TopASNs[0].Value != "64500" || report.TopASNs[0].Label != "EXAMPLE-NET" {
+		t.Fatalf("unexpected asns: %+v", report.TopASNs)
+	}
+	if len(report.Rules) != 3 || report.Rules[0].Owner != "SQL 注入拦截" || report.Rules[1].Owner != "限速" || report.Rules[2].Owner != "" {
+		t.Fatalf("unexpected rules: %+v", report.Rules)
+	}
+}
+
+func TestParseASNBlockExpressionMergesValues(t *testing.T) {
+	existing := parseASNBlockExpression("ip.src.asnum in {64501 64500}")
+	merged := mergeASNValues(existing, []uint32{64500, 13335})
+	if got := buildASNBlockExpression(merged); got != "ip.src.asnum in {13335 64500 64501}" {
+		t.Fatalf("unexpected expression: %s", got)
+	}
+}
@@ -0,0 +1,348 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+const zoneSnapshotVersion = 1
+
+// zoneSnapshotPhases 是快照/迁移时会整体复制的规则集阶段。
+var zoneSnapshotPhases = []string{firewallCustomPhase, cacheSettingsPhase, rateLimitPhase}
+
+// zoneSnapshotSettings 是快照/迁移时会复制的 Zone 设置，不可编辑的设置会被跳过。
+var zoneSnapshotSettings = []string{
+	"ssl", "always_use_https", "automatic_https_rewrites", "min_tls_version", "tls_1_3",
+	"opportunistic_encryption", "security_level", "browser_check", "challenge_ttl",
+	"brotli", "early_hints", "http3", "0rtt", "ipv6", "websockets",
+	"cache_level", "browser_cache_ttl", "always_online", "rocket_loader",
+	"email_obfuscation", "hotlink_protection", "ip_geolocation",
+}
+
+// ZoneSnapshot 是 Zone 的 DNS、规则集和关键设置的完整副本，可写入 JSON 文件后再回放。
+type ZoneSnapshot struct {
+	Version      int                         `json:"version"`
+	Domain       string                      `json:"domain"`
+	ZoneID       string                      `json:"zone_id"`
+	AccountLabel string                      `json:"account_label"`
+	TakenAt      time.Time                   `json:"taken_at"`
+	Reason       string                      `json:"reason,omitempty"`
+	DNSRecords   []SnapshotDNSRecord         `json:"dns_records"`
+	Rulesets     map[string][]map[string]any `json:"rulesets"`
+	Settings     map[string]any              `json:"settings"`
+}
+
+// SnapshotDNSRecord 只保留回放 DNS 记录需要的字段。
+type SnapshotDNSRecord struct {
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Content  string         `json:"content,omitempty"`
+	TTL      int            `json:"ttl"`
+	Proxied  *bool          `json:"proxied,omitempty"`
+	Priority *int           `json:"priority,omitempty"`
+	Comment  string         `json:"comment,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// Key 是 DNS 记录在快照对比/回放时的身份：类型 + 名称 + 内容。
+func (r SnapshotDNSRecord) Key() string {
+	content := r.Content
+	if content == "" && len(r.Data) > 0 {
+		content = jsonString(r.Data)
+	}
+	return strings.ToUpper(r.Type) + "|" + strings.ToLower(strings.TrimSuffix(r.Name, ".")) + "|" + content
+}
+
+// ZoneApplyOptions 控制回放行为；PruneDNS 会删除快照中不存在的 DNS 记录。
+type ZoneApplyOptions struct {
+	PruneDNS bool
+}
+
+// ZoneApplyResult 汇总一次快照回放的结果，单项失败不会中断其他项。
+type ZoneApplyResult struct {
+	DNSCreated      int
+	DNSUpdated      int
+	DNSDeleted      int
+	DNSUnchanged    int
+	RulesetsApplied []string
+	SettingsApplied []string
+	Failed          []string
+}
+
+type zoneSettingItem struct {
+	ID       string `json:"id"`
+	Value    any    `json:"value"`
+	Editable bool   `json:"editable"`
+}
+
+// SnapshotZone 读取 Zone 的 DNS 记录、zoneSnapshotPhases 规则集和 zoneSnapshotSettings 设置。
+func (c *apiClient) SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (ZoneSnapshot, error) {
+	snap := ZoneSnapshot{
+		Version:      zoneSnapshotVersion,
+		Domain:       strings.ToLower(strings.TrimSpace(domain)),
+		ZoneID:       zoneID,
+		AccountLabel: account.Label,
+		TakenAt:      time.Now().UTC(),
+		Rulesets:     map[string][]map[string]any{},
+		Settings:     map[string]any{},
+	}
+	if strings.TrimSpace(zoneID) == "" {
+		return snap, errors.New("zoneID is empty")
+	}
+	records, err := c.listSnapshotDNSRecords(ctx, account, zoneID)
+	if err != nil {
+		return snap, fmt.Errorf("读取 DNS 记录失败: %w", err)
+	}
+	snap.DNSRecords = records
+
+	for _, phase := range zoneSnapshotPhases {
+		var entry struct {
+			Rules []map[string]any `json:"rules"`
+		}
+		path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
+		if err := c.Do(ctx, account, http.MethodGet, path, nil, &entry); err != nil {
+			var apiErr *CloudflareAPIError
+			if errors.As(err, &apiErr) && apiErr.IsStatus(http.StatusNotFound) {
+				continue
+			}
+			return snap, fmt.Errorf("读取规则集 %s 失败: %w", phase, err)
+		}
+		rules := make([]map[string]any, 0, len(entry.Rules))
+		for _, rule := range entry.Rules {
+			rules = append(rules, cleanSnapshotRule(rule))
+		}
+		snap.Rulesets[phase] = rules
+	}
+
+	settings, err := c.listZoneSettings(ctx, account, zoneID)
+	if err != nil {
+		return snap, fmt.Errorf("读取 Zone 设置失败: %w", err)
+	}
+	for _, key := range zoneSnapshotSettings {
+		item, ok := settings[key]
+		if !ok || !item.Editable {
+			continue
+		}
+		snap.Settings[key] = item.Value
+	}
+	return snap, nil
+}
+
+// ApplyZoneSnapshot 把快照回放到 zoneID：DNS 按类型+名称+内容增量写入，规则集整体替换，设置逐项更新。
+func (c *apiClient) ApplyZoneSnapshot(ctx context.Context, account config.CF, zoneID string, snap ZoneSnapshot, opts ZoneApplyOptions) (ZoneApplyResult, error) {
+	var result ZoneApplyResult
+	if strings.TrimSpace(zoneID) == "" {
+		return result, errors.New("zoneID is empty")
+	}
+	existing, err := c.listSnapshotDNSRecords(ctx, account, zoneID)
+	if err != nil {
+		return result, fmt.Errorf("读取目标 DNS 记录失败: %w", err)
+	}
+	existingByKey := make(map[string]SnapshotDNSRecord, len(existing))
+	for _, record := range existing {
+		existingByKey[record.Key()] = record
+	}
+	wanted := make(map[string]struct{}, len(snap.DNSRecords))
+	for _, record := range snap.DNSRecords {
+		if skipSnapshotDNSRecord(record, snap.Domain) {
+			continue
+		}
+		key := record.Key()
+		wanted[key] = struct{}{}
+		body := snapshotDNSRecordBody(record)
+		current, ok := existingByKey[key]
+		if !ok {
+			if err := c.Do(ctx, account, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", zoneID), body, nil); err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("DNS %s %s: %v", record.Type, record.Name, err))
+				continue
+			}
+			result.DNSCreated++
+			continue
+		}
+		if snapshotDNSRecordEqual(current, record) {
+			result.DNSUnchanged++
+			continue
+		}
+		if err := c.Do(ctx, account, http.MethodPatch, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, current.ID), body, nil); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("DNS %s %s: %v", record.Type, record.Name, err))
+			continue
+		}
+		result.DNSUpdated++
+	}
+	if opts.PruneDNS {
+		for _, record := range existing {
+			if skipSnapshotDNSRecord(record, snap.Domain) {
+				continue
+			}
+			if _, ok := wanted[record.Key()]; ok {
+				continue
+			}
+			if err := c.Do(ctx, account, http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, record.ID), nil, nil); err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("删除 DNS %s %s: %v", record.Type, record.Name, err))
+				continue
+			}
+			result.DNSDeleted++
+		}
+	}
+
+	phases := make([]string, 0, len(snap.Rulesets))
+	for phase := range snap.Rulesets {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	for _, phase := range phases {
+		rules := make([]map[string]any, 0, len(snap.Rulesets[phase]))
+		for _, rule := range snap.Rulesets[phase] {
+			rules = append(rules, cleanSnapshotRule(rule))
+		}
+		path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
+		if err := c.Do(ctx, account, http.MethodPut, path, map[string]any{"rules": rules}, nil); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("规则集 %s: %v", phase, err))
+			continue
+		}
+		result.RulesetsApplied = append(result.RulesetsApplied, fmt.Sprintf("%s(%d)", phase, len(rules)))
+	}
+
+	current, err := c.listZoneSettings(ctx, account, zoneID)
+	if err != nil {
+		result.Failed = append(result.Failed, fmt.Sprintf("读取目标 Zone 设置失败: %v", err))
+		return result, nil
+	}
+	keys := make([]string, 0, len(snap.Settings))
+	for key := range snap.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := snap.Settings[key]
+		if item, ok := current[key]; ok && jsonEqual(item.Value, value) {
+			continue
+		}
+		path := fmt.Sprintf("/zones/%s/settings/%s", zoneID, key)
+		if err := c.Do(ctx, account, http.MethodPatch, path, map[string]any{"value": value}, nil); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("设置 %s: %v", key, err))
+			continue
+		}
+		result.SettingsApplied = append(result.SettingsApplied, key)
+	}
+	return result, nil
+}
+
+// DeleteZoneByID 按 zone_id 删除 Zone，避免同名 Zone 存在于多个账号时误删。
+func (c *apiClient) DeleteZoneByID(ctx context.Context, account config.CF, zoneID string) error {
+	if strings.TrimSpace(zoneID) == "" {
+		return errors.New("zoneID is empty")
+	}
+	return c.Do(ctx, account, http.MethodDelete, fmt.Sprintf("/zones/%s", zoneID), nil, nil)
+}
+
+func (c *apiClient) listSnapshotDNSRecords(ctx context.Context, account config.CF, zoneID string) ([]SnapshotDNSRecord, error) {
+	const perPage = 100
+	var out []SnapshotDNSRecord
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(perPage))
+		var records []SnapshotDNSRecord
+		if err := c.Do(ctx, account, http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?%s", zoneID, q.Encode()), nil, &records); err != nil {
+			return nil, err
+		}
+		out = append(out, records...)
+		if len(records) < perPage {
+			break
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Key() < out[j].Key() })
+	return out, nil
+}
+
+func (c *apiClient) listZoneSettings(ctx context.Context, account config.CF, zoneID string) (map[string]zoneSettingItem, error) {
+	var items []zoneSettingItem
+	if err := c.Do(ctx, account, http.MethodGet, fmt.Sprintf("/zones/%s/settings", zoneID), nil, &items); err != nil {
+		return nil, err
+	}
+	out := make(map[string]zoneSettingItem, len(items))
+	for _, item := range items {
+		out[item.ID] = item
+	}
+	return out, nil
+}
+
+// cleanSnapshotRule 去掉规则上由 Cloudflare 生成的字段，使其可以在其他 Zone 重新创建。
+func cleanSnapshotRule(rule map[string]any) map[string]any {
+	out := make(map[string]any, len(rule))
+	for key, value := range rule {
+		switch key {
+		case "id", "version", "last_updated", "ref", "categories":
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// skipSnapshotDNSRecord 跳过 Cloudflare 自己管理的根域 NS/SOA 记录。
+func skipSnapshotDNSRecord(record SnapshotDNSRecord, domain string) bool {
+	typ := strings.ToUpper(record.Type)
+	if typ == "SOA" {
+		return true
+	}
+	name := strings.ToLower(strings.TrimSuffix(record.Name, "."))
+	return typ == "NS" && (domain == "" || name == strings.ToLower(domain))
+}
+
+func snapshotDNSRecordBody(record SnapshotDNSRecord) map[string]any {
+	body := map[string]any{
+		"type": record.Type,
+		"name": record.Name,
+		"ttl":  record.TTL,
+	}
+	if record.Content != "" {
+		body["content"] = record.Content
+	}
+	if record.Proxied != nil {
+		body["proxied"] = *record.Proxied
+	}
+	if record.Priority != nil {
+		body["priority"] = *record.Priority
+	}
+	if record.Comment != "" {
+		body["comment"] = record.Comment
+	}
+	if len(record.Data) > 0 {
+		body["data"] = record.Data
+	}
+	return body
+}
+
+func snapshotDNSRecordEqual(a, b SnapshotDNSRecord) bool {
+	if a.TTL != b.TTL || a.Comment != b.Comment {
+		return false
+	}
+	if (a.Proxied == nil) != (b.Proxied == nil) || (a.Proxied != nil && *a.Proxied != *b.Proxied) {
+		return false
+	}
+	if (a.Priority == nil) != (b.Priority == nil) || (a.Priority != nil && *a.Priority != *b.Priority) {
+		return false
+	}
+	return true
+}
+
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
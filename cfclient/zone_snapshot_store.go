@@ -0,0 +1,284 @@
+package cfclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const zoneSnapshotIDLayout = "20060102-150405"
+
+// ZoneSnapshotStore 把快照按 <Dir>/<domain>/<id>.json 存到本地磁盘。
+// Retention 之前的快照会被清理，但每个 Zone 至少保留最新一份；MaxPerZone > 0 时限制单个 Zone 的份数。
+type ZoneSnapshotStore struct {
+	Dir        string
+	Retention  time.Duration
+	MaxPerZone int
+}
+
+// ZoneSnapshotInfo 是快照文件的摘要，用于列表展示。
+type ZoneSnapshotInfo struct {
+	ID           string
+	Domain       string
+	AccountLabel string
+	ZoneID       string
+	TakenAt      time.Time
+	Reason       string
+	DNSRecords   int
+	Rules        int
+	Settings     int
+	Path         string
+}
+
+// ZoneSnapshotDiff 描述 Current 相对 Base 的变化；DNS 记录按 类型+名称+内容 对齐。
+type ZoneSnapshotDiff struct {
+	DNSAdded        []SnapshotDNSRecord
+	DNSRemoved      []SnapshotDNSRecord
+	DNSChanged      []SnapshotDNSRecord
+	RulesetsChanged []string
+	SettingsChanged []string
+}
+
+// Empty 表示两个快照没有差异。
+func (d ZoneSnapshotDiff) Empty() bool {
+	return len(d.DNSAdded) == 0 && len(d.DNSRemoved) == 0 && len(d.DNSChanged) == 0 &&
+		len(d.RulesetsChanged) == 0 && len(d.SettingsChanged) == 0
+}
+
+func NewZoneSnapshotStore(dir string, retention time.Duration, maxPerZone int) *ZoneSnapshotStore {
+	return &ZoneSnapshotStore{Dir: dir, Retention: retention, MaxPerZone: maxPerZone}
+}
+
+// Save 写入快照并按保留策略清理同一 Zone 的旧快照；keep 中的快照 ID 不会被清理。
+func (s *ZoneSnapshotStore) Save(snap ZoneSnapshot, keep ...string) (ZoneSnapshotInfo, error) {
+	domain := normalizeSnapshotDomain(snap.Domain)
+	if domain == "" {
+		return ZoneSnapshotInfo{}, errors.New("快照缺少域名")
+	}
+	if snap.TakenAt.IsZero() {
+		snap.TakenAt = time.Now().UTC()
+	}
+	dir := filepath.Join(s.Dir, domain)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ZoneSnapshotInfo{}, fmt.Errorf("创建快照目录 %s 失败: %w", dir, err)
+	}
+	base := snap.TakenAt.UTC().Format(zoneSnapshotIDLayout)
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return ZoneSnapshotInfo{}, fmt.Errorf("序列化快照失败: %w", err)
+	}
+	path := filepath.Join(dir, id+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return ZoneSnapshotInfo{}, fmt.Errorf("写入快照 %s 失败: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return ZoneSnapshotInfo{}, fmt.Errorf("替换快照 %s 失败: %w", path, err)
+	}
+	if _, err := s.Prune(domain, time.Now(), keep...); err != nil {
+		return zoneSnapshotInfo(id, path, snap), err
+	}
+	return zoneSnapshotInfo(id, path, snap), nil
+}
+
+// List 按时间倒序返回某个域名的快照。
+func (s *ZoneSnapshotStore) List(domain string) ([]ZoneSnapshotInfo, error) {
+	domain = normalizeSnapshotDomain(domain)
+	if domain == "" {
+		return nil, errors.New("域名不能为空")
+	}
+	dir := filepath.Join(s.Dir, domain)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取快照目录 %s 失败: %w", dir, err)
+	}
+	var out []ZoneSnapshotInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		path := filepath.Join(dir, entry.Name())
+		snap, err := readZoneSnapshotFile(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, zoneSnapshotInfo(id, path, snap))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].TakenAt.Equal(out[j].TakenAt) {
+			return out[i].TakenAt.After(out[j].TakenAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out, nil
+}
+
+// Load 读取指定快照；id 可以是完整 ID，也可以是 "latest"。
+func (s *ZoneSnapshotStore) Load(domain, id string) (ZoneSnapshot, ZoneSnapshotInfo, error) {
+	id = strings.TrimSuffix(strings.TrimSpace(id), ".json")
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return ZoneSnapshot{}, ZoneSnapshotInfo{}, fmt.Errorf("快照 ID 无效: %q", id)
+	}
+	if strings.EqualFold(id, "latest") {
+		items, err := s.List(domain)
+		if err != nil {
+			return ZoneSnapshot{}, ZoneSnapshotInfo{}, err
+		}
+		if len(items) == 0 {
+			return ZoneSnapshot{}, ZoneSnapshotInfo{}, fmt.Errorf("%s 没有快照", domain)
+		}
+		id = items[0].ID
+	}
+	path := filepath.Join(s.Dir, normalizeSnapshotDomain(domain), id+".json")
+	snap, err := readZoneSnapshotFile(path)
+	if err != nil {
+		return ZoneSnapshot{}, ZoneSnapshotInfo{}, err
+	}
+	return snap, zoneSnapshotInfo(id, path, snap), nil
+}
+
+// Prune 按保留策略删除旧快照，返回删除的文件数；keep 中的快照 ID（例如正在恢复的快照）始终保留。
+func (s *ZoneSnapshotStore) Prune(domain string, now time.Time, keep ...string) (int, error) {
+	items, err := s.List(domain)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	var errs []error
+	for i, item := range items {
+		if i == 0 || slices.Contains(keep, item.ID) {
+			continue
+		}
+		expired := s.Retention > 0 && now.Sub(item.TakenAt) > s.Retention
+		overflow := s.MaxPerZone > 0 && i >= s.MaxPerZone
+		if !expired && !overflow {
+			continue
+		}
+		if err := os.Remove(item.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("删除快照 %s 失败: %w", item.Path, err))
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// DiffZoneSnapshots 对比两个快照，返回 current 相对 base 的变化。
+func DiffZoneSnapshots(base, current ZoneSnapshot) ZoneSnapshotDiff {
+	var diff ZoneSnapshotDiff
+
+	baseRecords := make(map[string]SnapshotDNSRecord)
+	for _, record := range base.DNSRecords {
+		if !skipSnapshotDNSRecord(record, base.Domain) {
+			baseRecords[record.Key()] = record
+		}
+	}
+	seen := make(map[string]bool)
+	for _, record := range current.DNSRecords {
+		if skipSnapshotDNSRecord(record, current.Domain) {
+			continue
+		}
+		key := record.Key()
+		seen[key] = true
+		old, ok := baseRecords[key]
+		switch {
+		case !ok:
+			diff.DNSAdded = append(diff.DNSAdded, record)
+		case !snapshotDNSRecordEqual(old, record):
+			diff.DNSChanged = append(diff.DNSChanged, record)
+		}
+	}
+	for _, record := range base.DNSRecords {
+		if skipSnapshotDNSRecord(record, base.Domain) || seen[record.Key()] {
+			continue
+		}
+		diff.DNSRemoved = append(diff.DNSRemoved, record)
+	}
+
+	for _, phase := range unionSnapshotKeys(base.Rulesets, current.Rulesets) {
+		if jsonString(base.Rulesets[phase]) != jsonString(current.Rulesets[phase]) {
+			diff.RulesetsChanged = append(diff.RulesetsChanged, phase)
+		}
+	}
+	for _, key := range unionSnapshotKeys(base.Settings, current.Settings) {
+		if jsonString(base.Settings[key]) != jsonString(current.Settings[key]) {
+			diff.SettingsChanged = append(diff.SettingsChanged, key)
+		}
+	}
+	return diff
+}
+
+func unionSnapshotKeys[V any](a, b map[string]V) []string {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+	out := make([]string, 0, len(keys))
+	for key := range keys {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func readZoneSnapshotFile(path string) (ZoneSnapshot, error) {
+	var snap ZoneSnapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return snap, fmt.Errorf("快照不存在: %s", filepath.Base(path))
+		}
+		return snap, fmt.Errorf("读取快照 %s 失败: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("解析快照 %s 失败: %w", path, err)
+	}
+	return snap, nil
+}
+
+func zoneSnapshotInfo(id, path string, snap ZoneSnapshot) ZoneSnapshotInfo {
+	rules := 0
+	for _, items := range snap.Rulesets {
+		rules += len(items)
+	}
+	return ZoneSnapshotInfo{
+		ID:           id,
+		Domain:       snap.Domain,
+		AccountLabel: snap.AccountLabel,
+		ZoneID:       snap.ZoneID,
+		TakenAt:      snap.TakenAt,
+		Reason:       snap.Reason,
+		DNSRecords:   len(snap.DNSRecords),
+		Rules:        rules,
+		Settings:     len(snap.Settings),
+		Path:         path,
+	}
+}
+
+func normalizeSnapshotDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if strings.ContainsAny(domain, `/\`) || strings.Contains(domain, "..") {
+		return ""
+	}
+	return domain
+}
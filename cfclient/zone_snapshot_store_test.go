@@ -0,0 +1,88 @@
+package cfclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneSnapshotStoreSaveListLoadAndPrune(t *testing.T) {
+	store := NewZoneSnapshotStore(t.TempDir(), 48*time.Hour, 3)
+	now := time.Now().UTC()
+	for _, age := range []time.Duration{96 * time.Hour, 30 * time.Hour, 20 * time.Hour, 10 * time.Hour, time.Hour} {
+		if _, err := store.Save(ZoneSnapshot{Domain: "Example.com", TakenAt: now.Add(-age), Reason: "scheduled"}); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+
+	items, err := store.List("example.com")
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected retention to keep 3 snapshots, got %d: %+v", len(items), items)
+	}
+	if !items[0].TakenAt.After(items[1].TakenAt) {
+		t.Fatalf("expected newest first: %+v", items)
+	}
+
+	snap, info, err := store.Load("example.com", "latest")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if info.ID != items[0].ID || snap.Reason != "scheduled" {
+		t.Fatalf("unexpected latest snapshot: %+v %+v", info, snap)
+	}
+	if _, _, err := store.Load("example.com", "../secret"); err == nil {
+		t.Fatalf("expected invalid id to be rejected")
+	}
+
+	old := NewZoneSnapshotStore(store.Dir, time.Minute, 0)
+	removed, err := old.Prune("example.com", now)
+	if err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("expected latest snapshot to survive retention, removed=%d", removed)
+	}
+}
+
+func TestDiffZoneSnapshotsReportsRecordRulesetAndSettingChanges(t *testing.T) {
+	on, off := true, false
+	base := ZoneSnapshot{
+		Domain: "example.com",
+		DNSRecords: []SnapshotDNSRecord{
+			{Type: "NS", Name: "example.com", Content: "a.ns.cloudflare.com", TTL: 1},
+			{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 1, Proxied: &on},
+			{Type: "A", Name: "old.example.com", Content: "203.0.113.2", TTL: 1},
+		},
+		Rulesets: map[string][]map[string]any{firewallCustomPhase: {{"expression": "true"}}},
+		Settings: map[string]any{"ssl": "strict", "brotli": "on"},
+	}
+	current := ZoneSnapshot{
+		Domain: "example.com",
+		DNSRecords: []SnapshotDNSRecord{
+			{Type: "NS", Name: "example.com", Content: "b.ns.cloudflare.com", TTL: 1},
+			{Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 1, Proxied: &off},
+			{Type: "A", Name: "new.example.com", Content: "203.0.113.3", TTL: 1},
+		},
+		Rulesets: map[string][]map[string]any{firewallCustomPhase: {{"expression": "true"}}},
+		Settings: map[string]any{"ssl": "full", "brotli": "on"},
+	}
+
+	diff := DiffZoneSnapshots(base, current)
+	if len(diff.DNSAdded) != 1 || diff.DNSAdded[0].Name != "new.example.com" {
+		t.Fatalf("unexpected added records: %+v", diff.DNSAdded)
+	}
+	if len(diff.DNSRemoved) != 1 || diff.DNSRemoved[0].Name != "old.example.com" {
+		t.Fatalf("unexpected removed records: %+v", diff.DNSRemoved)
+	}
+	if len(diff.DNSChanged) != 1 || diff.DNSChanged[0].Name != "www.example.com" {
+		t.Fatalf("unexpected changed records: %+v", diff.DNSChanged)
+	}
+	if len(diff.RulesetsChanged) != 0 || len(diff.SettingsChanged) != 1 || diff.SettingsChanged[0] != "ssl" {
+		t.Fatalf("unexpected ruleset/setting diff: %+v", diff)
+	}
+	if !DiffZoneSnapshots(base, base).Empty() {
+		t.Fatalf("expected identical snapshots to have empty diff")
+	}
+}
@@ -0,0 +1,121 @@
+package cfclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"DomainC/config"
+)
+
+func TestSnapshotZoneCollectsRecordsRulesetsAndSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/dns_records":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{
+				{"id": "ns1", "type": "NS", "name": "example.com", "content": "a.ns.cloudflare.com", "ttl": 1},
+				{"id": "a1", "type": "A", "name": "www.example.com", "content": "203.0.113.1", "ttl": 1, "proxied": true},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/rulesets/phases/http_request_firewall_custom/entrypoint":
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "rs1", "rules": []map[string]any{
+				{"id": "rule1", "version": "3", "description": "telegram-auto-sqli-block", "expression": "true", "action": "block", "enabled": true},
+			}})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/zones/zone1/rulesets/phases/"):
+			writeCFResponse(t, w, http.StatusNotFound, false, nil, "not found")
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone1/settings":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{
+				{"id": "ssl", "value": "strict", "editable": true},
+				{"id": "http2", "value": "on", "editable": false},
+				{"id": "always_use_https", "value": "on", "editable": false},
+			})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server)
+	snap, err := client.SnapshotZone(context.Background(), config.CF{Label: "src", APIToken: "secret"}, "zone1", "Example.com")
+	if err != nil {
+		t.Fatalf("SnapshotZone returned error: %v", err)
+	}
+	if snap.Domain != "example.com" || len(snap.DNSRecords) != 2 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	rules := snap.Rulesets[firewallCustomPhase]
+	if len(rules) != 1 || rules[0]["id"] != nil || rules[0]["version"] != nil || rules[0]["description"] != "telegram-auto-sqli-block" {
+		t.Fatalf("unexpected rules: %+v", snap.Rulesets)
+	}
+	if _, ok := snap.Rulesets[cacheSettingsPhase]; ok {
+		t.Fatalf("missing entrypoint should be skipped: %+v", snap.Rulesets)
+	}
+	if len(snap.Settings) != 1 || snap.Settings["ssl"] != "strict" {
+		t.Fatalf("unexpected settings: %+v", snap.Settings)
+	}
+}
+
+func TestApplyZoneSnapshotReplaysIntoEmptyZone(t *testing.T) {
+	var mu sync.Mutex
+	var created []map[string]any
+	var putRules map[string]any
+	var patched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone2/dns_records":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{})
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/zone2/dns_records":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body)
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "new"})
+		case r.Method == http.MethodPut && r.URL.Path == "/zones/zone2/rulesets/phases/http_request_firewall_custom/entrypoint":
+			_ = json.NewDecoder(r.Body).Decode(&putRules)
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{"id": "rs2"})
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/zone2/settings":
+			writeCFResponse(t, w, http.StatusOK, true, []map[string]any{
+				{"id": "ssl", "value": "full", "editable": true},
+				{"id": "brotli", "value": "on", "editable": true},
+			})
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/zones/zone2/settings/"):
+			patched = append(patched, strings.TrimPrefix(r.URL.Path, "/zones/zone2/settings/"))
+			writeCFResponse(t, w, http.StatusOK, true, map[string]any{})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	proxied := true
+	snap := ZoneSnapshot{
+		Domain: "example.com",
+		DNSRecords: []SnapshotDNSRecord{
+			{ID: "ns1", Type: "NS", Name: "example.com", Content: "a.ns.cloudflare.com", TTL: 1},
+			{ID: "a1", Type: "A", Name: "www.example.com", Content: "203.0.113.1", TTL: 1, Proxied: &proxied},
+		},
+		Rulesets: map[string][]map[string]any{
+			firewallCustomPhase: {{"id": "old", "description": "telegram-auto-sqli-block", "expression": "true", "action": "block", "enabled": true}},
+		},
+		Settings: map[string]any{"ssl": "strict", "brotli": "on"},
+	}
+	client := newTestAPIClient(server)
+	result, err := client.ApplyZoneSnapshot(context.Background(), config.CF{Label: "dst", APIToken: "secret"}, "zone2", snap, ZoneApplyOptions{})
+	if err != nil {
+		t.Fatalf("ApplyZoneSnapshot returned error: %v", err)
+	}
+	if len(result.Failed) != 0 || result.DNSCreated != 1 || len(created) != 1 || created[0]["name"] != "www.example.com" || created[0]["proxied"] != true {
+		t.Fatalf("unexpected dns result: %+v created=%+v", result, created)
+	}
+	rules, _ := putRules["rules"].([]any)
+	if len(rules) != 1 || rules[0].(map[string]any)["id"] != nil {
+		t.Fatalf("unexpected ruleset body: %+v", putRules)
+	}
+	if len(patched) != 1 || patched[0] != "ssl" {
+		t.Fatalf("expected only changed settings to be patched, got %v", patched)
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccountSelector 是批量命令的账号选择器，多个条件用逗号分隔并取并集：
+//
+//	all            全部账号
+//	<label>        指定账号
+//	group:<name>   cloudflareAccounts[].group 为 name 的账号
+//	tag:<name>     cloudflareAccounts[].tags 含 name 的账号（调用方还可以按资产缓存中的 Zone 标签补充域名）
+type AccountSelector struct {
+	Raw    string
+	All    bool
+	Labels []string
+	Groups []string
+	Tags   []string
+}
+
+// ParseAccountSelector 解析选择器字符串，空字符串或空条件返回错误。
+func ParseAccountSelector(raw string) (AccountSelector, error) {
+	sel := AccountSelector{Raw: strings.TrimSpace(raw)}
+	for _, term := range strings.Split(sel.Raw, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		kind, value, ok := strings.Cut(term, ":")
+		if !ok {
+			if isAllAccountsTerm(term) {
+				sel.All = true
+			} else {
+				sel.Labels = append(sel.Labels, term)
+			}
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return AccountSelector{}, fmt.Errorf("选择器 %q 缺少名称", term)
+		}
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "group", "g":
+			sel.Groups = append(sel.Groups, value)
+		case "tag", "t":
+			sel.Tags = append(sel.Tags, value)
+		default:
+			return AccountSelector{}, fmt.Errorf("未知选择器类型 %q（支持 all、账号标签、group:名称、tag:名称）", kind)
+		}
+	}
+	if !sel.All && len(sel.Labels) == 0 && len(sel.Groups) == 0 && len(sel.Tags) == 0 {
+		return AccountSelector{}, fmt.Errorf("账号选择器为空")
+	}
+	return sel, nil
+}
+
+// IsMultiAccountSelector 判断参数是否为可能命中多个账号的选择器（all、group:、tag: 或逗号列表）。
+func IsMultiAccountSelector(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	return isAllAccountsTerm(raw) || strings.Contains(raw, ",") || strings.Contains(raw, ":")
+}
+
+func isAllAccountsTerm(term string) bool {
+	switch strings.ToLower(strings.TrimSpace(term)) {
+	case "all", "*", "all_accounts", "allaccounts", "accounts", "全部", "全部账号", "所有账号":
+		return true
+	}
+	return false
+}
+
+// Match 返回按账号标签、分组、标签命中的账号，保持配置顺序。
+// 未知的账号标签返回错误，分组或标签没有命中不算错误（标签可能只用于 Zone）。
+func (s AccountSelector) Match(accounts []CF) ([]CF, error) {
+	for _, label := range s.Labels {
+		found := false
+		for _, acc := range accounts {
+			if strings.EqualFold(strings.TrimSpace(acc.Label), label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未找到 Cloudflare 账号: %s", label)
+		}
+	}
+	var out []CF
+	for _, acc := range accounts {
+		if s.matches(acc) {
+			out = append(out, acc)
+		}
+	}
+	return out, nil
+}
+
+func (s AccountSelector) matches(acc CF) bool {
+	if s.All {
+		return true
+	}
+	for _, label := range s.Labels {
+		if strings.EqualFold(strings.TrimSpace(acc.Label), label) {
+			return true
+		}
+	}
+	for _, group := range s.Groups {
+		if strings.EqualFold(strings.TrimSpace(acc.Group), group) {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		if acc.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag 判断账号是否带有指定标签（忽略大小写）。
+func (c CF) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(strings.TrimSpace(t), strings.TrimSpace(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountGroups 返回配置中出现的分组名，按首次出现顺序。
+func AccountGroups(accounts []CF) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, acc := range accounts {
+		group := strings.TrimSpace(acc.Group)
+		if group == "" || seen[strings.ToLower(group)] {
+			continue
+		}
+		seen[strings.ToLower(group)] = true
+		out = append(out, group)
+	}
+	return out
+}
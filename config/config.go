@@ -6,35 +6,71 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	AlertDays           int         `yaml:"alertDays"`
-	AssetCacheFile      string      `yaml:"assetCacheFile"`
-	AbuseReport         AbuseReport `yaml:"abuseReport"`
-	Telegram            Telegram    `yaml:"telegram"`
-	CloudflareAccounts  []CF        `yaml:"cloudflareAccounts"`
-	CloudflareProvision CFProvision `yaml:"cloudflareProvision"`
-	Registrars          []Registrar `yaml:"registrars"`
-	DomainFiles         []string    `yaml:"domainFiles"`
+	AlertDays           int          `yaml:"alertDays"`
+	AssetCacheFile      string       `yaml:"assetCacheFile"`
+	AbuseReport         AbuseReport  `yaml:"abuseReport"`
+	IPBlock             IPBlock      `yaml:"ipBlock"`
+	AttackMode          AttackMode   `yaml:"attackMode"`
+	ZoneMove            ZoneMove     `yaml:"zoneMove"`
+	WAFEvents           WAFEvents    `yaml:"wafEvents"`
+	TrafficAlert        TrafficAlert `yaml:"trafficAlert"`
+	ZoneSnapshot        ZoneSnapshot `yaml:"zoneSnapshot"`
+	DNSUndo             DNSUndo      `yaml:"dnsUndo"`
+	OperationLog        OperationLog `yaml:"operationLog"`
+	DryRun              DryRun       `yaml:"dryRun"`
+	Approval            Approval     `yaml:"approval"`
+	API                 API          `yaml:"api"`
+	Metrics             Metrics      `yaml:"metrics"`
+	Schedule            Schedule     `yaml:"schedule"`
+	Leader              Leader       `yaml:"leader"`
+	Reload              Reload       `yaml:"reload"`
+	Secrets             Secrets      `yaml:"secrets"`
+	TokenCheck          TokenCheck   `yaml:"tokenCheck"`
+	Telegram            Telegram     `yaml:"telegram"`
+	CloudflareAccounts  []CF         `yaml:"cloudflareAccounts"`
+	CloudflareProvision CFProvision  `yaml:"cloudflareProvision"`
+	Registrars          []Registrar  `yaml:"registrars"`
+	DomainFiles         []string     `yaml:"domainFiles"`
 
 	AWSTargets map[string]AWSTarget `yaml:"awsTargets"`
 }
 
 type Telegram struct {
-	BotToken       string  `yaml:"botToken"`
-	ChatID         int64   `yaml:"chatID"`
-	ChatIDs        []int64 `yaml:"chatIDs"`
-	AllowedChatIDs []int64 `yaml:"allowedChatIds"`
+	BotToken       string          `yaml:"botToken"`
+	ChatID         int64           `yaml:"chatID"`
+	ChatIDs        []int64         `yaml:"chatIDs"`
+	AllowedChatIDs []int64         `yaml:"allowedChatIds"`
+	Webhook        TelegramWebhook `yaml:"webhook"`
 }
 
+// TelegramWebhook 配置 webhook 模式；未配置 url 时使用长轮询。
+// 配置了 certFile/keyFile 时内置 HTTPS 服务，否则监听 HTTP（由前置负载均衡终止 TLS）。
+type TelegramWebhook struct {
+	Enabled        *bool  `yaml:"enabled"`
+	URL            string `yaml:"url"`
+	ListenAddr     string `yaml:"listenAddr"`
+	SecretToken    string `yaml:"secretToken"`
+	CertFile       string `yaml:"certFile"`
+	KeyFile        string `yaml:"keyFile"`
+	UploadCert     bool   `yaml:"uploadCert"`
+	MaxConnections int    `yaml:"maxConnections"`
+}
+
+// CF 是一个 Cloudflare 账号；Group 和 Tags 供批量命令的 group:/tag: 选择器使用。
 type CF struct {
-	Label     string `yaml:"label"`
-	Email     string `yaml:"email"`
-	APIToken  string `yaml:"apiToken"`
-	AccountID string `yaml:"accountID"`
+	Label     string   `yaml:"label"`
+	Email     string   `yaml:"email"`
+	APIToken  string   `yaml:"apiToken"`
+	AccountID string   `yaml:"accountID"`
+	Group     string   `yaml:"group"`
+	Tags      []string `yaml:"tags"`
 }
 
 type CFProvision struct {
@@ -62,12 +98,138 @@ type GoDaddyConfig struct {
 	APISecret string `yaml:"apiSecret"`
 }
 type AbuseReport struct {
+	Enabled        *bool  `yaml:"enabled"`
+	CacheFile      string `yaml:"cacheFile"`
+	ScanHour       int    `yaml:"scanHour"`
+	ScanMinute     int    `yaml:"scanMinute"`
+	PerPage        int    `yaml:"perPage"`
+	MaxPages       int    `yaml:"maxPages"`
+	DigestOpenDays int    `yaml:"digestOpenDays"`
+}
+
+type IPBlock struct {
+	ExpiryFile           string `yaml:"expiryFile"`
+	SweepIntervalMinutes int    `yaml:"sweepIntervalMinutes"`
+}
+
+type AttackMode struct {
+	StateFile string `yaml:"stateFile"`
+}
+
+type ZoneMove struct {
+	StateFile string `yaml:"stateFile"`
+}
+
+type WAFEvents struct {
+	DailyEnabled *bool `yaml:"dailyEnabled"`
+	ReportHour   int   `yaml:"reportHour"`
+	ReportMinute int   `yaml:"reportMinute"`
+	Hours        int   `yaml:"hours"`
+}
+
+type TrafficAlert struct {
+	Enabled            *bool   `yaml:"enabled"`
+	BaselineFile       string  `yaml:"baselineFile"`
+	IntervalMinutes    int     `yaml:"intervalMinutes"`
+	WindowMinutes      int     `yaml:"windowMinutes"`
+	SpikeRatio         float64 `yaml:"spikeRatio"`
+	DropRatio          float64 `yaml:"dropRatio"`
+	Max5xxRate         float64 `yaml:"max5xxRate"`
+	MaxOriginErrorRate float64 `yaml:"maxOriginErrorRate"`
+	MinRequests        int     `yaml:"minRequests"`
+	MinSamples         int     `yaml:"minSamples"`
+	MaxSamples         int     `yaml:"maxSamples"`
+	CooldownMinutes    int     `yaml:"cooldownMinutes"`
+}
+
+type ZoneSnapshot struct {
+	Enabled       *bool  `yaml:"enabled"`
+	Dir           string `yaml:"dir"`
+	RetentionDays int    `yaml:"retentionDays"`
+	MaxPerZone    int    `yaml:"maxPerZone"`
+	Hour          int    `yaml:"hour"`
+	Minute        int    `yaml:"minute"`
+}
+
+type DNSUndo struct {
+	StateFile     string `yaml:"stateFile"`
+	WindowMinutes int    `yaml:"windowMinutes"`
+}
+
+// OperationLog 记录机器人对 Zone 的写操作，供滥用报告等场景查询最近谁动过该域名。
+type OperationLog struct {
+	File          string `yaml:"file"`
+	RetentionDays int    `yaml:"retentionDays"`
+}
+
+type DryRun struct {
+	Enabled *bool  `yaml:"enabled"`
+	PlanDir string `yaml:"planDir"`
+}
+
+// Approval 配置需要第二人批准的操作；Approvers 为 Telegram 用户 ID 或用户名，留空表示允许的群内任何其他人。
+type Approval struct {
+	Actions       []string `yaml:"actions"`
+	Approvers     []string `yaml:"approvers"`
+	WindowMinutes int      `yaml:"windowMinutes"`
+}
+
+// API 是可选的 HTTP REST 接口；Tokens 为空时不启动。
+type API struct {
+	Enabled    *bool      `yaml:"enabled"`
+	ListenAddr string     `yaml:"listenAddr"`
+	Tokens     []APIToken `yaml:"tokens"`
+}
+
+// Metrics 是可选的 Prometheus 指标与健康检查监听，listenAddr 非空时默认开启。
+type Metrics struct {
 	Enabled    *bool  `yaml:"enabled"`
-	CacheFile  string `yaml:"cacheFile"`
-	ScanHour   int    `yaml:"scanHour"`
-	ScanMinute int    `yaml:"scanMinute"`
-	PerPage    int    `yaml:"perPage"`
-	MaxPages   int    `yaml:"maxPages"`
+	ListenAddr string `yaml:"listenAddr"`
+}
+
+// Schedule 声明定时任务；jobs 中未列出的内置任务沿用各功能原有的 hour/minute 配置。
+type Schedule struct {
+	Timezone       string         `yaml:"timezone"`
+	StateFile      string         `yaml:"stateFile"`
+	CatchUpMinutes int            `yaml:"catchUpMinutes"`
+	Jobs           []ScheduledJob `yaml:"jobs"`
+}
+
+// ScheduledJob 的 Cron 为 5 段 cron 表达式（分 时 日 月 周）或 @daily 等简写，Timezone 为 IANA 时区名。
+type ScheduledJob struct {
+	Name           string `yaml:"name"`
+	Cron           string `yaml:"cron"`
+	Timezone       string `yaml:"timezone"`
+	Enabled        *bool  `yaml:"enabled"`
+	CatchUpMinutes *int   `yaml:"catchUpMinutes"`
+}
+
+// Leader 控制单活实例选举；多个实例需指向同一个 lockFile（例如共享卷）。
+type Leader struct {
+	Enabled      *bool  `yaml:"enabled"`
+	Backend      string `yaml:"backend"`
+	LockFile     string `yaml:"lockFile"`
+	LeaseSeconds int    `yaml:"leaseSeconds"`
+	InstanceID   string `yaml:"instanceId"`
+}
+
+// Reload 控制配置热加载；SIGHUP 始终触发重新加载，watch 开启时还会按间隔检查文件修改时间。
+type Reload struct {
+	Watch           *bool `yaml:"watch"`
+	IntervalSeconds int   `yaml:"intervalSeconds"`
+}
+
+// TokenCheck 控制 Cloudflare API token 权限自检；startup 默认开启，只在发现问题时推送。
+type TokenCheck struct {
+	Startup        *bool `yaml:"startup"`
+	ExpiryWarnDays int   `yaml:"expiryWarnDays"`
+}
+
+// APIToken 的 Role 为 read、write 或 admin，高级角色包含低级角色的权限。
+type APIToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Role  string `yaml:"role"`
 }
 
 type AWSCreds struct {
@@ -81,89 +243,224 @@ type AWSTarget struct {
 	Creds  AWSCreds `yaml:"creds"`
 }
 
-var Cfg Config
+// current 保存当前生效的配置。热加载用 Set 整体替换指针，读取方通过 Cfg() 拿到一致的快照，不得修改返回值。
+var current atomic.Pointer[Config]
+
+func init() { current.Store(&Config{}) }
+
+// Cfg 返回当前配置快照，可以在任意 goroutine 中并发调用。
+func Cfg() *Config { return current.Load() }
+
+// Set 原子替换当前配置，供启动加载、热加载和测试使用。
+func Set(c Config) { current.Store(&c) }
+
+var loadedPath string
 
 func Load(path string) error {
+	parsed, err := Parse(path)
+	if err != nil {
+		return err
+	}
+	Set(parsed)
+	loadedPath = path
+	return nil
+}
+
+// Parse 读取配置文件、应用环境变量覆盖并解析 env:/file:/enc: 密钥引用，但不修改当前配置，供热加载先校验再替换。
+func Parse(path string) (Config, error) {
+	var parsed Config
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("读取配置文件失败: %w", err)
+		return parsed, fmt.Errorf("读取配置文件失败: %w", err)
 	}
-	if err := yaml.Unmarshal(data, &Cfg); err != nil {
-		return fmt.Errorf("解析配置失败: %w", err)
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return parsed, fmt.Errorf("解析配置失败: %w", err)
 	}
-	applyEnvOverrides()
-	return nil
+	applyEnvOverrides(&parsed)
+	if err := resolveSecrets(&parsed); err != nil {
+		return parsed, fmt.Errorf("解析密钥失败: %w", err)
+	}
+	return parsed, nil
 }
 
-func applyEnvOverrides() {
+// LoadedPath 返回最近一次 Load 使用的配置文件路径。
+func LoadedPath() string {
+	if loadedPath == "" {
+		return "config.yaml"
+	}
+	return loadedPath
+}
+
+func applyEnvOverrides(c *Config) {
 	if token := strings.TrimSpace(os.Getenv("CLOUDFLARE_API_TOKEN")); token != "" {
 		accountID := strings.TrimSpace(os.Getenv("CLOUDFLARE_ACCOUNT_ID"))
-		if len(Cfg.CloudflareAccounts) == 0 {
-			Cfg.CloudflareAccounts = append(Cfg.CloudflareAccounts, CF{
+		if len(c.CloudflareAccounts) == 0 {
+			c.CloudflareAccounts = append(c.CloudflareAccounts, CF{
 				Label:     "env",
 				APIToken:  token,
 				AccountID: accountID,
 			})
 		} else {
-			if strings.TrimSpace(Cfg.CloudflareAccounts[0].APIToken) == "" {
-				Cfg.CloudflareAccounts[0].APIToken = token
+			if strings.TrimSpace(c.CloudflareAccounts[0].APIToken) == "" {
+				c.CloudflareAccounts[0].APIToken = token
 			}
-			if accountID != "" && strings.TrimSpace(Cfg.CloudflareAccounts[0].AccountID) == "" {
-				Cfg.CloudflareAccounts[0].AccountID = accountID
+			if accountID != "" && strings.TrimSpace(c.CloudflareAccounts[0].AccountID) == "" {
+				c.CloudflareAccounts[0].AccountID = accountID
 			}
 		}
 	}
 
 	if value := strings.TrimSpace(os.Getenv("CF_DEFAULT_BLOCK_COUNTRIES")); value != "" {
-		Cfg.CloudflareProvision.DefaultBlockCountries = value
+		c.CloudflareProvision.DefaultBlockCountries = value
 	}
 	if value := strings.TrimSpace(os.Getenv("CF_ENABLE_SPEED_RECOMMENDATIONS")); value != "" {
 		if parsed, ok := parseBool(value); ok {
-			Cfg.CloudflareProvision.EnableSpeedRecommendations = &parsed
+			c.CloudflareProvision.EnableSpeedRecommendations = &parsed
 		}
 	}
 	if value := strings.TrimSpace(os.Getenv("CF_ENABLE_RUM_AUTO_INSTALL")); value != "" {
 		if parsed, ok := parseBool(value); ok {
-			Cfg.CloudflareProvision.EnableRUMAutoInstall = &parsed
+			c.CloudflareProvision.EnableRUMAutoInstall = &parsed
 		}
 	}
 	if value := strings.TrimSpace(os.Getenv("CF_ENABLE_CACHE_RULE")); value != "" {
 		if parsed, ok := parseBool(value); ok {
-			Cfg.CloudflareProvision.EnableCacheRule = &parsed
+			c.CloudflareProvision.EnableCacheRule = &parsed
 		}
 	}
 	if value := strings.TrimSpace(os.Getenv("CF_EXTRA_ZONE_SETTINGS")); value != "" {
-		Cfg.CloudflareProvision.ExtraZoneSettings = value
+		c.CloudflareProvision.ExtraZoneSettings = value
 	}
 	if value := strings.TrimSpace(os.Getenv("TELEGRAM_ALLOWED_CHAT_IDS")); value != "" {
-		Cfg.Telegram.AllowedChatIDs = parseInt64List(value)
+		c.Telegram.AllowedChatIDs = parseInt64List(value)
 	}
 	if value := strings.TrimSpace(os.Getenv("ABUSE_REPORT_ENABLED")); value != "" {
 		if parsed, ok := parseBool(value); ok {
-			Cfg.AbuseReport.Enabled = &parsed
+			c.AbuseReport.Enabled = &parsed
 		}
 	}
 	if value := strings.TrimSpace(os.Getenv("ABUSE_REPORT_CACHE_FILE")); value != "" {
-		Cfg.AbuseReport.CacheFile = value
+		c.AbuseReport.CacheFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("IP_BLOCK_EXPIRY_FILE")); value != "" {
+		c.IPBlock.ExpiryFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("ATTACK_MODE_STATE_FILE")); value != "" {
+		c.AttackMode.StateFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("ZONE_MOVE_STATE_FILE")); value != "" {
+		c.ZoneMove.StateFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("TRAFFIC_ALERT_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.TrafficAlert.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("TRAFFIC_ALERT_BASELINE_FILE")); value != "" {
+		c.TrafficAlert.BaselineFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("DNS_UNDO_STATE_FILE")); value != "" {
+		c.DNSUndo.StateFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("OPERATION_LOG_FILE")); value != "" {
+		c.OperationLog.File = value
+	}
+	if value := strings.TrimSpace(os.Getenv("DRY_RUN")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.DryRun.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.Telegram.Webhook.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_URL")); value != "" {
+		c.Telegram.Webhook.URL = value
+	}
+	if value := strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_LISTEN_ADDR")); value != "" {
+		c.Telegram.Webhook.ListenAddr = value
+	}
+	if value := strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_SECRET")); value != "" {
+		c.Telegram.Webhook.SecretToken = value
+	}
+	if value := strings.TrimSpace(os.Getenv("API_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.API.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("API_LISTEN_ADDR")); value != "" {
+		c.API.ListenAddr = value
+	}
+	if value := strings.TrimSpace(os.Getenv("TOKEN_CHECK_STARTUP")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.TokenCheck.Startup = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("CONFIG_WATCH")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.Reload.Watch = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("LEADER_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.Leader.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("LEADER_LOCK_FILE")); value != "" {
+		c.Leader.LockFile = value
+	}
+	if value := strings.TrimSpace(os.Getenv("LEADER_INSTANCE_ID")); value != "" {
+		c.Leader.InstanceID = value
+	}
+	if value := strings.TrimSpace(os.Getenv("SCHEDULE_TIMEZONE")); value != "" {
+		c.Schedule.Timezone = value
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.Metrics.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_LISTEN_ADDR")); value != "" {
+		c.Metrics.ListenAddr = value
+	}
+	if value := strings.TrimSpace(os.Getenv("APPROVAL_ACTIONS")); value != "" {
+		c.Approval.Actions = splitConfigList(value)
+	}
+	if value := strings.TrimSpace(os.Getenv("APPROVAL_APPROVERS")); value != "" {
+		c.Approval.Approvers = splitConfigList(value)
+	}
+	if value := strings.TrimSpace(os.Getenv("ZONE_SNAPSHOT_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.ZoneSnapshot.Enabled = &parsed
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("ZONE_SNAPSHOT_DIR")); value != "" {
+		c.ZoneSnapshot.Dir = value
+	}
+	if value := strings.TrimSpace(os.Getenv("WAF_EVENTS_DAILY_ENABLED")); value != "" {
+		if parsed, ok := parseBool(value); ok {
+			c.WAFEvents.DailyEnabled = &parsed
+		}
 	}
 }
 
 func EffectiveAlertDays() int {
-	if Cfg.AlertDays <= 0 {
+	if Cfg().AlertDays <= 0 {
 		return 7
 	}
-	return Cfg.AlertDays
+	return Cfg().AlertDays
 }
 
 func AbuseReportEnabled() bool {
-	if Cfg.AbuseReport.Enabled == nil {
+	if Cfg().AbuseReport.Enabled == nil {
 		return true
 	}
-	return *Cfg.AbuseReport.Enabled
+	return *Cfg().AbuseReport.Enabled
 }
 
 func AbuseReportCacheFile() string {
-	value := strings.TrimSpace(Cfg.AbuseReport.CacheFile)
+	value := strings.TrimSpace(Cfg().AbuseReport.CacheFile)
 	if value == "" {
 		return "abuse_report_cache.json"
 	}
@@ -171,72 +468,526 @@ func AbuseReportCacheFile() string {
 }
 
 func AbuseReportScanHour() int {
-	if Cfg.AbuseReport.ScanHour < 0 || Cfg.AbuseReport.ScanHour > 23 {
+	if Cfg().AbuseReport.ScanHour < 0 || Cfg().AbuseReport.ScanHour > 23 {
 		return 15
 	}
-	if Cfg.AbuseReport.ScanHour == 0 && Cfg.AbuseReport.ScanMinute == 0 {
+	if Cfg().AbuseReport.ScanHour == 0 && Cfg().AbuseReport.ScanMinute == 0 {
 		return 15
 	}
-	return Cfg.AbuseReport.ScanHour
+	return Cfg().AbuseReport.ScanHour
 }
 
 func AbuseReportScanMinute() int {
-	if Cfg.AbuseReport.ScanMinute < 0 || Cfg.AbuseReport.ScanMinute > 59 {
+	if Cfg().AbuseReport.ScanMinute < 0 || Cfg().AbuseReport.ScanMinute > 59 {
 		return 30
 	}
-	if Cfg.AbuseReport.ScanHour == 0 && Cfg.AbuseReport.ScanMinute == 0 {
+	if Cfg().AbuseReport.ScanHour == 0 && Cfg().AbuseReport.ScanMinute == 0 {
 		return 30
 	}
-	return Cfg.AbuseReport.ScanMinute
+	return Cfg().AbuseReport.ScanMinute
 }
 
 func AbuseReportPerPage() int {
-	if Cfg.AbuseReport.PerPage <= 0 {
+	if Cfg().AbuseReport.PerPage <= 0 {
 		return 50
 	}
-	if Cfg.AbuseReport.PerPage > 100 {
+	if Cfg().AbuseReport.PerPage > 100 {
 		return 100
 	}
-	return Cfg.AbuseReport.PerPage
+	return Cfg().AbuseReport.PerPage
 }
 
 func AbuseReportMaxPages() int {
-	if Cfg.AbuseReport.MaxPages <= 0 {
+	if Cfg().AbuseReport.MaxPages <= 0 {
 		return 5
 	}
-	if Cfg.AbuseReport.MaxPages > 20 {
+	if Cfg().AbuseReport.MaxPages > 20 {
 		return 20
 	}
-	return Cfg.AbuseReport.MaxPages
+	return Cfg().AbuseReport.MaxPages
+}
+
+func AbuseReportDigestOpenDays() int {
+	if Cfg().AbuseReport.DigestOpenDays <= 0 {
+		return 7
+	}
+	return Cfg().AbuseReport.DigestOpenDays
+}
+
+func IPBlockExpiryFile() string {
+	value := strings.TrimSpace(Cfg().IPBlock.ExpiryFile)
+	if value == "" {
+		return "ip_block_expiry.json"
+	}
+	return value
+}
+
+func IPBlockSweepInterval() time.Duration {
+	if Cfg().IPBlock.SweepIntervalMinutes <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(Cfg().IPBlock.SweepIntervalMinutes) * time.Minute
+}
+
+func AttackModeStateFile() string {
+	value := strings.TrimSpace(Cfg().AttackMode.StateFile)
+	if value == "" {
+		return "attack_mode_state.json"
+	}
+	return value
+}
+
+// ZoneMoveStateFile 保存等待激活和等待删除旧 Zone 的迁移任务，重启后继续处理。
+func ZoneMoveStateFile() string {
+	value := strings.TrimSpace(Cfg().ZoneMove.StateFile)
+	if value == "" {
+		return "zone_move_state.json"
+	}
+	return value
+}
+
+// WAFEventsDailyEnabled 默认关闭，开启后每天按账号推送 WAF 事件汇总。
+func WAFEventsDailyEnabled() bool {
+	if Cfg().WAFEvents.DailyEnabled == nil {
+		return false
+	}
+	return *Cfg().WAFEvents.DailyEnabled
+}
+
+func WAFEventsReportHour() int {
+	if Cfg().WAFEvents.ReportHour < 0 || Cfg().WAFEvents.ReportHour > 23 {
+		return 9
+	}
+	if Cfg().WAFEvents.ReportHour == 0 && Cfg().WAFEvents.ReportMinute == 0 {
+		return 9
+	}
+	return Cfg().WAFEvents.ReportHour
+}
+
+func WAFEventsReportMinute() int {
+	if Cfg().WAFEvents.ReportMinute < 0 || Cfg().WAFEvents.ReportMinute > 59 {
+		return 0
+	}
+	return Cfg().WAFEvents.ReportMinute
+}
+
+func WAFEventsHours() int {
+	if Cfg().WAFEvents.Hours <= 0 || Cfg().WAFEvents.Hours > 168 {
+		return 24
+	}
+	return Cfg().WAFEvents.Hours
+}
+
+// TrafficAlertEnabled 默认关闭；阈值为 0 时由 app.TrafficAlertService 使用内置默认值。
+func TrafficAlertEnabled() bool {
+	if Cfg().TrafficAlert.Enabled == nil {
+		return false
+	}
+	return *Cfg().TrafficAlert.Enabled
+}
+
+func TrafficAlertBaselineFile() string {
+	value := strings.TrimSpace(Cfg().TrafficAlert.BaselineFile)
+	if value == "" {
+		return "traffic_baseline.json"
+	}
+	return value
+}
+
+func TrafficAlertInterval() time.Duration {
+	if Cfg().TrafficAlert.IntervalMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(Cfg().TrafficAlert.IntervalMinutes) * time.Minute
+}
+
+func TrafficAlertWindow() time.Duration {
+	if Cfg().TrafficAlert.WindowMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(Cfg().TrafficAlert.WindowMinutes) * time.Minute
+}
+
+// ZoneSnapshotEnabled 控制每日定时快照；删除/恢复前的快照不受此开关影响。
+func ZoneSnapshotEnabled() bool {
+	if Cfg().ZoneSnapshot.Enabled == nil {
+		return false
+	}
+	return *Cfg().ZoneSnapshot.Enabled
+}
+
+func ZoneSnapshotDir() string {
+	value := strings.TrimSpace(Cfg().ZoneSnapshot.Dir)
+	if value == "" {
+		return "zone_snapshots"
+	}
+	return value
+}
+
+func ZoneSnapshotRetention() time.Duration {
+	if Cfg().ZoneSnapshot.RetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(Cfg().ZoneSnapshot.RetentionDays) * 24 * time.Hour
+}
+
+func ZoneSnapshotMaxPerZone() int {
+	if Cfg().ZoneSnapshot.MaxPerZone <= 0 {
+		return 60
+	}
+	return Cfg().ZoneSnapshot.MaxPerZone
+}
+
+func ZoneSnapshotHour() int {
+	if Cfg().ZoneSnapshot.Hour < 0 || Cfg().ZoneSnapshot.Hour > 23 {
+		return 3
+	}
+	if Cfg().ZoneSnapshot.Hour == 0 && Cfg().ZoneSnapshot.Minute == 0 {
+		return 3
+	}
+	return Cfg().ZoneSnapshot.Hour
+}
+
+func ZoneSnapshotMinute() int {
+	if Cfg().ZoneSnapshot.Minute < 0 || Cfg().ZoneSnapshot.Minute > 59 {
+		return 0
+	}
+	return Cfg().ZoneSnapshot.Minute
+}
+
+func DNSUndoStateFile() string {
+	value := strings.TrimSpace(Cfg().DNSUndo.StateFile)
+	if value == "" {
+		return "dns_undo.json"
+	}
+	return value
+}
+
+func OperationLogFile() string {
+	value := strings.TrimSpace(Cfg().OperationLog.File)
+	if value == "" {
+		return "operation_log.json"
+	}
+	return value
+}
+
+// OperationLogRetention 是操作记录保留时长，默认 90 天。
+func OperationLogRetention() time.Duration {
+	if Cfg().OperationLog.RetentionDays <= 0 {
+		return 90 * 24 * time.Hour
+	}
+	return time.Duration(Cfg().OperationLog.RetentionDays) * 24 * time.Hour
+}
+
+// DNSUndoWindow 是 DNS 变更后允许点击“撤销”的时长，默认 30 分钟。
+func DNSUndoWindow() time.Duration {
+	if Cfg().DNSUndo.WindowMinutes <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(Cfg().DNSUndo.WindowMinutes) * time.Minute
+}
+
+// DryRunEnabled 是启动时的全局 dry-run 开关，运行中可通过 /dryrun on|off 覆盖。
+func DryRunEnabled() bool {
+	if Cfg().DryRun.Enabled == nil {
+		return false
+	}
+	return *Cfg().DryRun.Enabled
+}
+
+func DryRunPlanDir() string {
+	value := strings.TrimSpace(Cfg().DryRun.PlanDir)
+	if value == "" {
+		return "dryrun_plans"
+	}
+	return value
+}
+
+// TelegramWebhookEnabled 默认在配置了 telegram.webhook.url 时开启。
+func TelegramWebhookEnabled() bool {
+	if Cfg().Telegram.Webhook.Enabled != nil {
+		return *Cfg().Telegram.Webhook.Enabled && strings.TrimSpace(Cfg().Telegram.Webhook.URL) != ""
+	}
+	return strings.TrimSpace(Cfg().Telegram.Webhook.URL) != ""
+}
+
+func TelegramWebhookListenAddr() string {
+	value := strings.TrimSpace(Cfg().Telegram.Webhook.ListenAddr)
+	if value == "" {
+		return ":8443"
+	}
+	return value
+}
+
+// APIEnabled 默认在配置了 api.tokens 时开启。
+func APIEnabled() bool {
+	if len(Cfg().API.Tokens) == 0 {
+		return false
+	}
+	if Cfg().API.Enabled == nil {
+		return true
+	}
+	return *Cfg().API.Enabled
+}
+
+func APIListenAddr() string {
+	value := strings.TrimSpace(Cfg().API.ListenAddr)
+	if value == "" {
+		return "127.0.0.1:8080"
+	}
+	return value
+}
+
+// 内置定时任务名称，同时用作状态文件和监控指标中的 job 标签。
+const (
+	JobDailyReport       = "daily_report"
+	JobAbuseReportScan   = "abuse_report_scan"
+	JobAbuseReportDigest = "abuse_report_digest"
+	JobZoneSnapshot      = "zone_snapshot"
+	JobWAFEventsDigest   = "waf_events_digest"
+)
+
+// ScheduledJobs 返回内置任务（按原有 hour/minute 生成 cron）与 schedule.jobs 合并后的列表，同名以配置为准。
+func ScheduledJobs() []ScheduledJob {
+	jobs := []ScheduledJob{
+		{Name: JobDailyReport, Cron: "0 15 * * *"},
+		{Name: JobAbuseReportScan, Cron: fmt.Sprintf("%d %d * * *", AbuseReportScanMinute(), AbuseReportScanHour())},
+		{Name: JobAbuseReportDigest, Cron: "0 10 * * 1"},
+		{Name: JobZoneSnapshot, Cron: fmt.Sprintf("%d %d * * *", ZoneSnapshotMinute(), ZoneSnapshotHour())},
+		{Name: JobWAFEventsDigest, Cron: fmt.Sprintf("%d %d * * *", WAFEventsReportMinute(), WAFEventsReportHour())},
+	}
+	for _, configured := range Cfg().Schedule.Jobs {
+		name := strings.TrimSpace(configured.Name)
+		if name == "" {
+			continue
+		}
+		configured.Name = name
+		replaced := false
+		for i := range jobs {
+			if strings.EqualFold(jobs[i].Name, name) {
+				if strings.TrimSpace(configured.Cron) == "" {
+					configured.Cron = jobs[i].Cron
+				}
+				configured.Name = jobs[i].Name
+				jobs[i] = configured
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			jobs = append(jobs, configured)
+		}
+	}
+	return jobs
+}
+
+// FindScheduledJob 按名称（不区分大小写）查找定时任务。
+func FindScheduledJob(name string) (ScheduledJob, bool) {
+	for _, job := range ScheduledJobs() {
+		if strings.EqualFold(job.Name, name) {
+			return job, true
+		}
+	}
+	return ScheduledJob{}, false
+}
+
+func (j ScheduledJob) IsEnabled() bool {
+	return j.Enabled == nil || *j.Enabled
+}
+
+// ScheduleLocation 依次使用任务、schedule.timezone 的时区，均未配置时为本地时区。
+func ScheduleLocation(job ScheduledJob) (*time.Location, error) {
+	name := strings.TrimSpace(job.Timezone)
+	if name == "" {
+		name = strings.TrimSpace(Cfg().Schedule.Timezone)
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("定时任务 %s 的时区 %q 无效: %w", job.Name, name, err)
+	}
+	return loc, nil
+}
+
+// ScheduleCatchUpWindow 默认 6 小时；配置为负数时关闭补跑。
+func ScheduleCatchUpWindow(job ScheduledJob) time.Duration {
+	minutes := Cfg().Schedule.CatchUpMinutes
+	if job.CatchUpMinutes != nil {
+		minutes = *job.CatchUpMinutes
+	} else if minutes == 0 {
+		minutes = 360
+	}
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func ScheduleStateFile() string {
+	value := strings.TrimSpace(Cfg().Schedule.StateFile)
+	if value == "" {
+		return "scheduler_state.json"
+	}
+	return value
+}
+
+// LeaderEnabled 默认开启，避免误启动的第二个实例重复发送日报和轮询 Telegram。
+func LeaderEnabled() bool {
+	if Cfg().Leader.Enabled == nil {
+		return true
+	}
+	return *Cfg().Leader.Enabled
+}
+
+// LeaderBackend 目前只支持 file。
+func LeaderBackend() string {
+	value := strings.ToLower(strings.TrimSpace(Cfg().Leader.Backend))
+	if value == "" {
+		return "file"
+	}
+	return value
+}
+
+func LeaderLockFile() string {
+	value := strings.TrimSpace(Cfg().Leader.LockFile)
+	if value == "" {
+		return "leader.lock"
+	}
+	return value
+}
+
+func LeaderLease() time.Duration {
+	if Cfg().Leader.LeaseSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(Cfg().Leader.LeaseSeconds) * time.Second
+}
+
+// LeaderInstanceID 默认为 主机名-进程号。
+func LeaderInstanceID() string {
+	if value := strings.TrimSpace(Cfg().Leader.InstanceID); value != "" {
+		return value
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func ReloadWatchEnabled() bool {
+	return Cfg().Reload.Watch != nil && *Cfg().Reload.Watch
+}
+
+func TokenCheckOnStartup() bool {
+	if Cfg().TokenCheck.Startup == nil {
+		return true
+	}
+	return *Cfg().TokenCheck.Startup
+}
+
+// TokenExpiryWarnDays 是 token 到期前开始提醒的天数，默认 14。
+func TokenExpiryWarnDays() int {
+	if Cfg().TokenCheck.ExpiryWarnDays <= 0 {
+		return 14
+	}
+	return Cfg().TokenCheck.ExpiryWarnDays
+}
+
+func ReloadWatchInterval() time.Duration {
+	if Cfg().Reload.IntervalSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(Cfg().Reload.IntervalSeconds) * time.Second
+}
+
+// MetricsEnabled 默认在配置了 metrics.listenAddr 时开启。
+func MetricsEnabled() bool {
+	if Cfg().Metrics.Enabled != nil {
+		return *Cfg().Metrics.Enabled
+	}
+	return strings.TrimSpace(Cfg().Metrics.ListenAddr) != ""
+}
+
+func MetricsListenAddr() string {
+	value := strings.TrimSpace(Cfg().Metrics.ListenAddr)
+	if value == "" {
+		return ":9090"
+	}
+	return value
+}
+
+// ApprovalRequired 判断 action 是否在 approval.actions 中（不区分大小写）。
+func ApprovalRequired(action string) bool {
+	for _, item := range Cfg().Approval.Actions {
+		if strings.EqualFold(strings.TrimSpace(item), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalWindow 是等待第二人批准的时长，默认 30 分钟。
+func ApprovalWindow() time.Duration {
+	if Cfg().Approval.WindowMinutes <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(Cfg().Approval.WindowMinutes) * time.Minute
+}
+
+// IsApprover 判断用户是否可以批准；未配置 approvers 时所有人都可以（仍不能批准自己的申请）。
+func IsApprover(userID int64, username string) bool {
+	if len(Cfg().Approval.Approvers) == 0 {
+		return true
+	}
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	for _, item := range Cfg().Approval.Approvers {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "@")
+		if item == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(item, 10, 64); err == nil {
+			if id == userID {
+				return true
+			}
+			continue
+		}
+		if username != "" && strings.EqualFold(item, username) {
+			return true
+		}
+	}
+	return false
 }
 
 func DefaultBlockCountries() []string {
-	return splitConfigList(Cfg.CloudflareProvision.DefaultBlockCountries)
+	return splitConfigList(Cfg().CloudflareProvision.DefaultBlockCountries)
 }
 
 func EnableSpeedRecommendations() bool {
-	if Cfg.CloudflareProvision.EnableSpeedRecommendations == nil {
+	if Cfg().CloudflareProvision.EnableSpeedRecommendations == nil {
 		return true
 	}
-	return *Cfg.CloudflareProvision.EnableSpeedRecommendations
+	return *Cfg().CloudflareProvision.EnableSpeedRecommendations
 }
 
 func EnableRUMAutoInstall() bool {
-	if Cfg.CloudflareProvision.EnableRUMAutoInstall == nil {
+	if Cfg().CloudflareProvision.EnableRUMAutoInstall == nil {
 		return true
 	}
-	return *Cfg.CloudflareProvision.EnableRUMAutoInstall
+	return *Cfg().CloudflareProvision.EnableRUMAutoInstall
 }
 
 func EnableCacheRule() bool {
-	if Cfg.CloudflareProvision.EnableCacheRule == nil {
+	if Cfg().CloudflareProvision.EnableCacheRule == nil {
 		return true
 	}
-	return *Cfg.CloudflareProvision.EnableCacheRule
+	return *Cfg().CloudflareProvision.EnableCacheRule
 }
 
 func ExtraZoneSettings() map[string]any {
-	raw := strings.TrimSpace(Cfg.CloudflareProvision.ExtraZoneSettings)
+	raw := strings.TrimSpace(Cfg().CloudflareProvision.ExtraZoneSettings)
 	if raw == "" {
 		return nil
 	}
@@ -264,8 +1015,8 @@ func ExtraZoneSettings() map[string]any {
 }
 
 func IsTelegramChatAllowed(chatID int64) bool {
-	if len(Cfg.Telegram.AllowedChatIDs) > 0 {
-		for _, allowed := range Cfg.Telegram.AllowedChatIDs {
+	if len(Cfg().Telegram.AllowedChatIDs) > 0 {
+		for _, allowed := range Cfg().Telegram.AllowedChatIDs {
 			if allowed == chatID {
 				return true
 			}
@@ -288,12 +1039,12 @@ func IsTelegramChatAllowed(chatID int64) bool {
 // preferred multi-chat setting; chatID and allowedChatIds remain supported for
 // backwards compatibility.
 func TelegramChatIDs() []int64 {
-	configured := Cfg.Telegram.ChatIDs
-	if len(configured) == 0 && Cfg.Telegram.ChatID != 0 {
-		configured = []int64{Cfg.Telegram.ChatID}
+	configured := Cfg().Telegram.ChatIDs
+	if len(configured) == 0 && Cfg().Telegram.ChatID != 0 {
+		configured = []int64{Cfg().Telegram.ChatID}
 	}
 	if len(configured) == 0 {
-		configured = Cfg.Telegram.AllowedChatIDs
+		configured = Cfg().Telegram.AllowedChatIDs
 	}
 
 	seen := make(map[int64]struct{}, len(configured))
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 密钥字段支持三种引用形式，其余值按明文处理：
+//
+//	env:NAME       读取环境变量 NAME
+//	file:/path     读取文件内容（去掉首尾空白）
+//	enc:BASE64     用 secrets.keyFile 中的 AES-256-GCM 密钥解密
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+	secretEncPrefix  = "enc:"
+)
+
+const secretMask = "***"
+
+// Secrets 配置 enc: 值使用的本地密钥文件。
+type Secrets struct {
+	KeyFile string `yaml:"keyFile"`
+}
+
+// SecretKeyFile 返回解密 enc: 值所用的密钥文件路径，默认 secret.key。
+func SecretKeyFile(c *Config) string {
+	if value := strings.TrimSpace(os.Getenv("SECRET_KEY_FILE")); value != "" {
+		return value
+	}
+	if value := strings.TrimSpace(c.Secrets.KeyFile); value != "" {
+		return value
+	}
+	return "secret.key"
+}
+
+type secretResolver struct {
+	keyFile string
+	key     []byte
+	keyErr  error
+	errs    []error
+}
+
+func (r *secretResolver) resolve(field string, value *string) {
+	raw := strings.TrimSpace(*value)
+	if raw == "" {
+		return
+	}
+	resolved, err := r.resolveValue(raw)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s: %w", field, err))
+		return
+	}
+	*value = resolved
+	registerSecret(resolved)
+}
+
+func (r *secretResolver) resolveValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, secretEnvPrefix):
+		name := strings.TrimSpace(strings.TrimPrefix(raw, secretEnvPrefix))
+		value, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(value) == "" {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return strings.TrimSpace(value), nil
+	case strings.HasPrefix(raw, secretFilePrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(raw, secretFilePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return "", fmt.Errorf("密钥文件 %s 为空", path)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, secretEncPrefix):
+		if r.key == nil && r.keyErr == nil {
+			r.key, r.keyErr = LoadSecretKey(r.keyFile)
+		}
+		if r.keyErr != nil {
+			return "", r.keyErr
+		}
+		return DecryptSecret(raw, r.key)
+	}
+	return raw, nil
+}
+
+// resolveSecrets 把所有密钥字段中的 env:/file:/enc: 引用替换为实际值，并登记到脱敏列表。
+func resolveSecrets(c *Config) error {
+	r := &secretResolver{keyFile: SecretKeyFile(c)}
+	r.resolve("telegram.botToken", &c.Telegram.BotToken)
+	r.resolve("telegram.webhook.secretToken", &c.Telegram.Webhook.SecretToken)
+	for i := range c.CloudflareAccounts {
+		field := fmt.Sprintf("cloudflareAccounts[%s]", fieldLabel(c.CloudflareAccounts[i].Label, i))
+		r.resolve(field+".apiToken", &c.CloudflareAccounts[i].APIToken)
+	}
+	for i := range c.Registrars {
+		field := fmt.Sprintf("registrars[%s]", fieldLabel(c.Registrars[i].Label, i))
+		if nc := c.Registrars[i].Namecheap; nc != nil {
+			r.resolve(field+".namecheap.apiKey", &nc.APIKey)
+		}
+		if gd := c.Registrars[i].GoDaddy; gd != nil {
+			r.resolve(field+".godaddy.apiKey", &gd.APIKey)
+			r.resolve(field+".godaddy.apiSecret", &gd.APISecret)
+		}
+	}
+	for name, target := range c.AWSTargets {
+		field := fmt.Sprintf("awsTargets[%s].creds", name)
+		r.resolve(field+".accessKeyId", &target.Creds.AccessKeyID)
+		r.resolve(field+".secretAccessKey", &target.Creds.SecretAccessKey)
+		r.resolve(field+".sessionToken", &target.Creds.SessionToken)
+		c.AWSTargets[name] = target
+	}
+	for i := range c.API.Tokens {
+		field := fmt.Sprintf("api.tokens[%s]", fieldLabel(c.API.Tokens[i].Name, i))
+		r.resolve(field+".token", &c.API.Tokens[i].Token)
+	}
+	return errors.Join(r.errs...)
+}
+
+func fieldLabel(label string, index int) string {
+	if label = strings.TrimSpace(label); label != "" {
+		return label
+	}
+	return fmt.Sprint(index)
+}
+
+// LoadSecretKey 读取 base64 编码的 32 字节 AES-256 密钥。
+func LoadSecretKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取解密密钥 %s 失败: %w", path, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("解密密钥 %s 不是有效的 base64: %w", path, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("解密密钥 %s 长度应为 32 字节，实际 %d", path, len(key))
+	}
+	return key, nil
+}
+
+// GenerateSecretKey 生成新的随机密钥并以 0600 权限写入 path，文件已存在时返回错误。
+func GenerateSecretKey(path string) error {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return fmt.Errorf("生成密钥失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("创建密钥文件失败: %w", err)
+	}
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	return f.Close()
+}
+
+// EncryptSecret 用 AES-256-GCM 加密明文，返回可直接写入配置的 enc: 值。
+func EncryptSecret(plaintext string, key []byte) (string, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretEncPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的 enc: 值。
+func DecryptSecret(value string, key []byte) (string, error) {
+	gcm, err := newSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(value, secretEncPrefix)))
+	if err != nil {
+		return "", fmt.Errorf("enc: 值不是有效的 base64")
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("enc: 值长度不足")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("enc: 值解密失败，请确认密钥文件是否匹配")
+	}
+	return string(plain), nil
+}
+
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("无效的解密密钥: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// 已加载的密钥值，日志和 Telegram 消息输出前会把它们替换为 ***。
+var (
+	secretsMu      sync.RWMutex
+	knownSecrets   = make(map[string]bool)
+	secretReplacer *strings.Replacer
+)
+
+// 过短的值容易误伤正常文本，不参与替换。
+const minRedactLength = 8
+
+func registerSecret(value string) {
+	if len(value) < minRedactLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if knownSecrets[value] {
+		return
+	}
+	knownSecrets[value] = true
+	values := make([]string, 0, len(knownSecrets))
+	for v := range knownSecrets {
+		values = append(values, v)
+	}
+	// 长值优先，避免一个密钥是另一个的子串时只替换了一部分。
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		pairs = append(pairs, v, secretMask)
+	}
+	secretReplacer = strings.NewReplacer(pairs...)
+}
+
+// Redact 把 s 中出现的已加载密钥替换为 ***。
+func Redact(s string) string {
+	secretsMu.RLock()
+	replacer := secretReplacer
+	secretsMu.RUnlock()
+	if replacer == nil || s == "" {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+type redactWriter struct{ w io.Writer }
+
+// RedactWriter 包装 w，写入前脱敏，用于 log.SetOutput 和命令行输出。
+func RedactWriter(w io.Writer) io.Writer { return redactWriter{w: w} }
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// 以下 String/GoString 让 %v、%+v、%#v 打印配置结构时不输出密钥。
+
+func (c CF) String() string {
+	return fmt.Sprintf("{Label:%s Email:%s APIToken:%s AccountID:%s Group:%s Tags:%v}", c.Label, c.Email, maskSecret(c.APIToken), c.AccountID, c.Group, c.Tags)
+}
+func (c CF) GoString() string { return "config.CF" + c.String() }
+
+func (c NamecheapConfig) String() string {
+	return fmt.Sprintf("{User:%s APIKey:%s ClientIP:%s}", c.User, maskSecret(c.APIKey), c.ClientIP)
+}
+func (c NamecheapConfig) GoString() string { return "config.NamecheapConfig" + c.String() }
+
+func (c GoDaddyConfig) String() string {
+	return fmt.Sprintf("{APIKey:%s APISecret:%s}", maskSecret(c.APIKey), maskSecret(c.APISecret))
+}
+func (c GoDaddyConfig) GoString() string { return "config.GoDaddyConfig" + c.String() }
+
+func (c AWSCreds) String() string {
+	return fmt.Sprintf("{AccessKeyID:%s SecretAccessKey:%s SessionToken:%s}", maskSecret(c.AccessKeyID), maskSecret(c.SecretAccessKey), maskSecret(c.SessionToken))
+}
+func (c AWSCreds) GoString() string { return "config.AWSCreds" + c.String() }
+
+func (t APIToken) String() string {
+	return fmt.Sprintf("{Name:%s Token:%s Role:%s}", t.Name, maskSecret(t.Token), t.Role)
+}
+func (t APIToken) GoString() string { return "config.APIToken" + t.String() }
+
+func (t Telegram) String() string {
+	return fmt.Sprintf("{BotToken:%s ChatID:%d ChatIDs:%v AllowedChatIDs:%v WebhookURL:%s}", maskSecret(t.BotToken), t.ChatID, t.ChatIDs, t.AllowedChatIDs, t.Webhook.URL)
+}
+func (t Telegram) GoString() string { return "config.Telegram" + t.String() }
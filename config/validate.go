@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"DomainC/scheduler"
+)
+
+const (
+	ProblemError   = "error"
+	ProblemWarning = "warning"
+)
+
+// Problem 是配置校验发现的问题。Message 只引用字段路径和标签，不包含 token、密钥等敏感值。
+type Problem struct {
+	Level   string
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("[%s] %s: %s", p.Level, p.Field, p.Message)
+}
+
+// HasErrors 判断是否存在会阻止热加载的错误级问题。
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Level == ProblemError {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	botTokenPattern  = regexp.MustCompile(`^\d+:[A-Za-z0-9_-]{20,}$`)
+	accountIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+)
+
+// Validate 检查重复标签、缺失凭据、未知注册商类型、无效 chat ID 等问题。
+func Validate(c *Config) []Problem {
+	var problems []Problem
+	add := func(level, field, format string, args ...any) {
+		problems = append(problems, Problem{Level: level, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	validateTelegram(c.Telegram, add)
+
+	if len(c.CloudflareAccounts) == 0 {
+		add(ProblemError, "cloudflareAccounts", "未配置任何 Cloudflare 账号")
+	}
+	seenCF := make(map[string]int)
+	for i, acc := range c.CloudflareAccounts {
+		field := fmt.Sprintf("cloudflareAccounts[%d]", i)
+		label := strings.TrimSpace(acc.Label)
+		if label == "" {
+			add(ProblemWarning, field, "label 为空，命令中无法按账号选择")
+		} else {
+			field = fmt.Sprintf("cloudflareAccounts[%s]", label)
+			key := strings.ToLower(label)
+			if prev, ok := seenCF[key]; ok {
+				add(ProblemError, field, "label 与 cloudflareAccounts[%d] 重复", prev)
+			} else {
+				seenCF[key] = i
+			}
+		}
+		if strings.TrimSpace(acc.APIToken) == "" {
+			add(ProblemError, field, "缺少 apiToken")
+		}
+		if id := strings.TrimSpace(acc.AccountID); id != "" && !accountIDPattern.MatchString(id) {
+			add(ProblemWarning, field, "accountID 不是 32 位十六进制字符串")
+		}
+		if strings.ContainsAny(label, ",:") || strings.ContainsAny(acc.Group, ",:") {
+			add(ProblemWarning, field, "label 或 group 含有逗号或冒号，账号选择器无法匹配")
+		}
+		for _, tag := range acc.Tags {
+			if strings.TrimSpace(tag) == "" || strings.ContainsAny(tag, ",:") {
+				add(ProblemWarning, field, "tags 中有空值或含逗号、冒号的标签 %q", tag)
+			}
+		}
+	}
+
+	seenRegistrar := make(map[string]int)
+	for i, r := range c.Registrars {
+		field := fmt.Sprintf("registrars[%d]", i)
+		label := strings.TrimSpace(r.Label)
+		if label == "" {
+			add(ProblemError, field, "label 为空")
+		} else {
+			field = fmt.Sprintf("registrars[%s]", label)
+			key := strings.ToLower(label)
+			if prev, ok := seenRegistrar[key]; ok {
+				add(ProblemError, field, "label 与 registrars[%d] 重复", prev)
+			} else {
+				seenRegistrar[key] = i
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(r.Type)) {
+		case "namecheap":
+			if r.Namecheap == nil {
+				add(ProblemError, field, "type 为 namecheap 但缺少 namecheap 配置")
+				continue
+			}
+			if strings.TrimSpace(r.Namecheap.User) == "" || strings.TrimSpace(r.Namecheap.APIKey) == "" {
+				add(ProblemError, field, "namecheap 缺少 user 或 apiKey")
+			}
+			if strings.TrimSpace(r.Namecheap.ClientIP) == "" {
+				add(ProblemError, field, "namecheap 缺少 clientIP")
+			}
+		case "godaddy":
+			if r.GoDaddy == nil || strings.TrimSpace(r.GoDaddy.APIKey) == "" || strings.TrimSpace(r.GoDaddy.APISecret) == "" {
+				add(ProblemError, field, "godaddy 缺少 apiKey 或 apiSecret")
+			}
+		case "":
+			add(ProblemError, field, "缺少 type")
+		default:
+			add(ProblemError, field, "未知注册商类型 %q（支持 namecheap、godaddy）", strings.TrimSpace(r.Type))
+		}
+	}
+
+	for name, target := range c.AWSTargets {
+		field := fmt.Sprintf("awsTargets[%s]", name)
+		if strings.TrimSpace(target.Region) == "" {
+			add(ProblemError, field, "缺少 region")
+		}
+		hasKey := strings.TrimSpace(target.Creds.AccessKeyID) != ""
+		hasSecret := strings.TrimSpace(target.Creds.SecretAccessKey) != ""
+		if hasKey != hasSecret {
+			add(ProblemError, field, "creds 需要同时配置 accessKeyId 和 secretAccessKey")
+		}
+	}
+
+	seenTokenNames := make(map[string]bool)
+	seenTokens := make(map[string]string)
+	for i, t := range c.API.Tokens {
+		field := fmt.Sprintf("api.tokens[%d]", i)
+		if name := strings.TrimSpace(t.Name); name != "" {
+			field = fmt.Sprintf("api.tokens[%s]", name)
+			if seenTokenNames[strings.ToLower(name)] {
+				add(ProblemError, field, "name 重复")
+			}
+			seenTokenNames[strings.ToLower(name)] = true
+		}
+		secret := strings.TrimSpace(t.Token)
+		if secret == "" {
+			add(ProblemError, field, "缺少 token")
+		} else if prev, ok := seenTokens[secret]; ok {
+			add(ProblemError, field, "token 与 %s 相同", prev)
+		} else {
+			seenTokens[secret] = field
+		}
+		switch strings.ToLower(strings.TrimSpace(t.Role)) {
+		case "", "read", "readonly", "write", "admin":
+		default:
+			add(ProblemError, field, "未知角色 %q（支持 read、write、admin）", strings.TrimSpace(t.Role))
+		}
+	}
+
+	for i, job := range c.Schedule.Jobs {
+		field := fmt.Sprintf("schedule.jobs[%d]", i)
+		if name := strings.TrimSpace(job.Name); name != "" {
+			field = fmt.Sprintf("schedule.jobs[%s]", name)
+		} else {
+			add(ProblemError, field, "name 为空")
+		}
+		loc := time.Local
+		tz := strings.TrimSpace(job.Timezone)
+		if tz == "" {
+			tz = strings.TrimSpace(c.Schedule.Timezone)
+		}
+		if tz != "" {
+			parsed, err := time.LoadLocation(tz)
+			if err != nil {
+				add(ProblemError, field, "时区 %q 无效", tz)
+			} else {
+				loc = parsed
+			}
+		}
+		if spec := strings.TrimSpace(job.Cron); spec != "" {
+			if _, err := scheduler.ParseCron(spec, loc); err != nil {
+				add(ProblemError, field, "%v", err)
+			}
+		}
+	}
+
+	if backend := strings.ToLower(strings.TrimSpace(c.Leader.Backend)); backend != "" && backend != "file" {
+		add(ProblemError, "leader.backend", "不支持的后端 %q", backend)
+	}
+	return problems
+}
+
+func validateTelegram(t Telegram, add func(level, field, format string, args ...any)) {
+	token := strings.TrimSpace(t.BotToken)
+	if token == "" {
+		add(ProblemError, "telegram.botToken", "缺少 botToken")
+	} else if !botTokenPattern.MatchString(token) {
+		add(ProblemError, "telegram.botToken", "格式无效（应为 <数字ID>:<密钥>）")
+	}
+	if t.ChatID == 0 && len(t.ChatIDs) == 0 && len(t.AllowedChatIDs) == 0 {
+		add(ProblemError, "telegram.chatIDs", "未配置任何 chat ID")
+	}
+	checkIDs := func(field string, ids []int64) {
+		seen := make(map[int64]bool, len(ids))
+		for i, id := range ids {
+			if id == 0 {
+				add(ProblemError, fmt.Sprintf("%s[%d]", field, i), "chat ID 不能为 0")
+				continue
+			}
+			if seen[id] {
+				add(ProblemWarning, fmt.Sprintf("%s[%d]", field, i), "chat ID %d 重复", id)
+			}
+			seen[id] = true
+		}
+	}
+	checkIDs("telegram.chatIDs", t.ChatIDs)
+	checkIDs("telegram.allowedChatIds", t.AllowedChatIDs)
+	if t.Webhook.URL != "" && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(t.Webhook.URL)), "https://") {
+		add(ProblemError, "telegram.webhook.url", "必须使用 https")
+	}
+	if t.Webhook.URL != "" && strings.TrimSpace(t.Webhook.SecretToken) == "" {
+		add(ProblemWarning, "telegram.webhook.secretToken", "未配置，webhook 不会启动，将使用长轮询")
+	}
+}
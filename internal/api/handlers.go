@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/reminder"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const maxRequestBody = 1 << 20
+
+type accountView struct {
+	Label     string `json:"label"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type zoneView struct {
+	Account   string     `json:"account"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Paused    bool       `json:"paused"`
+	Plan      string     `json:"plan,omitempty"`
+	CreatedOn *time.Time `json:"created_on,omitempty"`
+}
+
+type dnsRecordView struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Proxied bool   `json:"proxied"`
+	TTL     int    `json:"ttl"`
+}
+
+type dnsRecordRequest struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Proxied bool   `json:"proxied"`
+	TTL     int    `json:"ttl"`
+}
+
+type createZoneRequest struct {
+	Domain         string   `json:"domain"`
+	Account        string   `json:"account"`
+	BlockCountries []string `json:"block_countries"`
+	EnableSpeed    bool     `json:"enable_speed"`
+	EnableRUM      bool     `json:"enable_rum"`
+	SyncRegistrar  bool     `json:"sync_registrar"`
+}
+
+type createZoneResult struct {
+	Account       string            `json:"account"`
+	Domain        string            `json:"domain"`
+	ZoneID        string            `json:"zone_id"`
+	ZoneStatus    string            `json:"zone_status"`
+	ZoneCreated   bool              `json:"zone_created"`
+	NameServers   []string          `json:"name_servers"`
+	CountryBlock  string            `json:"country_block,omitempty"`
+	SpeedStatus   map[string]string `json:"speed_status,omitempty"`
+	CacheRule     string            `json:"cache_rule,omitempty"`
+	RUM           string            `json:"rum,omitempty"`
+	Warnings      []string          `json:"warnings,omitempty"`
+	RegistrarSync string            `json:"registrar_sync,omitempty"`
+}
+
+type ipListItemRequest struct {
+	IP      string `json:"ip"`
+	Comment string `json:"comment"`
+}
+
+func (s *Server) listAccounts(r *http.Request) (any, error) {
+	accounts := s.accounts()
+	out := make([]accountView, 0, len(accounts))
+	for _, acc := range accounts {
+		out = append(out, accountView{Label: acc.Label, AccountID: acc.AccountID})
+	}
+	return out, nil
+}
+
+func (s *Server) listZones(r *http.Request) (any, error) {
+	accounts := s.accounts()
+	if label := strings.TrimSpace(r.URL.Query().Get("account")); label != "" {
+		acc, err := s.accountByLabel(label)
+		if err != nil {
+			return nil, err
+		}
+		accounts = []config.CF{acc}
+	}
+	out := []zoneView{}
+	for _, acc := range accounts {
+		zones, err := s.CFClient.ListZoneSummaries(r.Context(), acc)
+		if err != nil {
+			return nil, fmt.Errorf("读取账号 %s 的 Zone 失败: %w", acc.Label, err)
+		}
+		for _, zone := range zones {
+			view := zoneView{Account: acc.Label, ID: zone.ID, Name: zone.Name, Status: zone.Status, Paused: zone.Paused, Plan: zone.Plan}
+			if !zone.CreatedOn.IsZero() {
+				created := zone.CreatedOn
+				view.CreatedOn = &created
+			}
+			out = append(out, view)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) createZone(r *http.Request) (any, error) {
+	var req createZoneRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	domain := strings.ToLower(strings.TrimSpace(req.Domain))
+	if domain == "" {
+		return nil, errorf(http.StatusBadRequest, "domain 不能为空")
+	}
+	provisioner, ok := s.CFClient.(cfclient.Provisioner)
+	if !ok {
+		return nil, errorf(http.StatusNotImplemented, "当前 Cloudflare 客户端不支持 Zone 初始化")
+	}
+	var account config.CF
+	if strings.TrimSpace(req.Account) != "" {
+		acc, err := s.accountByLabel(req.Account)
+		if err != nil {
+			return nil, err
+		}
+		account = acc
+	} else {
+		// 只有确认所有账号都没有该 Zone 时才默认使用第一个账号，查询出错时要求显式指定，避免在错误的账号中创建。
+		acc, _, err := s.findZone(r.Context(), domain)
+		switch {
+		case err == nil:
+			account = acc
+		case !errors.Is(err, cfclient.ErrZoneNotFound):
+			return nil, errorf(http.StatusBadGateway, fmt.Sprintf("无法确认 %s 所在账号，请在请求中指定 account: %v", domain, err))
+		case len(s.accounts()) > 0:
+			account = s.accounts()[0]
+		default:
+			return nil, errorf(http.StatusBadRequest, "未配置可用的 Cloudflare 账号")
+		}
+	}
+	blockCountries, err := cfclient.NormalizeCountryCodes(req.BlockCountries)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, fmt.Sprintf("block_countries 参数错误: %v", err))
+	}
+
+	result, err := provisioner.ProvisionCloudflareZone(r.Context(), account, domain, cfclient.ProvisionOptions{
+		AccountID:           account.AccountID,
+		BlockCountries:      blockCountries,
+		EnableSpeed:         req.EnableSpeed,
+		EnableRUM:           req.EnableRUM,
+		ExtraZoneSettings:   config.ExtraZoneSettings(),
+		CreateZoneIfMissing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.Runtime != nil {
+		s.Runtime.RecordDomainChange(context.Background(), reminder.DomainChange{Domain: result.Domain, Source: account.Label, IsCF: true, ZoneID: result.ZoneID, Status: result.ZoneStatus})
+	}
+	out := createZoneResult{
+		Account:      account.Label,
+		Domain:       result.Domain,
+		ZoneID:       result.ZoneID,
+		ZoneStatus:   result.ZoneStatus,
+		ZoneCreated:  result.ZoneCreated,
+		NameServers:  result.NameServers,
+		CountryBlock: result.CountryBlockStatus,
+		SpeedStatus:  result.SpeedStatus,
+		CacheRule:    result.CacheRuleStatus,
+		RUM:          result.RUMStatus,
+		Warnings:     result.Warnings,
+	}
+	if req.SyncRegistrar {
+		if s.Registrar == nil {
+			out.RegistrarSync = "未配置注册商"
+		} else if registrar, err := s.Registrar.SetNameServersForDomain(r.Context(), result.Domain, result.NameServers); err != nil {
+			out.RegistrarSync = "同步失败: " + err.Error()
+		} else {
+			out.RegistrarSync = fmt.Sprintf("已同步到 %s (%s)", registrar.Label, registrar.Type)
+		}
+	}
+	return out, nil
+}
+
+func (s *Server) listDNS(r *http.Request) (any, error) {
+	account, zone, err := s.resolveZone(r)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.CFClient.ListDNSRecords(r.Context(), account, zone.Name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dnsRecordView, 0, len(records))
+	for _, rec := range records {
+		out = append(out, dnsRecordFromCloudflare(rec))
+	}
+	return out, nil
+}
+
+func (s *Server) setDNS(r *http.Request) (any, error) {
+	var req dnsRecordRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Type) == "" || strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Content) == "" {
+		return nil, errorf(http.StatusBadRequest, "type、name、content 不能为空")
+	}
+	account, zone, err := s.resolveZone(r)
+	if err != nil {
+		return nil, err
+	}
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = 1
+	}
+	record, err := s.CFClient.UpsertDNSRecord(r.Context(), account, zone.Name, cfclient.DNSRecordParams{
+		Type:    strings.ToUpper(strings.TrimSpace(req.Type)),
+		Name:    strings.TrimSpace(req.Name),
+		Content: strings.TrimSpace(req.Content),
+		Proxied: req.Proxied,
+		TTL:     ttl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dnsRecordFromCloudflare(record), nil
+}
+
+func (s *Server) deleteDNS(r *http.Request) (any, error) {
+	account, zone, err := s.resolveZone(r)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(r.PathValue("name"))
+	deleted, err := s.CFClient.DeleteDNSRecord(r.Context(), account, zone.Name, name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"name": name, "deleted": deleted}, nil
+}
+
+func (s *Server) purgeCache(r *http.Request) (any, error) {
+	account, zone, err := s.resolveZone(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CFClient.PurgeZoneCache(r.Context(), account, zone.ID); err != nil {
+		return nil, err
+	}
+	return map[string]any{"zone": zone.Name, "zone_id": zone.ID, "purged": true}, nil
+}
+
+func (s *Server) listIPLists(r *http.Request) (any, error) {
+	account, err := s.accountByLabel(r.PathValue("label"))
+	if err != nil {
+		return nil, err
+	}
+	return s.CFClient.ListCustomLists(r.Context(), account)
+}
+
+func (s *Server) listIPListItems(r *http.Request) (any, error) {
+	account, err := s.accountByLabel(r.PathValue("label"))
+	if err != nil {
+		return nil, err
+	}
+	return s.CFClient.ListCustomListItems(r.Context(), account, r.PathValue("id"))
+}
+
+func (s *Server) addIPListItem(r *http.Request) (any, error) {
+	account, err := s.accountByLabel(r.PathValue("label"))
+	if err != nil {
+		return nil, err
+	}
+	var req ipListItemRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	ip := strings.TrimSpace(req.IP)
+	if ip == "" {
+		return nil, errorf(http.StatusBadRequest, "ip 不能为空")
+	}
+	return s.CFClient.CreateCustomListItem(r.Context(), account, r.PathValue("id"), cloudflare.ListItemCreateRequest{
+		IP:      &ip,
+		Comment: strings.TrimSpace(req.Comment),
+	})
+}
+
+func (s *Server) deleteIPListItem(r *http.Request) (any, error) {
+	account, err := s.accountByLabel(r.PathValue("label"))
+	if err != nil {
+		return nil, err
+	}
+	return s.CFClient.DeleteCustomListItem(r.Context(), account, r.PathValue("id"), r.PathValue("item"))
+}
+
+func (s *Server) runDailyReport(r *http.Request) (any, error) {
+	if s.Reporter == nil {
+		return nil, errorf(http.StatusNotImplemented, "未配置每日报告")
+	}
+	// 报告可能耗时较长，不随请求取消。
+	if err := s.Reporter.RunDaily(context.WithoutCancel(r.Context())); err != nil {
+		return nil, err
+	}
+	return map[string]any{"sent": true}, nil
+}
+
+func (s *Server) accountByLabel(label string) (config.CF, error) {
+	label = strings.TrimSpace(label)
+	for _, acc := range s.accounts() {
+		if strings.EqualFold(acc.Label, label) {
+			return acc, nil
+		}
+	}
+	return config.CF{}, errorf(http.StatusNotFound, "未找到 Cloudflare 账号: "+label)
+}
+
+// resolveZone 按 ?account= 指定的账号或依次在所有账号中查找 {domain}。
+func (s *Server) resolveZone(r *http.Request) (config.CF, cfclient.ZoneDetail, error) {
+	domain := strings.ToLower(strings.TrimSpace(r.PathValue("domain")))
+	if domain == "" {
+		return config.CF{}, cfclient.ZoneDetail{}, errorf(http.StatusBadRequest, "domain 不能为空")
+	}
+	if label := strings.TrimSpace(r.URL.Query().Get("account")); label != "" {
+		account, err := s.accountByLabel(label)
+		if err != nil {
+			return config.CF{}, cfclient.ZoneDetail{}, err
+		}
+		zone, err := s.CFClient.GetZoneDetails(r.Context(), account, domain)
+		return account, zone, err
+	}
+	return s.findZone(r.Context(), domain)
+}
+
+func (s *Server) findZone(ctx context.Context, domain string) (config.CF, cfclient.ZoneDetail, error) {
+	for _, acc := range s.accounts() {
+		zone, err := s.CFClient.GetZoneDetails(ctx, acc, domain)
+		if err != nil {
+			if errors.Is(err, cfclient.ErrZoneNotFound) || strings.Contains(strings.ToLower(err.Error()), "zone not found") {
+				continue
+			}
+			return config.CF{}, cfclient.ZoneDetail{}, err
+		}
+		return acc, zone, nil
+	}
+	return config.CF{}, cfclient.ZoneDetail{}, fmt.Errorf("%w: %s", cfclient.ErrZoneNotFound, domain)
+}
+
+func decodeBody(r *http.Request, out any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return errorf(http.StatusBadRequest, "请求体不是有效的 JSON: "+err.Error())
+	}
+	return nil
+}
+
+func dnsRecordFromCloudflare(rec cloudflare.DNSRecord) dnsRecordView {
+	view := dnsRecordView{ID: rec.ID, Type: rec.Type, Name: rec.Name, Content: rec.Content, TTL: rec.TTL}
+	if rec.Proxied != nil {
+		view.Proxied = *rec.Proxied
+	}
+	return view
+}
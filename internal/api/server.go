@@ -0,0 +1,230 @@
+// Package api 提供与 Telegram 命令等价的 HTTP REST 接口，供内部门户和 CI 调用。
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/registrarclient"
+	"DomainC/reminder"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Role 按 read < write < admin 递增。
+type Role int
+
+const (
+	RoleRead Role = iota + 1
+	RoleWrite
+	RoleAdmin
+)
+
+func parseRole(raw string) (Role, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "read", "readonly", "":
+		return RoleRead, true
+	case "write":
+		return RoleWrite, true
+	case "admin":
+		return RoleAdmin, true
+	}
+	return 0, false
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleRead:
+		return "read"
+	case RoleWrite:
+		return "write"
+	case RoleAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+// DailyReporter 是 /reports/daily 触发的每日到期报告，生产环境为 app.AssetReminderService。
+type DailyReporter interface {
+	RunDaily(ctx context.Context) error
+}
+
+// Server 复用 cfclient.Client、registrarclient.Manager 和 reminder.Runtime 执行与命令相同的操作。
+type Server struct {
+	CFClient  cfclient.Client
+	Registrar *registrarclient.Manager
+	Runtime   *reminder.Runtime
+	Accounts  []config.CF
+	Tokens    []config.APIToken
+	Reporter  DailyReporter
+
+	// mu 保护热加载时替换的 Accounts 和 Tokens。
+	mu sync.RWMutex
+}
+
+// SetAccounts 在配置热加载后替换账号列表，对之后的请求生效。
+func (s *Server) SetAccounts(accounts []config.CF) {
+	s.mu.Lock()
+	s.Accounts = append([]config.CF(nil), accounts...)
+	s.mu.Unlock()
+}
+
+// SetTokens 在配置热加载后替换 API token，被移除的 token 立即失效。
+func (s *Server) SetTokens(tokens []config.APIToken) {
+	s.mu.Lock()
+	s.Tokens = append([]config.APIToken(nil), tokens...)
+	s.mu.Unlock()
+}
+
+func (s *Server) accounts() []config.CF {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Accounts
+}
+
+func (s *Server) tokens() []config.APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Tokens
+}
+
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func errorf(status int, msg string) error {
+	return &apiError{status: status, msg: msg}
+}
+
+type response struct {
+	OK     bool   `json:"ok"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type tokenInfo struct {
+	Name string
+	Role Role
+}
+
+type handlerFunc func(r *http.Request) (any, error)
+
+// Handler 返回挂载全部路由的 http.Handler。
+// 写接口只按 token 角色授权，不走 Telegram 的二人审批；approval.actions 覆盖的操作（删除 Zone、
+// 批量关闭规则）因此不在 API 中提供，新增这类接口前需要先接入审批。
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPISpec)
+	})
+	s.route(mux, "GET /api/v1/accounts", RoleRead, s.listAccounts)
+	s.route(mux, "GET /api/v1/zones", RoleRead, s.listZones)
+	s.route(mux, "POST /api/v1/zones", RoleAdmin, s.createZone)
+	s.route(mux, "GET /api/v1/zones/{domain}/dns", RoleRead, s.listDNS)
+	s.route(mux, "PUT /api/v1/zones/{domain}/dns", RoleWrite, s.setDNS)
+	s.route(mux, "DELETE /api/v1/zones/{domain}/dns/{name}", RoleWrite, s.deleteDNS)
+	s.route(mux, "POST /api/v1/zones/{domain}/purge", RoleWrite, s.purgeCache)
+	s.route(mux, "GET /api/v1/accounts/{label}/lists", RoleRead, s.listIPLists)
+	s.route(mux, "GET /api/v1/accounts/{label}/lists/{id}/items", RoleRead, s.listIPListItems)
+	s.route(mux, "POST /api/v1/accounts/{label}/lists/{id}/items", RoleWrite, s.addIPListItem)
+	s.route(mux, "DELETE /api/v1/accounts/{label}/lists/{id}/items/{item}", RoleWrite, s.deleteIPListItem)
+	s.route(mux, "POST /api/v1/reports/daily", RoleAdmin, s.runDailyReport)
+	return mux
+}
+
+func (s *Server) route(mux *http.ServeMux, pattern string, need Role, fn handlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		token, err := s.authenticate(r)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, response{Error: err.Error()})
+			return
+		}
+		if token.Role < need {
+			writeJSON(w, http.StatusForbidden, response{Error: "token 角色 " + token.Role.String() + " 无权执行该操作，需要 " + need.String()})
+			return
+		}
+		if r.Method != http.MethodGet {
+			log.Printf("API 操作: token=%s role=%s %s %s", token.Name, token.Role, r.Method, r.URL.Path)
+		}
+		result, err := fn(r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var apiErr *apiError
+			if errors.As(err, &apiErr) {
+				status = apiErr.status
+			} else if errors.Is(err, cfclient.ErrZoneNotFound) {
+				status = http.StatusNotFound
+			}
+			writeJSON(w, status, response{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, response{OK: true, Result: result})
+	})
+}
+
+func (s *Server) authenticate(r *http.Request) (tokenInfo, error) {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return tokenInfo{}, errors.New("缺少 Authorization: Bearer <token>")
+	}
+	presented := []byte(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+	for _, t := range s.tokens() {
+		secret := strings.TrimSpace(t.Token)
+		if secret == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare(presented, []byte(secret)) == 1 {
+			role, ok := parseRole(t.Role)
+			if !ok {
+				return tokenInfo{}, errors.New("token 角色配置无效")
+			}
+			return tokenInfo{Name: t.Name, Role: role}, nil
+		}
+	}
+	return tokenInfo{}, errors.New("token 无效")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// ListenAndServe 启动 API 服务直到 ctx 结束。
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	log.Printf("HTTP API 已启动: %s", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
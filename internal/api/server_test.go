@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+type fakeCFClient struct {
+	cfclient.Client
+	zones    map[string]string
+	upserted []cfclient.DNSRecordParams
+	upsertTo string
+	// lookupErr 模拟查询 Zone 时的网络错误。
+	lookupErr   error
+	provisioned []string
+}
+
+func (f *fakeCFClient) GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	if f.lookupErr != nil {
+		return cfclient.ZoneDetail{}, f.lookupErr
+	}
+	if f.zones[domain] == account.Label {
+		return cfclient.ZoneDetail{ID: "zone-" + domain, Name: domain, Status: "active"}, nil
+	}
+	return cfclient.ZoneDetail{}, cfclient.ErrZoneNotFound
+}
+
+func (f *fakeCFClient) ListZoneSummaries(ctx context.Context, account config.CF) ([]cfclient.ZoneSummary, error) {
+	var out []cfclient.ZoneSummary
+	for domain, label := range f.zones {
+		if label == account.Label {
+			out = append(out, cfclient.ZoneSummary{ID: "zone-" + domain, Name: domain, Status: "active"})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeCFClient) UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params cfclient.DNSRecordParams) (cloudflare.DNSRecord, error) {
+	f.upserted = append(f.upserted, params)
+	f.upsertTo = account.Label
+	proxied := params.Proxied
+	return cloudflare.DNSRecord{ID: "rec1", Type: params.Type, Name: params.Name + "." + domain, Content: params.Content, TTL: params.TTL, Proxied: &proxied}, nil
+}
+
+func (f *fakeCFClient) ProvisionCloudflareZone(ctx context.Context, account config.CF, domain string, opts cfclient.ProvisionOptions) (*cfclient.ProvisionResult, error) {
+	f.provisioned = append(f.provisioned, account.Label+"/"+domain)
+	return &cfclient.ProvisionResult{Domain: domain, ZoneID: "zone-" + domain, ZoneStatus: "pending", ZoneCreated: true}, nil
+}
+
+func newTestServer(client *fakeCFClient) *httptest.Server {
+	s := &Server{
+		CFClient: client,
+		Accounts: []config.CF{{Label: "main"}, {Label: "backup"}},
+		Tokens: []config.APIToken{
+			{Name: "portal", Token: "read-token", Role: "read"},
+			{Name: "ci", Token: "write-token", Role: "write"},
+		},
+	}
+	return httptest.NewServer(s.Handler())
+}
+
+func doJSON(t *testing.T, srv *httptest.Server, method, path, token, body string) (int, response) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestAPIEnforcesBearerTokenRoles(t *testing.T) {
+	client := &fakeCFClient{zones: map[string]string{"example.com": "backup"}}
+	srv := newTestServer(client)
+	defer srv.Close()
+
+	body := `{"type":"a","name":"www","content":"203.0.113.10","proxied":true}`
+	if status, _ := doJSON(t, srv, http.MethodPut, "/api/v1/zones/example.com/dns", "", body); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", status)
+	}
+	if status, _ := doJSON(t, srv, http.MethodPut, "/api/v1/zones/example.com/dns", "wrong", body); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unknown token, got %d", status)
+	}
+	if status, _ := doJSON(t, srv, http.MethodPut, "/api/v1/zones/example.com/dns", "read-token", body); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for read token, got %d", status)
+	}
+	if len(client.upserted) != 0 {
+		t.Fatalf("rejected requests must not reach Cloudflare: %+v", client.upserted)
+	}
+
+	status, resp := doJSON(t, srv, http.MethodPut, "/api/v1/zones/example.com/dns", "write-token", body)
+	if status != http.StatusOK || !resp.OK {
+		t.Fatalf("expected success, got %d %+v", status, resp)
+	}
+	if client.upsertTo != "backup" || len(client.upserted) != 1 || client.upserted[0].Type != "A" || client.upserted[0].TTL != 1 {
+		t.Fatalf("unexpected upsert account=%s params=%+v", client.upsertTo, client.upserted)
+	}
+	if status, _ := doJSON(t, srv, http.MethodPost, "/api/v1/reports/daily", "write-token", ""); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for admin endpoint, got %d", status)
+	}
+}
+
+func TestAPIListsZonesAndServesOpenAPI(t *testing.T) {
+	client := &fakeCFClient{zones: map[string]string{"example.com": "main", "example.net": "backup"}}
+	srv := newTestServer(client)
+	defer srv.Close()
+
+	status, resp := doJSON(t, srv, http.MethodGet, "/api/v1/zones?account=backup", "read-token", "")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d %+v", status, resp)
+	}
+	zones, _ := resp.Result.([]any)
+	if len(zones) != 1 || zones[0].(map[string]any)["name"] != "example.net" || zones[0].(map[string]any)["account"] != "backup" {
+		t.Fatalf("unexpected zones: %+v", resp.Result)
+	}
+
+	if status, resp := doJSON(t, srv, http.MethodGet, "/api/v1/zones/missing.com/dns", "read-token", ""); status != http.StatusNotFound || resp.OK {
+		t.Fatalf("expected 404 for unknown zone, got %d %+v", status, resp)
+	}
+
+	res, err := srv.Client().Get(srv.URL + "/api/v1/openapi.json")
+	if err != nil {
+		t.Fatalf("get openapi: %v", err)
+	}
+	defer res.Body.Close()
+	var spec map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&spec); err != nil || spec["openapi"] == nil {
+		t.Fatalf("invalid openapi document: %v %+v", err, spec)
+	}
+}
+
+func TestCreateZoneFallsBackToFirstAccountOnlyWhenZoneIsMissing(t *testing.T) {
+	client := &fakeCFClient{zones: map[string]string{"example.net": "backup"}}
+	s := &Server{
+		CFClient: client,
+		Accounts: []config.CF{{Label: "main"}, {Label: "backup"}},
+		Tokens:   []config.APIToken{{Name: "ops", Token: "admin-token", Role: "admin"}},
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	if status, resp := doJSON(t, srv, http.MethodPost, "/api/v1/zones", "admin-token", `{"domain":"example.net"}`); status != http.StatusOK {
+		t.Fatalf("existing zone: expected 200, got %d %+v", status, resp)
+	}
+	if status, resp := doJSON(t, srv, http.MethodPost, "/api/v1/zones", "admin-token", `{"domain":"new.com"}`); status != http.StatusOK {
+		t.Fatalf("missing zone: expected 200, got %d %+v", status, resp)
+	}
+	if got := strings.Join(client.provisioned, ","); got != "backup/example.net,main/new.com" {
+		t.Fatalf("unexpected provision targets: %s", got)
+	}
+
+	client.lookupErr = errors.New("dial tcp: i/o timeout")
+	status, resp := doJSON(t, srv, http.MethodPost, "/api/v1/zones", "admin-token", `{"domain":"other.com"}`)
+	if status != http.StatusBadGateway || !strings.Contains(resp.Error, "account") {
+		t.Fatalf("lookup failure must require an explicit account, got %d %+v", status, resp)
+	}
+	if status, resp := doJSON(t, srv, http.MethodPost, "/api/v1/zones", "admin-token", `{"domain":"other.com","account":"backup"}`); status != http.StatusOK {
+		t.Fatalf("explicit account: expected 200, got %d %+v", status, resp)
+	}
+	if got := client.provisioned[len(client.provisioned)-1]; got != "backup/other.com" {
+		t.Fatalf("explicit account not used: %s", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"DomainC/cfclient"
@@ -28,6 +29,13 @@ type AbuseReportService struct {
 	CacheFile string
 	PerPage   int
 	MaxPages  int
+	// DigestOpenDays 是周报中“长期未关闭”的天数阈值，默认 7 天。
+	DigestOpenDays int
+	// Assets 用于查询报告域名的归属账号和注册商，为空时跳过资产关联。
+	Assets AbuseAssetLookup
+	live   liveAccounts
+	// scanMu 串行化定时扫描和 /abuse scan，避免并发读写去重缓存。
+	scanMu sync.Mutex
 }
 
 type AbuseReportCache struct {
@@ -37,20 +45,42 @@ type AbuseReportCache struct {
 }
 
 type AbuseReportCacheItem struct {
-	Key         string    `json:"key"`
-	ID          string    `json:"id,omitempty"`
-	Source      string    `json:"source,omitempty"`
-	AccountID   string    `json:"account_id,omitempty"`
-	Domain      string    `json:"domain,omitempty"`
-	ReportType  string    `json:"report_type,omitempty"`
-	Status      string    `json:"status,omitempty"`
-	Mitigation  string    `json:"mitigation,omitempty"`
-	Title       string    `json:"title,omitempty"`
-	Summary     string    `json:"summary,omitempty"`
-	Date        time.Time `json:"date,omitempty"`
-	FirstSeenAt time.Time `json:"first_seen_at,omitempty"`
-	LastSeenAt  time.Time `json:"last_seen_at,omitempty"`
-	NotifiedAt  time.Time `json:"notified_at,omitempty"`
+	Key         string         `json:"key"`
+	ID          string         `json:"id,omitempty"`
+	Source      string         `json:"source,omitempty"`
+	AccountID   string         `json:"account_id,omitempty"`
+	Domain      string         `json:"domain,omitempty"`
+	ReportType  string         `json:"report_type,omitempty"`
+	Status      string         `json:"status,omitempty"`
+	Mitigation  string         `json:"mitigation,omitempty"`
+	Title       string         `json:"title,omitempty"`
+	Summary     string         `json:"summary,omitempty"`
+	Reporter    string         `json:"reporter,omitempty"`
+	URLs        []string       `json:"urls,omitempty"`
+	Raw         map[string]any `json:"raw,omitempty"`
+	Date        time.Time      `json:"date,omitempty"`
+	FirstSeenAt time.Time      `json:"first_seen_at,omitempty"`
+	LastSeenAt  time.Time      `json:"last_seen_at,omitempty"`
+	NotifiedAt  time.Time      `json:"notified_at,omitempty"`
+	// NotifiedStatus/NotifiedMitigation 是最近一次通知时的状态，与当前值不同时发送跟进通知。
+	NotifiedStatus     string             `json:"notified_status,omitempty"`
+	NotifiedMitigation string             `json:"notified_mitigation,omitempty"`
+	ResolvedAt         time.Time          `json:"resolved_at,omitempty"`
+	History            []AbuseReportEvent `json:"history,omitempty"`
+}
+
+// AbuseReportEvent 记录扫描中观察到的一次状态或缓解措施变化。
+type AbuseReportEvent struct {
+	At         time.Time `json:"at"`
+	Status     string    `json:"status,omitempty"`
+	Mitigation string    `json:"mitigation,omitempty"`
+}
+
+// abuseReportChange 是已通知报告在本次扫描中的状态变化。
+type abuseReportChange struct {
+	Item           AbuseReportCacheItem
+	PrevStatus     string
+	PrevMitigation string
 }
 
 type abuseScanError struct {
@@ -62,19 +92,39 @@ func (s *AbuseReportService) RunDaily(ctx context.Context) error {
 	if s == nil || s.CFClient == nil || s.Sender == nil {
 		return ErrMissingDependencies
 	}
-	if len(s.Accounts) == 0 {
+	accounts := s.live.get(s.Accounts)
+	if len(accounts) == 0 {
 		return errors.New("no cloudflare accounts configured")
 	}
+	_, err := s.scan(ctx, accounts, true)
+	return err
+}
+
+// abuseScanResult 汇总一次扫描的结果，供 /abuse scan 回复。
+type abuseScanResult struct {
+	Accounts int
+	Reports  int
+	New      int
+	Changed  int
+	Errors   []abuseScanError
+}
 
+// scan 扫描指定账号并发送新报告和状态变化通知；只扫描部分账号时不更新 LastScanAt，
+// 以免周报把其它账号未被扫描到的报告误标为“最近一次扫描未返回”。
+func (s *AbuseReportService) scan(ctx context.Context, accounts []config.CF, full bool) (abuseScanResult, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	result := abuseScanResult{Accounts: len(accounts)}
 	now := time.Now()
 	cache, err := loadAbuseReportCache(s.cachePath())
 	if err != nil {
-		return err
+		return result, err
 	}
-
 	newReports := make([]cfclient.AbuseReportInfo, 0)
+	changes := make([]abuseReportChange, 0)
 	scanErrors := make([]abuseScanError, 0)
-	for _, acc := range s.Accounts {
+	for _, acc := range accounts {
 		reports, err := s.CFClient.ListAbuseReports(ctx, acc, cfclient.AbuseReportListOptions{PerPage: s.perPage(), MaxPages: s.maxPages()})
 		if err != nil {
 			scanErrors = append(scanErrors, abuseScanError{Source: acc.Label, Err: err})
@@ -82,6 +132,7 @@ func (s *AbuseReportService) RunDaily(ctx context.Context) error {
 			continue
 		}
 		log.Printf("[abuse_report] scan_account_done source=%s reports=%d", acc.Label, len(reports))
+		result.Reports += len(reports)
 		for _, report := range reports {
 			key := abuseReportKey(report)
 			item, existed := cache.Reports[key]
@@ -89,34 +140,56 @@ func (s *AbuseReportService) RunDaily(ctx context.Context) error {
 				item = AbuseReportCacheItem{Key: key, FirstSeenAt: now}
 			}
 			fillAbuseCacheItem(&item, report, now)
-			cache.Reports[key] = item
 			if item.NotifiedAt.IsZero() {
 				newReports = append(newReports, report)
+			} else if abuseStateChanged(item.NotifiedStatus, item.Status) || abuseStateChanged(item.NotifiedMitigation, item.Mitigation) {
+				changes = append(changes, abuseReportChange{Item: item, PrevStatus: item.NotifiedStatus, PrevMitigation: item.NotifiedMitigation})
 			}
+			cache.Reports[key] = item
+		}
+	}
+	if full {
+		cache.LastScanAt = now
+	}
+	result.New = len(newReports)
+	result.Changed = len(changes)
+	result.Errors = scanErrors
+
+	if len(changes) > 0 {
+		sortAbuseReportChanges(changes)
+		if err := s.Sender.Send(ctx, FormatAbuseReportChangeMessage(changes, now)); err != nil {
+			_ = saveAbuseReportCache(s.cachePath(), cache)
+			return result, err
+		}
+		for _, change := range changes {
+			item := cache.Reports[change.Item.Key]
+			item.NotifiedStatus = item.Status
+			item.NotifiedMitigation = item.Mitigation
+			cache.Reports[change.Item.Key] = item
 		}
 	}
-	cache.LastScanAt = now
 
 	if len(newReports) == 0 {
 		if err := saveAbuseReportCache(s.cachePath(), cache); err != nil {
-			return err
+			return result, err
 		}
 		if len(scanErrors) > 0 {
-			log.Printf("[abuse_report] scan_done new=0 errors=%d", len(scanErrors))
+			log.Printf("[abuse_report] scan_done new=0 changed=%d errors=%d", len(changes), len(scanErrors))
 		} else {
-			log.Printf("[abuse_report] scan_done new=0")
+			log.Printf("[abuse_report] scan_done new=0 changed=%d", len(changes))
 		}
-		return nil
+		return result, nil
 	}
 
 	sortAbuseReports(newReports)
 	msg := FormatAbuseReportMessage(newReports, scanErrors, now)
 	if err := s.Sender.Send(ctx, msg); err != nil {
 		_ = saveAbuseReportCache(s.cachePath(), cache)
-		return err
+		return result, err
 	}
 
-	reportPath, cleanup, err := BuildAbuseReportHTML(newReports, scanErrors, now)
+	contexts := s.BuildAbuseReportContexts(ctx, newReports)
+	reportPath, cleanup, err := BuildAbuseReportHTML(newReports, contexts, scanErrors, now)
 	if err != nil {
 		log.Printf("[abuse_report] build_html_failed err=%v", err)
 		reportPath, cleanup, err = BuildAbuseReportCSV(newReports, now)
@@ -127,7 +200,7 @@ func (s *AbuseReportService) RunDaily(ctx context.Context) error {
 			caption := fmt.Sprintf("%s: Cloudflare 新增滥用报告 %d 条，HTML 生成失败，已降级 CSV", now.Format("2006-01-02"), len(newReports))
 			if err := s.Sender.SendDocumentPath(ctx, reportPath, caption); err != nil {
 				_ = saveAbuseReportCache(s.cachePath(), cache)
-				return err
+				return result, err
 			}
 		}
 	} else {
@@ -135,21 +208,26 @@ func (s *AbuseReportService) RunDaily(ctx context.Context) error {
 		caption := fmt.Sprintf("%s: Cloudflare 新增滥用报告 %d 条，详情见 HTML 报告", now.Format("2006-01-02"), len(newReports))
 		if err := s.Sender.SendDocumentPath(ctx, reportPath, caption); err != nil {
 			_ = saveAbuseReportCache(s.cachePath(), cache)
-			return err
+			return result, err
 		}
 	}
+	if err := s.sendAbuseQuickActions(ctx, newReports, contexts); err != nil {
+		log.Printf("[abuse_report] quick_actions_failed err=%v", err)
+	}
 
 	for _, report := range newReports {
 		key := abuseReportKey(report)
 		item := cache.Reports[key]
 		item.NotifiedAt = now
+		item.NotifiedStatus = item.Status
+		item.NotifiedMitigation = item.Mitigation
 		cache.Reports[key] = item
 	}
 	if err := saveAbuseReportCache(s.cachePath(), cache); err != nil {
-		return err
+		return result, err
 	}
-	log.Printf("[abuse_report] scan_done new=%d errors=%d", len(newReports), len(scanErrors))
-	return nil
+	log.Printf("[abuse_report] scan_done new=%d changed=%d errors=%d", len(newReports), len(changes), len(scanErrors))
+	return result, nil
 }
 
 func (s *AbuseReportService) cachePath() string {
@@ -269,7 +347,8 @@ type abuseReportInsight struct {
 	Evidence      string
 }
 
-func BuildAbuseReportHTML(reports []cfclient.AbuseReportInfo, scanErrors []abuseScanError, now time.Time) (string, func(), error) {
+// BuildAbuseReportHTML 渲染报告附件；contexts 按报告 key 提供资产归属、当前解析和最近操作，可为空。
+func BuildAbuseReportHTML(reports []cfclient.AbuseReportInfo, contexts map[string]AbuseReportContext, scanErrors []abuseScanError, now time.Time) (string, func(), error) {
 	file, err := os.CreateTemp("", fmt.Sprintf("cf_abuse_reports_%s_*.html", now.Format("20060102_150405")))
 	if err != nil {
 		return "", func() {}, err
@@ -291,7 +370,7 @@ func BuildAbuseReportHTML(reports []cfclient.AbuseReportInfo, scanErrors []abuse
 	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">")
 	sb.WriteString("<title>Cloudflare 滥用报告</title>")
 	sb.WriteString(`<style>
-body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","Microsoft YaHei",Arial,sans-serif;margin:0;background:#f5f7fb;color:#182033;line-height:1.55}.wrap{max-width:1280px;margin:0 auto;padding:24px}.header{background:#111827;color:white;border-radius:18px;padding:22px 26px;margin-bottom:18px}.header h1{margin:0 0 8px;font-size:26px}.header p{margin:4px 0;color:#d1d5db}.cards{display:grid;grid-template-columns:repeat(auto-fit,minmax(180px,1fr));gap:12px;margin:18px 0}.card{background:white;border-radius:14px;padding:16px;border:1px solid #e5e7eb;box-shadow:0 1px 3px rgba(15,23,42,.06)}.card .num{font-size:26px;font-weight:800;margin-top:4px}.card .label{color:#64748b}.section{background:white;border:1px solid #e5e7eb;border-radius:16px;padding:18px;margin:16px 0;box-shadow:0 1px 3px rgba(15,23,42,.04)}.section h2{font-size:20px;margin:0 0 12px}.tip{background:#fff7ed;border:1px solid #fed7aa;border-radius:12px;padding:12px 14px;color:#7c2d12}.risk-high{color:#b91c1c;font-weight:700}.risk-mid{color:#b45309;font-weight:700}.risk-low{color:#047857;font-weight:700}.pill{display:inline-block;border-radius:999px;padding:3px 10px;font-size:12px;font-weight:700;background:#eef2ff;color:#3730a3}.table-wrap{overflow:auto;border:1px solid #e5e7eb;border-radius:14px}table{width:100%;border-collapse:collapse;background:white;min-width:1180px}th,td{border-bottom:1px solid #e5e7eb;padding:10px 12px;text-align:left;vertical-align:top}th{background:#f8fafc;font-weight:700;white-space:nowrap}tr:hover{background:#f8fafc}.muted{color:#64748b}.mono{font-family:ui-monospace,SFMono-Regular,Menlo,Monaco,Consolas,"Liberation Mono",monospace;font-size:12px}.url{word-break:break-all}.summary{max-width:360px}.action{max-width:360px}.raw{white-space:pre-wrap;background:#0b1020;color:#e5e7eb;border-radius:10px;padding:12px;max-height:360px;overflow:auto;font-size:12px}details{margin-top:8px}summary{cursor:pointer;color:#2563eb;font-weight:600}.grid2{display:grid;grid-template-columns:repeat(auto-fit,minmax(260px,1fr));gap:10px}.list{margin:0;padding-left:18px}.footer{color:#64748b;font-size:12px;margin-top:18px}
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","Microsoft YaHei",Arial,sans-serif;margin:0;background:#f5f7fb;color:#182033;line-height:1.55}.wrap{max-width:1280px;margin:0 auto;padding:24px}.header{background:#111827;color:white;border-radius:18px;padding:22px 26px;margin-bottom:18px}.header h1{margin:0 0 8px;font-size:26px}.header p{margin:4px 0;color:#d1d5db}.cards{display:grid;grid-template-columns:repeat(auto-fit,minmax(180px,1fr));gap:12px;margin:18px 0}.card{background:white;border-radius:14px;padding:16px;border:1px solid #e5e7eb;box-shadow:0 1px 3px rgba(15,23,42,.06)}.card .num{font-size:26px;font-weight:800;margin-top:4px}.card .label{color:#64748b}.section{background:white;border:1px solid #e5e7eb;border-radius:16px;padding:18px;margin:16px 0;box-shadow:0 1px 3px rgba(15,23,42,.04)}.section h2{font-size:20px;margin:0 0 12px}.tip{background:#fff7ed;border:1px solid #fed7aa;border-radius:12px;padding:12px 14px;color:#7c2d12}.risk-high{color:#b91c1c;font-weight:700}.risk-mid{color:#b45309;font-weight:700}.risk-low{color:#047857;font-weight:700}.pill{display:inline-block;border-radius:999px;padding:3px 10px;font-size:12px;font-weight:700;background:#eef2ff;color:#3730a3}.table-wrap{overflow:auto;border:1px solid #e5e7eb;border-radius:14px}table{width:100%;border-collapse:collapse;background:white;min-width:1400px}th,td{border-bottom:1px solid #e5e7eb;padding:10px 12px;text-align:left;vertical-align:top}th{background:#f8fafc;font-weight:700;white-space:nowrap}tr:hover{background:#f8fafc}.muted{color:#64748b}.mono{font-family:ui-monospace,SFMono-Regular,Menlo,Monaco,Consolas,"Liberation Mono",monospace;font-size:12px}.url{word-break:break-all}.summary{max-width:360px}.action{max-width:360px}.raw{white-space:pre-wrap;background:#0b1020;color:#e5e7eb;border-radius:10px;padding:12px;max-height:360px;overflow:auto;font-size:12px}details{margin-top:8px}summary{cursor:pointer;color:#2563eb;font-weight:600}.grid2{display:grid;grid-template-columns:repeat(auto-fit,minmax(260px,1fr));gap:10px}.list{margin:0;padding-left:18px}.footer{color:#64748b;font-size:12px;margin-top:18px}
 </style></head><body><div class="wrap">`)
 	sb.WriteString("<div class=\"header\"><h1>Cloudflare 滥用报告</h1>")
 	sb.WriteString(fmt.Sprintf("<p>生成时间：%s</p>", escapeHTML(now.Format("2006-01-02 15:04:05"))))
@@ -327,7 +406,7 @@ body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","Microsoft YaHei",A
 	sb.WriteString("</div>")
 
 	sb.WriteString("<div class=\"section\"><h2>报告明细与排查建议</h2><div class=\"table-wrap\"><table><thead><tr>")
-	for _, h := range []string{"风险", "账号", "域名", "白话说明", "可能原因", "建议处理", "报告信息", "证据/详情"} {
+	for _, h := range []string{"风险", "账号", "域名", "归属与指向", "白话说明", "可能原因", "建议处理", "报告信息", "证据/详情"} {
 		sb.WriteString("<th>" + escapeHTML(h) + "</th>")
 	}
 	sb.WriteString("</tr></thead><tbody>")
@@ -348,6 +427,7 @@ body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","Microsoft YaHei",A
 		sb.WriteString(fmt.Sprintf("<td><span class=\"%s\">%s</span></td>", riskClass, escapeHTML(insight.RiskLevel)))
 		sb.WriteString(fmt.Sprintf("<td>%s</td>", escapeHTML(displayAbuseValue(report.AccountLabel, "未知账号"))))
 		sb.WriteString(fmt.Sprintf("<td><b>%s</b><br><span class=\"muted mono\">%s</span></td>", escapeHTML(displayAbuseValue(report.Domain, "未识别域名")), escapeHTML(firstNonEmpty(report.ID, abuseReportKey(report)))))
+		sb.WriteString("<td class=\"summary\">" + abuseReportContextHTML(contexts[abuseReportKey(report)]) + "</td>")
 		sb.WriteString(fmt.Sprintf("<td class=\"summary\">%s<br><span class=\"pill\">%s</span></td>", escapeHTML(insight.PlainSummary), escapeHTML(insight.TypeLabel)))
 		sb.WriteString(fmt.Sprintf("<td class=\"summary\">%s</td>", escapeHTML(insight.PossibleCause)))
 		sb.WriteString(fmt.Sprintf("<td class=\"action\">%s</td>", escapeHTML(insight.Action)))
@@ -721,6 +801,23 @@ func saveAbuseReportCache(path string, cache AbuseReportCache) error {
 }
 
 func fillAbuseCacheItem(item *AbuseReportCacheItem, report cfclient.AbuseReportInfo, now time.Time) {
+	// 旧版缓存没有历史和通知时的状态，以上次扫描的值为基线，避免升级后把所有报告当作变化。
+	if len(item.History) == 0 && !item.LastSeenAt.IsZero() {
+		item.History = append(item.History, AbuseReportEvent{At: item.LastSeenAt, Status: item.Status, Mitigation: item.Mitigation})
+		if !item.NotifiedAt.IsZero() {
+			item.NotifiedStatus = item.Status
+			item.NotifiedMitigation = item.Mitigation
+		}
+	}
+	if len(item.History) == 0 || abuseStateChanged(item.Status, report.Status) || abuseStateChanged(item.Mitigation, report.Mitigation) {
+		item.History = append(item.History, AbuseReportEvent{At: now, Status: report.Status, Mitigation: report.Mitigation})
+	}
+	switch {
+	case isAbuseReportClosed(report.Status) && item.ResolvedAt.IsZero():
+		item.ResolvedAt = now
+	case !isAbuseReportClosed(report.Status):
+		item.ResolvedAt = time.Time{}
+	}
 	item.ID = report.ID
 	item.Source = report.AccountLabel
 	item.AccountID = report.AccountID
@@ -730,6 +827,9 @@ func fillAbuseCacheItem(item *AbuseReportCacheItem, report cfclient.AbuseReportI
 	item.Mitigation = report.Mitigation
 	item.Title = report.Title
 	item.Summary = report.Summary
+	item.Reporter = report.Reporter
+	item.URLs = append([]string(nil), report.URLs...)
+	item.Raw = report.Raw
 	item.Date = report.Date
 	if item.FirstSeenAt.IsZero() {
 		item.FirstSeenAt = now
@@ -0,0 +1,253 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/reminder"
+	"DomainC/telegram"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const (
+	abuseOperationLookback = 14 * 24 * time.Hour
+	abuseOperationLimit    = 5
+	abuseHostnameLimit     = 5
+	abuseQuickActionLimit  = 5
+)
+
+// AbuseAssetLookup 查询资产缓存中的域名记录，*reminder.Store 实现了该接口。
+type AbuseAssetLookup interface {
+	GetRecord(domain string) (reminder.Record, bool, error)
+}
+
+// AbuseReportContext 是报告域名在本地资产和机器人操作中的关联信息。
+type AbuseReportContext struct {
+	Zone       string
+	Accounts   []string
+	Registrar  string
+	Paused     bool
+	InCache    bool
+	Hostnames  []string
+	DNSTargets map[string][]string
+	DNSError   string
+	Operations []telegram.OperationEntry
+}
+
+// BuildAbuseReportContexts 为每条报告补充归属账号、注册商、被举报主机名的当前解析和最近操作，按报告 key 索引。
+// 单条查询失败只记录在对应字段中，不影响通知发送。
+func (s *AbuseReportService) BuildAbuseReportContexts(ctx context.Context, reports []cfclient.AbuseReportInfo) map[string]AbuseReportContext {
+	accounts := make(map[string]config.CF)
+	for _, acc := range s.live.get(s.Accounts) {
+		accounts[acc.Label] = acc
+	}
+	out := make(map[string]AbuseReportContext, len(reports))
+	type dnsLookup struct {
+		records []cloudflare.DNSRecord
+		err     error
+	}
+	dnsCache := make(map[string]dnsLookup)
+	for _, report := range reports {
+		rc := AbuseReportContext{Zone: reminder.NormalizeDomain(report.Domain)}
+		if s.Assets != nil && rc.Zone != "" {
+			if rec, ok := lookupAbuseAssetRecord(s.Assets, rc.Zone); ok {
+				rc.InCache = true
+				rc.Zone = rec.Domain
+				rc.Registrar = rec.Registrar
+				rc.Paused = rec.Paused
+				for _, owner := range reminder.RecordAccounts(rec) {
+					if !owner.Unknown {
+						rc.Accounts = append(rc.Accounts, reminder.NormalizeSource(owner.Source))
+					}
+				}
+			}
+		}
+		if len(rc.Accounts) == 0 && strings.TrimSpace(report.AccountLabel) != "" {
+			rc.Accounts = []string{report.AccountLabel}
+		}
+		rc.Hostnames = abuseReportHostnames(report, rc.Zone)
+
+		if account, ok := accounts[report.AccountLabel]; ok && rc.Zone != "" && s.CFClient != nil {
+			cacheKey := account.Label + "|" + rc.Zone
+			lookup, seen := dnsCache[cacheKey]
+			if !seen {
+				lookup.records, lookup.err = s.CFClient.ListDNSRecords(ctx, account, rc.Zone)
+				if lookup.err != nil {
+					log.Printf("[abuse_report] dns_lookup_failed source=%s zone=%s err=%v", account.Label, rc.Zone, lookup.err)
+				}
+				dnsCache[cacheKey] = lookup
+			}
+			if lookup.err != nil {
+				rc.DNSError = lookup.err.Error()
+			}
+			rc.DNSTargets = make(map[string][]string)
+			for _, record := range lookup.records {
+				name := reminder.NormalizeDomain(record.Name)
+				for _, host := range rc.Hostnames {
+					if name == host {
+						rc.DNSTargets[host] = append(rc.DNSTargets[host], formatAbuseDNSTarget(record.Type, record.Content, record.Proxied))
+					}
+				}
+			}
+		}
+
+		if rc.Zone != "" {
+			ops, err := telegram.RecentOperations(rc.Zone, time.Now().Add(-abuseOperationLookback), abuseOperationLimit)
+			if err != nil {
+				log.Printf("[abuse_report] operation_log_failed zone=%s err=%v", rc.Zone, err)
+			}
+			rc.Operations = ops
+		}
+		out[abuseReportKey(report)] = rc
+	}
+	return out
+}
+
+// lookupAbuseAssetRecord 从主机名逐级向上查找资产缓存中的 Zone 记录。
+func lookupAbuseAssetRecord(assets AbuseAssetLookup, host string) (reminder.Record, bool) {
+	labels := strings.Split(host, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		rec, ok, err := assets.GetRecord(strings.Join(labels[i:], "."))
+		if err != nil {
+			log.Printf("[abuse_report] asset_lookup_failed domain=%s err=%v", host, err)
+			return reminder.Record{}, false
+		}
+		if ok && !rec.Deleted {
+			return rec, true
+		}
+	}
+	return reminder.Record{}, false
+}
+
+// abuseReportHostnames 收集报告域名和证据 URL 中属于该 Zone 的主机名。
+func abuseReportHostnames(report cfclient.AbuseReportInfo, zone string) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	add := func(host string) {
+		host = reminder.NormalizeDomain(host)
+		if host == "" || seen[host] || len(hosts) >= abuseHostnameLimit {
+			return
+		}
+		if zone != "" && host != zone && !strings.HasSuffix(host, "."+zone) {
+			return
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	add(report.Domain)
+	for _, raw := range report.URLs {
+		candidate := strings.TrimSpace(raw)
+		if !strings.Contains(candidate, "://") {
+			candidate = "http://" + candidate
+		}
+		if parsed, err := url.Parse(candidate); err == nil {
+			add(parsed.Hostname())
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func formatAbuseDNSTarget(recordType, content string, proxied *bool) string {
+	target := fmt.Sprintf("%s %s", recordType, content)
+	if proxied != nil && *proxied {
+		target += "（代理）"
+	}
+	return target
+}
+
+// abuseQuickActionHost 选择“改指向”按钮使用的主机名：优先证据 URL 中有解析记录的主机。
+func abuseQuickActionHost(report cfclient.AbuseReportInfo, rc AbuseReportContext) string {
+	for _, host := range rc.Hostnames {
+		if len(rc.DNSTargets[host]) > 0 {
+			return host
+		}
+	}
+	return firstNonEmpty(rc.Zone, report.Domain)
+}
+
+// sendAbuseQuickActions 为前几条报告发送清缓存、暂停 Zone、改指向按钮。
+func (s *AbuseReportService) sendAbuseQuickActions(ctx context.Context, reports []cfclient.AbuseReportInfo, contexts map[string]AbuseReportContext) error {
+	var sb strings.Builder
+	var buttons [][]telegram.Button
+	sb.WriteString("【滥用报告快捷处理】\n确认报告属实后可直接处理；暂停 Zone 需要二次确认，改指向会进入 /setdns 的新目标输入。")
+	for _, report := range reports {
+		if len(buttons) >= abuseQuickActionLimit {
+			break
+		}
+		rc := contexts[abuseReportKey(report)]
+		if strings.TrimSpace(report.AccountLabel) == "" || rc.Zone == "" {
+			continue
+		}
+		host := abuseQuickActionHost(report, rc)
+		row := telegram.AbuseQuickActionButtons(len(buttons)+1, report.AccountLabel, rc.Zone, host)
+		if len(row) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s（账号 %s，改指向对象 %s）", len(buttons)+1, rc.Zone, report.AccountLabel, host))
+		buttons = append(buttons, row)
+	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	return s.Sender.SendWithButtons(ctx, sb.String(), buttons)
+}
+
+// abuseReportContextHTML 渲染 HTML 报告中的“归属与指向”单元格。
+func abuseReportContextHTML(rc AbuseReportContext) string {
+	var sb strings.Builder
+	accounts := "未知"
+	if len(rc.Accounts) > 0 {
+		accounts = strings.Join(rc.Accounts, ", ")
+	}
+	sb.WriteString("账号：" + escapeHTML(accounts))
+	sb.WriteString("<br>注册商：" + escapeHTML(displayAbuseValue(rc.Registrar, "未知")))
+	switch {
+	case !rc.InCache:
+		sb.WriteString("<br><span class=\"muted\">资产缓存中未找到该域名</span>")
+	case rc.Paused:
+		sb.WriteString("<br><span class=\"risk-mid\">Zone 已暂停</span>")
+	}
+
+	sb.WriteString("<br><b>当前解析：</b>")
+	switch {
+	case rc.DNSError != "":
+		sb.WriteString("<span class=\"muted\">查询失败：" + escapeHTML(compactAbuseText(rc.DNSError, 120)) + "</span>")
+	case rc.DNSTargets == nil:
+		sb.WriteString("<span class=\"muted\">未查询</span>")
+	default:
+		for _, host := range rc.Hostnames {
+			targets := rc.DNSTargets[host]
+			sb.WriteString("<br><span class=\"mono\">" + escapeHTML(host) + "</span> → ")
+			if len(targets) == 0 {
+				sb.WriteString("<span class=\"muted\">无解析记录</span>")
+				continue
+			}
+			sb.WriteString("<span class=\"mono\">" + escapeHTML(strings.Join(targets, "; ")) + "</span>")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("<br><b>最近 %d 天机器人操作：</b>", int(abuseOperationLookback.Hours()/24)))
+	if len(rc.Operations) == 0 {
+		sb.WriteString("<span class=\"muted\">无</span>")
+	}
+	for _, op := range rc.Operations {
+		line := fmt.Sprintf("%s %s", op.At.Local().Format("01-02 15:04"), op.Operation)
+		if op.Operator != "" {
+			line += " 操作人 " + op.Operator
+		}
+		if op.Detail != "" {
+			line += "（" + op.Detail + "）"
+		}
+		sb.WriteString("<br>" + escapeHTML(line))
+	}
+	return sb.String()
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunWeeklyDigest 汇总本地缓存中超过 DigestOpenDays 天仍未关闭的报告，没有时只写日志。
+func (s *AbuseReportService) RunWeeklyDigest(ctx context.Context) error {
+	if s == nil || s.Sender == nil {
+		return ErrMissingDependencies
+	}
+	cache, err := loadAbuseReportCache(s.cachePath())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	open := OpenAbuseReports(cache, s.digestOpenDays(), now)
+	if len(open) == 0 {
+		log.Printf("[abuse_report] digest_done open=0 days=%d", s.digestOpenDays())
+		return nil
+	}
+	if err := s.Sender.Send(ctx, FormatAbuseReportDigest(open, cache.LastScanAt, s.digestOpenDays(), now)); err != nil {
+		return err
+	}
+	log.Printf("[abuse_report] digest_done open=%d days=%d", len(open), s.digestOpenDays())
+	return nil
+}
+
+func (s *AbuseReportService) digestOpenDays() int {
+	if s.DigestOpenDays <= 0 {
+		return 7
+	}
+	return s.DigestOpenDays
+}
+
+// OpenAbuseReports 返回未关闭且已持续至少 days 天的报告，按持续时间从长到短排序。
+func OpenAbuseReports(cache AbuseReportCache, days int, now time.Time) []AbuseReportCacheItem {
+	out := make([]AbuseReportCacheItem, 0)
+	for _, item := range cache.Reports {
+		if isAbuseReportClosed(item.Status) {
+			continue
+		}
+		if now.Sub(abuseReportOpenedAt(item)) < time.Duration(days)*24*time.Hour {
+			continue
+		}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ai, aj := abuseReportOpenedAt(out[i]), abuseReportOpenedAt(out[j])
+		if !ai.Equal(aj) {
+			return ai.Before(aj)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// FormatAbuseReportDigest 渲染长期未关闭报告周报；lastScan 之前就不再返回的报告单独标注。
+func FormatAbuseReportDigest(items []AbuseReportCacheItem, lastScan time.Time, days int, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("【Cloudflare 滥用报告周报】")
+	sb.WriteString(fmt.Sprintf("\n超过 %d 天仍未关闭的报告: %d 条", days, len(items)))
+	sb.WriteString(fmt.Sprintf("\n生成时间: %s", now.Format("2006-01-02 15:04:05")))
+
+	accountCounts := map[string]int{}
+	for _, item := range items {
+		accountCounts[displayAbuseValue(item.Source, "未知账号")]++
+	}
+	sb.WriteString("\n\n按账号统计:")
+	for _, key := range sortedStringKeys(accountCounts) {
+		sb.WriteString(fmt.Sprintf("\n- %s: %d 条", key, accountCounts[key]))
+	}
+
+	sb.WriteString("\n\n明细:")
+	limit := len(items)
+	if limit > 20 {
+		limit = 20
+	}
+	for i := 0; i < limit; i++ {
+		item := items[i]
+		sb.WriteString(fmt.Sprintf("\n%d. %s（%s）已持续 %d 天", i+1, displayAbuseValue(item.Domain, "未识别域名"), displayAbuseValue(item.Source, "未知账号"), abuseReportOpenDays(item, now)))
+		sb.WriteString(fmt.Sprintf("\n   状态: %s；Cloudflare处理: %s", humanAbuseStatus(item.Status), humanAbuseMitigation(item.Mitigation)))
+		if changes := len(item.History) - 1; changes > 0 {
+			sb.WriteString(fmt.Sprintf("；期间变化 %d 次", changes))
+		}
+		if !lastScan.IsZero() && item.LastSeenAt.Before(lastScan) {
+			sb.WriteString(fmt.Sprintf("\n   ⚠️ 最近一次扫描未返回该报告（最后出现 %s），请到 Cloudflare 后台确认", item.LastSeenAt.Format("2006-01-02")))
+		}
+	}
+	if len(items) > limit {
+		sb.WriteString(fmt.Sprintf("\n\n还有 %d 条未列出。", len(items)-limit))
+	}
+	return sb.String()
+}
+
+// FormatAbuseReportChangeMessage 渲染已通知报告的状态或缓解措施变化。
+func FormatAbuseReportChangeMessage(changes []abuseReportChange, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("【Cloudflare 滥用报告状态变化】")
+	sb.WriteString(fmt.Sprintf("\n%d 条已通知的报告状态或处理动作有变化", len(changes)))
+	sb.WriteString(fmt.Sprintf("\n扫描时间: %s", now.Format("2006-01-02 15:04:05")))
+	for i, change := range changes {
+		item := change.Item
+		icon := "🔄"
+		switch {
+		case isAbuseReportClosed(item.Status):
+			icon = "✅"
+		case abuseStateChanged(change.PrevMitigation, item.Mitigation) && strings.TrimSpace(item.Mitigation) != "":
+			icon = "⚠️"
+		}
+		sb.WriteString(fmt.Sprintf("\n\n%d. %s %s", i+1, icon, displayAbuseValue(item.Domain, "未识别域名")))
+		sb.WriteString(fmt.Sprintf("\n   账号: %s；报告: %s", displayAbuseValue(item.Source, "未知账号"), firstNonEmpty(item.ID, item.Key)))
+		if abuseStateChanged(change.PrevStatus, item.Status) {
+			sb.WriteString(fmt.Sprintf("\n   状态: %s → %s", humanAbuseStatus(change.PrevStatus), humanAbuseStatus(item.Status)))
+		}
+		if abuseStateChanged(change.PrevMitigation, item.Mitigation) {
+			to := humanAbuseMitigation(item.Mitigation)
+			if strings.TrimSpace(item.Mitigation) == "" {
+				to = "已解除/未返回"
+			}
+			sb.WriteString(fmt.Sprintf("\n   Cloudflare处理: %s → %s", humanAbuseMitigation(change.PrevMitigation), to))
+		}
+		if isAbuseReportClosed(item.Status) {
+			sb.WriteString(fmt.Sprintf("\n   从首次发现到关闭用时 %d 天", abuseReportOpenDays(item, item.ResolvedAt)))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n   已持续 %d 天", abuseReportOpenDays(item, now)))
+		}
+	}
+	return sb.String()
+}
+
+func sortAbuseReportChanges(changes []abuseReportChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Item.Source != changes[j].Item.Source {
+			return changes[i].Item.Source < changes[j].Item.Source
+		}
+		return changes[i].Item.Domain < changes[j].Item.Domain
+	})
+}
+
+func abuseStateChanged(prev, current string) bool {
+	return !strings.EqualFold(strings.TrimSpace(prev), strings.TrimSpace(current))
+}
+
+func isAbuseReportClosed(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "closed", "resolved", "rejected", "dismissed", "completed":
+		return true
+	}
+	return false
+}
+
+func abuseReportOpenedAt(item AbuseReportCacheItem) time.Time {
+	if !item.Date.IsZero() {
+		return item.Date
+	}
+	return item.FirstSeenAt
+}
+
+func abuseReportOpenDays(item AbuseReportCacheItem, until time.Time) int {
+	opened := abuseReportOpenedAt(item)
+	if opened.IsZero() || until.Before(opened) {
+		return 0
+	}
+	return int(until.Sub(opened).Hours() / 24)
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+const (
+	abuseHistoryDefaultDays = 30
+	abuseHistoryListLimit   = 30
+)
+
+// ScanAbuseReports 立即扫描指定账号（为空时扫描全部账号），新报告和状态变化照常推送并写入去重缓存，返回扫描摘要。
+func (s *AbuseReportService) ScanAbuseReports(ctx context.Context, accounts []config.CF) (string, error) {
+	if s == nil || s.CFClient == nil || s.Sender == nil {
+		return "", ErrMissingDependencies
+	}
+	all := s.live.get(s.Accounts)
+	if len(accounts) == 0 {
+		accounts = all
+	}
+	if len(accounts) == 0 {
+		return "", errors.New("no cloudflare accounts configured")
+	}
+	result, err := s.scan(ctx, accounts, coversAllAccounts(accounts, all))
+	if err != nil {
+		return "", err
+	}
+	return FormatAbuseScanResult(result), nil
+}
+
+// ListAbuseReportHistory 查询本地缓存中 days 天内的报告（含已通知的），query 可以是账号标签或域名。
+func (s *AbuseReportService) ListAbuseReportHistory(query string, days int) (string, error) {
+	cache, err := loadAbuseReportCache(s.cachePath())
+	if err != nil {
+		return "", err
+	}
+	if days <= 0 {
+		days = abuseHistoryDefaultDays
+	}
+	now := time.Now()
+	return FormatAbuseReportHistory(FilterAbuseReports(cache, query, days, now), query, days, now), nil
+}
+
+// ShowAbuseReport 按报告 ID 或缓存 key 渲染完整报告，包括解读、状态历史和 Cloudflare 原始字段。
+func (s *AbuseReportService) ShowAbuseReport(id string) (string, error) {
+	cache, err := loadAbuseReportCache(s.cachePath())
+	if err != nil {
+		return "", err
+	}
+	items := FindAbuseReports(cache, id)
+	if len(items) == 0 {
+		return "", fmt.Errorf("本地缓存中没有报告 %s，可先执行 /abuse scan", strings.TrimSpace(id))
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, FormatAbuseReportDetail(item, time.Now()))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func coversAllAccounts(accounts, all []config.CF) bool {
+	scanned := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		scanned[acc.Label] = true
+	}
+	for _, acc := range all {
+		if !scanned[acc.Label] {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterAbuseReports 返回报告日期（缺失时为首次发现时间）在 days 天内的报告，最新的在前；
+// query 与账号标签相同时按账号过滤，否则按域名及其子域名过滤。
+func FilterAbuseReports(cache AbuseReportCache, query string, days int, now time.Time) []AbuseReportCacheItem {
+	query = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(query)), ".")
+	byAccount := false
+	if query != "" {
+		for _, item := range cache.Reports {
+			if strings.EqualFold(item.Source, query) {
+				byAccount = true
+				break
+			}
+		}
+	}
+	cutoff := now.Add(-time.Duration(days) * 24 * time.Hour)
+	out := make([]AbuseReportCacheItem, 0)
+	for _, item := range cache.Reports {
+		if abuseReportOpenedAt(item).Before(cutoff) {
+			continue
+		}
+		if query != "" {
+			if byAccount {
+				if !strings.EqualFold(item.Source, query) {
+					continue
+				}
+			} else {
+				domain := strings.ToLower(strings.TrimSpace(item.Domain))
+				if domain != query && !strings.HasSuffix(domain, "."+query) {
+					continue
+				}
+			}
+		}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ai, aj := abuseReportOpenedAt(out[i]), abuseReportOpenedAt(out[j])
+		if !ai.Equal(aj) {
+			return ai.After(aj)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// FindAbuseReports 按报告 ID 或缓存 key 查找；同一 ID 出现在多个账号时全部返回。
+func FindAbuseReports(cache AbuseReportCache, id string) []AbuseReportCacheItem {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil
+	}
+	var out []AbuseReportCacheItem
+	for _, item := range cache.Reports {
+		if strings.EqualFold(item.ID, id) || strings.EqualFold(item.Key, id) {
+			out = append(out, item)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// FormatAbuseScanResult 渲染 /abuse scan 的结果摘要。
+func FormatAbuseScanResult(result abuseScanResult) string {
+	var sb strings.Builder
+	sb.WriteString("【滥用报告扫描完成】")
+	sb.WriteString(fmt.Sprintf("\n扫描账号: %d 个，接口返回报告: %d 条", result.Accounts, result.Reports))
+	if result.New > 0 {
+		sb.WriteString(fmt.Sprintf("\n新增报告: %d 条（已单独推送通知和 HTML 报告）", result.New))
+	} else {
+		sb.WriteString("\n新增报告: 0 条")
+	}
+	if result.Changed > 0 {
+		sb.WriteString(fmt.Sprintf("\n状态变化: %d 条（已推送跟进通知）", result.Changed))
+	}
+	if len(result.Errors) > 0 {
+		sb.WriteString(fmt.Sprintf("\n扫描失败账号: %d 个", len(result.Errors)))
+		for _, item := range result.Errors {
+			sb.WriteString(fmt.Sprintf("\n- %s: %s", displayAbuseValue(item.Source, "未知账号"), compactAbuseText(item.Err.Error(), 160)))
+		}
+	}
+	return sb.String()
+}
+
+// FormatAbuseReportHistory 渲染 /abuse list 的结果，最多列出 30 条。
+func FormatAbuseReportHistory(items []AbuseReportCacheItem, query string, days int, now time.Time) string {
+	scope := "全部账号"
+	if strings.TrimSpace(query) != "" {
+		scope = strings.TrimSpace(query)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【滥用报告记录】%s，最近 %d 天: %d 条", scope, days, len(items)))
+	if len(items) == 0 {
+		sb.WriteString("\n本地缓存中没有符合条件的报告。")
+		return sb.String()
+	}
+	open := 0
+	for _, item := range items {
+		if !isAbuseReportClosed(item.Status) {
+			open++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("（未关闭 %d 条）", open))
+	limit := len(items)
+	if limit > abuseHistoryListLimit {
+		limit = abuseHistoryListLimit
+	}
+	for i := 0; i < limit; i++ {
+		item := items[i]
+		sb.WriteString(fmt.Sprintf("\n\n%d. %s（%s）%s", i+1, displayAbuseValue(item.Domain, "未识别域名"), displayAbuseValue(item.Source, "未知账号"), abuseReportOpenedAt(item).Local().Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("\n   %s；状态: %s；Cloudflare处理: %s", humanAbuseType(item.ReportType), humanAbuseStatus(item.Status), humanAbuseMitigation(item.Mitigation)))
+		notified := "未通知"
+		if !item.NotifiedAt.IsZero() {
+			notified = "已通知 " + item.NotifiedAt.Local().Format("01-02 15:04")
+		}
+		sb.WriteString(fmt.Sprintf("\n   %s；ID: %s", notified, firstNonEmpty(item.ID, item.Key)))
+	}
+	if len(items) > limit {
+		sb.WriteString(fmt.Sprintf("\n\n还有 %d 条未列出，可按账号或域名缩小范围。", len(items)-limit))
+	}
+	sb.WriteString("\n\n查看完整内容: /abuse show <ID>")
+	return sb.String()
+}
+
+// FormatAbuseReportDetail 渲染单条报告的完整内容：解读、状态历史、证据 URL 和 Cloudflare 原始字段。
+func FormatAbuseReportDetail(item AbuseReportCacheItem, now time.Time) string {
+	report := abuseCacheItemReport(item)
+	insight := explainAbuseReport(report)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【滥用报告】%s", displayAbuseValue(item.Domain, "未识别域名")))
+	sb.WriteString(fmt.Sprintf("\nID: %s", firstNonEmpty(item.ID, item.Key)))
+	sb.WriteString(fmt.Sprintf("\n账号: %s", displayAbuseValue(item.Source, "未知账号")))
+	sb.WriteString(fmt.Sprintf("\n类型: %s；风险: %s", insight.TypeLabel, insight.RiskLevel))
+	sb.WriteString(fmt.Sprintf("\n状态: %s；Cloudflare处理: %s", humanAbuseStatus(item.Status), humanAbuseMitigation(item.Mitigation)))
+	if !item.Date.IsZero() {
+		sb.WriteString(fmt.Sprintf("\n报告日期: %s", item.Date.Local().Format("2006-01-02 15:04:05")))
+	}
+	sb.WriteString(fmt.Sprintf("\n首次发现: %s；最后出现: %s", formatAbuseTime(item.FirstSeenAt), formatAbuseTime(item.LastSeenAt)))
+	if item.NotifiedAt.IsZero() {
+		sb.WriteString("\n通知: 未通知")
+	} else {
+		sb.WriteString(fmt.Sprintf("\n通知: %s", formatAbuseTime(item.NotifiedAt)))
+	}
+	if isAbuseReportClosed(item.Status) {
+		sb.WriteString(fmt.Sprintf("\n已关闭，从首次发现到关闭用时 %d 天", abuseReportOpenDays(item, item.ResolvedAt)))
+	} else {
+		sb.WriteString(fmt.Sprintf("\n已持续 %d 天", abuseReportOpenDays(item, now)))
+	}
+	if item.Reporter != "" {
+		sb.WriteString("\n举报方: " + item.Reporter)
+	}
+
+	sb.WriteString("\n\n白话说明: " + insight.PlainSummary)
+	sb.WriteString("\n可能原因: " + insight.PossibleCause)
+	sb.WriteString("\n建议处理: " + insight.Action)
+	if item.Title != "" {
+		sb.WriteString("\n\n标题: " + item.Title)
+	}
+	if item.Summary != "" {
+		sb.WriteString("\n摘要: " + item.Summary)
+	}
+	if len(item.URLs) > 0 {
+		sb.WriteString("\n\n证据 URL:")
+		for _, u := range item.URLs {
+			sb.WriteString("\n- " + u)
+		}
+	}
+	if len(item.History) > 0 {
+		sb.WriteString("\n\n状态历史:")
+		for _, event := range item.History {
+			sb.WriteString(fmt.Sprintf("\n- %s %s；%s", formatAbuseTime(event.At), humanAbuseStatus(event.Status), humanAbuseMitigation(event.Mitigation)))
+		}
+	}
+	if raw := abuseRawJSON(item.Raw); raw != "" {
+		sb.WriteString("\n\nCloudflare 原始字段:\n" + raw)
+	} else {
+		sb.WriteString("\n\n缓存中没有原始字段（该报告最近一次扫描早于原始字段缓存功能，重新扫描后可查看）。")
+	}
+	return sb.String()
+}
+
+func abuseCacheItemReport(item AbuseReportCacheItem) cfclient.AbuseReportInfo {
+	return cfclient.AbuseReportInfo{
+		ID:           item.ID,
+		AccountLabel: item.Source,
+		AccountID:    item.AccountID,
+		Domain:       item.Domain,
+		ReportType:   item.ReportType,
+		Status:       item.Status,
+		Date:         item.Date,
+		Mitigation:   item.Mitigation,
+		Title:        item.Title,
+		Summary:      item.Summary,
+		Reporter:     item.Reporter,
+		URLs:         item.URLs,
+		Raw:          item.Raw,
+	}
+}
+
+func formatAbuseTime(t time.Time) string {
+	if t.IsZero() {
+		return "未知"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
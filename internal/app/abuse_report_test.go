@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/reminder"
+	"DomainC/telegram"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+type fakeAbuseCF struct {
+	*fakeCF
+	reports []cfclient.AbuseReportInfo
+	records []cloudflare.DNSRecord
+}
+
+func (f *fakeAbuseCF) ListDNSRecords(ctx context.Context, account config.CF, domain string) ([]cloudflare.DNSRecord, error) {
+	return f.records, nil
+}
+
+type fakeAbuseAssets map[string]reminder.Record
+
+func (f fakeAbuseAssets) GetRecord(domain string) (reminder.Record, bool, error) {
+	rec, ok := f[domain]
+	return rec, ok, nil
+}
+
+func (f *fakeAbuseCF) ListAbuseReports(ctx context.Context, account config.CF, opts cfclient.AbuseReportListOptions) ([]cfclient.AbuseReportInfo, error) {
+	return f.reports, nil
+}
+
+func TestAbuseReportServiceTracksStatusChangesAndDigest(t *testing.T) {
+	sender := &fakeSender{}
+	cf := &fakeAbuseCF{fakeCF: &fakeCF{}}
+	cachePath := filepath.Join(t.TempDir(), "abuse.json")
+	service := &AbuseReportService{CFClient: cf, Accounts: []config.CF{{Label: "main"}}, Sender: sender, CacheFile: cachePath, DigestOpenDays: 3}
+
+	opened := time.Now().Add(-10 * 24 * time.Hour)
+	cf.reports = []cfclient.AbuseReportInfo{
+		{ID: "r1", AccountLabel: "main", Domain: "a.example", ReportType: "phishing", Status: "accepted", Date: opened},
+		{ID: "r2", AccountLabel: "main", Domain: "b.example", ReportType: "spam", Status: "accepted", Date: opened},
+	}
+	if err := service.RunDaily(context.Background()); err != nil {
+		t.Fatalf("first scan: %v", err)
+	}
+	sender.messages = nil
+
+	if err := service.RunDaily(context.Background()); err != nil {
+		t.Fatalf("unchanged scan: %v", err)
+	}
+	if len(sender.messages) != 0 {
+		t.Fatalf("unchanged reports must not be notified again: %v", sender.messages)
+	}
+
+	cf.reports[0].Mitigation = "active: misleading_interstitial"
+	cf.reports[1].Status = "closed"
+	if err := service.RunDaily(context.Background()); err != nil {
+		t.Fatalf("changed scan: %v", err)
+	}
+	if len(sender.messages) != 1 || !strings.Contains(sender.messages[0], "状态变化") ||
+		!strings.Contains(sender.messages[0], "a.example") || !strings.Contains(sender.messages[0], "已关闭/已解决") {
+		t.Fatalf("expected one follow-up notification, got %v", sender.messages)
+	}
+
+	cache, err := loadAbuseReportCache(cachePath)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	r1 := cache.Reports["main:r1"]
+	if len(r1.History) != 2 || r1.NotifiedMitigation != r1.Mitigation {
+		t.Fatalf("unexpected history for r1: %+v", r1)
+	}
+	if cache.Reports["main:r2"].ResolvedAt.IsZero() {
+		t.Fatalf("expected r2 to be marked resolved")
+	}
+
+	sender.messages = nil
+	if err := service.RunWeeklyDigest(context.Background()); err != nil {
+		t.Fatalf("digest: %v", err)
+	}
+	if len(sender.messages) != 1 || !strings.Contains(sender.messages[0], "a.example") || strings.Contains(sender.messages[0], "b.example") {
+		t.Fatalf("digest should list only the open report, got %v", sender.messages)
+	}
+}
+
+func TestBuildAbuseReportContextsCorrelatesAssetsDNSAndOperations(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.OperationLog.File = filepath.Join(t.TempDir(), "operation_log.json")
+	config.Set(cfg)
+	telegram.RecordOperation(telegram.OperationEntry{Operation: "setdns", Account: "main", Zone: "example.com", Operator: "@ops", Detail: "1 条记录"})
+	telegram.RecordOperation(telegram.OperationEntry{Operation: "cls", Account: "main", Zone: "other.com"})
+
+	proxied := true
+	cf := &fakeAbuseCF{fakeCF: &fakeCF{}, records: []cloudflare.DNSRecord{
+		{ID: "1", Type: "A", Name: "promo.example.com", Content: "203.0.113.9", Proxied: &proxied},
+		{ID: "2", Type: "A", Name: "example.com", Content: "198.51.100.1"},
+	}}
+	assets := fakeAbuseAssets{"example.com": {
+		Domain:    "example.com",
+		Registrar: "namecheap-main",
+		Accounts:  []reminder.AccountRecord{{Source: "main"}, {Source: "backup"}},
+	}}
+	service := &AbuseReportService{CFClient: cf, Accounts: []config.CF{{Label: "main"}}, Assets: assets}
+
+	report := cfclient.AbuseReportInfo{ID: "r1", AccountLabel: "main", Domain: "promo.example.com", URLs: []string{"https://promo.example.com/login", "https://unrelated.net/x"}}
+	rc := service.BuildAbuseReportContexts(context.Background(), []cfclient.AbuseReportInfo{report})[abuseReportKey(report)]
+
+	if !rc.InCache || rc.Zone != "example.com" || rc.Registrar != "namecheap-main" || strings.Join(rc.Accounts, ",") != "backup,main" {
+		t.Fatalf("unexpected asset correlation: %+v", rc)
+	}
+	if len(rc.Hostnames) != 1 || rc.Hostnames[0] != "promo.example.com" {
+		t.Fatalf("expected only in-zone hostnames, got %v", rc.Hostnames)
+	}
+	if targets := rc.DNSTargets["promo.example.com"]; len(targets) != 1 || targets[0] != "A 203.0.113.9（代理）" {
+		t.Fatalf("unexpected dns targets: %v", rc.DNSTargets)
+	}
+	if len(rc.Operations) != 1 || rc.Operations[0].Operation != "setdns" {
+		t.Fatalf("expected recent operation on the zone, got %+v", rc.Operations)
+	}
+
+	buttons := telegram.AbuseQuickActionButtons(1, "main", rc.Zone, abuseQuickActionHost(report, rc))
+	if len(buttons) != 3 || buttons[2].CallbackData != "abuse_repoint|main|promo.example.com" {
+		t.Fatalf("unexpected quick action buttons: %+v", buttons)
+	}
+}
+
+func TestAbuseReportOnDemandScanListAndShow(t *testing.T) {
+	sender := &fakeSender{}
+	cf := &fakeAbuseCF{fakeCF: &fakeCF{}}
+	cachePath := filepath.Join(t.TempDir(), "abuse.json")
+	accounts := []config.CF{{Label: "main"}, {Label: "backup"}}
+	service := &AbuseReportService{CFClient: cf, Accounts: accounts, Sender: sender, CacheFile: cachePath}
+
+	cf.reports = []cfclient.AbuseReportInfo{
+		{ID: "r1", AccountLabel: "main", Domain: "shop.example.com", ReportType: "phishing", Status: "accepted", Date: time.Now().Add(-2 * 24 * time.Hour),
+			Reporter: "abuse-desk", URLs: []string{"https://shop.example.com/login"}, Raw: map[string]any{"justification": "fake login page"}},
+		{ID: "r2", AccountLabel: "main", Domain: "old.net", ReportType: "spam", Status: "closed", Date: time.Now().Add(-60 * 24 * time.Hour)},
+	}
+	summary, err := service.ScanAbuseReports(context.Background(), accounts[:1])
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if !strings.Contains(summary, "新增报告: 2 条") {
+		t.Fatalf("unexpected scan summary: %s", summary)
+	}
+	cache, err := loadAbuseReportCache(cachePath)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	if !cache.LastScanAt.IsZero() {
+		t.Fatalf("partial scan must not update LastScanAt")
+	}
+
+	list, err := service.ListAbuseReportHistory("example.com", 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(list, "shop.example.com") || !strings.Contains(list, "已通知") || strings.Contains(list, "old.net") {
+		t.Fatalf("domain filter should include notified subdomain report only: %s", list)
+	}
+	list, _ = service.ListAbuseReportHistory("main", 90)
+	if !strings.Contains(list, "old.net") || !strings.Contains(list, "2 条") {
+		t.Fatalf("account filter with 90 days should include both reports: %s", list)
+	}
+
+	detail, err := service.ShowAbuseReport("r1")
+	if err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	for _, want := range []string{"abuse-desk", "https://shop.example.com/login", "fake login page", "状态历史"} {
+		if !strings.Contains(detail, want) {
+			t.Fatalf("detail missing %q: %s", want, detail)
+		}
+	}
+	if _, err := service.ShowAbuseReport("missing"); err == nil {
+		t.Fatalf("expected error for unknown report id")
+	}
+}
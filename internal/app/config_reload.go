@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+	"DomainC/registrarclient"
+	"DomainC/telegram"
+)
+
+// ErrConfigInvalid 表示新配置存在错误级问题，热加载被拒绝，继续使用旧配置。
+var ErrConfigInvalid = errors.New("配置校验未通过")
+
+// liveAccounts 让服务在热加载后改用新的账号列表；未调用 set 时沿用构造时的 Accounts 字段。
+type liveAccounts struct {
+	mu       sync.RWMutex
+	accounts []config.CF
+	set      bool
+}
+
+func (l *liveAccounts) get(fallback []config.CF) []config.CF {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.set {
+		return fallback
+	}
+	return l.accounts
+}
+
+func (l *liveAccounts) replace(accounts []config.CF) {
+	l.mu.Lock()
+	l.accounts = append([]config.CF(nil), accounts...)
+	l.set = true
+	l.mu.Unlock()
+}
+
+func (s *AbuseReportService) SetAccounts(accounts []config.CF)  { s.live.replace(accounts) }
+func (s *TrafficAlertService) SetAccounts(accounts []config.CF) { s.live.replace(accounts) }
+func (s *ZoneSnapshotService) SetAccounts(accounts []config.CF) { s.live.replace(accounts) }
+
+// AccountsSetter 由 telegram.CommandHandler、reminder.Runtime 和各定时服务实现。
+type AccountsSetter interface {
+	SetAccounts(accounts []config.CF)
+}
+
+// TokensSetter 由 api.Server 实现，热加载后替换 API token。
+type TokensSetter interface {
+	SetTokens(tokens []config.APIToken)
+}
+
+// ConfigReloader 重新读取配置文件，校验通过后替换当前配置并把新账号推送给各组件。
+// 监听地址、定时任务、leader 选举和 Telegram 发送目标在启动时确定，修改后仍需重启。
+type ConfigReloader struct {
+	Path      string
+	Sender    telegram.Sender
+	Targets   []AccountsSetter
+	Registrar *registrarclient.Manager
+	// APITokens 为空表示未启用 HTTP API。
+	APITokens TokensSetter
+	// OnAccountsChanged 在 Cloudflare 账号列表变化后异步调用，例如重新同步资产缓存。
+	OnAccountsChanged func(ctx context.Context)
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// Reload 返回校验发现的问题；存在错误级问题时返回 ErrConfigInvalid 且不做任何替换。
+func (r *ConfigReloader) Reload(ctx context.Context) ([]config.Problem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	parsed, err := config.Parse(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	problems := config.Validate(&parsed)
+	if config.HasErrors(problems) {
+		return problems, ErrConfigInvalid
+	}
+
+	accountsChanged := !reflect.DeepEqual(config.Cfg().CloudflareAccounts, parsed.CloudflareAccounts)
+	config.Set(parsed)
+	for _, target := range r.Targets {
+		if target != nil {
+			target.SetAccounts(parsed.CloudflareAccounts)
+		}
+	}
+	if r.Registrar != nil {
+		r.Registrar.SetRegistrars(parsed.Registrars)
+	}
+	if r.APITokens != nil {
+		r.APITokens.SetTokens(parsed.API.Tokens)
+	}
+	if accountsChanged && r.OnAccountsChanged != nil {
+		go r.OnAccountsChanged(ctx)
+	}
+	return problems, nil
+}
+
+// Run 在收到 hup 信号或（watch > 0 时）配置文件修改时间变化后重新加载，并把结果发到 Telegram。
+func (r *ConfigReloader) Run(ctx context.Context, hup <-chan os.Signal, watch time.Duration) {
+	r.modTime = r.fileModTime()
+	var tick <-chan time.Time
+	if watch > 0 {
+		ticker := time.NewTicker(watch)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("收到 SIGHUP，重新加载配置 %s", r.Path)
+		case <-tick:
+			mod := r.fileModTime()
+			if mod.IsZero() || mod.Equal(r.modTime) {
+				continue
+			}
+			log.Printf("检测到配置文件 %s 已修改，重新加载", r.Path)
+		}
+		r.modTime = r.fileModTime()
+		problems, err := r.Reload(ctx)
+		r.notify(ctx, problems, err)
+	}
+}
+
+func (r *ConfigReloader) fileModTime() time.Time {
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (r *ConfigReloader) notify(ctx context.Context, problems []config.Problem, err error) {
+	var msg string
+	if err != nil {
+		log.Printf("配置热加载失败: %v", err)
+		msg = fmt.Sprintf("❌ 配置热加载失败，继续使用旧配置: %v", err)
+		if len(problems) > 0 {
+			msg += "\n" + telegram.FormatConfigProblems(problems)
+		}
+	} else {
+		log.Printf("配置热加载完成: cloudflare=%d registrars=%d problems=%d", len(config.Cfg().CloudflareAccounts), len(config.Cfg().Registrars), len(problems))
+		labels := make([]string, 0, len(config.Cfg().CloudflareAccounts))
+		for _, acc := range config.Cfg().CloudflareAccounts {
+			labels = append(labels, acc.Label)
+		}
+		msg = fmt.Sprintf("♻️ 配置已重新加载\nCloudflare 账号: %s\n注册商 %d 个，AWS 目标 %d 个",
+			strings.Join(labels, ", "), len(config.Cfg().Registrars), len(config.Cfg().AWSTargets))
+		if len(problems) > 0 {
+			msg += "\n" + telegram.FormatConfigProblems(problems)
+		}
+	}
+	if r.Sender == nil {
+		return
+	}
+	if sendErr := r.Sender.Send(ctx, msg); sendErr != nil {
+		log.Printf("发送配置热加载通知失败: %v", sendErr)
+	}
+}
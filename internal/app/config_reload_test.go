@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"DomainC/config"
+)
+
+type fakeAccountsSetter struct {
+	accounts []config.CF
+}
+
+func (f *fakeAccountsSetter) SetAccounts(accounts []config.CF) { f.accounts = accounts }
+
+type fakeTokensSetter struct {
+	tokens []config.APIToken
+}
+
+func (f *fakeTokensSetter) SetTokens(tokens []config.APIToken) { f.tokens = tokens }
+
+const reloadTestConfig = `telegram:
+  botToken: "123456:abcdefghijklmnopqrstuvwxyz"
+  chatIDs: [1001]
+cloudflareAccounts:
+  - label: main
+    apiToken: secret-token-main
+  - label: SECOND
+    apiToken: secret-token-second
+api:
+  tokens:
+    - name: ci
+      token: ci-token
+      role: write
+`
+
+func writeReloadConfig(t *testing.T, path, secondLabel string) {
+	t.Helper()
+	content := strings.Replace(reloadTestConfig, "SECOND", secondLabel, 1)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestConfigReloaderSwapsAccountsAndRejectsInvalidConfig(t *testing.T) {
+	old := *config.Cfg()
+	t.Cleanup(func() { config.Set(old) })
+	config.Set(config.Config{CloudflareAccounts: []config.CF{{Label: "main", APIToken: "x"}}})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeReloadConfig(t, path, "second")
+
+	target := &fakeAccountsSetter{}
+	tokens := &fakeTokensSetter{}
+	changed := make(chan struct{}, 1)
+	reloader := &ConfigReloader{
+		Path:              path,
+		Targets:           []AccountsSetter{target},
+		APITokens:         tokens,
+		OnAccountsChanged: func(ctx context.Context) { changed <- struct{}{} },
+	}
+	if _, err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+	if len(target.accounts) != 2 || target.accounts[1].Label != "second" {
+		t.Fatalf("accounts not pushed to target: %+v", target.accounts)
+	}
+	if len(tokens.tokens) != 1 || tokens.tokens[0].Name != "ci" {
+		t.Fatalf("api tokens not pushed: %+v", tokens.tokens)
+	}
+	if len(config.Cfg().CloudflareAccounts) != 2 {
+		t.Fatalf("config.Cfg not replaced: %+v", config.Cfg().CloudflareAccounts)
+	}
+	<-changed
+
+	// 重复 label 是错误级问题，旧配置应保持不变。
+	writeReloadConfig(t, path, "main")
+	problems, err := reloader.Reload(context.Background())
+	if !errors.Is(err, ErrConfigInvalid) {
+		t.Fatalf("expected ErrConfigInvalid, got %v", err)
+	}
+	if config.Cfg().CloudflareAccounts[1].Label != "second" || target.accounts[1].Label != "second" {
+		t.Fatalf("invalid config should not be applied")
+	}
+	report := problemsText(problems)
+	if !strings.Contains(report, "重复") {
+		t.Fatalf("expected duplicate label problem, got %q", report)
+	}
+	if strings.Contains(report, "secret-token") {
+		t.Fatalf("problems must not contain secrets: %q", report)
+	}
+}
+
+func problemsText(problems []config.Problem) string {
+	lines := make([]string, 0, len(problems))
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -121,7 +121,7 @@ func (f *fakeCF) GetCustomList(ctx context.Context, account config.CF, listID st
 func (f *fakeCF) ListCustomListItems(ctx context.Context, account config.CF, listID string) ([]cloudflare.ListItem, error) {
 	return nil, nil
 }
-func (f *fakeCF) CreateCustomListItem(ctx context.Context, account config.CF, listID string, ip string, comment string) ([]cloudflare.ListItem, error) {
+func (f *fakeCF) CreateCustomListItem(ctx context.Context, account config.CF, listID string, item cloudflare.ListItemCreateRequest) ([]cloudflare.ListItem, error) {
 	return nil, nil
 }
 func (f *fakeCF) DeleteCustomListItem(ctx context.Context, account config.CF, listID string, itemID string) ([]cloudflare.ListItem, error) {
@@ -145,7 +145,7 @@ func TestNotifierSendsAlertsAndDeletes(t *testing.T) {
 	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc", Expiry: expiry, IsCF: true}}
 
 	cfg := config.CF{Label: "acc"}
-	config.Cfg.CloudflareAccounts = []config.CF{cfg}
+	config.Set(config.Config{CloudflareAccounts: []config.CF{cfg}})
 
 	if err := notifier.Notify(context.Background(), domains); err != nil {
 		t.Fatalf("notify returned error: %v", err)
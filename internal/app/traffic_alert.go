@@ -0,0 +1,457 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/telegram"
+)
+
+const (
+	trafficAlertSpike       = "spike"
+	trafficAlertDrop        = "drop"
+	trafficAlert5xx         = "5xx"
+	trafficAlertOriginError = "origin_error"
+)
+
+// TrafficStatsClient 是流量异常检测需要的 Cloudflare 能力。
+type TrafficStatsClient interface {
+	ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error)
+	FetchZoneTrafficStats(ctx context.Context, account config.CF, zoneIDs []string, since, until time.Time) (map[string]cfclient.ZoneTrafficStats, error)
+}
+
+// TrafficAlertThresholds 控制何时认为 Zone 流量异常。
+type TrafficAlertThresholds struct {
+	SpikeRatio         float64
+	DropRatio          float64
+	Max5xxRate         float64
+	MaxOriginErrorRate float64
+	MinRequests        int
+	MinSamples         int
+	MaxSamples         int
+	Cooldown           time.Duration
+}
+
+// TrafficAlertService 定时拉取各 Zone 请求量/状态码分布，与磁盘上的滚动基线比较后发送告警。
+type TrafficAlertService struct {
+	CFClient     TrafficStatsClient
+	Accounts     []config.CF
+	Sender       telegram.Sender
+	BaselineFile string
+	Window       time.Duration
+	Thresholds   TrafficAlertThresholds
+	live         liveAccounts
+}
+
+type TrafficBaseline struct {
+	Version int                            `json:"version"`
+	Zones   map[string]TrafficZoneBaseline `json:"zones"`
+}
+
+type TrafficZoneBaseline struct {
+	AccountLabel string               `json:"account_label"`
+	ZoneName     string               `json:"zone_name"`
+	Samples      []TrafficSample      `json:"samples"`
+	LastAlerts   map[string]time.Time `json:"last_alerts,omitempty"`
+}
+
+type TrafficSample struct {
+	At              time.Time `json:"at"`
+	Requests        int       `json:"requests"`
+	Rate5xx         float64   `json:"rate_5xx"`
+	OriginErrorRate float64   `json:"origin_error_rate"`
+}
+
+// TrafficAnomaly 是一条待告警的异常。
+type TrafficAnomaly struct {
+	AccountLabel     string
+	ZoneName         string
+	Kind             string
+	Stats            cfclient.ZoneTrafficStats
+	BaselineRequests float64
+	Samples          int
+}
+
+func (s *TrafficAlertService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("[traffic_alert] run_failed err=%v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 拉取一次数据、更新基线，并对超出阈值且不在冷却期内的 Zone 发送告警。
+func (s *TrafficAlertService) RunOnce(ctx context.Context, now time.Time) error {
+	if s == nil || s.CFClient == nil || s.Sender == nil {
+		return ErrMissingDependencies
+	}
+	baseline, err := loadTrafficBaseline(s.baselinePath())
+	if err != nil {
+		return err
+	}
+	thresholds := s.thresholds()
+	until := now.UTC().Truncate(time.Minute)
+	since := until.Add(-s.window())
+
+	var anomalies []TrafficAnomaly
+	var scanErrors []abuseScanError
+	for _, acc := range s.live.get(s.Accounts) {
+		zones, err := s.CFClient.ListZones(ctx, acc)
+		if err != nil {
+			scanErrors = append(scanErrors, abuseScanError{Source: acc.Label, Err: err})
+			continue
+		}
+		names := make(map[string]string, len(zones))
+		zoneIDs := make([]string, 0, len(zones))
+		for _, zone := range zones {
+			if strings.TrimSpace(zone.ID) == "" {
+				continue
+			}
+			names[zone.ID] = zone.Name
+			zoneIDs = append(zoneIDs, zone.ID)
+		}
+		stats, err := s.CFClient.FetchZoneTrafficStats(ctx, acc, zoneIDs, since, until)
+		if err != nil {
+			scanErrors = append(scanErrors, abuseScanError{Source: acc.Label, Err: err})
+			continue
+		}
+		for _, zoneID := range zoneIDs {
+			current := stats[zoneID]
+			current.ZoneID = zoneID
+			zb := baseline.Zones[zoneID]
+			zb.AccountLabel = acc.Label
+			zb.ZoneName = names[zoneID]
+			for _, kind := range DetectTrafficAnomalies(zb.Samples, current, thresholds) {
+				if last, ok := zb.LastAlerts[kind]; ok && now.Sub(last) < thresholds.Cooldown {
+					continue
+				}
+				if zb.LastAlerts == nil {
+					zb.LastAlerts = map[string]time.Time{}
+				}
+				zb.LastAlerts[kind] = now
+				anomalies = append(anomalies, TrafficAnomaly{
+					AccountLabel:     acc.Label,
+					ZoneName:         names[zoneID],
+					Kind:             kind,
+					Stats:            current,
+					BaselineRequests: medianTrafficRequests(zb.Samples),
+					Samples:          len(zb.Samples),
+				})
+			}
+			zb.Samples = append(zb.Samples, TrafficSample{
+				At:              until,
+				Requests:        current.Requests,
+				Rate5xx:         current.Rate5xx(),
+				OriginErrorRate: current.OriginErrorRate(),
+			})
+			if len(zb.Samples) > thresholds.MaxSamples {
+				zb.Samples = zb.Samples[len(zb.Samples)-thresholds.MaxSamples:]
+			}
+			baseline.Zones[zoneID] = zb
+		}
+	}
+	for zoneID, zb := range baseline.Zones {
+		if len(zb.Samples) == 0 || now.Sub(zb.Samples[len(zb.Samples)-1].At) > 7*24*time.Hour {
+			delete(baseline.Zones, zoneID)
+		}
+	}
+	for _, item := range scanErrors {
+		log.Printf("[traffic_alert] scan_account_failed source=%s err=%v", item.Source, item.Err)
+	}
+	if err := saveTrafficBaseline(s.baselinePath(), baseline); err != nil {
+		return err
+	}
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	sortTrafficAnomalies(anomalies)
+	if err := s.Sender.Send(ctx, FormatTrafficAlertMessage(anomalies, s.window(), now)); err != nil {
+		return err
+	}
+	reportPath, cleanup, err := BuildTrafficAlertHTML(anomalies, scanErrors, s.window(), thresholds, now)
+	if err != nil {
+		log.Printf("[traffic_alert] build_html_failed err=%v", err)
+		return nil
+	}
+	defer cleanup()
+	caption := fmt.Sprintf("%s: 流量异常 %d 条，详情见 HTML 报告", now.Format("2006-01-02 15:04"), len(anomalies))
+	return s.Sender.SendDocumentPath(ctx, reportPath, caption)
+}
+
+// DetectTrafficAnomalies 用历史样本的中位数作为请求量基线；样本不足时只检查错误率。
+func DetectTrafficAnomalies(samples []TrafficSample, current cfclient.ZoneTrafficStats, t TrafficAlertThresholds) []string {
+	var kinds []string
+	if len(samples) >= t.MinSamples {
+		base := medianTrafficRequests(samples)
+		if current.Requests >= t.MinRequests && base > 0 && float64(current.Requests) >= base*t.SpikeRatio {
+			kinds = append(kinds, trafficAlertSpike)
+		}
+		if base >= float64(t.MinRequests) && float64(current.Requests) <= base*t.DropRatio {
+			kinds = append(kinds, trafficAlertDrop)
+		}
+	}
+	if current.Requests >= t.MinRequests && current.Rate5xx() >= t.Max5xxRate {
+		kinds = append(kinds, trafficAlert5xx)
+	}
+	if current.OriginRequests >= t.MinRequests && current.OriginErrorRate() >= t.MaxOriginErrorRate {
+		kinds = append(kinds, trafficAlertOriginError)
+	}
+	return kinds
+}
+
+func medianTrafficRequests(samples []TrafficSample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]int, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, sample.Requests)
+	}
+	sort.Ints(values)
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return float64(values[mid])
+	}
+	return float64(values[mid-1]+values[mid]) / 2
+}
+
+func humanTrafficAnomaly(kind string) string {
+	switch kind {
+	case trafficAlertSpike:
+		return "请求量暴涨（可能被攻击/刷量）"
+	case trafficAlertDrop:
+		return "请求量骤降（可能源站或解析故障）"
+	case trafficAlert5xx:
+		return "边缘 5xx 比例过高"
+	case trafficAlertOriginError:
+		return "源站错误率过高"
+	}
+	return kind
+}
+
+func sortTrafficAnomalies(items []TrafficAnomaly) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].AccountLabel != items[j].AccountLabel {
+			return items[i].AccountLabel < items[j].AccountLabel
+		}
+		if items[i].ZoneName != items[j].ZoneName {
+			return items[i].ZoneName < items[j].ZoneName
+		}
+		return items[i].Kind < items[j].Kind
+	})
+}
+
+// FormatTrafficAlertMessage 生成纯文本告警摘要，详情放在 HTML 附件中。
+func FormatTrafficAlertMessage(anomalies []TrafficAnomaly, window time.Duration, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【Cloudflare 流量异常】\n时间: %s\n统计窗口: 最近 %s\n异常: %d 条\n", now.Format("2006-01-02 15:04"), window, len(anomalies)))
+	limit := len(anomalies)
+	if limit > 20 {
+		limit = 20
+	}
+	for i, item := range anomalies[:limit] {
+		sb.WriteString(fmt.Sprintf("\n%d. %s（%s）\n   %s\n   %s\n", i+1, item.ZoneName, item.AccountLabel, humanTrafficAnomaly(item.Kind), trafficAnomalyDetail(item)))
+	}
+	if len(anomalies) > limit {
+		sb.WriteString(fmt.Sprintf("\n... 另有 %d 条，见 HTML 报告", len(anomalies)-limit))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func trafficAnomalyDetail(item TrafficAnomaly) string {
+	switch item.Kind {
+	case trafficAlertSpike, trafficAlertDrop:
+		return fmt.Sprintf("请求数 %d，基线 %.0f（%d 个样本）", item.Stats.Requests, item.BaselineRequests, item.Samples)
+	case trafficAlert5xx:
+		return fmt.Sprintf("5xx %d / %d（%.1f%%）", item.Stats.Status5xx, item.Stats.Requests, item.Stats.Rate5xx()*100)
+	case trafficAlertOriginError:
+		return fmt.Sprintf("源站 5xx %d / 回源 %d（%.1f%%）", item.Stats.OriginErrors, item.Stats.OriginRequests, item.Stats.OriginErrorRate()*100)
+	}
+	return ""
+}
+
+func BuildTrafficAlertHTML(anomalies []TrafficAnomaly, scanErrors []abuseScanError, window time.Duration, t TrafficAlertThresholds, now time.Time) (string, func(), error) {
+	file, err := os.CreateTemp("", fmt.Sprintf("cf_traffic_alert_%s_*.html", now.Format("20060102_150405")))
+	if err != nil {
+		return "", func() {}, err
+	}
+	path := file.Name()
+	cleanup := func() { _ = os.Remove(path) }
+
+	var sb strings.Builder
+	sb.WriteString("<!doctype html><html lang=\"zh-CN\"><head><meta charset=\"utf-8\">")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">")
+	sb.WriteString("<title>Cloudflare 流量异常</title>")
+	sb.WriteString(`<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","Microsoft YaHei",Arial,sans-serif;margin:0;background:#f5f7fb;color:#182033;line-height:1.55}.wrap{max-width:1280px;margin:0 auto;padding:24px}.header{background:#111827;color:white;border-radius:18px;padding:22px 26px;margin-bottom:18px}.header h1{margin:0 0 8px;font-size:26px}.header p{margin:4px 0;color:#d1d5db}.cards{display:grid;grid-template-columns:repeat(auto-fit,minmax(180px,1fr));gap:12px;margin:18px 0}.card{background:white;border-radius:14px;padding:16px;border:1px solid #e5e7eb}.card .num{font-size:26px;font-weight:800;margin-top:4px}.card .label{color:#64748b}.section{background:white;border:1px solid #e5e7eb;border-radius:16px;padding:18px;margin:16px 0}.section h2{font-size:20px;margin:0 0 12px}.table-wrap{overflow:auto;border:1px solid #e5e7eb;border-radius:14px}table{width:100%;border-collapse:collapse;background:white}th,td{border-bottom:1px solid #e5e7eb;padding:10px 12px;text-align:left;vertical-align:top}th{background:#f8fafc;font-weight:700;white-space:nowrap}.list{margin:0;padding-left:18px}.muted{color:#64748b}
+</style></head><body><div class="wrap">`)
+	sb.WriteString("<div class=\"header\"><h1>Cloudflare 流量异常</h1>")
+	sb.WriteString(fmt.Sprintf("<p>生成时间：%s；统计窗口：最近 %s</p>", escapeHTML(now.Format("2006-01-02 15:04:05")), escapeHTML(window.String())))
+	sb.WriteString(fmt.Sprintf("<p>阈值：暴涨 ≥ 基线 × %.1f，骤降 ≤ 基线 × %.2f，5xx ≥ %.1f%%，源站错误 ≥ %.1f%%，最少请求 %d</p></div>",
+		t.SpikeRatio, t.DropRatio, t.Max5xxRate*100, t.MaxOriginErrorRate*100, t.MinRequests))
+
+	kindCounts := map[string]int{}
+	for _, item := range anomalies {
+		kindCounts[humanTrafficAnomaly(item.Kind)]++
+	}
+	sb.WriteString("<div class=\"cards\">")
+	sb.WriteString(metricCard("异常条数", fmt.Sprintf("%d", len(anomalies))))
+	for _, key := range sortedStringKeys(kindCounts) {
+		sb.WriteString(metricCard(key, fmt.Sprintf("%d", kindCounts[key])))
+	}
+	sb.WriteString("</div>")
+
+	sb.WriteString("<div class=\"section\"><h2>异常明细</h2><div class=\"table-wrap\"><table><thead><tr>")
+	for _, h := range []string{"账号", "域名", "异常", "说明", "请求数", "基线", "2xx", "3xx", "4xx", "5xx", "回源", "源站 5xx"} {
+		sb.WriteString("<th>" + escapeHTML(h) + "</th>")
+	}
+	sb.WriteString("</tr></thead><tbody>")
+	for _, item := range anomalies {
+		st := item.Stats
+		sb.WriteString("<tr>")
+		for _, cell := range []string{
+			item.AccountLabel,
+			item.ZoneName,
+			humanTrafficAnomaly(item.Kind),
+			trafficAnomalyDetail(item),
+			fmt.Sprintf("%d", st.Requests),
+			fmt.Sprintf("%.0f", item.BaselineRequests),
+			fmt.Sprintf("%d", st.Status2xx),
+			fmt.Sprintf("%d", st.Status3xx),
+			fmt.Sprintf("%d", st.Status4xx),
+			fmt.Sprintf("%d (%.1f%%)", st.Status5xx, st.Rate5xx()*100),
+			fmt.Sprintf("%d", st.OriginRequests),
+			fmt.Sprintf("%d (%.1f%%)", st.OriginErrors, st.OriginErrorRate()*100),
+		} {
+			sb.WriteString("<td>" + escapeHTML(cell) + "</td>")
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</tbody></table></div></div>")
+	if len(scanErrors) > 0 {
+		sb.WriteString("<div class=\"section\"><h2>查询失败账号</h2><ul class=\"list\">")
+		for _, item := range scanErrors {
+			sb.WriteString(fmt.Sprintf("<li>%s：%s</li>", escapeHTML(item.Source), escapeHTML(compactAbuseText(item.Err.Error(), 220))))
+		}
+		sb.WriteString("</ul></div>")
+	}
+	sb.WriteString("<p class=\"muted\">基线为最近样本请求数的中位数；同一 Zone 同类异常在冷却期内不会重复告警。</p></div></body></html>")
+
+	if _, err := file.WriteString(sb.String()); err != nil {
+		file.Close()
+		cleanup()
+		return "", func() {}, err
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return path, cleanup, nil
+}
+
+func (s *TrafficAlertService) baselinePath() string {
+	if strings.TrimSpace(s.BaselineFile) != "" {
+		return strings.TrimSpace(s.BaselineFile)
+	}
+	return "traffic_baseline.json"
+}
+
+func (s *TrafficAlertService) window() time.Duration {
+	if s.Window <= 0 {
+		return time.Hour
+	}
+	return s.Window
+}
+
+func (s *TrafficAlertService) thresholds() TrafficAlertThresholds {
+	t := s.Thresholds
+	if t.SpikeRatio <= 1 {
+		t.SpikeRatio = 3
+	}
+	if t.DropRatio <= 0 || t.DropRatio >= 1 {
+		t.DropRatio = 0.3
+	}
+	if t.Max5xxRate <= 0 {
+		t.Max5xxRate = 0.05
+	}
+	if t.MaxOriginErrorRate <= 0 {
+		t.MaxOriginErrorRate = 0.1
+	}
+	if t.MinRequests <= 0 {
+		t.MinRequests = 1000
+	}
+	if t.MinSamples <= 0 {
+		t.MinSamples = 4
+	}
+	if t.MaxSamples < t.MinSamples {
+		t.MaxSamples = 96
+	}
+	if t.Cooldown <= 0 {
+		t.Cooldown = time.Hour
+	}
+	return t
+}
+
+func loadTrafficBaseline(path string) (TrafficBaseline, error) {
+	baseline := TrafficBaseline{Version: 1, Zones: map[string]TrafficZoneBaseline{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return baseline, nil
+		}
+		return baseline, fmt.Errorf("读取流量基线失败: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return baseline, nil
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("解析流量基线失败: %w", err)
+	}
+	if baseline.Zones == nil {
+		baseline.Zones = map[string]TrafficZoneBaseline{}
+	}
+	return baseline, nil
+}
+
+func saveTrafficBaseline(path string, baseline TrafficBaseline) error {
+	dir := filepath.Dir(path)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建流量基线目录失败: %w", err)
+		}
+	}
+	baseline.Version = 1
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化流量基线失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入流量基线失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("替换流量基线失败: %w", err)
+	}
+	return nil
+}
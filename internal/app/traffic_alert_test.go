@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeTrafficClient struct {
+	requests int
+	status5x int
+}
+
+func (f *fakeTrafficClient) ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error) {
+	return []cfclient.ZoneDetail{{ID: "zone1", Name: "example.com"}}, nil
+}
+
+func (f *fakeTrafficClient) FetchZoneTrafficStats(ctx context.Context, account config.CF, zoneIDs []string, since, until time.Time) (map[string]cfclient.ZoneTrafficStats, error) {
+	return map[string]cfclient.ZoneTrafficStats{
+		"zone1": {ZoneID: "zone1", Requests: f.requests, Status2xx: f.requests - f.status5x, Status5xx: f.status5x},
+	}, nil
+}
+
+func TestTrafficAlertServiceAlertsOnSpikeAfterBaselineAndRespectsCooldown(t *testing.T) {
+	client := &fakeTrafficClient{requests: 2000}
+	sender := &fakeSender{}
+	svc := &TrafficAlertService{
+		CFClient:     client,
+		Accounts:     []config.CF{{Label: "main"}},
+		Sender:       sender,
+		BaselineFile: filepath.Join(t.TempDir(), "baseline.json"),
+		Thresholds:   TrafficAlertThresholds{MinSamples: 3},
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := svc.RunOnce(context.Background(), now); err != nil {
+			t.Fatalf("RunOnce returned error: %v", err)
+		}
+		now = now.Add(15 * time.Minute)
+	}
+	if len(sender.messages) != 0 {
+		t.Fatalf("expected no alert while building baseline, got %v", sender.messages)
+	}
+
+	client.requests = 9000
+	if err := svc.RunOnce(context.Background(), now); err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+	if len(sender.messages) != 2 || !strings.Contains(sender.messages[0], "请求量暴涨") || !strings.Contains(sender.messages[0], "基线 2000") {
+		t.Fatalf("unexpected alert messages: %v", sender.messages)
+	}
+	if !strings.HasPrefix(sender.messages[1], "DOC:") {
+		t.Fatalf("expected HTML attachment, got %q", sender.messages[1])
+	}
+
+	now = now.Add(15 * time.Minute)
+	if err := svc.RunOnce(context.Background(), now); err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+	if len(sender.messages) != 2 {
+		t.Fatalf("expected cooldown to suppress repeat alert, got %v", sender.messages)
+	}
+}
+
+func TestDetectTrafficAnomaliesChecksErrorRatesWithoutBaseline(t *testing.T) {
+	thresholds := (&TrafficAlertService{}).thresholds()
+	kinds := DetectTrafficAnomalies(nil, cfclient.ZoneTrafficStats{Requests: 5000, Status5xx: 600, OriginRequests: 3000, OriginErrors: 50}, thresholds)
+	if len(kinds) != 1 || kinds[0] != trafficAlert5xx {
+		t.Fatalf("unexpected anomalies: %v", kinds)
+	}
+}
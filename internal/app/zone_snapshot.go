@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/telegram"
+)
+
+// ZoneSnapshotClient 是定时快照需要的 Cloudflare 能力。
+type ZoneSnapshotClient interface {
+	ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error)
+	SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (cfclient.ZoneSnapshot, error)
+}
+
+// ZoneSnapshotService 每天把所有账号下每个 Zone 的 DNS、规则集和设置写入本地快照目录。
+type ZoneSnapshotService struct {
+	CFClient ZoneSnapshotClient
+	Accounts []config.CF
+	Sender   telegram.Sender
+	Store    *cfclient.ZoneSnapshotStore
+	Delay    time.Duration
+	live     liveAccounts
+}
+
+// ZoneSnapshotSummary 汇总一次定时快照的结果。
+type ZoneSnapshotSummary struct {
+	Zones  int
+	Saved  int
+	Errors []abuseScanError
+}
+
+// RunDaily 执行一次快照；有失败时通过 Sender 推送汇总。
+func (s *ZoneSnapshotService) RunDaily(ctx context.Context) error {
+	if s == nil || s.CFClient == nil || s.Store == nil {
+		return ErrMissingDependencies
+	}
+	summary := s.RunOnce(ctx)
+	log.Printf("[zone_snapshot] done zones=%d saved=%d errors=%d", summary.Zones, summary.Saved, len(summary.Errors))
+	if len(summary.Errors) == 0 || s.Sender == nil {
+		return nil
+	}
+	return s.Sender.Send(ctx, FormatZoneSnapshotSummary(summary))
+}
+
+// RunOnce 遍历账号和 Zone 逐个快照，单个 Zone 失败不影响其他 Zone。
+func (s *ZoneSnapshotService) RunOnce(ctx context.Context) ZoneSnapshotSummary {
+	var summary ZoneSnapshotSummary
+	for _, acc := range s.live.get(s.Accounts) {
+		zones, err := s.CFClient.ListZones(ctx, acc)
+		if err != nil {
+			summary.Errors = append(summary.Errors, abuseScanError{Source: acc.Label, Err: err})
+			continue
+		}
+		for _, zone := range zones {
+			if strings.TrimSpace(zone.ID) == "" || strings.TrimSpace(zone.Name) == "" {
+				continue
+			}
+			if ctx.Err() != nil {
+				summary.Errors = append(summary.Errors, abuseScanError{Source: acc.Label, Err: ctx.Err()})
+				return summary
+			}
+			summary.Zones++
+			snap, err := s.CFClient.SnapshotZone(ctx, acc, zone.ID, zone.Name)
+			if err == nil {
+				snap.Reason = "scheduled"
+				_, err = s.Store.Save(snap)
+			}
+			if err != nil {
+				summary.Errors = append(summary.Errors, abuseScanError{Source: acc.Label + "/" + zone.Name, Err: err})
+			} else {
+				summary.Saved++
+			}
+			if s.Delay > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(s.Delay):
+				}
+			}
+		}
+	}
+	return summary
+}
+
+func FormatZoneSnapshotSummary(summary ZoneSnapshotSummary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【Zone 定时快照】Zone: %d，成功: %d，失败: %d\n", summary.Zones, summary.Saved, len(summary.Errors)))
+	for i, item := range summary.Errors {
+		if i >= 20 {
+			sb.WriteString(fmt.Sprintf("... 省略 %d 条\n", len(summary.Errors)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %v\n", item.Source, item.Err))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeSnapshotClient struct{}
+
+func (f *fakeSnapshotClient) ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error) {
+	return []cfclient.ZoneDetail{{ID: "zone1", Name: "good.com"}, {ID: "zone2", Name: "bad.com"}}, nil
+}
+
+func (f *fakeSnapshotClient) SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (cfclient.ZoneSnapshot, error) {
+	if zoneID == "zone2" {
+		return cfclient.ZoneSnapshot{}, errors.New("rulesets forbidden")
+	}
+	return cfclient.ZoneSnapshot{Domain: domain, ZoneID: zoneID, AccountLabel: account.Label, TakenAt: time.Now().UTC()}, nil
+}
+
+func TestZoneSnapshotServiceSavesZonesAndReportsFailures(t *testing.T) {
+	sender := &fakeSender{}
+	store := cfclient.NewZoneSnapshotStore(t.TempDir(), 24*time.Hour, 5)
+	svc := &ZoneSnapshotService{
+		CFClient: &fakeSnapshotClient{},
+		Accounts: []config.CF{{Label: "main"}},
+		Sender:   sender,
+		Store:    store,
+	}
+	if err := svc.RunDaily(context.Background()); err != nil {
+		t.Fatalf("RunDaily returned error: %v", err)
+	}
+
+	items, err := store.List("good.com")
+	if err != nil || len(items) != 1 || items[0].Reason != "scheduled" || items[0].AccountLabel != "main" {
+		t.Fatalf("unexpected stored snapshots: %+v err=%v", items, err)
+	}
+	if len(sender.messages) != 1 || !strings.Contains(sender.messages[0], "成功: 1，失败: 1") || !strings.Contains(sender.messages[0], "main/bad.com") {
+		t.Fatalf("unexpected summary: %v", sender.messages)
+	}
+}
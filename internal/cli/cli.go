@@ -0,0 +1,613 @@
+// Package cli 实现无需 Telegram 的单次运行子命令，便于在 cron 或脚本中调用。
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/internal/app"
+	"DomainC/registrarclient"
+	"DomainC/reminder"
+	"DomainC/telegram"
+)
+
+// Env 是子命令运行所需的依赖；Run 使用真实实现，测试可替换。
+type Env struct {
+	Stdin    io.Reader
+	Stdout   io.Writer
+	Stderr   io.Writer
+	CFClient cfclient.Client
+}
+
+const usage = `用法: global-cf-auto [-config config.yaml] <命令> [参数]
+
+命令:
+  sync                同步 Cloudflare 域名到资产缓存 [-refresh] [-json]
+  report              生成到期提醒日报 [-out DIR] [-telegram] [-json]
+  abuse-scan          扫描 Cloudflare 滥用报告 [-out DIR] [-telegram] [-json]
+  dns export          导出 DNS 为 CSV [-account 选择器] [-o FILE]
+  zone provision      创建并初始化 Zone -domain DOMAIN [-account 选择器] [-block CN,RU] [-speed] [-rum] [-sync-registrar] [-json]
+  cache inspect       查看资产缓存 [-domain DOMAIN] [-json]
+  secret keygen       生成解密 enc: 配置值的密钥文件 [-key FILE]
+  secret encrypt      加密密钥值，输出 enc: 字符串 [-key FILE] [VALUE]（省略 VALUE 时从标准输入读取）
+
+不带命令时启动 Telegram 机器人和定时任务。`
+
+// Run 解析全局参数并执行子命令，返回进程退出码。
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("global-cf-auto", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	configPath := global.String("config", "config.yaml", "配置文件路径")
+	global.Usage = func() { fmt.Fprintln(stderr, usage) }
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	rest := global.Args()
+	if len(rest) == 0 || rest[0] == "help" || rest[0] == "-h" {
+		fmt.Fprintln(stdout, usage)
+		return 0
+	}
+	stdout, stderr = config.RedactWriter(stdout), config.RedactWriter(stderr)
+	env := &Env{Stdin: os.Stdin, Stdout: stdout, Stderr: stderr}
+	// secret 子命令用于准备配置，不需要先加载配置。
+	if rest[0] != "secret" {
+		if err := config.Load(*configPath); err != nil {
+			fmt.Fprintf(stderr, "加载配置失败: %v\n", err)
+			return 1
+		}
+		env.CFClient = cfclient.NewClient()
+	}
+	if err := env.Dispatch(ctx, rest); err != nil {
+		fmt.Fprintf(stderr, "错误: %v\n", err)
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// Dispatch 执行 args 指定的子命令（不含全局参数）。
+func (e *Env) Dispatch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError{usage}
+	}
+	cmd, rest := args[0], args[1:]
+	switch cmd {
+	case "sync":
+		return e.runSync(ctx, rest)
+	case "report":
+		return e.runReport(ctx, rest)
+	case "abuse-scan":
+		return e.runAbuseScan(ctx, rest)
+	case "dns":
+		if len(rest) == 0 || rest[0] != "export" {
+			return usageError{"用法: dns export [-account all|label|group:名称|tag:名称] [-o FILE]"}
+		}
+		return e.runDNSExport(ctx, rest[1:])
+	case "zone":
+		if len(rest) == 0 || rest[0] != "provision" {
+			return usageError{"用法: zone provision -domain DOMAIN [...]"}
+		}
+		return e.runZoneProvision(ctx, rest[1:])
+	case "cache":
+		if len(rest) == 0 || rest[0] != "inspect" {
+			return usageError{"用法: cache inspect [-domain DOMAIN] [-json]"}
+		}
+		return e.runCacheInspect(rest[1:])
+	case "secret":
+		if len(rest) == 0 {
+			return usageError{"用法: secret keygen|encrypt [-key FILE] [VALUE]"}
+		}
+		switch rest[0] {
+		case "keygen":
+			return e.runSecretKeygen(rest[1:])
+		case "encrypt":
+			return e.runSecretEncrypt(rest[1:])
+		}
+		return usageError{"用法: secret keygen|encrypt [-key FILE] [VALUE]"}
+	}
+	return usageError{fmt.Sprintf("未知命令 %q\n\n%s", cmd, usage)}
+}
+
+func (e *Env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.Stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Sprintf("多余的参数: %s", strings.Join(fs.Args(), " "))}
+	}
+	return nil
+}
+
+func (e *Env) print(asJSON bool, value any, text string) error {
+	if asJSON {
+		enc := json.NewEncoder(e.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+	_, err := fmt.Fprintln(e.Stdout, strings.TrimRight(text, "\n"))
+	return err
+}
+
+func assetStore() *reminder.Store {
+	cachePath := config.Cfg().AssetCacheFile
+	if cachePath == "" {
+		cachePath = reminder.DefaultCachePath
+	}
+	return reminder.NewFileStore(cachePath)
+}
+
+func (e *Env) newRuntime() *reminder.Runtime {
+	return reminder.NewRuntime(reminder.RuntimeOptions{
+		Store:        assetStore(),
+		CFClient:     e.CFClient,
+		Accounts:     config.Cfg().CloudflareAccounts,
+		Registrar:    registrarclient.NewManager(nil, config.Cfg().Registrars),
+		Whois:        app.DefaultWhoisClient{},
+		RefreshDelay: 2 * time.Second,
+		QueryTimeout: 15 * time.Second,
+		TLS:          10 * time.Second,
+	})
+}
+
+// sender 返回输出目标：-telegram 时使用机器人，否则把消息收集到 stdout。
+func (e *Env) sender(useTelegram bool, outDir string) (telegram.Sender, *captureSender, error) {
+	if useTelegram {
+		bot, err := telegram.NewMultiBotSender(config.Cfg().Telegram.BotToken, config.TelegramChatIDs(), 2, time.Second, 10*time.Second)
+		if err != nil {
+			return nil, nil, fmt.Errorf("初始化 Telegram 失败: %w", err)
+		}
+		return bot, nil, nil
+	}
+	capture := &captureSender{outDir: outDir}
+	return capture, capture, nil
+}
+
+func (e *Env) runSync(ctx context.Context, args []string) error {
+	fs := e.flagSet("sync")
+	asJSON := fs.Bool("json", false, "输出 JSON")
+	refresh := fs.Bool("refresh", false, "同步后立即补全到期信息（较慢）")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	rt := e.newRuntime()
+	summary := rt.SyncCloudflareDomainsOnce(ctx)
+	if *refresh {
+		if err := rt.RefreshCandidates(ctx, config.EffectiveAlertDays(), time.Now()); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("补全到期信息失败: %v", err))
+		}
+	}
+	text := fmt.Sprintf("资产缓存同步完成: 账号 %d/%d，域名 %d，新增 %d，更新 %d，标记未知 %d，待补全 %d",
+		summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.Updated, summary.MarkedUnknown, summary.QueuedRefresh)
+	for _, errText := range summary.Errors {
+		text += "\n- " + errText
+	}
+	if err := e.print(*asJSON, summary, text); err != nil {
+		return err
+	}
+	if len(summary.Errors) > 0 {
+		return fmt.Errorf("同步存在 %d 个错误", len(summary.Errors))
+	}
+	return nil
+}
+
+func (e *Env) runReport(ctx context.Context, args []string) error {
+	fs := e.flagSet("report")
+	asJSON := fs.Bool("json", false, "输出 JSON")
+	outDir := fs.String("out", "", "保存报告附件的目录")
+	useTelegram := fs.Bool("telegram", false, "发送到 Telegram 并标记已提醒（与定时任务相同）")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	rt := e.newRuntime()
+	alertDays := config.EffectiveAlertDays()
+	if *useTelegram {
+		sender, _, err := e.sender(true, "")
+		if err != nil {
+			return err
+		}
+		service := &app.AssetReminderService{Runtime: rt, Sender: sender, AlertDays: alertDays}
+		if err := service.RunDaily(ctx); err != nil {
+			return err
+		}
+		return e.print(*asJSON, map[string]any{"sent": true}, "日报已发送到 Telegram。")
+	}
+
+	// 只预览，不调用 MarkAlertsSent，避免影响机器人的日报。
+	now := time.Now()
+	alerts, err := rt.DueAlerts(alertDays, now)
+	if err != nil {
+		return err
+	}
+	summary, err := app.BuildAssetSummary(rt.Store())
+	if err != nil {
+		return err
+	}
+	msg := app.FormatAssetDailyMessageWithSummary(alerts, summary, alertDays, now)
+	capture := &captureSender{outDir: *outDir}
+	if *outDir != "" {
+		path, caption, cleanup, err := app.BuildAssetReportFile(rt.Store(), alerts, alertDays, now)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if path != "" {
+			if err := capture.SendDocumentPath(ctx, path, caption); err != nil {
+				return err
+			}
+		}
+	}
+	capture.Messages = append(capture.Messages, msg)
+	return e.print(*asJSON, map[string]any{"alerts": alerts, "message": msg, "documents": capture.Documents}, capture.Text())
+}
+
+func (e *Env) runAbuseScan(ctx context.Context, args []string) error {
+	fs := e.flagSet("abuse-scan")
+	asJSON := fs.Bool("json", false, "输出 JSON")
+	outDir := fs.String("out", "", "保存 HTML/CSV 报告的目录")
+	useTelegram := fs.Bool("telegram", false, "发送到 Telegram")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	sender, capture, err := e.sender(*useTelegram, *outDir)
+	if err != nil {
+		return err
+	}
+	cacheFile := config.AbuseReportCacheFile()
+	if capture != nil {
+		// 只预览时在缓存副本上扫描，避免写入 NotifiedAt 导致机器人不再推送这些报告。
+		preview, cleanup, err := copyToTemp(cacheFile)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		cacheFile = preview
+	}
+	service := &app.AbuseReportService{
+		CFClient:  e.CFClient,
+		Accounts:  config.Cfg().CloudflareAccounts,
+		Sender:    sender,
+		CacheFile: cacheFile,
+		PerPage:   config.AbuseReportPerPage(),
+		MaxPages:  config.AbuseReportMaxPages(),
+		Assets:    assetStore(),
+	}
+	if err := service.RunDaily(ctx); err != nil {
+		return err
+	}
+	if capture == nil {
+		return e.print(*asJSON, map[string]any{"sent": true}, "扫描完成，新报告已发送到 Telegram。")
+	}
+	text := capture.Text()
+	if len(capture.Messages) == 0 {
+		text = "没有新的 Cloudflare 滥用报告。"
+	}
+	return e.print(*asJSON, map[string]any{"messages": capture.Messages, "documents": capture.Documents}, text)
+}
+
+func (e *Env) runDNSExport(ctx context.Context, args []string) error {
+	fs := e.flagSet("dns export")
+	label := fs.String("account", "all", "账号选择器：all、账号标签、group:分组、tag:标签")
+	output := fs.String("o", "", "输出文件，默认写到 stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	targets, err := telegram.ResolveAccountTargets(config.Cfg().CloudflareAccounts, *label, assetStore())
+	if err != nil {
+		return err
+	}
+	data, _, err := telegram.BuildDNSExportCSVForTargets(ctx, e.CFClient, targets)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = e.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.Stderr, "已导出到 %s\n", *output)
+	return nil
+}
+
+type provisionOutput struct {
+	Account       string                    `json:"account"`
+	Result        *cfclient.ProvisionResult `json:"result"`
+	RegistrarSync string                    `json:"registrar_sync,omitempty"`
+}
+
+func (e *Env) runZoneProvision(ctx context.Context, args []string) error {
+	fs := e.flagSet("zone provision")
+	domain := fs.String("domain", "", "域名")
+	label := fs.String("account", "", "账号选择器（账号标签、group:分组、tag:标签），需只命中一个账号，默认第一个账号")
+	block := fs.String("block", "", "拦截的国家/地区代码，如 CN,RU")
+	speed := fs.Bool("speed", false, "开启速度优化")
+	rum := fs.Bool("rum", false, "开启 RUM")
+	create := fs.Bool("create", true, "Zone 不存在时创建")
+	syncRegistrar := fs.Bool("sync-registrar", false, "把 NS 同步到注册商")
+	asJSON := fs.Bool("json", false, "输出 JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	name := strings.ToLower(strings.TrimSpace(*domain))
+	if name == "" {
+		return usageError{"缺少 -domain"}
+	}
+	provisioner, ok := e.CFClient.(cfclient.Provisioner)
+	if !ok {
+		return errors.New("当前 Cloudflare 客户端不支持 Zone 初始化")
+	}
+	accounts, err := selectAccounts(*label)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return errors.New("未配置可用的 Cloudflare 账号")
+	}
+	if strings.TrimSpace(*label) != "" && len(accounts) != 1 {
+		return usageError{fmt.Sprintf("-account %s 命中了 %d 个账号，zone provision 只能指定一个账号", *label, len(accounts))}
+	}
+	account := accounts[0]
+	countries, err := cfclient.NormalizeCountryCodes([]string{*block})
+	if err != nil {
+		return usageError{fmt.Sprintf("-block 参数错误: %v", err)}
+	}
+	result, err := provisioner.ProvisionCloudflareZone(ctx, account, name, cfclient.ProvisionOptions{
+		AccountID:           account.AccountID,
+		BlockCountries:      countries,
+		EnableSpeed:         *speed,
+		EnableRUM:           *rum,
+		ExtraZoneSettings:   config.ExtraZoneSettings(),
+		CreateZoneIfMissing: *create,
+	})
+	if err != nil {
+		return err
+	}
+	e.newRuntime().RecordDomainChange(ctx, reminder.DomainChange{Domain: result.Domain, Source: account.Label, IsCF: true, ZoneID: result.ZoneID, Status: result.ZoneStatus})
+
+	out := provisionOutput{Account: account.Label, Result: result}
+	if *syncRegistrar {
+		registrar, err := registrarclient.NewManager(nil, config.Cfg().Registrars).SetNameServersForDomain(ctx, result.Domain, result.NameServers)
+		if err != nil {
+			out.RegistrarSync = "同步失败: " + err.Error()
+		} else {
+			out.RegistrarSync = fmt.Sprintf("已同步到 %s (%s)", registrar.Label, registrar.Type)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("域名: %s\n账号: %s\nZone ID: %s\n状态: %s\n", result.Domain, account.Label, result.ZoneID, result.ZoneStatus))
+	if len(result.NameServers) > 0 {
+		sb.WriteString("NS:\n  " + strings.Join(result.NameServers, "\n  ") + "\n")
+	}
+	if result.CountryBlockStatus != "" {
+		sb.WriteString("国家拦截: " + result.CountryBlockStatus + "\n")
+	}
+	for _, key := range sortedKeys(result.SpeedStatus) {
+		sb.WriteString(fmt.Sprintf("速度设置 %s: %s\n", key, result.SpeedStatus[key]))
+	}
+	for _, warning := range result.Warnings {
+		sb.WriteString("⚠️ " + warning + "\n")
+	}
+	if out.RegistrarSync != "" {
+		sb.WriteString("注册商: " + out.RegistrarSync + "\n")
+	}
+	return e.print(*asJSON, out, sb.String())
+}
+
+func (e *Env) runCacheInspect(args []string) error {
+	fs := e.flagSet("cache inspect")
+	domain := fs.String("domain", "", "只显示该域名")
+	asJSON := fs.Bool("json", false, "输出 JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cachePath := config.Cfg().AssetCacheFile
+	if cachePath == "" {
+		cachePath = reminder.DefaultCachePath
+	}
+	cache, err := reminder.NewFileStore(cachePath).Load()
+	if err != nil {
+		return err
+	}
+	filter := reminder.NormalizeDomain(*domain)
+	records := make([]reminder.Record, 0, len(cache.Records))
+	for _, rec := range cache.Records {
+		if rec == nil {
+			continue
+		}
+		if filter != "" && reminder.NormalizeDomain(rec.Domain) != filter {
+			continue
+		}
+		records = append(records, *rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Domain < records[j].Domain })
+	if filter != "" && len(records) == 0 {
+		return fmt.Errorf("缓存中没有域名 %s", filter)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("缓存文件: %s（更新于 %s），共 %d 条\n", cachePath, cache.UpdatedAt, len(records)))
+	for _, rec := range records {
+		sources := make([]string, 0, len(rec.Accounts))
+		for _, acc := range rec.Accounts {
+			sources = append(sources, acc.Source)
+		}
+		state := rec.Status
+		if rec.Deleted {
+			state = "deleted"
+		}
+		sb.WriteString(fmt.Sprintf("%s\t账号=%s\t状态=%s\t域名到期=%s\t证书=%d", rec.Domain, strings.Join(sources, ","), state, rec.DomainExpiry, len(rec.Certificates)))
+		if rec.LastRefreshError != "" {
+			sb.WriteString("\t刷新错误=" + rec.LastRefreshError)
+		}
+		sb.WriteString("\n")
+	}
+	return e.print(*asJSON, records, sb.String())
+}
+
+func (e *Env) runSecretKeygen(args []string) error {
+	fs := e.flagSet("secret keygen")
+	keyFile := fs.String("key", config.SecretKeyFile(&config.Config{}), "密钥文件路径")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := config.GenerateSecretKey(*keyFile); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(e.Stdout, "已生成密钥文件 %s，请妥善保管并在配置中设置 secrets.keyFile\n", *keyFile)
+	return err
+}
+
+func (e *Env) runSecretEncrypt(args []string) error {
+	fs := e.flagSet("secret encrypt")
+	keyFile := fs.String("key", config.SecretKeyFile(&config.Config{}), "密钥文件路径")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() > 1 {
+		return usageError{fmt.Sprintf("多余的参数: %s", strings.Join(fs.Args()[1:], " "))}
+	}
+	key, err := config.LoadSecretKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	value := fs.Arg(0)
+	if value == "" && e.Stdin != nil {
+		data, err := io.ReadAll(e.Stdin)
+		if err != nil {
+			return fmt.Errorf("读取标准输入失败: %w", err)
+		}
+		value = strings.TrimSpace(string(data))
+	}
+	if value == "" {
+		return usageError{"需要加密的值为空"}
+	}
+	encrypted, err := config.EncryptSecret(value, key)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(e.Stdout, encrypted)
+	return err
+}
+
+// selectAccounts 按账号选择器返回命中的账号，空选择器表示全部账号。
+func selectAccounts(label string) ([]config.CF, error) {
+	if strings.TrimSpace(label) == "" {
+		return append([]config.CF(nil), config.Cfg().CloudflareAccounts...), nil
+	}
+	targets, err := telegram.ResolveAccountTargets(config.Cfg().CloudflareAccounts, label, assetStore())
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]config.CF, 0, len(targets))
+	for _, target := range targets {
+		accounts = append(accounts, target.Account)
+	}
+	return accounts, nil
+}
+
+// copyToTemp 把 path 复制到临时文件，path 不存在时返回一个不存在的临时路径。
+func copyToTemp(path string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "domainc-preview-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	dst := filepath.Join(dir, filepath.Base(path))
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return dst, cleanup, nil
+	}
+	if err == nil {
+		err = os.WriteFile(dst, data, 0o600)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dst, cleanup, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// captureSender 把本应发往 Telegram 的消息收集起来，附件复制到 outDir。
+type captureSender struct {
+	telegram.NoopSender
+	outDir    string
+	Messages  []string
+	Documents []string
+}
+
+func (c *captureSender) Send(ctx context.Context, msg string) error {
+	c.Messages = append(c.Messages, msg)
+	return nil
+}
+
+func (c *captureSender) SendHTML(ctx context.Context, msg string) error {
+	return c.Send(ctx, msg)
+}
+
+func (c *captureSender) SendWithButtons(ctx context.Context, msg string, buttons [][]telegram.Button) error {
+	return c.Send(ctx, msg)
+}
+
+func (c *captureSender) SendDocumentPath(ctx context.Context, path string, caption string) error {
+	if c.outDir == "" {
+		c.Documents = append(c.Documents, caption+"（使用 -out 保存附件）")
+		return nil
+	}
+	if err := os.MkdirAll(c.outDir, 0o755); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(c.outDir, filepath.Base(path))
+	if err := os.WriteFile(dest, data, 0o644); err != nil {
+		return err
+	}
+	c.Documents = append(c.Documents, dest)
+	return nil
+}
+
+// Text 把消息和附件拼成纯文本输出。
+func (c *captureSender) Text() string {
+	var sb strings.Builder
+	sb.WriteString(strings.Join(c.Messages, "\n\n"))
+	for _, doc := range c.Documents {
+		sb.WriteString("\n附件: " + doc)
+	}
+	return sb.String()
+}
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/reminder"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+type fakeCFClient struct {
+	cfclient.Client
+}
+
+func (fakeCFClient) ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error) {
+	return []cfclient.ZoneDetail{{ID: "z1", Name: "example.com", Status: "active"}}, nil
+}
+
+func (fakeCFClient) ListDNSRecords(ctx context.Context, account config.CF, domain string) ([]cloudflare.DNSRecord, error) {
+	return []cloudflare.DNSRecord{{Type: "A", Name: "www.example.com", Content: "203.0.113.1"}}, nil
+}
+
+func TestCacheInspectPrintsJSONForDomain(t *testing.T) {
+	prev := *config.Cfg()
+	defer config.Set(prev)
+	cfg := prev
+	cfg.AssetCacheFile = filepath.Join(t.TempDir(), "cache.json")
+	config.Set(cfg)
+
+	store := reminder.NewFileStore(config.Cfg().AssetCacheFile)
+	for _, domain := range []string{"example.com", "example.net"} {
+		if _, err := store.UpsertDomain(reminder.DomainChange{Domain: domain, Source: "main", IsCF: true, Status: "active"}); err != nil {
+			t.Fatalf("UpsertDomain: %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	env := &Env{Stdout: &stdout, Stderr: &stderr, CFClient: fakeCFClient{}}
+	if err := env.Dispatch(context.Background(), []string{"cache", "inspect", "-domain", "Example.NET", "-json"}); err != nil {
+		t.Fatalf("cache inspect returned error: %v (stderr=%s)", err, stderr.String())
+	}
+	var records []reminder.Record
+	if err := json.Unmarshal(stdout.Bytes(), &records); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(records) != 1 || records[0].Domain != "example.net" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	err := env.Dispatch(context.Background(), []string{"cache", "inspect", "-domain", "missing.org"})
+	if err == nil {
+		t.Fatalf("expected error for domain missing from cache")
+	}
+}
+
+func TestDNSExportWritesCSVAndRejectsUnknownCommands(t *testing.T) {
+	prev := *config.Cfg()
+	defer config.Set(prev)
+	cfg := prev
+	cfg.CloudflareAccounts = []config.CF{{Label: "main"}}
+	config.Set(cfg)
+
+	var stdout, stderr bytes.Buffer
+	env := &Env{Stdout: &stdout, Stderr: &stderr, CFClient: fakeCFClient{}}
+	if err := env.Dispatch(context.Background(), []string{"dns", "export", "-account", "main"}); err != nil {
+		t.Fatalf("dns export returned error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "main,example.com,www.example.com,A,203.0.113.1") {
+		t.Fatalf("unexpected CSV:\n%s", stdout.String())
+	}
+
+	err := env.Dispatch(context.Background(), []string{"dns", "export", "-account", "nope"})
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("expected unknown account error, got %v", err)
+	}
+	var usageErr usageError
+	if err := env.Dispatch(context.Background(), []string{"frobnicate"}); !errors.As(err, &usageErr) {
+		t.Fatalf("expected usage error for unknown command, got %v", err)
+	}
+}
+
+func TestDNSExportSelectsAccountsByGroupAndZoneTag(t *testing.T) {
+	prev := *config.Cfg()
+	defer config.Set(prev)
+	cfg := prev
+	cfg.AssetCacheFile = filepath.Join(t.TempDir(), "cache.json")
+	cfg.CloudflareAccounts = []config.CF{
+		{Label: "main", Group: "prod"},
+		{Label: "staging", Group: "staging"},
+		{Label: "brand-acct"},
+	}
+	config.Set(cfg)
+	store := reminder.NewFileStore(config.Cfg().AssetCacheFile)
+	if _, err := store.UpsertDomain(reminder.DomainChange{Domain: "example.com", Source: "brand-acct", IsCF: true, Status: "active"}); err != nil {
+		t.Fatalf("UpsertDomain: %v", err)
+	}
+	if _, err := store.SetDomainTags("example.com", []string{"Brand"}, nil); err != nil {
+		t.Fatalf("SetDomainTags: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	env := &Env{Stdout: &stdout, Stderr: &stderr, CFClient: fakeCFClient{}}
+	if err := env.Dispatch(context.Background(), []string{"dns", "export", "-account", "group:prod,tag:brand"}); err != nil {
+		t.Fatalf("dns export returned error: %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "main,example.com") || !strings.Contains(out, "brand-acct,example.com") || strings.Contains(out, "staging,") {
+		t.Fatalf("unexpected CSV:\n%s", out)
+	}
+
+	if err := env.Dispatch(context.Background(), []string{"dns", "export", "-account", "group:missing"}); err == nil {
+		t.Fatalf("expected error for selector without matches")
+	}
+}
+
+func TestSecretEncryptRoundTripsThroughConfig(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "secret.key")
+	var stdout, stderr bytes.Buffer
+	env := &Env{Stdin: strings.NewReader("cf-token-from-enc-value\n"), Stdout: &stdout, Stderr: &stderr}
+	if err := env.Dispatch(context.Background(), []string{"secret", "keygen", "-key", keyFile}); err != nil {
+		t.Fatalf("keygen returned error: %v", err)
+	}
+	stdout.Reset()
+	if err := env.Dispatch(context.Background(), []string{"secret", "encrypt", "-key", keyFile}); err != nil {
+		t.Fatalf("encrypt returned error: %v", err)
+	}
+	encrypted := strings.TrimSpace(stdout.String())
+	if !strings.HasPrefix(encrypted, "enc:") || strings.Contains(encrypted, "cf-token") {
+		t.Fatalf("unexpected encrypted value %q", encrypted)
+	}
+
+	tokenFile := filepath.Join(dir, "godaddy.secret")
+	if err := os.WriteFile(tokenFile, []byte("godaddy-secret-from-file\n"), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	t.Setenv("TEST_GODADDY_KEY", "godaddy-key-from-env")
+	configPath := filepath.Join(dir, "config.yaml")
+	content := "secrets:\n  keyFile: " + keyFile + "\n" +
+		"cloudflareAccounts:\n  - label: main\n    apiToken: \"" + encrypted + "\"\n" +
+		"registrars:\n  - label: gd\n    type: godaddy\n    godaddy:\n      apiKey: env:TEST_GODADDY_KEY\n      apiSecret: file:" + tokenFile + "\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	parsed, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if parsed.CloudflareAccounts[0].APIToken != "cf-token-from-enc-value" {
+		t.Fatalf("enc: value not decrypted: %q", parsed.CloudflareAccounts[0].APIToken)
+	}
+	gd := parsed.Registrars[0].GoDaddy
+	if gd.APIKey != "godaddy-key-from-env" || gd.APISecret != "godaddy-secret-from-file" {
+		t.Fatalf("env:/file: values not resolved: %+v", *gd)
+	}
+
+	formatted := fmt.Sprintf("%v %+v", parsed.CloudflareAccounts, *gd)
+	if strings.Contains(formatted, "cf-token") || strings.Contains(formatted, "godaddy-") {
+		t.Fatalf("formatted config leaks secrets: %s", formatted)
+	}
+	if got := config.Redact("request failed: token=cf-token-from-enc-value"); strings.Contains(got, "cf-token") {
+		t.Fatalf("Redact did not mask loaded secret: %s", got)
+	}
+
+	t.Setenv("TEST_GODADDY_KEY", "")
+	if _, err := config.Parse(configPath); err == nil || !strings.Contains(err.Error(), "TEST_GODADDY_KEY") {
+		t.Fatalf("expected missing env error, got %v", err)
+	}
+}
+
+type fakeAbuseCFClient struct {
+	fakeCFClient
+}
+
+func (fakeAbuseCFClient) ListAbuseReports(ctx context.Context, account config.CF, opts cfclient.AbuseReportListOptions) ([]cfclient.AbuseReportInfo, error) {
+	return []cfclient.AbuseReportInfo{{ID: "r1", AccountLabel: account.Label, Domain: "example.com", ReportType: "phishing", Status: "accepted"}}, nil
+}
+
+func TestAbuseScanPreviewDoesNotTouchSharedCache(t *testing.T) {
+	prev := *config.Cfg()
+	defer config.Set(prev)
+	cfg := prev
+	cfg.CloudflareAccounts = []config.CF{{Label: "main"}}
+	cfg.AbuseReport.CacheFile = filepath.Join(t.TempDir(), "abuse.json")
+	config.Set(cfg)
+
+	var stdout, stderr bytes.Buffer
+	env := &Env{Stdout: &stdout, Stderr: &stderr, CFClient: fakeAbuseCFClient{}}
+	if err := env.Dispatch(context.Background(), []string{"abuse-scan"}); err != nil {
+		t.Fatalf("abuse-scan returned error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "example.com") {
+		t.Fatalf("preview should list the new report:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(cfg.AbuseReport.CacheFile); !os.IsNotExist(err) {
+		t.Fatalf("preview must not write the shared abuse cache, stat err=%v", err)
+	}
+}
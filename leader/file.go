@@ -0,0 +1,95 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileBackend 把租约写在共享文件中，读改写期间持有 advisory 文件锁，适用于同一主机或共享卷上的多个实例。
+type FileBackend struct {
+	path string
+	now  func() time.Time
+}
+
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path, now: time.Now}
+}
+
+type fileLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (b *FileBackend) TryAcquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := b.withLease(func(lease *fileLease) bool {
+		now := b.now()
+		if lease.Holder != "" && lease.Holder != id && now.Before(lease.ExpiresAt) {
+			return false
+		}
+		lease.Holder = id
+		lease.ExpiresAt = now.Add(ttl).UTC()
+		acquired = true
+		return true
+	})
+	return acquired, err
+}
+
+func (b *FileBackend) Release(ctx context.Context, id string) error {
+	return b.withLease(func(lease *fileLease) bool {
+		if lease.Holder != id {
+			return false
+		}
+		*lease = fileLease{}
+		return true
+	})
+}
+
+// withLease 在文件锁内读取租约，fn 返回 true 时写回。
+func (b *FileBackend) withLease(fn func(lease *fileLease) bool) error {
+	if dir := filepath.Dir(b.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建租约目录 %s 失败: %w", dir, err)
+		}
+	}
+	f, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开租约文件 %s 失败: %w", b.path, err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("锁定租约文件 %s 失败: %w", b.path, err)
+	}
+	defer unlockFile(f)
+
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return fmt.Errorf("读取租约文件 %s 失败: %w", b.path, err)
+	}
+	var lease fileLease
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &lease); err != nil {
+			// 损坏的租约文件视为空闲，由本次写入覆盖。
+			lease = fileLease{}
+		}
+	}
+	if !fn(&lease) {
+		return nil
+	}
+	out, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("序列化租约失败: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("写入租约文件 %s 失败: %w", b.path, err)
+	}
+	if _, err := f.WriteAt(out, 0); err != nil {
+		return fmt.Errorf("写入租约文件 %s 失败: %w", b.path, err)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,138 @@
+// Package leader 提供单活实例选举：同一时间只有持有租约的实例运行定时任务、刷新队列和 Telegram 监听。
+package leader
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"DomainC/metrics"
+)
+
+// Backend 是租约存储的抽象，除文件锁外也可以由 Redis、数据库等实现。
+type Backend interface {
+	// TryAcquire 在租约空闲、已过期或已由 id 持有时获取/续约 ttl，成功返回 true。
+	TryAcquire(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// Release 释放 id 持有的租约，其他实例无需等待过期即可接管。
+	Release(ctx context.Context, id string) error
+}
+
+var isLeader = metrics.NewGaugeVec("domainc_leader", "当前实例是否持有 leader 租约（1 为 leader）", "instance")
+
+// Elector 周期性续约，成为 leader 时调用 onElected，失去租约时取消传给它的 ctx。
+type Elector struct {
+	backend Backend
+	id      string
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	leading bool
+}
+
+func NewElector(backend Backend, id string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &Elector{backend: backend, id: id, ttl: ttl}
+}
+
+// ID 返回当前实例标识。
+func (e *Elector) ID() string { return e.id }
+
+// IsLeader 报告当前实例是否持有租约。
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+func (e *Elector) setLeading(v bool) {
+	e.mu.Lock()
+	e.leading = v
+	e.mu.Unlock()
+	value := 0.0
+	if v {
+		value = 1
+	}
+	isLeader.Set(value, e.id)
+}
+
+// Run 阻塞直到 ctx 结束。每 ttl/3 续约一次；距上次成功续约超过 ttl 减一个续约间隔仍未续上时主动让位，
+// 保证在租约过期、其他实例可以接管之前停止本实例的 leader 工作。
+func (e *Elector) Run(ctx context.Context, onElected func(ctx context.Context)) {
+	interval := e.ttl / 3
+	// 续约时间按发起请求的时刻计算，租约最早在 lastRenew+ttl 过期，这里留出一个续约间隔的余量。
+	stepDownAfter := e.ttl - interval
+	var (
+		cancelLead context.CancelFunc
+		lastRenew  time.Time
+	)
+	e.setLeading(false)
+	stepDown := func(reason string) {
+		if cancelLead == nil {
+			return
+		}
+		log.Printf("实例 %s 不再是 leader: %s", e.id, reason)
+		cancelLead()
+		cancelLead = nil
+		e.setLeading(false)
+	}
+
+	for {
+		start := time.Now()
+		deadline := start.Add(interval)
+		if cancelLead != nil && lastRenew.Add(stepDownAfter).Before(deadline) {
+			deadline = lastRenew.Add(stepDownAfter)
+		}
+		tryCtx, cancelTry := context.WithDeadline(ctx, deadline)
+		acquired, err := e.backend.TryAcquire(tryCtx, e.id, e.ttl)
+		cancelTry()
+		now := time.Now()
+		switch {
+		case err != nil:
+			log.Printf("leader 租约续约失败: %v", err)
+			if cancelLead != nil && now.Sub(lastRenew) >= stepDownAfter {
+				stepDown("租约续约失败，租约即将过期")
+			}
+		case acquired:
+			lastRenew = start
+			if cancelLead == nil {
+				log.Printf("实例 %s 成为 leader，租约 %v", e.id, e.ttl)
+				cancelLead = e.lead(ctx, onElected)
+			}
+		default:
+			stepDown("租约已被其他实例持有")
+		}
+
+		wait := interval
+		if cancelLead != nil {
+			if untilStepDown := lastRenew.Add(stepDownAfter).Sub(now); untilStepDown < wait {
+				wait = untilStepDown
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			stepDown("进程退出")
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := e.backend.Release(releaseCtx, e.id); err != nil {
+				log.Printf("释放 leader 租约失败: %v", err)
+			}
+			cancel()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, onElected func(ctx context.Context)) context.CancelFunc {
+	leadCtx, cancel := context.WithCancel(ctx)
+	e.setLeading(true)
+	go onElected(leadCtx)
+	return cancel
+}
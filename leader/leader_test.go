@@ -0,0 +1,171 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileBackendLeaseExpiryAndRelease(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := NewFileBackend(filepath.Join(t.TempDir(), "leader.lock"))
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if ok, err := b.TryAcquire(ctx, "a", 30*time.Second); err != nil || !ok {
+		t.Fatalf("a should acquire free lease: %v %v", ok, err)
+	}
+	if ok, _ := b.TryAcquire(ctx, "b", 30*time.Second); ok {
+		t.Fatalf("b must not acquire while a holds the lease")
+	}
+	now = now.Add(20 * time.Second)
+	if ok, _ := b.TryAcquire(ctx, "a", 30*time.Second); !ok {
+		t.Fatalf("a should renew its own lease")
+	}
+	now = now.Add(29 * time.Second)
+	if ok, _ := b.TryAcquire(ctx, "b", 30*time.Second); ok {
+		t.Fatalf("renewed lease must still be valid")
+	}
+	now = now.Add(2 * time.Second)
+	if ok, _ := b.TryAcquire(ctx, "b", 30*time.Second); !ok {
+		t.Fatalf("b should take over after lease expiry")
+	}
+
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatalf("release by non-holder: %v", err)
+	}
+	if ok, _ := b.TryAcquire(ctx, "a", 30*time.Second); ok {
+		t.Fatalf("release by non-holder must not free the lease")
+	}
+	if err := b.Release(ctx, "b"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if ok, _ := b.TryAcquire(ctx, "a", 30*time.Second); !ok {
+		t.Fatalf("a should acquire released lease immediately")
+	}
+}
+
+type scriptedBackend struct {
+	mu       sync.Mutex
+	grant    bool
+	released bool
+}
+
+func (b *scriptedBackend) TryAcquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.grant, nil
+}
+
+func (b *scriptedBackend) Release(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.released = true
+	return nil
+}
+
+func TestElectorCancelsLeaderWorkWhenLeaseIsLost(t *testing.T) {
+	backend := &scriptedBackend{grant: true}
+	e := NewElector(backend, "test", 30*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	elected := make(chan context.Context, 2)
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx, func(leadCtx context.Context) { elected <- leadCtx })
+		close(done)
+	}()
+
+	var leadCtx context.Context
+	select {
+	case leadCtx = <-elected:
+	case <-time.After(time.Second):
+		t.Fatalf("elector never became leader")
+	}
+	if !e.IsLeader() {
+		t.Fatalf("IsLeader should be true after election")
+	}
+
+	backend.mu.Lock()
+	backend.grant = false
+	backend.mu.Unlock()
+	select {
+	case <-leadCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("leader work was not cancelled after losing the lease")
+	}
+
+	cancel()
+	<-done
+	if e.IsLeader() || !backend.released {
+		t.Fatalf("expected step-down and release on shutdown (leader=%v released=%v)", e.IsLeader(), backend.released)
+	}
+}
+
+// unreachableBackend 在 down 时模拟租约存储不可达：续约报错，但已写入的租约仍在有效期内。
+type unreachableBackend struct {
+	*FileBackend
+	mu   sync.Mutex
+	down bool
+}
+
+func (b *unreachableBackend) TryAcquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	down := b.down
+	b.mu.Unlock()
+	if down {
+		return false, errors.New("lease store unreachable")
+	}
+	return b.FileBackend.TryAcquire(ctx, id, ttl)
+}
+
+func TestElectorStepsDownBeforeLeaseCanBeTakenOver(t *testing.T) {
+	const ttl = 300 * time.Millisecond
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	flaky := &unreachableBackend{FileBackend: NewFileBackend(path)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := NewElector(flaky, "a", ttl)
+	elected := make(chan context.Context, 1)
+	go first.Run(ctx, func(leadCtx context.Context) { elected <- leadCtx })
+	var leadCtx context.Context
+	select {
+	case leadCtx = <-elected:
+	case <-time.After(time.Second):
+		t.Fatalf("first elector never became leader")
+	}
+
+	flaky.mu.Lock()
+	flaky.down = true
+	flaky.mu.Unlock()
+
+	lost := make(chan time.Time, 1)
+	go func() {
+		<-leadCtx.Done()
+		lost <- time.Now()
+	}()
+	taken := make(chan time.Time, 1)
+	second := NewElector(NewFileBackend(path), "b", ttl)
+	go second.Run(ctx, func(context.Context) { taken <- time.Now() })
+
+	var lostAt, takenAt time.Time
+	select {
+	case takenAt = <-taken:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("second elector never took over the expired lease")
+	}
+	select {
+	case lostAt = <-lost:
+	default:
+		t.Fatalf("second elector acquired the lease while the first was still leading")
+	}
+	if !lostAt.Before(takenAt) {
+		t.Fatalf("first elector stepped down at %s, after takeover at %s", lostAt, takenAt)
+	}
+	if first.IsLeader() || !second.IsLeader() {
+		t.Fatalf("unexpected leadership: first=%v second=%v", first.IsLeader(), second.IsLeader())
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package leader
+
+import (
+	"os"
+	"sync"
+)
+
+// 非 unix 平台没有 flock，退化为进程内互斥；跨进程仍依赖租约过期时间。
+var fileLockMu sync.Mutex
+
+func lockFile(f *os.File) error {
+	fileLockMu.Lock()
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	fileLockMu.Unlock()
+	return nil
+}
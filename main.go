@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"DomainC/callback"
 	"DomainC/cfclient"
 	"DomainC/config"
+	"DomainC/internal/api"
 	"DomainC/internal/app"
+	"DomainC/internal/cli"
+	"DomainC/leader"
+	"DomainC/metrics"
 	"DomainC/registrarclient"
 	"DomainC/reminder"
 	"DomainC/scheduler"
@@ -16,18 +25,35 @@ import (
 )
 
 func main() {
+	// 日志输出前替换已加载的密钥（Telegram 请求 URL 等错误信息中可能带有 token）。
+	log.SetOutput(config.RedactWriter(os.Stderr))
+
+	// 带参数时作为命令行工具执行单次操作，不启动机器人和定时任务。
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	if err := config.Load("config.yaml"); err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	if config.MetricsEnabled() {
+		go func() {
+			if err := metrics.ListenAndServe(ctx, config.MetricsListenAddr()); err != nil {
+				log.Printf("Metrics 监听停止: %v", err)
+			}
+		}()
+	}
+
 	cfClient := cfclient.NewClient()
-	registrarManager := registrarclient.NewManager(nil, config.Cfg.Registrars)
+	callback.SetCFClient(cfClient)
+	registrarManager := registrarclient.NewManager(nil, config.Cfg().Registrars)
 	var sender telegram.Sender
 	botSender, err := telegram.NewMultiBotSender(
-		config.Cfg.Telegram.BotToken,
+		config.Cfg().Telegram.BotToken,
 		config.TelegramChatIDs(),
 		2,
 		time.Second,
@@ -35,20 +61,21 @@ func main() {
 	)
 	if err != nil {
 		log.Printf("初始化 Telegram 失败，使用空实现: %v", err)
+		metrics.SetNotReady("telegram", err.Error())
 		sender = telegram.NoopSender{}
 		telegram.SetDefaultSender(sender)
 	} else {
 		sender = botSender
 	}
 
-	cachePath := config.Cfg.AssetCacheFile
+	cachePath := config.Cfg().AssetCacheFile
 	if cachePath == "" {
 		cachePath = reminder.DefaultCachePath
 	}
 	reminderRuntime := reminder.NewRuntime(reminder.RuntimeOptions{
 		Store:        reminder.NewFileStore(cachePath),
 		CFClient:     cfClient,
-		Accounts:     config.Cfg.CloudflareAccounts,
+		Accounts:     config.Cfg().CloudflareAccounts,
 		Registrar:    registrarManager,
 		Whois:        app.DefaultWhoisClient{},
 		RefreshDelay: 2 * time.Second,
@@ -56,56 +83,238 @@ func main() {
 		TLS:          10 * time.Second,
 	})
 	reminder.SetDefaultRuntime(reminderRuntime)
-	go reminderRuntime.Run(ctx)
-	go func() {
-		log.Printf("开始启动资产缓存同步")
-		summary := reminderRuntime.SyncCloudflareDomainsOnce(ctx)
-		if len(summary.Errors) > 0 {
-			log.Printf("启动资产缓存同步完成但存在错误: accounts=%d/%d domains=%d added=%d updated=%d unknown=%d queued=%d errors=%v",
-				summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.Updated, summary.MarkedUnknown, summary.QueuedRefresh, summary.Errors)
-			return
-		}
-		log.Printf("启动资产缓存同步完成: accounts=%d/%d domains=%d added=%d updated=%d unknown=%d queued=%d",
-			summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.Updated, summary.MarkedUnknown, summary.QueuedRefresh)
-	}()
+	reminderRuntime.RegisterMetrics()
 
-	commandHandler := telegram.NewCommandHandler(cfClient, registrarManager, sender, config.Cfg.CloudflareAccounts, 0)
+	commandHandler := telegram.NewCommandHandler(cfClient, registrarManager, sender, config.Cfg().CloudflareAccounts, 0)
 
-	go func() {
-		if err := sender.StartListener(ctx, callback.HandleCallback, commandHandler.HandleMessage); err != nil {
-			log.Printf("Telegram 监听停止: %v", err)
+	var trafficAlertService *app.TrafficAlertService
+	if config.TrafficAlertEnabled() {
+		if statsClient, ok := cfClient.(app.TrafficStatsClient); ok {
+			cfg := config.Cfg().TrafficAlert
+			trafficAlertService = &app.TrafficAlertService{
+				CFClient:     statsClient,
+				Accounts:     config.Cfg().CloudflareAccounts,
+				Sender:       sender,
+				BaselineFile: config.TrafficAlertBaselineFile(),
+				Window:       config.TrafficAlertWindow(),
+				Thresholds: app.TrafficAlertThresholds{
+					SpikeRatio:         cfg.SpikeRatio,
+					DropRatio:          cfg.DropRatio,
+					Max5xxRate:         cfg.Max5xxRate,
+					MaxOriginErrorRate: cfg.MaxOriginErrorRate,
+					MinRequests:        cfg.MinRequests,
+					MinSamples:         cfg.MinSamples,
+					MaxSamples:         cfg.MaxSamples,
+					Cooldown:           time.Duration(cfg.CooldownMinutes) * time.Minute,
+				},
+			}
 		}
-	}()
+	}
 
 	assetReminder := &app.AssetReminderService{
 		Runtime:   reminderRuntime,
 		Sender:    sender,
 		AlertDays: config.EffectiveAlertDays(),
 	}
-	sched := scheduler.NewDailyScheduler()
-	sched.ScheduleDaily(ctx, 15, 0, func() {
-		log.Printf("开始每日到期提醒任务")
-		if err := assetReminder.RunDaily(ctx); err != nil {
-			log.Printf("每日到期提醒任务失败: %v", err)
+
+	var apiServer *api.Server
+	if config.APIEnabled() {
+		apiServer = &api.Server{
+			CFClient:  cfClient,
+			Registrar: registrarManager,
+			Runtime:   reminderRuntime,
+			Accounts:  config.Cfg().CloudflareAccounts,
+			Tokens:    config.Cfg().API.Tokens,
+			Reporter:  assetReminder,
 		}
-	})
+		go func() {
+			if err := apiServer.ListenAndServe(ctx, config.APIListenAddr()); err != nil {
+				log.Printf("HTTP API 停止: %v", err)
+			}
+		}()
+	}
+
+	// 定时任务的执行时间、时区和补跑窗口见 config.ScheduledJobs；功能未开启的任务不注册。
+	jobHandlers := map[string]func(ctx context.Context) error{
+		config.JobDailyReport: assetReminder.RunDaily,
+	}
+
+	reloadTargets := []app.AccountsSetter{commandHandler, reminderRuntime}
+	if apiServer != nil {
+		reloadTargets = append(reloadTargets, apiServer)
+	}
+	if trafficAlertService != nil {
+		reloadTargets = append(reloadTargets, trafficAlertService)
+	}
 
 	if config.AbuseReportEnabled() {
 		abuseReportService := &app.AbuseReportService{
-			CFClient:  cfClient,
-			Accounts:  config.Cfg.CloudflareAccounts,
-			Sender:    sender,
-			CacheFile: config.AbuseReportCacheFile(),
-			PerPage:   config.AbuseReportPerPage(),
-			MaxPages:  config.AbuseReportMaxPages(),
+			CFClient:       cfClient,
+			Accounts:       config.Cfg().CloudflareAccounts,
+			Sender:         sender,
+			CacheFile:      config.AbuseReportCacheFile(),
+			PerPage:        config.AbuseReportPerPage(),
+			MaxPages:       config.AbuseReportMaxPages(),
+			DigestOpenDays: config.AbuseReportDigestOpenDays(),
+			Assets:         reminderRuntime.Store(),
 		}
-		sched.ScheduleDaily(ctx, config.AbuseReportScanHour(), config.AbuseReportScanMinute(), func() {
-			log.Printf("开始每日 Cloudflare 滥用报告扫描任务")
-			if err := abuseReportService.RunDaily(ctx); err != nil {
-				log.Printf("每日 Cloudflare 滥用报告扫描任务失败: %v", err)
+		jobHandlers[config.JobAbuseReportScan] = abuseReportService.RunDaily
+		jobHandlers[config.JobAbuseReportDigest] = abuseReportService.RunWeeklyDigest
+		commandHandler.AbuseReports = abuseReportService
+		reloadTargets = append(reloadTargets, abuseReportService)
+	}
+
+	if config.ZoneSnapshotEnabled() {
+		if snapshotClient, ok := cfClient.(app.ZoneSnapshotClient); ok {
+			zoneSnapshotService := &app.ZoneSnapshotService{
+				CFClient: snapshotClient,
+				Accounts: config.Cfg().CloudflareAccounts,
+				Sender:   sender,
+				Store:    cfclient.NewZoneSnapshotStore(config.ZoneSnapshotDir(), config.ZoneSnapshotRetention(), config.ZoneSnapshotMaxPerZone()),
+				Delay:    time.Second,
 			}
-		})
+			jobHandlers[config.JobZoneSnapshot] = zoneSnapshotService.RunDaily
+			reloadTargets = append(reloadTargets, zoneSnapshotService)
+		}
 	}
 
+	if config.WAFEventsDailyEnabled() {
+		jobHandlers[config.JobWAFEventsDigest] = func(ctx context.Context) error {
+			telegram.SendWAFEventsDigests(ctx, cfClient, liveCloudflareAccounts(), sender, config.WAFEventsHours())
+			return nil
+		}
+	}
+
+	sched := scheduler.NewCronScheduler(config.ScheduleStateFile())
+	registerScheduledJobs(sched, jobHandlers)
+
+	// 以下工作只在持有 leader 租约的实例上运行，失去租约时 leaderCtx 被取消，由接管的实例继续。
+	runLeaderWork := func(leaderCtx context.Context) {
+		go reminderRuntime.Run(leaderCtx)
+		metrics.SetNotReady("asset_sync", "启动资产缓存同步进行中")
+		go func() {
+			log.Printf("开始启动资产缓存同步")
+			summary := reminderRuntime.SyncCloudflareDomainsOnce(leaderCtx)
+			metrics.SetReady("asset_sync")
+			if len(summary.Errors) > 0 {
+				log.Printf("启动资产缓存同步完成但存在错误: accounts=%d/%d domains=%d added=%d updated=%d unknown=%d queued=%d errors=%v",
+					summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.Updated, summary.MarkedUnknown, summary.QueuedRefresh, summary.Errors)
+				return
+			}
+			log.Printf("启动资产缓存同步完成: accounts=%d/%d domains=%d added=%d updated=%d unknown=%d queued=%d",
+				summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.Updated, summary.MarkedUnknown, summary.QueuedRefresh)
+		}()
+
+		go func() {
+			if err := sender.StartListener(leaderCtx, callback.HandleCallback, commandHandler.HandleMessage); err != nil && leaderCtx.Err() == nil {
+				log.Printf("Telegram 监听停止: %v", err)
+				metrics.SetNotReady("telegram", "监听停止: "+err.Error())
+			}
+		}()
+
+		if config.TokenCheckOnStartup() {
+			go telegram.RunStartupTokenCheck(leaderCtx, cfClient, liveCloudflareAccounts, sender, config.TokenExpiryWarnDays())
+		}
+		go telegram.RunIPBlockExpirySweeper(leaderCtx, cfClient, liveCloudflareAccounts, sender, config.IPBlockSweepInterval())
+		go telegram.RunAttackModeRestorer(leaderCtx, cfClient, liveCloudflareAccounts, sender)
+		go telegram.ResumeZoneMoves(leaderCtx, cfClient, liveCloudflareAccounts, sender)
+		if trafficAlertService != nil {
+			go trafficAlertService.Run(leaderCtx, config.TrafficAlertInterval())
+		}
+		sched.Start(leaderCtx)
+	}
+
+	isLeader := func() bool { return true }
+	if config.LeaderEnabled() {
+		elector, err := newLeaderElector()
+		if err != nil {
+			log.Fatalf("初始化 leader 选举失败: %v", err)
+		}
+		log.Printf("leader 选举已开启: instance=%s backend=%s", elector.ID(), config.LeaderBackend())
+		isLeader = elector.IsLeader
+		go elector.Run(ctx, runLeaderWork)
+	} else {
+		runLeaderWork(ctx)
+	}
+
+	// SIGHUP 或（开启 reload.watch 时）配置文件变化后热加载账号和注册商配置。
+	reloader := &app.ConfigReloader{
+		Path:      config.LoadedPath(),
+		Sender:    sender,
+		Targets:   reloadTargets,
+		Registrar: registrarManager,
+		OnAccountsChanged: func(ctx context.Context) {
+			if !isLeader() {
+				return
+			}
+			summary := reminderRuntime.ResyncCloudflareDomains(ctx)
+			log.Printf("热加载后资产缓存同步完成: accounts=%d/%d domains=%d added=%d unknown=%d errors=%d",
+				summary.ScannedAccounts, summary.ConfiguredAccounts, summary.DomainsSeen, summary.Added, summary.MarkedUnknown, len(summary.Errors))
+		},
+	}
+	if apiServer != nil {
+		reloader.APITokens = apiServer
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var watchInterval time.Duration
+	if config.ReloadWatchEnabled() {
+		watchInterval = config.ReloadWatchInterval()
+	}
+	go reloader.Run(ctx, hup, watchInterval)
+
 	<-ctx.Done()
 }
+
+// liveCloudflareAccounts 返回当前配置中的账号，供长期运行的后台任务在每轮读取，热加载后无需重启。
+func liveCloudflareAccounts() []config.CF {
+	return config.Cfg().CloudflareAccounts
+}
+
+func newLeaderElector() (*leader.Elector, error) {
+	var backend leader.Backend
+	switch config.LeaderBackend() {
+	case "file":
+		backend = leader.NewFileBackend(config.LeaderLockFile())
+	default:
+		return nil, fmt.Errorf("不支持的 leader.backend: %s", config.LeaderBackend())
+	}
+	return leader.NewElector(backend, config.LeaderInstanceID(), config.LeaderLease()), nil
+}
+
+func registerScheduledJobs(sched *scheduler.CronScheduler, handlers map[string]func(ctx context.Context) error) {
+	builtin := map[string]bool{
+		config.JobDailyReport:       true,
+		config.JobAbuseReportScan:   true,
+		config.JobAbuseReportDigest: true,
+		config.JobZoneSnapshot:      true,
+		config.JobWAFEventsDigest:   true,
+	}
+	for _, job := range config.ScheduledJobs() {
+		if !job.IsEnabled() {
+			log.Printf("定时任务 %s 已在配置中关闭", job.Name)
+			continue
+		}
+		run, ok := handlers[job.Name]
+		if !ok {
+			if !builtin[job.Name] {
+				log.Printf("忽略未知定时任务 %s", job.Name)
+			}
+			continue
+		}
+		loc, err := config.ScheduleLocation(job)
+		if err != nil {
+			log.Printf("注册定时任务失败: %v", err)
+			continue
+		}
+		if err := sched.Add(scheduler.Job{
+			Name:     job.Name,
+			Spec:     job.Cron,
+			Location: loc,
+			CatchUp:  config.ScheduleCatchUpWindow(job),
+			Run:      run,
+		}); err != nil {
+			log.Printf("注册定时任务失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteTextFormatsCountersGaugesAndHistograms(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounterVec("test_calls_total", "调用次数", "method", "code")
+	depth := r.NewGaugeVec("test_queue_depth", "队列深度")
+	latency := r.NewHistogramVec("test_latency_seconds", "耗时", []float64{1, 0.1}, "method")
+
+	calls.Inc("GET", "200")
+	calls.Add(2, "GET", "200")
+	calls.Inc("POST", `5"00`)
+	r.OnCollect(func() { depth.Set(7) })
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE test_calls_total counter",
+		`test_calls_total{method="GET",code="200"} 3`,
+		`test_calls_total{method="POST",code="5\"00"} 1`,
+		"test_queue_depth 7",
+		`test_latency_seconds_bucket{method="GET",le="0.1"} 1`,
+		`test_latency_seconds_bucket{method="GET",le="1"} 2`,
+		`test_latency_seconds_bucket{method="GET",le="+Inf"} 2`,
+		`test_latency_seconds_sum{method="GET"} 0.55`,
+		`test_latency_seconds_count{method="GET"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReadyzReflectsPendingComponents(t *testing.T) {
+	srv := httptest.NewServer(Handler(NewRegistry()))
+	defer srv.Close()
+
+	SetNotReady("asset_sync", "同步中")
+	defer SetReady("asset_sync")
+
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("get readyz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while not ready, got %d", resp.StatusCode)
+	}
+
+	SetReady("asset_sync")
+	for _, path := range []string{"/readyz", "/healthz", "/metrics"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+}
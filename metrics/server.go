@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	jobLastRun = NewGaugeVec("domainc_scheduled_job_last_run_timestamp_seconds",
+		"定时任务最近一次执行结束的时间（Unix 秒）", "job")
+	jobLastSuccess = NewGaugeVec("domainc_scheduled_job_last_success_timestamp_seconds",
+		"定时任务最近一次成功的时间（Unix 秒）", "job")
+	jobRuns = NewCounterVec("domainc_scheduled_job_runs_total",
+		"定时任务执行次数", "job", "result")
+)
+
+// RecordJobRun 记录定时任务执行结果，err 为 nil 时同时更新最近成功时间。
+func RecordJobRun(job string, err error) {
+	now := time.Now()
+	jobLastRun.SetTime(now, job)
+	if err != nil {
+		jobRuns.Inc(job, "error")
+		return
+	}
+	jobRuns.Inc(job, "success")
+	jobLastSuccess.SetTime(now, job)
+}
+
+var readiness = struct {
+	sync.Mutex
+	pending map[string]string
+}{pending: make(map[string]string)}
+
+// SetNotReady 标记某个组件尚未就绪，/readyz 会返回 503 并列出原因。
+func SetNotReady(component, reason string) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	if strings.TrimSpace(reason) == "" {
+		reason = "未就绪"
+	}
+	readiness.pending[component] = reason
+}
+
+// SetReady 标记组件已就绪。
+func SetReady(component string) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	delete(readiness.pending, component)
+}
+
+// NotReady 返回尚未就绪的组件及原因，按组件名排序。
+func NotReady() []string {
+	readiness.Lock()
+	defer readiness.Unlock()
+	out := make([]string, 0, len(readiness.pending))
+	for component, reason := range readiness.pending {
+		out = append(out, component+": "+reason)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Handler 挂载 /metrics、/healthz 与 /readyz。
+func Handler(r *Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			log.Printf("输出 metrics 失败: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if pending := NotReady(); len(pending) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintln(w, strings.Join(pending, "\n"))
+			return
+		}
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// ListenAndServe 启动指标与健康检查监听直到 ctx 结束。
+func ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           Handler(defaultRegistry),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	log.Printf("Metrics 监听已启动: %s", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
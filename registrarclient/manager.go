@@ -19,6 +19,7 @@ const (
 // Manager 提供基于配置的注册商查询/修改能力。
 type Manager struct {
 	client          Client
+	regMu           sync.RWMutex
 	registrars      []config.Registrar
 	limitMu         sync.Mutex
 	limitedUntil    map[string]time.Time
@@ -79,8 +80,9 @@ func NewManager(client Client, registrars []config.Registrar) *Manager {
 }
 
 func (m *Manager) Registrars() []config.Registrar {
-	out := make([]config.Registrar, 0, len(m.registrars))
-	for _, r := range m.registrars {
+	registrars := m.currentRegistrars()
+	out := make([]config.Registrar, 0, len(registrars))
+	for _, r := range registrars {
 		if strings.TrimSpace(r.Label) == "" || strings.TrimSpace(r.Type) == "" {
 			continue
 		}
@@ -93,7 +95,7 @@ func (m *Manager) RegistrarByLabel(label string) (config.Registrar, bool) {
 	if strings.TrimSpace(label) == "" {
 		return config.Registrar{}, false
 	}
-	for _, r := range m.registrars {
+	for _, r := range m.currentRegistrars() {
 		if strings.EqualFold(strings.TrimSpace(r.Label), strings.TrimSpace(label)) {
 			return r, true
 		}
@@ -105,8 +107,9 @@ func (m *Manager) registrarsForDomain(domain string) []config.Registrar {
 	if strings.TrimSpace(domain) == "" {
 		return nil
 	}
-	all := make([]config.Registrar, 0, len(m.registrars))
-	for _, r := range m.registrars {
+	registrars := m.currentRegistrars()
+	all := make([]config.Registrar, 0, len(registrars))
+	for _, r := range registrars {
 		if strings.TrimSpace(r.Label) == "" || strings.TrimSpace(r.Type) == "" {
 			continue
 		}
@@ -132,9 +135,22 @@ func (m *Manager) registrarsForDomain(domain string) []config.Registrar {
 	return results
 }
 
+// SetRegistrars 在配置热加载后替换注册商列表；限流冷却和域名归属缓存按 label 保留。
+func (m *Manager) SetRegistrars(registrars []config.Registrar) {
+	m.regMu.Lock()
+	m.registrars = append([]config.Registrar(nil), registrars...)
+	m.regMu.Unlock()
+}
+
+func (m *Manager) currentRegistrars() []config.Registrar {
+	m.regMu.RLock()
+	defer m.regMu.RUnlock()
+	return m.registrars
+}
+
 // SetNameServersForDomain 尝试将 NS 写入到对应注册商。
 func (m *Manager) SetNameServersForDomain(ctx context.Context, domain string, nameServers []string) (config.Registrar, error) {
-	if len(m.registrars) == 0 {
+	if len(m.currentRegistrars()) == 0 {
 		return config.Registrar{}, fmt.Errorf("未配置注册商")
 	}
 
@@ -142,6 +158,7 @@ func (m *Manager) SetNameServersForDomain(ctx context.Context, domain string, na
 	for _, r := range m.registrarsForDomain(domain) {
 		isCached := m.isCachedRegistrar(domain, r)
 		if m.isRegistrarRateLimited(r) {
+			registrarCooldownSkips.Inc(strings.TrimSpace(r.Label))
 			syncErr.RateLimited = append(syncErr.RateLimited, strings.TrimSpace(r.Label)+"(cooldown)")
 			if isCached {
 				return config.Registrar{}, syncErr
@@ -152,7 +169,9 @@ func (m *Manager) SetNameServersForDomain(ctx context.Context, domain string, na
 			syncErr.Failed = append(syncErr.Failed, fmt.Sprintf("[%s] 等待限速失败: %v", r.Label, err))
 			continue
 		}
+		started := time.Now()
 		err := m.client.SetNameServers(ctx, r, domain, nameServers)
+		observeRegistrarCall(r, "set_nameservers", started, err)
 		if err != nil {
 			if errors.Is(err, ErrDomainNotFound) {
 				syncErr.NotFound = append(syncErr.NotFound, strings.TrimSpace(r.Label))
@@ -244,7 +263,9 @@ func (m *Manager) markRegistrarRateLimited(registrar config.Registrar) {
 	if m.limitedUntil == nil {
 		m.limitedUntil = make(map[string]time.Time)
 	}
-	m.limitedUntil[label] = time.Now().Add(registrarRateLimitCooldown)
+	until := time.Now().Add(registrarRateLimitCooldown)
+	m.limitedUntil[label] = until
+	registrarCooldownUntil.SetTime(until, label)
 }
 
 func (m *Manager) cachedRegistrarLabel(domain string) string {
@@ -291,7 +312,7 @@ func normalizeRegistrarDomainKey(domain string) string {
 }
 
 func (m *Manager) GetExpireAtForDomain(ctx context.Context, domain string) (config.Registrar, time.Time, error) {
-	if len(m.registrars) == 0 {
+	if len(m.currentRegistrars()) == 0 {
 		return config.Registrar{}, time.Time{}, fmt.Errorf("未配置注册商")
 	}
 	domain = strings.TrimSpace(domain)
@@ -315,7 +336,9 @@ func (m *Manager) GetExpireAtForDomain(ctx context.Context, domain string) (conf
 			continue
 		}
 
+		started := time.Now()
 		expAt, err := getter.namecheapGetExpireAt(ctx, *r.Namecheap, domain)
+		observeRegistrarCall(r, "get_expiry", started, err)
 		if err != nil {
 			// 该账号下没有这个域名：继续尝试下一个账号
 			if errors.Is(err, ErrDomainNotFound) {
@@ -336,13 +359,14 @@ func (m *Manager) GetExpireAtForDomain(ctx context.Context, domain string) (conf
 
 // GetNameServersForDomain 尝试从注册商读取 NS。
 func (m *Manager) GetNameServersForDomain(ctx context.Context, domain string) (config.Registrar, []string, error) {
-	if len(m.registrars) == 0 {
+	if len(m.currentRegistrars()) == 0 {
 		return config.Registrar{}, nil, fmt.Errorf("未配置注册商")
 	}
 	syncErr := &SyncError{Domain: strings.TrimSpace(domain)}
 	for _, r := range m.registrarsForDomain(domain) {
 		isCached := m.isCachedRegistrar(domain, r)
 		if m.isRegistrarRateLimited(r) {
+			registrarCooldownSkips.Inc(strings.TrimSpace(r.Label))
 			syncErr.RateLimited = append(syncErr.RateLimited, strings.TrimSpace(r.Label)+"(cooldown)")
 			if isCached {
 				return config.Registrar{}, nil, syncErr
@@ -353,7 +377,9 @@ func (m *Manager) GetNameServersForDomain(ctx context.Context, domain string) (c
 			syncErr.Failed = append(syncErr.Failed, fmt.Sprintf("[%s] 等待限速失败: %v", r.Label, err))
 			continue
 		}
+		started := time.Now()
 		ns, err := m.client.GetNameServers(ctx, r, domain)
+		observeRegistrarCall(r, "get_nameservers", started, err)
 		if err != nil {
 			if errors.Is(err, ErrDomainNotFound) {
 				syncErr.NotFound = append(syncErr.NotFound, strings.TrimSpace(r.Label))
@@ -384,5 +410,8 @@ func (m *Manager) GetNameServersForDomain(ctx context.Context, domain string) (c
 
 // ListDomainsForRegistrar 查询指定注册商的域名列表。
 func (m *Manager) ListDomainsForRegistrar(ctx context.Context, registrar config.Registrar) ([]string, error) {
-	return m.client.ListDomains(ctx, registrar)
+	started := time.Now()
+	domains, err := m.client.ListDomains(ctx, registrar)
+	observeRegistrarCall(registrar, "list_domains", started, err)
+	return domains, err
 }
@@ -0,0 +1,37 @@
+package registrarclient
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"DomainC/config"
+	"DomainC/metrics"
+)
+
+var (
+	registrarRequests = metrics.NewCounterVec("domainc_registrar_requests_total",
+		"注册商 API 调用次数，result 为 ok、not_found、rate_limited 或 error", "registrar", "operation", "result")
+	registrarLatency = metrics.NewHistogramVec("domainc_registrar_request_duration_seconds",
+		"注册商 API 调用耗时", nil, "registrar", "operation")
+	registrarCooldownSkips = metrics.NewCounterVec("domainc_registrar_cooldown_skips_total",
+		"因注册商处于限流冷却期而跳过的调用次数", "registrar")
+	registrarCooldownUntil = metrics.NewGaugeVec("domainc_registrar_cooldown_until_timestamp_seconds",
+		"注册商限流冷却结束时间（Unix 秒），小于当前时间表示不在冷却期", "registrar")
+)
+
+func observeRegistrarCall(registrar config.Registrar, operation string, started time.Time, err error) {
+	label := strings.TrimSpace(registrar.Label)
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrDomainNotFound):
+		result = "not_found"
+	case errors.Is(err, ErrRegistrarRateLimited):
+		result = "rate_limited"
+	default:
+		result = "error"
+	}
+	registrarRequests.Inc(label, operation, result)
+	registrarLatency.ObserveDuration(time.Since(started), label, operation)
+}
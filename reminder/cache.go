@@ -35,11 +35,13 @@ type Record struct {
 	DomainExpiry          string              `json:"domain_expiry,omitempty"`
 	DomainExpiryUpdatedAt string              `json:"domain_expiry_updated_at,omitempty"`
 	DomainLastAlertDate   string              `json:"domain_last_alert_date,omitempty"`
+	Registrar             string              `json:"registrar,omitempty"`
 	Certificates          []CertificateRecord `json:"certificates,omitempty"`
 	Deleted               bool                `json:"deleted,omitempty"`
 	PendingRefresh        bool                `json:"pending_refresh,omitempty"`
 	LastRefreshAt         string              `json:"last_refresh_at,omitempty"`
 	LastRefreshError      string              `json:"last_refresh_error,omitempty"`
+	Tags                  []string            `json:"tags,omitempty"`
 	CreatedAt             string              `json:"created_at,omitempty"`
 	UpdatedAt             string              `json:"updated_at,omitempty"`
 }
@@ -266,6 +268,9 @@ func mergeRecord(dst *Record, src Record) {
 			dst.DomainLastAlertDate = src.DomainLastAlertDate
 		}
 	}
+	if dst.Registrar == "" {
+		dst.Registrar = src.Registrar
+	}
 	dst.Certificates = mergeCertificates(dst.Certificates, src.Certificates)
 	dst.Deleted = dst.Deleted && src.Deleted
 	dst.PendingRefresh = dst.PendingRefresh || src.PendingRefresh
@@ -283,6 +288,7 @@ func mergeRecord(dst *Record, src Record) {
 	if isNewerTime(src.UpdatedAt, dst.UpdatedAt) {
 		dst.UpdatedAt = src.UpdatedAt
 	}
+	dst.Tags = mergeTags(dst.Tags, src.Tags, nil)
 	for _, source := range src.Sources {
 		addSource(dst, source)
 	}
@@ -713,6 +719,84 @@ func (s *Store) UpdateRecord(ref DomainRef, fn func(*Record)) error {
 	return s.saveLocked(c)
 }
 
+// SetDomainTags 给资产缓存中的域名添加/移除 Zone 标签，返回更新后的记录；域名不在缓存中时返回错误。
+func (s *Store) SetDomainTags(domain string, add []string, remove []string) (Record, error) {
+	domain = NormalizeDomain(domain)
+	if domain == "" {
+		return Record{}, fmt.Errorf("域名为空")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.loadLocked()
+	if err != nil {
+		return Record{}, err
+	}
+	rec := c.Records[DomainCacheKey(domain)]
+	if rec == nil || rec.Deleted {
+		return Record{}, fmt.Errorf("资产缓存中没有域名 %s", domain)
+	}
+	rec.Tags = mergeTags(rec.Tags, add, remove)
+	rec.UpdatedAt = time.Now().Format(time.RFC3339)
+	if err := s.saveLocked(c); err != nil {
+		return Record{}, err
+	}
+	return *rec, nil
+}
+
+// ListByTag 返回带有指定 Zone 标签的有效记录。
+func (s *Store) ListByTag(tag string) ([]Record, error) {
+	records, err := s.ListActive()
+	if err != nil {
+		return nil, err
+	}
+	var out []Record
+	for _, rec := range records {
+		if RecordHasTag(rec, tag) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// RecordHasTag 判断记录是否带有指定标签（忽略大小写）。
+func RecordHasTag(rec Record, tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range rec.Tags {
+		if normalizeTag(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// mergeTags 合并标签并去重（统一小写），再去掉 remove 中的标签，结果排序。
+func mergeTags(current []string, add []string, remove []string) []string {
+	set := make(map[string]bool, len(current)+len(add))
+	for _, list := range [][]string{current, add} {
+		for _, tag := range list {
+			if tag = normalizeTag(tag); tag != "" {
+				set[tag] = true
+			}
+		}
+	}
+	for _, tag := range remove {
+		delete(set, normalizeTag(tag))
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(set))
+	for tag := range set {
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func (s *Store) GetRecord(domain string) (Record, bool, error) {
 	domain = NormalizeDomain(domain)
 	if domain == "" {
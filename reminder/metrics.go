@@ -0,0 +1,78 @@
+package reminder
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"DomainC/metrics"
+)
+
+var (
+	refreshQueueDepth = metrics.NewGaugeVec("domainc_reminder_refresh_queue_depth",
+		"等待刷新到期信息的域名数量")
+	refreshResults = metrics.NewCounterVec("domainc_reminder_refresh_total",
+		"资产刷新次数，result 为 success 或 error", "result")
+	assetExpiry = metrics.NewGaugeVec("domainc_assets",
+		"资产缓存中按到期区间统计的数量，kind 为 domain 或 certificate", "kind", "bucket")
+)
+
+// expiryBuckets 按剩余天数上限升序排列，超过最后一档归入 over_90d。
+var expiryBuckets = []struct {
+	name    string
+	maxDays int
+}{
+	{"expired", 0},
+	{"within_7d", 7},
+	{"within_30d", 30},
+	{"within_90d", 90},
+}
+
+func expiryBucket(t time.Time, ok bool, now time.Time) string {
+	if !ok {
+		return "unknown"
+	}
+	days := daysUntil(t, now)
+	for _, b := range expiryBuckets {
+		if days <= b.maxDays {
+			return b.name
+		}
+	}
+	return "over_90d"
+}
+
+// expiryBucketCounts 统计域名和证书在各到期区间的数量，键为 kind 与 bucket。
+func expiryBucketCounts(records []Record, now time.Time) map[[2]string]int {
+	counts := make(map[[2]string]int)
+	for _, rec := range records {
+		t, ok := parseDate(rec.DomainExpiry)
+		counts[[2]string{"domain", expiryBucket(t, ok, now)}]++
+		for _, cert := range rec.Certificates {
+			if strings.TrimSpace(cert.NotAfter) == "" {
+				continue
+			}
+			t, ok := parseTimeValue(cert.NotAfter)
+			counts[[2]string{"certificate", expiryBucket(t, ok, now)}]++
+		}
+	}
+	return counts
+}
+
+// RegisterMetrics 在每次抓取 /metrics 时采集刷新队列深度和资产到期分布。
+func (r *Runtime) RegisterMetrics() {
+	if r == nil {
+		return
+	}
+	metrics.Default().OnCollect(func() {
+		refreshQueueDepth.Set(float64(len(r.jobs)))
+		records, err := r.store.ListActive()
+		if err != nil {
+			log.Printf("[reminder] metrics_list_failed err=%v", err)
+			return
+		}
+		assetExpiry.Reset()
+		for key, count := range expiryBucketCounts(records, time.Now()) {
+			assetExpiry.Set(float64(count), key[0], key[1])
+		}
+	})
+}
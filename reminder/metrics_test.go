@@ -0,0 +1,33 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryBucketCountsGroupsDomainsAndCertificates(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Domain: "a.com", DomainExpiry: "2025-12-20", Certificates: []CertificateRecord{{NotAfter: "2026-01-05T00:00:00Z"}}},
+		{Domain: "b.com", DomainExpiry: "2026-01-20", Certificates: []CertificateRecord{{NotAfter: ""}, {NotAfter: "2026-03-01T00:00:00Z"}}},
+		{Domain: "c.com", DomainExpiry: "2027-01-01"},
+		{Domain: "d.com"},
+	}
+	counts := expiryBucketCounts(records, now)
+	want := map[[2]string]int{
+		{"domain", "expired"}:         1,
+		{"domain", "within_30d"}:      1,
+		{"domain", "over_90d"}:        1,
+		{"domain", "unknown"}:         1,
+		{"certificate", "within_7d"}:  1,
+		{"certificate", "within_90d"}: 1,
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("unexpected buckets: %+v", counts)
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Fatalf("bucket %v = %d, want %d (all=%+v)", key, counts[key], n, counts)
+		}
+	}
+}
@@ -36,6 +36,7 @@ type RuntimeOptions struct {
 type Runtime struct {
 	store        *Store
 	cfClient     cfclient.Client
+	accountsMu   sync.RWMutex
 	accounts     []config.CF
 	registrar    RegistrarExpiry
 	whois        WhoisClient
@@ -125,21 +126,46 @@ func (r *Runtime) SyncCloudflareDomainsOnce(ctx context.Context) StartupSyncSumm
 	return summary
 }
 
+// SetAccounts 在配置热加载后替换 Cloudflare 账号列表，下一次同步开始使用。
+func (r *Runtime) SetAccounts(accounts []config.CF) {
+	if r == nil {
+		return
+	}
+	r.accountsMu.Lock()
+	r.accounts = append([]config.CF(nil), accounts...)
+	r.accountsMu.Unlock()
+}
+
+func (r *Runtime) currentAccounts() []config.CF {
+	r.accountsMu.RLock()
+	defer r.accountsMu.RUnlock()
+	return r.accounts
+}
+
+// ResyncCloudflareDomains 与启动同步相同，但可以重复执行，用于热加载新增账号后补齐资产缓存。
+func (r *Runtime) ResyncCloudflareDomains(ctx context.Context) StartupSyncSummary {
+	if r == nil || r.store == nil || r.cfClient == nil {
+		return StartupSyncSummary{}
+	}
+	return r.syncCloudflareDomains(ctx)
+}
+
 func (r *Runtime) syncCloudflareDomains(ctx context.Context) StartupSyncSummary {
-	summary := StartupSyncSummary{ConfiguredAccounts: len(r.accounts)}
-	if len(r.accounts) == 0 {
+	accounts := r.currentAccounts()
+	summary := StartupSyncSummary{ConfiguredAccounts: len(accounts)}
+	if len(accounts) == 0 {
 		log.Printf("[reminder] startup_sync_skip reason=no_cloudflare_accounts")
 		return summary
 	}
 
-	configuredSources := make([]string, 0, len(r.accounts))
-	for _, acc := range r.accounts {
+	configuredSources := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
 		configuredSources = append(configuredSources, accountCacheLabel(acc))
 	}
 
 	changes := make([]DomainChange, 0)
-	successfulSources := make([]string, 0, len(r.accounts))
-	for i, acc := range r.accounts {
+	successfulSources := make([]string, 0, len(accounts))
+	for i, acc := range accounts {
 		label := accountCacheLabel(acc)
 		if strings.TrimSpace(acc.APIToken) == "" {
 			errText := fmt.Sprintf("Cloudflare 账号 %s 缺少 apiToken，跳过启动同步", label)
@@ -185,7 +211,7 @@ func (r *Runtime) syncCloudflareDomains(ctx context.Context) StartupSyncSummary
 		}
 		log.Printf("[reminder] startup_sync_account_done source=%s domains=%d", label, len(domains))
 
-		if r.refreshDelay > 0 && i < len(r.accounts)-1 {
+		if r.refreshDelay > 0 && i < len(accounts)-1 {
 			select {
 			case <-ctx.Done():
 				summary.Errors = append(summary.Errors, ctx.Err().Error())
@@ -268,8 +294,11 @@ func (r *Runtime) Run(ctx context.Context) {
 				}
 			}
 			if err := r.RefreshDomain(ctx, ref); err != nil {
+				refreshResults.Inc("error")
 				log.Printf("[reminder] refresh_failed domain=%s source=%s err=%v", ref.Domain, ref.Source, err)
+				continue
 			}
+			refreshResults.Inc("success")
 		}
 	}
 }
@@ -408,9 +437,11 @@ func (r *Runtime) RefreshDomain(ctx context.Context, ref DomainRef) error {
 
 	var errorsList []string
 	var domainExpiry string
+	registrarLabel := ""
 	if lookupDomainExpiry {
-		if t, ok, err := r.lookupDomainExpiry(ctx, ref.Domain); err == nil && ok {
+		if t, label, ok, err := r.lookupDomainExpiry(ctx, ref.Domain); err == nil && ok {
 			domainExpiry = dateString(t)
+			registrarLabel = label
 		} else if err != nil {
 			errorsList = append(errorsList, err.Error())
 		}
@@ -437,6 +468,9 @@ func (r *Runtime) RefreshDomain(ctx context.Context, ref DomainRef) error {
 			rec.DomainExpiry = domainExpiry
 			rec.DomainExpiryUpdatedAt = now
 		}
+		if registrarLabel != "" {
+			rec.Registrar = registrarLabel
+		}
 		if lookupCertificates {
 			// 从提醒缓存中清理旧版本通过平台 API 写入的 Origin CA 证书记录；
 			// 后续日报/提醒以当前 HTTPS 访问证书为准。
@@ -455,37 +489,38 @@ func (r *Runtime) RefreshDomain(ctx context.Context, ref DomainRef) error {
 	})
 }
 
-func (r *Runtime) lookupDomainExpiry(ctx context.Context, domain string) (time.Time, bool, error) {
+// lookupDomainExpiry 优先查询注册商（同时返回命中的注册商 label），失败时回退 whois。
+func (r *Runtime) lookupDomainExpiry(ctx context.Context, domain string) (time.Time, string, bool, error) {
 	if r.registrar != nil {
 		lookupCtx, cancel := context.WithTimeout(ctx, r.queryTimeout)
-		_, t, err := r.registrar.GetExpireAtForDomain(lookupCtx, domain)
+		registrar, t, err := r.registrar.GetExpireAtForDomain(lookupCtx, domain)
 		cancel()
 		if err == nil && !t.IsZero() {
-			return t, true, nil
+			return t, strings.TrimSpace(registrar.Label), true, nil
 		}
 	}
 	if r.whois == nil {
-		return time.Time{}, false, nil
+		return time.Time{}, "", false, nil
 	}
 	lookupCtx, cancel := context.WithTimeout(ctx, r.queryTimeout)
 	result, err := r.whois.Query(lookupCtx, domain)
 	cancel()
 	if err != nil {
-		return time.Time{}, false, fmt.Errorf("域名到期查询失败: %w", err)
+		return time.Time{}, "", false, fmt.Errorf("域名到期查询失败: %w", err)
 	}
 	result = strings.TrimSpace(result)
 	if t, err := time.Parse("2006-01-02", result); err == nil {
-		return t, true, nil
+		return t, "", true, nil
 	}
 	expiry, ok := tools.ExtractExpiry(result)
 	if !ok {
-		return time.Time{}, false, fmt.Errorf("域名到期解析失败")
+		return time.Time{}, "", false, fmt.Errorf("域名到期解析失败")
 	}
 	t, err := time.Parse("2006-01-02", strings.TrimSpace(expiry))
 	if err != nil {
-		return time.Time{}, false, fmt.Errorf("域名到期日期解析失败: %w", err)
+		return time.Time{}, "", false, fmt.Errorf("域名到期日期解析失败: %w", err)
 	}
-	return t, true, nil
+	return t, "", true, nil
 }
 
 func (r *Runtime) lookupServedCertificate(ctx context.Context, domain string) (CertificateRecord, error) {
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 是解析后的 5 段 cron 表达式（分 时 日 月 周），在 Location 时区内计算。
+type Schedule struct {
+	minute, hour, dom, month, dow bitset
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+type bitset uint64
+
+func (b bitset) has(v int) bool { return b&(1<<uint(v)) != 0 }
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析 cron 表达式，支持 *、列表、范围、步长（如 */15、1-5、0,30）以及 @daily 等简写。
+// 日和周同时受限时按标准 cron 语义取并集。loc 为空时使用本地时区。
+func ParseCron(spec string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	expr := strings.TrimSpace(spec)
+	if replaced, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = replaced
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式 %q 需要 5 段（分 时 日 月 周），实际 %d 段", spec, len(fields))
+	}
+	s := &Schedule{loc: loc}
+	var err error
+	if s.minute, _, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron 表达式 %q 分钟段无效: %w", spec, err)
+	}
+	if s.hour, _, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron 表达式 %q 小时段无效: %w", spec, err)
+	}
+	if s.dom, s.domAny, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron 表达式 %q 日期段无效: %w", spec, err)
+	}
+	if s.month, _, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron 表达式 %q 月份段无效: %w", spec, err)
+	}
+	if s.dow, s.dowAny, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron 表达式 %q 星期段无效: %w", spec, err)
+	}
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (bitset, bool, error) {
+	var out bitset
+	// 与标准 cron 一致，以 * 开头（包括 */N）的日或星期段视为不受限，此时两者按“且”组合，否则按“或”。
+	wildcard := strings.HasPrefix(field, "*")
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("步长 %q 无效", part)
+			}
+			rangePart, step = part[:idx], n
+		}
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, false, fmt.Errorf("范围 %q 无效", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, false, fmt.Errorf("取值 %q 无效", rangePart)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, false, fmt.Errorf("%q 超出范围 %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			out |= 1 << uint(v)
+		}
+	}
+	return out, wildcard, nil
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom.has(t.Day())
+	dowOK := s.dow.has(int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Location 返回计算所用的时区。
+func (s *Schedule) Location() *time.Location { return s.loc }
+
+// Next 返回 after 之后（不含）的下一次触发时间；5 年内无匹配时返回零值。
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !s.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.hour.has(t.Hour()) {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+			if !next.After(t) {
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if !s.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
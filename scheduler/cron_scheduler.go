@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/metrics"
+)
+
+// Job 描述一个按 cron 表达式执行的定时任务。
+type Job struct {
+	Name string
+	Spec string
+	// Location 为空时使用本地时区。
+	Location *time.Location
+	// CatchUp 大于 0 时，启动时若最近一次应执行时间在该窗口内且晚于上次成功时间，会立即补跑一次；
+	// 状态文件不存在时视为刚执行过，不补跑。
+	CatchUp time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// JobState 是持久化到状态文件中的单个任务执行记录。
+type JobState struct {
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastRun     time.Time `json:"last_run,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+type cronJob struct {
+	Job
+	schedule *Schedule
+}
+
+// CronScheduler 按 cron 表达式调度任务，并把每个任务的最近成功时间写入 statePath，
+// 重启后据此判断是否需要补跑错过的执行。
+type CronScheduler struct {
+	statePath string
+	now       func() time.Time
+
+	mu    sync.Mutex
+	jobs  []*cronJob
+	state map[string]JobState
+}
+
+func NewCronScheduler(statePath string) *CronScheduler {
+	return &CronScheduler{statePath: statePath, now: time.Now}
+}
+
+// Add 注册任务，cron 表达式无效或任务名重复时返回错误。
+func (s *CronScheduler) Add(job Job) error {
+	job.Name = strings.TrimSpace(job.Name)
+	if job.Name == "" {
+		return errors.New("定时任务名称不能为空")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("定时任务 %s 未指定执行函数", job.Name)
+	}
+	schedule, err := ParseCron(job.Spec, job.Location)
+	if err != nil {
+		return fmt.Errorf("定时任务 %s: %w", job.Name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("定时任务 %s 重复注册", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &cronJob{Job: job, schedule: schedule})
+	return nil
+}
+
+// Start 加载状态文件并为每个任务启动调度协程，ctx 结束后停止。
+func (s *CronScheduler) Start(ctx context.Context) {
+	if err := s.loadState(); err != nil {
+		log.Printf("加载定时任务状态失败，按无历史记录处理: %v", err)
+	}
+	s.mu.Lock()
+	jobs := append([]*cronJob(nil), s.jobs...)
+	s.mu.Unlock()
+	for _, job := range jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+// State 返回任务的持久化执行记录。
+func (s *CronScheduler) State(name string) (JobState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.state[name]
+	return st, ok
+}
+
+func (s *CronScheduler) loop(ctx context.Context, job *cronJob) {
+	if missed, ok := s.missedRun(job, s.now()); ok {
+		log.Printf("定时任务 %s 错过了 %s 的执行，启动后补跑", job.Name, missed.Format("2006-01-02 15:04 MST"))
+		s.execute(ctx, job)
+	}
+	for {
+		next := job.schedule.Next(s.now())
+		if next.IsZero() {
+			log.Printf("定时任务 %s 的 cron 表达式 %q 没有可执行时间，停止调度", job.Name, job.Spec)
+			return
+		}
+		wait := time.Until(next)
+		log.Printf("定时任务 %s 下次执行: %s（%v 后）", job.Name, next.Format("2006-01-02 15:04 MST"), wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.execute(ctx, job)
+	}
+}
+
+// missedRun 返回补跑窗口内最近一次应执行但晚于上次成功时间的触发点。
+func (s *CronScheduler) missedRun(job *cronJob, now time.Time) (time.Time, bool) {
+	if job.CatchUp <= 0 {
+		return time.Time{}, false
+	}
+	s.mu.Lock()
+	last := s.state[job.Name].LastSuccess
+	s.mu.Unlock()
+
+	var missed time.Time
+	for t := job.schedule.Next(now.Add(-job.CatchUp).Add(-time.Minute)); !t.IsZero() && !t.After(now); t = job.schedule.Next(t) {
+		missed = t
+	}
+	if missed.IsZero() || !missed.After(last) {
+		return time.Time{}, false
+	}
+	return missed, true
+}
+
+func (s *CronScheduler) execute(ctx context.Context, job *cronJob) {
+	if ctx.Err() != nil {
+		return
+	}
+	log.Printf("开始定时任务 %s", job.Name)
+	err := job.Run(ctx)
+	metrics.RecordJobRun(job.Name, err)
+	if err != nil {
+		log.Printf("定时任务 %s 失败: %v", job.Name, err)
+	}
+
+	s.mu.Lock()
+	if s.state == nil {
+		s.state = make(map[string]JobState)
+	}
+	st := s.state[job.Name]
+	st.LastRun = s.now().UTC()
+	st.LastError = ""
+	if err != nil {
+		st.LastError = err.Error()
+	} else {
+		st.LastSuccess = st.LastRun
+	}
+	s.state[job.Name] = st
+	saveErr := s.saveStateLocked()
+	s.mu.Unlock()
+	if saveErr != nil {
+		log.Printf("保存定时任务状态失败: %v", saveErr)
+	}
+}
+
+type stateFile struct {
+	Jobs map[string]JobState `json:"jobs"`
+}
+
+func (s *CronScheduler) loadState() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = make(map[string]JobState)
+	if strings.TrimSpace(s.statePath) == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s.seedStateLocked()
+		}
+		return fmt.Errorf("读取状态文件 %s 失败: %w", s.statePath, err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析状态文件 %s 失败: %w", s.statePath, err)
+	}
+	for name, st := range file.Jobs {
+		s.state[name] = st
+	}
+	return nil
+}
+
+// seedStateLocked 在首次部署（状态文件不存在）时把当前时间记为各任务的上次成功时间，
+// 避免旧版本刚发过的日报在启动补跑时重复发送。
+func (s *CronScheduler) seedStateLocked() error {
+	now := s.now().UTC()
+	for _, job := range s.jobs {
+		s.state[job.Name] = JobState{LastSuccess: now}
+	}
+	if len(s.jobs) == 0 {
+		return nil
+	}
+	log.Printf("定时任务状态文件 %s 不存在，按首次部署处理，本次启动不补跑", s.statePath)
+	return s.saveStateLocked()
+}
+
+// saveStateLocked 先写临时文件再重命名，避免进程中断时留下半截文件。
+func (s *CronScheduler) saveStateLocked() error {
+	if strings.TrimSpace(s.statePath) == "" {
+		return nil
+	}
+	if dir := filepath.Dir(s.statePath); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建状态目录 %s 失败: %w", dir, err)
+		}
+	}
+	data, err := json.MarshalIndent(stateFile{Jobs: s.state}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态文件 %s 失败: %w", s.statePath, err)
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入状态文件 %s 失败: %w", s.statePath, err)
+	}
+	if err := os.Rename(tmp, s.statePath); err != nil {
+		return fmt.Errorf("替换状态文件 %s 失败: %w", s.statePath, err)
+	}
+	return nil
+}
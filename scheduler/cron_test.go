@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCronNextHonoursTimezoneAndFields(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	s, err := ParseCron("30 15 * * 1-5", shanghai)
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	// 2026-01-02 是周五，北京时间 15:30 之后的下一次应为下周一。
+	after := time.Date(2026, 1, 2, 7, 31, 0, 0, time.UTC)
+	want := time.Date(2026, 1, 5, 15, 30, 0, 0, shanghai)
+	if got := s.Next(after); !got.Equal(want) {
+		t.Fatalf("Next = %s, want %s", got, want)
+	}
+
+	every, err := ParseCron("*/20 * * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCron step: %v", err)
+	}
+	if got := every.Next(time.Date(2026, 1, 1, 10, 41, 5, 0, time.UTC)); !got.Equal(time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("step Next = %s", got)
+	}
+
+	// 以 * 开头的日段（*/2）与星期段按“且”组合（同标准 cron）：1 月 6 日是周二但为双日，下一次是 1 月 13 日。
+	tuesdays, err := ParseCron("0 0 */2 * 2", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCron dom step: %v", err)
+	}
+	if got := tuesdays.Next(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)); !got.Equal(time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("dom step Next = %s", got)
+	}
+
+	for _, bad := range []string{"", "61 * * * *", "* * * *", "5-1 * * * *", "*/0 * * * *"} {
+		if _, err := ParseCron(bad, time.UTC); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCronSchedulerCatchesUpMissedRunOnceAndPersists(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "scheduler_state.json")
+	now := time.Date(2026, 3, 10, 15, 1, 0, 0, time.UTC)
+	runs := 0
+	spec := Job{Name: "daily_report", Spec: "0 15 * * *", Location: time.UTC, CatchUp: time.Hour, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}}
+
+	start := func() *CronScheduler {
+		t.Helper()
+		s := NewCronScheduler(statePath)
+		s.now = func() time.Time { return now }
+		if err := s.Add(spec); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if err := s.loadState(); err != nil {
+			t.Fatalf("loadState: %v", err)
+		}
+		return s
+	}
+
+	// 首次部署没有状态文件：视为刚执行过，不补跑旧版本已发送的 15:00 日报。
+	s := start()
+	if _, ok := s.missedRun(s.jobs[0], now); ok {
+		t.Fatalf("first deploy without state must not catch up")
+	}
+	if st, ok := s.State("daily_report"); !ok || !st.LastSuccess.Equal(now) {
+		t.Fatalf("first deploy should seed last success: %+v %v", st, ok)
+	}
+
+	// 次日 15:00 停机错过，重启后补跑一次。
+	now = now.Add(24 * time.Hour)
+	s = start()
+	missed, ok := s.missedRun(s.jobs[0], now)
+	if !ok || !missed.Equal(time.Date(2026, 3, 11, 15, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected missed 15:00 run, got %s %v", missed, ok)
+	}
+	s.execute(context.Background(), s.jobs[0])
+	if runs != 1 {
+		t.Fatalf("expected one run, got %d", runs)
+	}
+
+	// 重启后从状态文件得知 15:00 已执行，不再补跑。
+	restarted := start()
+	if st, ok := restarted.State("daily_report"); !ok || !st.LastSuccess.Equal(now) {
+		t.Fatalf("state not persisted: %+v %v", st, ok)
+	}
+	if _, ok := restarted.missedRun(restarted.jobs[0], now); ok {
+		t.Fatalf("run already recorded must not be caught up again")
+	}
+
+	// 超出补跑窗口的错过执行不补跑。
+	late := now.Add(3 * time.Hour).Add(24 * time.Hour)
+	if _, ok := restarted.missedRun(restarted.jobs[0], late); ok {
+		t.Fatalf("missed run outside catch-up window must be skipped")
+	}
+}
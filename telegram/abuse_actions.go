@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+// telegramCallbackDataLimit 是 Telegram 回调数据的字节上限。
+const telegramCallbackDataLimit = 64
+
+// AbuseQuickActionButtons 生成滥用报告第 index 条的清缓存、暂停 Zone、改指向按钮；
+// 回调数据直接携带账号和域名，超过 Telegram 长度限制的按钮会被省略。
+func AbuseQuickActionButtons(index int, account, zone, host string) []Button {
+	candidates := []Button{
+		{Text: fmt.Sprintf("🧹 %d 清缓存", index), CallbackData: fmt.Sprintf("abuse_purge|%s|%s", account, zone)},
+		{Text: fmt.Sprintf("⏸ %d 暂停", index), CallbackData: fmt.Sprintf("abuse_pause|%s|%s", account, zone)},
+		{Text: fmt.Sprintf("↪️ %d 改指向", index), CallbackData: fmt.Sprintf("abuse_repoint|%s|%s", account, host)},
+	}
+	var buttons []Button
+	for _, button := range candidates {
+		if len(button.CallbackData) <= telegramCallbackDataLimit {
+			buttons = append(buttons, button)
+		}
+	}
+	return buttons
+}
+
+// BeginAbuseRepoint 把 host 的现有解析记录作为已选中的 setdns 会话，并等待 userID 发送新的解析目标。
+func BeginAbuseRepoint(ctx context.Context, client cfclient.Client, account config.CF, host string, userID int64) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	zone, err := ResolveAccountZone(ctx, client, account, host)
+	if err != nil {
+		return "", err
+	}
+	records, err := client.ListDNSRecords(ctx, account, zone.Name)
+	if err != nil {
+		return "", err
+	}
+	var candidates []SetDNSRecordTarget
+	selected := make(map[string]bool)
+	for _, record := range records {
+		if record.ID == "" || strings.TrimSuffix(strings.ToLower(record.Name), ".") != host {
+			continue
+		}
+		var proxied *bool
+		if record.Proxied != nil {
+			v := *record.Proxied
+			proxied = &v
+		}
+		target := SetDNSRecordTarget{
+			Key:      zone.Name + ":" + record.ID,
+			ZoneName: zone.Name,
+			RecordID: record.ID,
+			Type:     record.Type,
+			Name:     record.Name,
+			Content:  record.Content,
+			TTL:      record.TTL,
+			Proxied:  proxied,
+			Matches:  []string{host},
+		}
+		candidates = append(candidates, target)
+		selected[target.Key] = true
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%s 没有可修改的解析记录", host)
+	}
+	sessionID := SetSetDNSSelection(SetDNSSelection{
+		AccountLabel: account.Label,
+		Keywords:     []string{host},
+		Candidates:   candidates,
+		Selected:     selected,
+	})
+	SetPendingSetDNSInput(userID, SetDNSInputRequest{
+		AccountLabel: account.Label,
+		SessionID:    sessionID,
+		Stage:        SetDNSInputNewTarget,
+	})
+	return BuildSetDNSNewTargetPrompt(account.Label, len(candidates)), nil
+}
+
+// ResolveAccountZone 在指定账号下按 a.b.example.com -> b.example.com -> example.com 的顺序查找 host 所属的 Zone。
+func ResolveAccountZone(ctx context.Context, client cfclient.Client, account config.CF, host string) (cfclient.ZoneDetail, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	labels := strings.Split(host, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		zone, err := client.GetZoneDetails(ctx, account, strings.Join(labels[i:], "."))
+		if err == nil {
+			return zone, nil
+		}
+		if !errors.Is(err, cfclient.ErrZoneNotFound) {
+			return cfclient.ZoneDetail{}, err
+		}
+	}
+	return cfclient.ZoneDetail{}, fmt.Errorf("%w: %s", cfclient.ErrZoneNotFound, host)
+}
@@ -0,0 +1,85 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeZoneLookup struct {
+	cfclient.Client
+	zones   map[string]bool
+	err     error
+	queried []string
+}
+
+func (f *fakeZoneLookup) GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	f.queried = append(f.queried, domain)
+	if f.err != nil {
+		return cfclient.ZoneDetail{}, f.err
+	}
+	if !f.zones[domain] {
+		return cfclient.ZoneDetail{}, cfclient.ErrZoneNotFound
+	}
+	return cfclient.ZoneDetail{ID: "zone-" + domain, Name: domain}, nil
+}
+
+func TestAbuseQuickActionButtonsDropsOverlongCallbackData(t *testing.T) {
+	buttons := AbuseQuickActionButtons(2, "main", "example.com", "promo.example.com")
+	if len(buttons) != 3 || buttons[0].CallbackData != "abuse_purge|main|example.com" || buttons[2].CallbackData != "abuse_repoint|main|promo.example.com" {
+		t.Fatalf("unexpected buttons: %+v", buttons)
+	}
+
+	// abuse_repoint|main|<host> 正好 64 字节时保留，多 1 字节时省略；Zone 按钮不受影响。
+	prefix := len("abuse_repoint|main|")
+	exact := strings.Repeat("a", telegramCallbackDataLimit-prefix-len(".example.com")) + ".example.com"
+	if buttons := AbuseQuickActionButtons(1, "main", "example.com", exact); len(buttons) != 3 || len(buttons[2].CallbackData) != telegramCallbackDataLimit {
+		t.Fatalf("64-byte callback data should be kept: %+v", buttons)
+	}
+	buttons = AbuseQuickActionButtons(1, "main", "example.com", "b"+exact)
+	if len(buttons) != 2 || strings.HasPrefix(buttons[1].CallbackData, "abuse_repoint") {
+		t.Fatalf("65-byte callback data should be dropped: %+v", buttons)
+	}
+
+	longZone := strings.Repeat("z", 60) + ".com"
+	if buttons := AbuseQuickActionButtons(1, "main", longZone, longZone); len(buttons) != 0 {
+		t.Fatalf("all overlong buttons should be dropped: %+v", buttons)
+	}
+}
+
+func TestResolveAccountZone(t *testing.T) {
+	client := &fakeZoneLookup{zones: map[string]bool{"example.com": true, "shop.example.net": true}}
+	account := config.CF{Label: "main"}
+
+	zone, err := ResolveAccountZone(context.Background(), client, account, " A.B.Example.COM. ")
+	if err != nil || zone.Name != "example.com" {
+		t.Fatalf("expected example.com, got %+v %v", zone, err)
+	}
+	if got := strings.Join(client.queried, ","); got != "a.b.example.com,b.example.com,example.com" {
+		t.Fatalf("unexpected lookup order: %s", got)
+	}
+
+	// 子域本身是 Zone 时优先返回更具体的 Zone。
+	if zone, err := ResolveAccountZone(context.Background(), client, account, "www.shop.example.net"); err != nil || zone.Name != "shop.example.net" {
+		t.Fatalf("expected shop.example.net, got %+v %v", zone, err)
+	}
+
+	// 不查询顶级后缀本身。
+	client.queried = nil
+	if _, err := ResolveAccountZone(context.Background(), client, account, "missing.org"); !errors.Is(err, cfclient.ErrZoneNotFound) {
+		t.Fatalf("expected ErrZoneNotFound, got %v", err)
+	}
+	if got := strings.Join(client.queried, ","); got != "missing.org" {
+		t.Fatalf("unexpected lookups for missing zone: %s", got)
+	}
+
+	// 非“未找到”错误直接返回，不再继续向上查找。
+	failing := &fakeZoneLookup{err: errors.New("api unavailable")}
+	if _, err := ResolveAccountZone(context.Background(), failing, account, "a.example.com"); err == nil || errors.Is(err, cfclient.ErrZoneNotFound) || len(failing.queried) != 1 {
+		t.Fatalf("expected api error after one lookup, got %v (%v)", err, failing.queried)
+	}
+}
@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+// AbuseReportQuerier 是 /abuse 命令依赖的滥用报告服务，生产环境为 app.AbuseReportService。
+type AbuseReportQuerier interface {
+	ScanAbuseReports(ctx context.Context, accounts []config.CF) (string, error)
+	ListAbuseReportHistory(query string, days int) (string, error)
+	ShowAbuseReport(id string) (string, error)
+}
+
+func (h *CommandHandler) handleAbuseCommand(args []string) {
+	if h.AbuseReports == nil {
+		h.sendText("滥用报告扫描未启用（abuseReport.enabled=false），无法使用 /abuse。")
+		return
+	}
+	if len(args) == 0 {
+		h.sendText(abuseUsage())
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "scan":
+		var accounts []config.CF
+		if len(args) >= 2 {
+			targets, err := h.resolveAccountTargets(args[1])
+			if err != nil {
+				h.sendText(err.Error())
+				return
+			}
+			accounts = TargetAccounts(targets)
+		}
+		scope := "全部账号"
+		if len(accounts) > 0 {
+			scope = fmt.Sprintf("%d 个账号", len(accounts))
+		}
+		h.sendText(fmt.Sprintf("正在扫描%s的 Cloudflare 滥用报告，新报告会按每日扫描的格式推送...", scope))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		summary, err := h.AbuseReports.ScanAbuseReports(ctx, accounts)
+		if err != nil {
+			h.sendText(fmt.Sprintf("滥用报告扫描失败: %v", err))
+			return
+		}
+		h.sendText(summary)
+	case "list", "ls":
+		query, days := parseAbuseListArgs(args[1:])
+		text, err := h.AbuseReports.ListAbuseReportHistory(query, days)
+		if err != nil {
+			h.sendText(fmt.Sprintf("读取滥用报告缓存失败: %v", err))
+			return
+		}
+		h.sendText(text)
+	case "show":
+		if len(args) < 2 {
+			h.sendText(abuseUsage())
+			return
+		}
+		text, err := h.AbuseReports.ShowAbuseReport(args[1])
+		if err != nil {
+			h.sendText(err.Error())
+			return
+		}
+		h.sendText(text)
+	default:
+		h.sendText(abuseUsage())
+	}
+}
+
+// parseAbuseListArgs 解析 /abuse list 的参数：纯数字为天数，其余为账号标签或域名，顺序不限。
+func parseAbuseListArgs(args []string) (string, int) {
+	var query string
+	var days int
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			days = n
+			continue
+		}
+		if query == "" {
+			query = arg
+		}
+	}
+	return query, days
+}
+
+func abuseUsage() string {
+	return "用法:\n/abuse scan [账号选择器] —— 立即扫描滥用报告，新报告照常推送并计入去重\n/abuse list [账号|域名] [天数] —— 查询本地缓存中的报告（含已通知的），默认最近 30 天\n/abuse show <报告ID> —— 查看完整报告、状态历史和 Cloudflare 原始字段"
+}
@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/reminder"
+)
+
+// AccountTarget 是选择器命中的账号；Domains 为空表示账号下全部域名，
+// 否则只处理这些域名（来自资产缓存中的 Zone 标签）。
+type AccountTarget struct {
+	Account config.CF
+	Domains []string
+}
+
+// ZoneTagLookup 按 Zone 标签查询资产缓存，*reminder.Store 实现了该接口。
+type ZoneTagLookup interface {
+	ListByTag(tag string) ([]reminder.Record, error)
+}
+
+// ResolveAccountTargets 解析 all、账号标签、group:、tag: 组成的选择器。
+// tag: 同时匹配账号标签和资产缓存中的 Zone 标签，后者只选中带标签的域名。
+func ResolveAccountTargets(accounts []config.CF, raw string, zones ZoneTagLookup) ([]AccountTarget, error) {
+	sel, err := config.ParseAccountSelector(raw)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := sel.Match(accounts)
+	if err != nil {
+		return nil, err
+	}
+	whole := make(map[string]bool, len(matched))
+	for _, acc := range matched {
+		whole[strings.ToLower(acc.Label)] = true
+	}
+
+	partial := make(map[string]map[string]bool)
+	if zones != nil {
+		for _, tag := range sel.Tags {
+			records, err := zones.ListByTag(tag)
+			if err != nil {
+				return nil, fmt.Errorf("读取资产缓存标签失败: %w", err)
+			}
+			for _, rec := range records {
+				for _, owner := range reminder.RecordAccounts(rec) {
+					key := strings.ToLower(reminder.NormalizeSource(owner.Source))
+					if owner.Unknown || whole[key] {
+						continue
+					}
+					if partial[key] == nil {
+						partial[key] = make(map[string]bool)
+					}
+					partial[key][reminder.NormalizeDomain(rec.Domain)] = true
+				}
+			}
+		}
+	}
+
+	var out []AccountTarget
+	for _, acc := range accounts {
+		key := strings.ToLower(strings.TrimSpace(acc.Label))
+		switch {
+		case whole[key]:
+			out = append(out, AccountTarget{Account: acc})
+		case len(partial[key]) > 0:
+			domains := make([]string, 0, len(partial[key]))
+			for domain := range partial[key] {
+				domains = append(domains, domain)
+			}
+			sort.Strings(domains)
+			out = append(out, AccountTarget{Account: acc, Domains: domains})
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("选择器 %s 没有匹配到任何账号或域名", sel.Raw)
+	}
+	return out, nil
+}
+
+// TargetAccounts 返回目标中的账号列表。
+func TargetAccounts(targets []AccountTarget) []config.CF {
+	out := make([]config.CF, 0, len(targets))
+	for _, target := range targets {
+		out = append(out, target.Account)
+	}
+	return out
+}
+
+// WholeAccountTargets 把账号列表转换为不过滤域名的目标。
+func WholeAccountTargets(accounts []config.CF) []AccountTarget {
+	out := make([]AccountTarget, 0, len(accounts))
+	for _, acc := range accounts {
+		out = append(out, AccountTarget{Account: acc})
+	}
+	return out
+}
+
+// FilterZones 按目标的 Domains 过滤 Zone 列表；Domains 为空时原样返回。
+func (t AccountTarget) FilterZones(zones []cfclient.ZoneDetail) []cfclient.ZoneDetail {
+	if len(t.Domains) == 0 {
+		return zones
+	}
+	want := make(map[string]bool, len(t.Domains))
+	for _, domain := range t.Domains {
+		want[domain] = true
+	}
+	var out []cfclient.ZoneDetail
+	for _, zone := range zones {
+		if want[reminder.NormalizeDomain(zone.Name)] {
+			out = append(out, zone)
+		}
+	}
+	return out
+}
+
+// DescribeTargets 用于任务提交提示，例如 "main, prod-a(2 个域名)"。
+func DescribeTargets(targets []AccountTarget) string {
+	parts := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if len(target.Domains) > 0 {
+			parts = append(parts, fmt.Sprintf("%s(%d 个域名)", target.Account.Label, len(target.Domains)))
+			continue
+		}
+		parts = append(parts, target.Account.Label)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resolveAccountTargets 使用资产缓存中的 Zone 标签解析选择器。
+func (h *CommandHandler) resolveAccountTargets(raw string) ([]AccountTarget, error) {
+	var zones ZoneTagLookup
+	if rt := reminder.DefaultRuntime(); rt != nil && rt.Store() != nil {
+		zones = rt.Store()
+	}
+	return ResolveAccountTargets(h.Accounts, raw, zones)
+}
+
+// resolveSingleAccount 用于只能作用于一个账号的参数：选择器必须恰好整体命中一个账号。
+func (h *CommandHandler) resolveSingleAccount(raw string) (*config.CF, error) {
+	targets, err := h.resolveAccountTargets(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) != 1 || len(targets[0].Domains) > 0 {
+		return nil, fmt.Errorf("选择器 %s 命中了 %s，这里只能指定一个账号", raw, DescribeTargets(targets))
+	}
+	account := targets[0].Account
+	return &account, nil
+}
+
+// accountSelectorHelp 列出可用的账号、分组和标签，附在各批量命令的提示中。
+func (h *CommandHandler) accountSelectorHelp() string {
+	var sb strings.Builder
+	sb.WriteString("账号选择器：all、账号标签、group:分组、tag:标签，可用逗号组合（如 group:prod,tag:brand）。")
+	if groups := config.AccountGroups(h.Accounts); len(groups) > 0 {
+		sb.WriteString("\n分组: " + strings.Join(groups, ", "))
+	}
+	seen := make(map[string]bool)
+	var tags []string
+	for _, acc := range h.Accounts {
+		for _, tag := range acc.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) > 0 {
+		sb.WriteString("\n账号标签: " + strings.Join(tags, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"DomainC/config"
+	"DomainC/reminder"
+)
+
+func TestParseAccountSelector(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "all", want: "all=true labels=[] groups=[] tags=[]"},
+		{raw: "全部账号", want: "all=true labels=[] groups=[] tags=[]"},
+		{raw: "main", want: "all=false labels=[main] groups=[] tags=[]"},
+		{raw: " group:prod , tag:brand ", want: "all=false labels=[] groups=[prod] tags=[brand]"},
+		{raw: "g:prod,t:brand,main", want: "all=false labels=[main] groups=[prod] tags=[brand]"},
+		{raw: "zone:example.com", wantErr: true},
+		{raw: "group:", wantErr: true},
+		{raw: "", wantErr: true},
+		{raw: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		sel, err := config.ParseAccountSelector(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAccountSelector(%q) should fail, got %+v", tt.raw, sel)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAccountSelector(%q) returned error: %v", tt.raw, err)
+			continue
+		}
+		got := fmt.Sprintf("all=%v labels=%v groups=%v tags=%v", sel.All, sel.Labels, sel.Groups, sel.Tags)
+		if got != tt.want {
+			t.Errorf("ParseAccountSelector(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+type fakeZoneTags map[string][]reminder.Record
+
+func (f fakeZoneTags) ListByTag(tag string) ([]reminder.Record, error) {
+	return f[strings.ToLower(tag)], nil
+}
+
+func TestResolveAccountTargets(t *testing.T) {
+	accounts := []config.CF{
+		{Label: "main", Group: "prod"},
+		{Label: "backup", Group: "prod", Tags: []string{"gaming"}},
+		{Label: "staging", Group: "staging"},
+	}
+	zones := fakeZoneTags{
+		"brand": {
+			{Domain: "b.example", Accounts: []reminder.AccountRecord{{Source: "staging"}}},
+			{Domain: "a.example", Accounts: []reminder.AccountRecord{{Source: "staging"}}},
+			{Domain: "gone.example", Accounts: []reminder.AccountRecord{{Source: "staging", Unknown: true}}},
+		},
+		"gaming": {
+			{Domain: "play.example", Accounts: []reminder.AccountRecord{{Source: "backup"}}},
+		},
+	}
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr string
+	}{
+		{name: "all", raw: "all", want: "main, backup, staging"},
+		{name: "label", raw: "Backup", want: "backup"},
+		{name: "group", raw: "group:prod", want: "main, backup"},
+		{name: "account tag wins over zone tag", raw: "tag:gaming", want: "backup"},
+		{name: "zone tag selects domains only", raw: "tag:brand", want: "staging(2 个域名)"},
+		{name: "union", raw: "main,tag:brand", want: "main, staging(2 个域名)"},
+		{name: "unknown label", raw: "nope", wantErr: "未找到 Cloudflare 账号"},
+		{name: "unknown selector kind", raw: "zone:x", wantErr: "未知选择器类型"},
+		{name: "empty match", raw: "group:missing", wantErr: "没有匹配到任何账号"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ResolveAccountTargets(accounts, tt.raw, zones)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got targets=%+v err=%v", tt.wantErr, targets, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAccountTargets(%q) returned error: %v", tt.raw, err)
+			}
+			if got := DescribeTargets(targets); got != tt.want {
+				t.Fatalf("ResolveAccountTargets(%q) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+
+	targets, err := ResolveAccountTargets(accounts, "tag:brand", zones)
+	if err != nil {
+		t.Fatalf("ResolveAccountTargets: %v", err)
+	}
+	if got := strings.Join(targets[0].Domains, ","); got != "a.example,b.example" {
+		t.Fatalf("zone tag domains = %s, want sorted a.example,b.example", got)
+	}
+}
+
+func TestResolveSingleAccount(t *testing.T) {
+	h := &CommandHandler{Accounts: []config.CF{{Label: "main", Group: "prod"}, {Label: "backup", Group: "prod"}, {Label: "staging", Group: "staging"}}}
+	if acc, err := h.resolveSingleAccount("group:staging"); err != nil || acc.Label != "staging" {
+		t.Fatalf("group with one account should resolve, got %+v err=%v", acc, err)
+	}
+	if _, err := h.resolveSingleAccount("group:prod"); err == nil {
+		t.Fatalf("selector matching two accounts should be rejected")
+	}
+}
@@ -0,0 +1,207 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 可在 approval.actions 中配置的操作名。
+const (
+	ApprovalActionDelete            = "delete"
+	ApprovalActionCFRulesAllDisable = "cf_rules_all_disable"
+)
+
+var (
+	ErrApprovalNotFound     = errors.New("审批不存在或已过期")
+	ErrApprovalSelf         = errors.New("不能批准自己发起的操作，需要另一位成员批准")
+	ErrApprovalNotPermitted = errors.New("你不在 approval.approvers 列表中，无权批准")
+)
+
+// PendingApproval 是一条等待第二人批准的操作。
+type PendingApproval struct {
+	Token       string
+	Action      string
+	Description string
+	RequesterID int64
+	Requester   string
+	Approver    string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+
+	run func(requester, approver string)
+}
+
+var approvalState = struct {
+	mu      sync.Mutex
+	pending map[string]*PendingApproval
+}{
+	pending: make(map[string]*PendingApproval),
+}
+
+// ApprovalRequired 判断 action 是否需要第二人批准；dry-run 不会执行写操作，不需要审批。
+// recorder 是调用方 BeginDryRun 的结果，审批判断和实际执行使用同一次 dry-run 决定，
+// 避免两者之间切换 /dryrun 导致真实写操作绕过审批。
+func ApprovalRequired(action string, recorder *cfclient.DryRunRecorder) bool {
+	return config.ApprovalRequired(action) && recorder == nil
+}
+
+// RequestApproval 登记待审批操作并发送带“批准/拒绝”按钮的消息；批准后在新的 goroutine 中执行 run。
+func RequestApproval(ctx context.Context, sender Sender, action, description string, requester *tgbotapi.User, run func(requester, approver string)) (string, error) {
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	now := time.Now()
+	item := &PendingApproval{
+		Token:       newInteractionToken(),
+		Action:      action,
+		Description: description,
+		Requester:   formatOperator(requester),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(config.ApprovalWindow()),
+		run:         run,
+	}
+	if requester != nil {
+		item.RequesterID = requester.ID
+	}
+
+	approvalState.mu.Lock()
+	pruneExpiredApprovalsLocked(now)
+	approvalState.pending[item.Token] = item
+	approvalState.mu.Unlock()
+
+	msg := fmt.Sprintf("🔐 需要第二人批准\n操作: %s\n%s\n\n申请人: %s\n请另一位授权成员在 %s 前点击“批准”，否则自动失效。",
+		action, description, item.Requester, item.ExpiresAt.Format("2006-01-02 15:04"))
+	buttons := [][]Button{{
+		{Text: "✅ 批准", CallbackData: "approval_approve|" + item.Token},
+		{Text: "❌ 拒绝", CallbackData: "approval_reject|" + item.Token},
+	}}
+	if err := sender.SendWithButtons(ctx, msg, buttons); err != nil {
+		approvalState.mu.Lock()
+		delete(approvalState.pending, item.Token)
+		approvalState.mu.Unlock()
+		return "", err
+	}
+	return item.Token, nil
+}
+
+// ApproveApproval 由第二人批准操作：校验有效期、申请人不同、审批人白名单，然后执行。
+func ApproveApproval(token string, approver *tgbotapi.User) (PendingApproval, error) {
+	approvalState.mu.Lock()
+	pruneExpiredApprovalsLocked(time.Now())
+	item, ok := approvalState.pending[token]
+	if !ok {
+		approvalState.mu.Unlock()
+		return PendingApproval{}, ErrApprovalNotFound
+	}
+	if approver == nil {
+		approvalState.mu.Unlock()
+		return *item, ErrApprovalNotPermitted
+	}
+	if approver.ID == item.RequesterID {
+		approvalState.mu.Unlock()
+		return *item, ErrApprovalSelf
+	}
+	if !config.IsApprover(approver.ID, approver.UserName) {
+		approvalState.mu.Unlock()
+		return *item, ErrApprovalNotPermitted
+	}
+	delete(approvalState.pending, token)
+	approvalState.mu.Unlock()
+
+	item.Approver = formatOperator(approver)
+	log.Printf("审批通过: action=%s requester=%s approver=%s", item.Action, item.Requester, item.Approver)
+	if item.run != nil {
+		go item.run(item.Requester, item.Approver)
+	}
+	return *item, nil
+}
+
+// RejectApproval 取消待审批操作；申请人和有权审批的人都可以拒绝。
+func RejectApproval(token string, user *tgbotapi.User) (PendingApproval, error) {
+	approvalState.mu.Lock()
+	defer approvalState.mu.Unlock()
+	pruneExpiredApprovalsLocked(time.Now())
+	item, ok := approvalState.pending[token]
+	if !ok {
+		return PendingApproval{}, ErrApprovalNotFound
+	}
+	if user == nil || (user.ID != item.RequesterID && !config.IsApprover(user.ID, user.UserName)) {
+		return *item, ErrApprovalNotPermitted
+	}
+	delete(approvalState.pending, token)
+	return *item, nil
+}
+
+// ListPendingApprovals 返回未过期的待审批操作，按创建时间排序。
+func ListPendingApprovals() []PendingApproval {
+	approvalState.mu.Lock()
+	defer approvalState.mu.Unlock()
+	pruneExpiredApprovalsLocked(time.Now())
+	items := make([]PendingApproval, 0, len(approvalState.pending))
+	for _, item := range approvalState.pending {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	return items
+}
+
+func pruneExpiredApprovalsLocked(now time.Time) {
+	for token, item := range approvalState.pending {
+		if now.After(item.ExpiresAt) {
+			log.Printf("审批已过期: action=%s requester=%s", item.Action, item.Requester)
+			delete(approvalState.pending, token)
+		}
+	}
+}
+
+// FormatApprovalFooter 是写入结果消息的申请人/批准人记录。
+func FormatApprovalFooter(requester, approver string) string {
+	return fmt.Sprintf("申请人: %s\n批准人: %s", requester, approver)
+}
+
+// BuildApprovalsList 渲染 /approvals 的输出。
+func BuildApprovalsList(items []PendingApproval, now time.Time) string {
+	var sb strings.Builder
+	actions := config.Cfg().Approval.Actions
+	if len(actions) == 0 {
+		sb.WriteString("当前未配置需要二人审批的操作（approval.actions）。\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("需要二人审批的操作: %s，有效期 %s\n", strings.Join(actions, ", "), config.ApprovalWindow()))
+	}
+	if len(items) == 0 {
+		sb.WriteString("\n没有待审批的操作。")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("\n待审批 %d 项:\n", len(items)))
+	for i, item := range items {
+		remaining := item.ExpiresAt.Sub(now).Round(time.Minute)
+		if remaining < 0 {
+			remaining = 0
+		}
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n   申请人: %s，发起于 %s，剩余 %s\n",
+			i+1, item.Action, firstLine(item.Description), item.Requester, item.CreatedAt.Format("01-02 15:04"), remaining))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func firstLine(s string) string {
+	if idx := strings.Index(s, "\n"); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+func (h *CommandHandler) handleApprovalsCommand() {
+	h.sendText(BuildApprovalsList(ListPendingApprovals(), time.Now()))
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"DomainC/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestApproveApprovalChecksRequesterAllowListAndExpiry(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.Approval = config.Approval{Actions: []string{ApprovalActionDelete}, Approvers: []string{"@lead", "42"}}
+	config.Set(cfg)
+
+	requester := &tgbotapi.User{ID: 1, UserName: "ops"}
+	runs := make(chan string, 4)
+	request := func() string {
+		t.Helper()
+		token, err := RequestApproval(context.Background(), NoopSender{}, ApprovalActionDelete, "删除 example.com", requester, func(requester, approver string) {
+			runs <- approver
+		})
+		if err != nil {
+			t.Fatalf("RequestApproval returned error: %v", err)
+		}
+		return token
+	}
+
+	token := request()
+	if _, err := ApproveApproval(token, requester); !errors.Is(err, ErrApprovalSelf) {
+		t.Fatalf("requester approving own action: got %v, want ErrApprovalSelf", err)
+	}
+	if _, err := ApproveApproval(token, &tgbotapi.User{ID: 7, UserName: "intern"}); !errors.Is(err, ErrApprovalNotPermitted) {
+		t.Fatalf("user outside approvers: got %v, want ErrApprovalNotPermitted", err)
+	}
+	item, err := ApproveApproval(token, &tgbotapi.User{ID: 9, UserName: "lead"})
+	if err != nil || item.Approver != "@lead" {
+		t.Fatalf("listed approver should succeed, item=%+v err=%v", item, err)
+	}
+	if _, err := ApproveApproval(token, &tgbotapi.User{ID: 42, UserName: "other"}); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("second approval: got %v, want ErrApprovalNotFound", err)
+	}
+	select {
+	case approver := <-runs:
+		if approver != "@lead" {
+			t.Fatalf("run called with approver %q", approver)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("approved action was not run")
+	}
+
+	expired := request()
+	approvalState.mu.Lock()
+	approvalState.pending[expired].ExpiresAt = time.Now().Add(-time.Minute)
+	approvalState.mu.Unlock()
+	if _, err := ApproveApproval(expired, &tgbotapi.User{ID: 42}); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("expired approval: got %v, want ErrApprovalNotFound", err)
+	}
+
+	select {
+	case approver := <-runs:
+		t.Fatalf("action ran more than once (approver %q)", approver)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestApprovalRequiredSkipsDryRun(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.Approval = config.Approval{Actions: []string{ApprovalActionDelete}}
+	config.Set(cfg)
+
+	_, recorder := BeginDryRun(nil, true)
+	if !ApprovalRequired(ApprovalActionDelete, nil) {
+		t.Fatalf("configured action should require approval")
+	}
+	if ApprovalRequired(ApprovalActionDelete, recorder) {
+		t.Fatalf("dry-run should not require approval")
+	}
+	if ApprovalRequired(ApprovalActionCFRulesAllDisable, nil) {
+		t.Fatalf("unconfigured action should not require approval")
+	}
+}
@@ -0,0 +1,555 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+const (
+	attackModePerAccountInterval = 1500 * time.Millisecond
+	attackModeCheckInterval      = time.Minute
+	attackModeMaxLines           = 80
+)
+
+type cloudflareAttackModeManager interface {
+	ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error)
+	EnableAttackMode(ctx context.Context, account config.CF, zoneID string, botFight bool) (cfclient.AttackModePrevious, error)
+	RestoreAttackMode(ctx context.Context, account config.CF, zoneID string, previous cfclient.AttackModePrevious) error
+}
+
+// AttackModeEntry 是一个由机器人开启攻击模式的 Zone；RestoreAt 为零值表示需要手动 /attack off。
+type AttackModeEntry struct {
+	AccountLabel string                      `json:"account_label"`
+	ZoneID       string                      `json:"zone_id"`
+	Domain       string                      `json:"domain"`
+	Previous     cfclient.AttackModePrevious `json:"previous"`
+	Operator     string                      `json:"operator,omitempty"`
+	EnabledAt    time.Time                   `json:"enabled_at"`
+	RestoreAt    time.Time                   `json:"restore_at"`
+	LastError    string                      `json:"last_error,omitempty"`
+}
+
+type attackModeState struct {
+	Version int                        `json:"version"`
+	Zones   map[string]AttackModeEntry `json:"zones"`
+}
+
+type attackModeTarget struct {
+	Account config.CF
+	ZoneID  string
+	Domain  string
+}
+
+type attackModeResult struct {
+	Action    string
+	Target    string
+	RestoreAt time.Time
+	Success   []string
+	Skipped   []string
+	Failed    []string
+}
+
+var attackModeMu sync.Mutex
+
+const attackModeMissingAccount = "账号不在配置中"
+
+func (h *CommandHandler) handleAttackCommand(args []string) {
+	args, dryRunArg := extractDryRunArg(args)
+	client, recorder := BeginDryRun(h.CFClient, dryRunArg)
+	manager, ok := client.(cloudflareAttackModeManager)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持攻击模式切换。")
+		return
+	}
+	if len(args) < 2 {
+		h.sendText(attackUsage())
+		return
+	}
+	target := strings.TrimSpace(args[0])
+	action := normalizeAttackAction(args[1])
+	if action == "" {
+		h.sendText("第二个参数必须是 on 或 off。\n\n" + attackUsage())
+		return
+	}
+	var duration time.Duration
+	botFight := false
+	for _, arg := range args[2:] {
+		switch strings.ToLower(strings.TrimSpace(arg)) {
+		case "bot", "botfight", "bot=on", "bot_fight", "bfm":
+			botFight = true
+			continue
+		}
+		parsed, err := parseHumanDuration(arg)
+		if err != nil {
+			h.sendText(fmt.Sprintf("持续时间%v\n\n%s", err, attackUsage()))
+			return
+		}
+		duration = parsed
+	}
+	if action == "off" && (duration > 0 || botFight) {
+		h.sendText("off 不需要持续时间或 bot 参数。\n\n" + attackUsage())
+		return
+	}
+
+	operator := formatOperator(h.operator)
+	path := config.AttackModeStateFile()
+	if action == "off" {
+		var entries []AttackModeEntry
+		var err error
+		if config.IsMultiAccountSelector(target) && !isCFIPBlockAllAccountsArg(target) {
+			var selected []AccountTarget
+			if selected, err = h.resolveAccountTargets(target); err != nil {
+				h.sendText(err.Error())
+				return
+			}
+			entries, err = selectAttackModeEntries(path, "all")
+			entries = filterAttackModeEntriesByTargets(entries, selected)
+		} else {
+			entries, err = selectAttackModeEntries(path, target)
+		}
+		if err != nil {
+			h.sendText("读取攻击模式状态失败: " + err.Error())
+			return
+		}
+		if len(entries) == 0 {
+			h.sendText(fmt.Sprintf("%s 没有由机器人开启的攻击模式记录，无需恢复。", target))
+			return
+		}
+		accounts := append([]config.CF(nil), h.Accounts...)
+		go func() {
+			result := restoreAttackModeEntries(context.Background(), manager, accounts, path, entries)
+			result.Target = target
+			h.finishAttackMode(recorder, "attack "+target+" off", result.Summary())
+		}()
+		h.sendText(fmt.Sprintf("攻击模式恢复任务已提交：目标 %s，Zone %d。", target, len(entries)))
+		return
+	}
+
+	targets, err := h.resolveAttackModeTargets(context.Background(), manager, target)
+	if err != nil {
+		h.sendText(err.Error())
+		return
+	}
+	if len(targets) == 0 {
+		h.sendText("没有匹配的域名。")
+		return
+	}
+	var restoreAt time.Time
+	if duration > 0 {
+		restoreAt = time.Now().Add(duration)
+	}
+	go func() {
+		result := enableAttackModeTargets(context.Background(), manager, path, targets, botFight, restoreAt, operator)
+		result.Target = target
+		h.finishAttackMode(recorder, "attack "+target+" on", result.Summary())
+	}()
+	restoreText := "需手动执行 /attack " + target + " off 恢复"
+	if !restoreAt.IsZero() {
+		restoreText = "将于 " + restoreAt.Format("2006-01-02 15:04") + " 自动恢复"
+	}
+	h.sendText(fmt.Sprintf("攻击模式开启任务已提交：目标 %s，Zone %d，Bot Fight Mode %s，%s。",
+		target, len(targets), attackBotFightLabel(botFight), restoreText))
+}
+
+// finishAttackMode 发送执行结果；dry-run 时附带计划文件。
+func (h *CommandHandler) finishAttackMode(recorder *cfclient.DryRunRecorder, title, summary string) {
+	if recorder != nil {
+		FinishDryRun(context.Background(), h.Sender, recorder, title, summary)
+		return
+	}
+	h.sendText(summary)
+}
+
+func normalizeAttackAction(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "on", "enable", "start", "开启":
+		return "on"
+	case "off", "disable", "stop", "restore", "关闭", "恢复":
+		return "off"
+	default:
+		return ""
+	}
+}
+
+func attackBotFightLabel(enabled bool) string {
+	if enabled {
+		return "开启"
+	}
+	return "不变"
+}
+
+// resolveAttackModeTargets 解析目标：all、group:、tag: 等选择器为命中的账号或域名，账号标签为该账号全部域名，其余按域名查找。
+func (h *CommandHandler) resolveAttackModeTargets(ctx context.Context, manager cloudflareAttackModeManager, target string) ([]attackModeTarget, error) {
+	var selected []AccountTarget
+	switch {
+	case isCFIPBlockAllAccountsArg(target):
+		selected = WholeAccountTargets(h.Accounts)
+	case config.IsMultiAccountSelector(target):
+		var err error
+		if selected, err = h.resolveAccountTargets(target); err != nil {
+			return nil, err
+		}
+	case h.getAccountByLabel(target) != nil:
+		selected = WholeAccountTargets([]config.CF{*h.getAccountByLabel(target)})
+	default:
+		account, zone, err := h.findZone(target)
+		if err != nil || account == nil {
+			return nil, fmt.Errorf("未找到域名或账号标签: %s", target)
+		}
+		return []attackModeTarget{{Account: *account, ZoneID: zone.ID, Domain: zone.Name}}, nil
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("未配置可用的 Cloudflare 账号")
+	}
+	var targets []attackModeTarget
+	for _, sel := range selected {
+		account := sel.Account
+		zones, err := manager.ListZones(ctx, account)
+		if err != nil {
+			return nil, fmt.Errorf("读取账号 %s 域名失败: %v", account.Label, err)
+		}
+		for _, zone := range sel.FilterZones(zones) {
+			if strings.TrimSpace(zone.ID) == "" {
+				continue
+			}
+			targets = append(targets, attackModeTarget{Account: account, ZoneID: zone.ID, Domain: zone.Name})
+		}
+	}
+	return targets, nil
+}
+
+func enableAttackModeTargets(ctx context.Context, manager cloudflareAttackModeManager, path string, targets []attackModeTarget, botFight bool, restoreAt time.Time, operator string) attackModeResult {
+	result := attackModeResult{Action: "on", RestoreAt: restoreAt}
+	dryRun := isDryRunClient(manager)
+	byAccount := map[string][]attackModeTarget{}
+	for _, target := range targets {
+		byAccount[target.Account.Label] = append(byAccount[target.Account.Label], target)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, accountTargets := range byAccount {
+		wg.Add(1)
+		go func(accountTargets []attackModeTarget) {
+			defer wg.Done()
+			pacer := newBatchAPIPacerWithInterval(attackModePerAccountInterval)
+			for _, target := range accountTargets {
+				name := target.Account.Label + "/" + target.Domain
+				if err := pacer.Wait(ctx); err != nil {
+					mu.Lock()
+					result.Failed = append(result.Failed, name+": 等待执行失败: "+err.Error())
+					mu.Unlock()
+					continue
+				}
+				previous, err := manager.EnableAttackMode(ctx, target.Account, target.ZoneID, botFight)
+				if err != nil && previous.SecurityLevel == "" {
+					mu.Lock()
+					result.Failed = append(result.Failed, name+": "+err.Error())
+					mu.Unlock()
+					continue
+				}
+				// 只要 security_level 已读到就落盘，确保后续能恢复，即便 Bot Fight Mode 切换失败；dry-run 不落盘。
+				var saveErr error
+				if !dryRun {
+					saveErr = updateAttackModeState(path, func(zones map[string]AttackModeEntry) {
+						entry, exists := zones[target.ZoneID]
+						if !exists {
+							entry = AttackModeEntry{
+								AccountLabel: target.Account.Label,
+								ZoneID:       target.ZoneID,
+								Domain:       target.Domain,
+								Previous:     previous,
+								EnabledAt:    time.Now(),
+							}
+						} else if entry.Previous.BotFightMode == nil {
+							entry.Previous.BotFightMode = previous.BotFightMode
+						}
+						entry.Operator = operator
+						entry.RestoreAt = restoreAt
+						entry.LastError = ""
+						zones[target.ZoneID] = entry
+					})
+				}
+				mu.Lock()
+				switch {
+				case saveErr != nil:
+					result.Failed = append(result.Failed, name+": 已开启但保存原始设置失败，请手动记录: "+saveErr.Error())
+				case err != nil:
+					result.Failed = append(result.Failed, name+": "+err.Error())
+				case previous.SecurityLevel == cfclient.SecurityLevelUnderAttack:
+					result.Skipped = append(result.Skipped, name+": 已处于 under_attack")
+				default:
+					result.Success = append(result.Success, fmt.Sprintf("%s: %s -> %s", name, previous.SecurityLevel, cfclient.SecurityLevelUnderAttack))
+				}
+				mu.Unlock()
+				if err == nil && saveErr == nil && !dryRun {
+					RecordOperation(OperationEntry{Operation: "attack_on", Account: target.Account.Label, Zone: target.Domain, Operator: operator})
+				}
+			}
+		}(accountTargets)
+	}
+	wg.Wait()
+	result.sort()
+	return result
+}
+
+func selectAttackModeEntries(path string, target string) ([]AttackModeEntry, error) {
+	state, err := loadAttackModeState(path)
+	if err != nil {
+		return nil, err
+	}
+	target = strings.ToLower(strings.TrimSpace(target))
+	domain := target
+	if normalized, err := extractDomainOrHost(target); err == nil {
+		domain = strings.ToLower(normalized)
+	}
+	all := isCFIPBlockAllAccountsArg(target)
+	var out []AttackModeEntry
+	for _, entry := range state.Zones {
+		if all || strings.EqualFold(entry.AccountLabel, target) || strings.EqualFold(entry.Domain, domain) ||
+			strings.HasSuffix(domain, "."+strings.ToLower(entry.Domain)) {
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AccountLabel == out[j].AccountLabel {
+			return out[i].Domain < out[j].Domain
+		}
+		return out[i].AccountLabel < out[j].AccountLabel
+	})
+	return out, nil
+}
+
+// filterAttackModeEntriesByTargets 保留属于选择器命中账号（或命中域名）的攻击模式记录。
+func filterAttackModeEntriesByTargets(entries []AttackModeEntry, targets []AccountTarget) []AttackModeEntry {
+	var out []AttackModeEntry
+	for _, entry := range entries {
+		for _, target := range targets {
+			if !strings.EqualFold(target.Account.Label, entry.AccountLabel) {
+				continue
+			}
+			if len(target.Domains) == 0 || len(target.FilterZones([]cfclient.ZoneDetail{{Name: entry.Domain}})) > 0 {
+				out = append(out, entry)
+			}
+			break
+		}
+	}
+	return out
+}
+
+func restoreAttackModeEntries(ctx context.Context, manager cloudflareAttackModeManager, accounts []config.CF, path string, entries []AttackModeEntry) attackModeResult {
+	result := attackModeResult{Action: "off"}
+	dryRun := isDryRunClient(manager)
+	byLabel := make(map[string]config.CF, len(accounts))
+	for _, account := range accounts {
+		byLabel[account.Label] = account
+	}
+	pacer := newBatchAPIPacerWithInterval(attackModePerAccountInterval)
+	for _, entry := range entries {
+		name := entry.AccountLabel + "/" + entry.Domain
+		account, ok := byLabel[entry.AccountLabel]
+		if !ok {
+			// 记录保留，账号重新加入配置后继续恢复；写入 LastError 让定时恢复只提醒一次。
+			result.Failed = append(result.Failed, name+": "+attackModeMissingAccount)
+			if !dryRun {
+				if err := updateAttackModeState(path, func(zones map[string]AttackModeEntry) {
+					if current, exists := zones[entry.ZoneID]; exists {
+						current.LastError = attackModeMissingAccount
+						zones[entry.ZoneID] = current
+					}
+				}); err != nil {
+					log.Printf("更新攻击模式状态失败: %v", err)
+				}
+			}
+			continue
+		}
+		if err := pacer.Wait(ctx); err != nil {
+			result.Failed = append(result.Failed, name+": 等待执行失败: "+err.Error())
+			continue
+		}
+		err := manager.RestoreAttackMode(ctx, account, entry.ZoneID, entry.Previous)
+		if dryRun {
+			if err != nil {
+				result.Failed = append(result.Failed, name+": "+err.Error())
+			} else {
+				result.Success = append(result.Success, fmt.Sprintf("%s: %s -> %s", name, cfclient.SecurityLevelUnderAttack, entry.Previous.SecurityLevel))
+			}
+			continue
+		}
+		saveErr := updateAttackModeState(path, func(zones map[string]AttackModeEntry) {
+			current, exists := zones[entry.ZoneID]
+			if !exists {
+				return
+			}
+			if err == nil {
+				delete(zones, entry.ZoneID)
+				return
+			}
+			current.LastError = err.Error()
+			zones[entry.ZoneID] = current
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, name+": "+err.Error())
+			continue
+		}
+		if saveErr != nil {
+			log.Printf("更新攻击模式状态失败: %v", saveErr)
+		}
+		RecordOperation(OperationEntry{Operation: "attack_off", Account: entry.AccountLabel, Zone: entry.Domain})
+		level := entry.Previous.SecurityLevel
+		if level == "" || level == cfclient.SecurityLevelUnderAttack {
+			result.Skipped = append(result.Skipped, name+": 开启前已是 under_attack，保持不变")
+			continue
+		}
+		result.Success = append(result.Success, fmt.Sprintf("%s: %s -> %s", name, cfclient.SecurityLevelUnderAttack, level))
+	}
+	result.sort()
+	return result
+}
+
+// RunAttackModeRestorer 每分钟检查到期的攻击模式并恢复原始设置，进程重启后会继续处理已持久化的记录。
+// accounts 每轮调用一次，配置热加载后的账号列表在下一轮生效。
+func RunAttackModeRestorer(ctx context.Context, client cfclient.Client, accounts func() []config.CF, sender Sender) {
+	manager, ok := client.(cloudflareAttackModeManager)
+	if !ok {
+		log.Printf("Cloudflare 客户端不支持攻击模式切换，跳过自动恢复")
+		return
+	}
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	ticker := time.NewTicker(attackModeCheckInterval)
+	defer ticker.Stop()
+	for {
+		path := config.AttackModeStateFile()
+		due, err := dueAttackModeEntries(path, time.Now())
+		if err == nil && len(due) > 0 && DryRunEnabled() {
+			// 全局 dry-run 期间不做真实恢复，记录保留到关闭 dry-run 后的下一轮。
+			log.Printf("全局 dry-run 已开启，暂缓恢复 %d 个到期的攻击模式", len(due))
+			due = nil
+		}
+		if err != nil {
+			log.Printf("读取攻击模式状态失败: %v", err)
+		} else if len(due) > 0 {
+			result := restoreAttackModeEntries(ctx, manager, accounts(), path, due)
+			result.Target = "到期自动恢复"
+			if len(result.Success) > 0 || len(result.Skipped) > 0 || hasNewAttackModeError(due, result) {
+				_ = sender.Send(ctx, result.Summary())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func dueAttackModeEntries(path string, now time.Time) ([]AttackModeEntry, error) {
+	state, err := loadAttackModeState(path)
+	if err != nil {
+		return nil, err
+	}
+	var due []AttackModeEntry
+	for _, entry := range state.Zones {
+		if !entry.RestoreAt.IsZero() && !entry.RestoreAt.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RestoreAt.Before(due[j].RestoreAt) })
+	return due, nil
+}
+
+// hasNewAttackModeError 避免同一个恢复失败每分钟重复提醒。
+func hasNewAttackModeError(due []AttackModeEntry, result attackModeResult) bool {
+	previous := map[string]string{}
+	for _, entry := range due {
+		previous[entry.AccountLabel+"/"+entry.Domain] = entry.LastError
+	}
+	for _, item := range result.Failed {
+		name, msg, _ := strings.Cut(item, ": ")
+		if previous[name] != msg {
+			return true
+		}
+	}
+	return false
+}
+
+func loadAttackModeState(path string) (attackModeState, error) {
+	attackModeMu.Lock()
+	defer attackModeMu.Unlock()
+	return readAttackModeState(path)
+}
+
+func readAttackModeState(path string) (attackModeState, error) {
+	var state attackModeState
+	if err := loadJSONStateFile(path, &state); err != nil {
+		return state, err
+	}
+	if state.Zones == nil {
+		state.Zones = map[string]AttackModeEntry{}
+	}
+	return state, nil
+}
+
+func updateAttackModeState(path string, fn func(zones map[string]AttackModeEntry)) error {
+	attackModeMu.Lock()
+	defer attackModeMu.Unlock()
+	state, err := readAttackModeState(path)
+	if err != nil {
+		return err
+	}
+	fn(state.Zones)
+	state.Version = 1
+	return saveJSONStateFile(path, state)
+}
+
+func (r *attackModeResult) sort() {
+	sort.Strings(r.Success)
+	sort.Strings(r.Skipped)
+	sort.Strings(r.Failed)
+}
+
+func (r attackModeResult) Summary() string {
+	var sb strings.Builder
+	title := "攻击模式开启完成"
+	if r.Action == "off" {
+		title = "攻击模式恢复完成"
+	}
+	sb.WriteString(fmt.Sprintf("%s\n目标: %s\n成功: %d\n跳过: %d\n失败: %d", title, r.Target, len(r.Success), len(r.Skipped), len(r.Failed)))
+	if r.Action == "on" {
+		if r.RestoreAt.IsZero() {
+			sb.WriteString("\n自动恢复: 无（需手动 /attack off）")
+		} else {
+			sb.WriteString("\n自动恢复: " + r.RestoreAt.Format("2006-01-02 15:04"))
+		}
+	}
+	lines := 0
+	write := func(prefix string, items []string) {
+		for _, item := range items {
+			if lines >= attackModeMaxLines {
+				return
+			}
+			sb.WriteString("\n" + prefix + item)
+			lines++
+		}
+	}
+	write("✅ ", r.Success)
+	write("⏭ ", r.Skipped)
+	write("❌ ", r.Failed)
+	if total := len(r.Success) + len(r.Skipped) + len(r.Failed); total > lines {
+		sb.WriteString(fmt.Sprintf("\n还有 %d 条明细未显示。", total-lines))
+	}
+	return sb.String()
+}
+
+func attackUsage() string {
+	return "用法：\n/attack example.com on 2h\n/attack 账号标签 on 30m bot\n/attack all on 6h\n/attack example.com off\n/attack all on 6h dryrun\n\n说明：on 会把 security_level 设为 under_attack，带 bot 时同时开启 Bot Fight Mode；持续时间支持 30m/2h/1d，到期后自动恢复原值；不带持续时间需手动 off。原值保存在本地状态文件，重启后继续生效。追加 dryrun 只输出计划，不修改设置也不保存状态。"
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeAttackModeManager struct {
+	cloudflareAttackModeManager
+	fail     map[string]bool
+	restored []string
+}
+
+func (f *fakeAttackModeManager) RestoreAttackMode(ctx context.Context, account config.CF, zoneID string, previous cfclient.AttackModePrevious) error {
+	if f.fail[zoneID] {
+		return errors.New("api unavailable")
+	}
+	f.restored = append(f.restored, account.Label+"/"+zoneID+"="+previous.SecurityLevel)
+	return nil
+}
+
+func TestDueAttackModeEntries(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "attack_mode.json")
+	err := updateAttackModeState(path, func(zones map[string]AttackModeEntry) {
+		zones["z-late"] = AttackModeEntry{ZoneID: "z-late", RestoreAt: now.Add(-time.Minute)}
+		zones["z-early"] = AttackModeEntry{ZoneID: "z-early", RestoreAt: now.Add(-time.Hour)}
+		zones["z-now"] = AttackModeEntry{ZoneID: "z-now", RestoreAt: now}
+		zones["z-future"] = AttackModeEntry{ZoneID: "z-future", RestoreAt: now.Add(time.Minute)}
+		zones["z-manual"] = AttackModeEntry{ZoneID: "z-manual"}
+	})
+	if err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	due, err := dueAttackModeEntries(path, now)
+	if err != nil {
+		t.Fatalf("dueAttackModeEntries: %v", err)
+	}
+	var ids []string
+	for _, entry := range due {
+		ids = append(ids, entry.ZoneID)
+	}
+	if got := strings.Join(ids, ","); got != "z-early,z-late,z-now" {
+		t.Fatalf("due entries = %s, want z-early,z-late,z-now", got)
+	}
+}
+
+func TestRestoreAttackModeEntries(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.OperationLog.File = filepath.Join(t.TempDir(), "operation_log.json")
+	config.Set(cfg)
+
+	path := filepath.Join(t.TempDir(), "attack_mode.json")
+	restoreAt := time.Now().Add(-time.Minute)
+	entries := []AttackModeEntry{
+		{AccountLabel: "main", ZoneID: "z1", RestoreAt: restoreAt, Domain: "ok.example", Previous: cfclient.AttackModePrevious{SecurityLevel: "medium"}},
+		{AccountLabel: "main", ZoneID: "z2", RestoreAt: restoreAt, Domain: "fail.example", Previous: cfclient.AttackModePrevious{SecurityLevel: "high"}},
+		{AccountLabel: "gone", ZoneID: "z3", RestoreAt: restoreAt, Domain: "gone.example", Previous: cfclient.AttackModePrevious{SecurityLevel: "low"}},
+	}
+	if err := updateAttackModeState(path, func(zones map[string]AttackModeEntry) {
+		for _, entry := range entries {
+			zones[entry.ZoneID] = entry
+		}
+	}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	manager := &fakeAttackModeManager{fail: map[string]bool{"z2": true}}
+	accounts := []config.CF{{Label: "main"}}
+	result := restoreAttackModeEntries(context.Background(), manager, accounts, path, entries)
+	if strings.Join(manager.restored, ",") != "main/z1=medium" {
+		t.Fatalf("restored = %v", manager.restored)
+	}
+	if len(result.Success) != 1 || len(result.Failed) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !hasNewAttackModeError(entries, result) {
+		t.Fatalf("first failure should be reported")
+	}
+
+	state, err := loadAttackModeState(path)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if _, ok := state.Zones["z1"]; ok {
+		t.Fatalf("restored zone should be removed from state")
+	}
+	if state.Zones["z2"].LastError != "api unavailable" {
+		t.Fatalf("failed zone should keep its error: %+v", state.Zones["z2"])
+	}
+	if state.Zones["z3"].LastError != attackModeMissingAccount {
+		t.Fatalf("zone of missing account should be kept with an error: %+v", state.Zones["z3"])
+	}
+
+	// 第二轮使用持久化后的记录，相同的失败不再提醒。
+	due, err := dueAttackModeEntries(path, time.Now())
+	if err != nil {
+		t.Fatalf("dueAttackModeEntries: %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("failed zones should stay due, got %+v", due)
+	}
+	manager.restored = nil
+	again := restoreAttackModeEntries(context.Background(), manager, accounts, path, due)
+	if hasNewAttackModeError(due, again) {
+		t.Fatalf("unchanged failures should not be reported twice: %+v", again.Failed)
+	}
+}
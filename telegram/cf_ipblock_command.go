@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"strings"
 	"sync"
@@ -66,11 +67,19 @@ func (h *CommandHandler) handleCFIPBlockCommand(args []string) {
 		h.sendText("未配置可用的 Cloudflare 账号，无法设置 WAF IP 黑名单。")
 		return
 	}
-	manager, ok := h.CFClient.(cloudflareAccountIPBlockManager)
+	args, dryRunArg := extractDryRunArg(args)
+	client, recorder := BeginDryRun(h.CFClient, dryRunArg)
+	manager, ok := client.(cloudflareAccountIPBlockManager)
 	if !ok {
 		h.sendText("当前 Cloudflare 客户端不支持 WAF IP 黑名单管理。")
 		return
 	}
+	selector, args := h.splitCFIPBlockSelector(args)
+	targets, err := h.resolveAccountTargets(selector)
+	if err != nil {
+		h.sendText(err.Error() + "\n\n" + h.accountSelectorHelp())
+		return
+	}
 	scope, scopedArgs := splitCFIPBlockScope(args)
 	if scope == "account" {
 		action, values, err := parseCFIPAccessArgs(scopedArgs)
@@ -78,28 +87,61 @@ func (h *CommandHandler) handleCFIPBlockCommand(args []string) {
 			h.sendText(err.Error() + "\n\n" + cfIPBlockUsage())
 			return
 		}
-		accounts := append([]config.CF(nil), h.Accounts...)
+		// 账号级规则作用于整个账号，只处理被整体选中的账号，不处理仅有部分域名命中 tag: 的账号。
+		var accounts []config.CF
+		for _, target := range targets {
+			if len(target.Domains) == 0 {
+				accounts = append(accounts, target.Account)
+			}
+		}
+		if len(accounts) == 0 {
+			h.sendText(fmt.Sprintf("选择器 %s 没有整体命中任何账号，账号级 IP 访问规则需要按账号选择。", selector))
+			return
+		}
 		go func() {
 			result := processCFIPAccessAllAccounts(context.Background(), manager, accounts, action, values)
-			h.sendText(result.Summary())
+			FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_ipblock account %s", action), result.Summary())
 		}()
-		h.sendText(fmt.Sprintf("Cloudflare 账号级 IP 访问规则任务已提交：动作 %s，账号 %d，目标 %s。",
-			action, len(accounts), cfIPAccessTargetLabel(action, values)))
+		h.sendText(fmt.Sprintf("Cloudflare 账号级 IP 访问规则任务已提交：动作 %s，账号 %s，目标 %s。",
+			action, DescribeTargets(WholeAccountTargets(accounts)), cfIPAccessTargetLabel(action, values)))
+		return
+	}
+	args, ttl, err := extractCFIPBlockTTL(scopedArgs)
+	if err != nil {
+		h.sendText(err.Error() + "\n\n" + cfIPBlockUsage())
 		return
 	}
-	args = scopedArgs
 	action, values, err := parseCFIPBlockArgs(args)
 	if err != nil {
 		h.sendText(err.Error() + "\n\n" + cfIPBlockUsage())
 		return
 	}
-	accounts := append([]config.CF(nil), h.Accounts...)
+	if ttl > 0 && action != "add" {
+		h.sendText("ttl 仅支持 add 动作。\n\n" + cfIPBlockUsage())
+		return
+	}
+	accounts := TargetAccounts(targets)
+	now := time.Now()
+	// dry-run 不写入到期记录，避免后台任务去解封一个并未真正封禁的 IP。
+	if recorder == nil {
+		if err := recordCFIPBlockExpiries(config.IPBlockExpiryFile(), action, values, accounts, ttl, formatOperator(h.operator), now); err != nil {
+			if ttl > 0 {
+				h.sendText("记录临时封禁到期时间失败，已取消任务: " + err.Error())
+				return
+			}
+			log.Printf("更新 IP 封禁到期记录失败: %v", err)
+		}
+	}
 	go func() {
-		result := processCFIPBlockAllAccounts(context.Background(), manager, accounts, action, values)
-		h.sendText(result.Summary())
+		result := processCFIPBlockAllAccounts(context.Background(), manager, targets, action, values)
+		FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_ipblock %s", action), result.Summary())
 	}()
-	h.sendText(fmt.Sprintf("Cloudflare WAF IP 黑名单任务已提交：动作 %s，账号 %d，目标 %s。\n账号之间并发执行，每个账号内部限速 %s/域名。",
-		action, len(accounts), cfIPBlockTargetLabel(action, values), cfIPBlockPerAccountInterval))
+	expiry := ""
+	if ttl > 0 {
+		expiry = fmt.Sprintf("\n临时封禁，到期时间 %s 后自动解封。", now.Add(ttl).Format("2006-01-02 15:04"))
+	}
+	h.sendText(fmt.Sprintf("Cloudflare WAF IP 黑名单任务已提交：动作 %s，账号 %s，目标 %s。\n账号之间并发执行，每个账号内部限速 %s/域名。%s",
+		action, DescribeTargets(targets), cfIPBlockTargetLabel(action, values), cfIPBlockPerAccountInterval, expiry))
 }
 
 func parseCFIPBlockArgs(args []string) (string, []string, error) {
@@ -139,10 +181,24 @@ func normalizeCFIPBlockAction(raw string) string {
 	}
 }
 
+// splitCFIPBlockSelector 取出可选的账号选择器参数（all、账号标签、group:、tag:），未指定时为全部账号。
+func (h *CommandHandler) splitCFIPBlockSelector(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "all", args
+	}
+	first := strings.TrimSpace(args[0])
+	switch {
+	case isCFIPBlockAllAccountsArg(first):
+		return "all", args[1:]
+	case normalizeCFIPBlockAction(first) != "" || isCFIPAccessScopeArg(first):
+		return "all", args
+	case config.IsMultiAccountSelector(first) || h.getAccountByLabel(first) != nil:
+		return first, args[1:]
+	}
+	return "all", args
+}
+
 func splitCFIPBlockScope(args []string) (string, []string) {
-	if len(args) > 0 && isCFIPBlockAllAccountsArg(args[0]) {
-		args = args[1:]
-	}
 	if len(args) > 0 && isCFIPAccessScopeArg(args[0]) {
 		return "account", args[1:]
 	}
@@ -192,7 +248,7 @@ func parseCFIPBlockValues(args []string) ([]string, error) {
 }
 
 func cfIPBlockUsage() string {
-	return "用法：\n/cf_ipblock add 1.2.3.4,5.6.7.8\n/cf_ipblock delete 1.2.3.4\n/cf_ipblock clear\n/cf_ipblock access clear\n/cf_ipblock access delete 1.2.3.4\n\n说明：默认操作每个域名的 Zone WAF 自定义规则 telegram-auto-block-ips。access 操作 Cloudflare 账号级 IP 访问规则，只删除备注为 telegram-auto-ip-blacklist 的规则。"
+	return "用法：\n/cf_ipblock [账号选择器] add 1.2.3.4,5.6.7.8\n/cf_ipblock group:prod add 1.2.3.4\n/cf_ipblock add 1.2.3.4 ttl=24h\n/cf_ipblock delete 1.2.3.4\n/cf_ipblock clear\n/cf_ipblock access clear\n/cf_ipblock access delete 1.2.3.4\n\n说明：账号选择器可省略（默认全部账号），支持 all、账号标签、group:分组、tag:标签。默认操作每个域名的 Zone WAF 自定义规则 telegram-auto-block-ips。access 操作 Cloudflare 账号级 IP 访问规则，只删除备注为 telegram-auto-ip-blacklist 的规则。ttl 支持 30m/24h/7d，到期后由后台自动解封；不带 ttl 的 add 或 delete 会取消对应 IP 的到期记录。\n查看规则：/ipaccess list 账号标签 [域名]"
 }
 
 func parseCFIPAccessArgs(args []string) (string, []string, error) {
@@ -304,22 +360,22 @@ func formatCFIPAccessPermissionError(err error) string {
 	return msg
 }
 
-func processCFIPBlockAllAccounts(ctx context.Context, manager cloudflareAccountIPBlockManager, accounts []config.CF, action string, values []string) cfIPBlockBatchResult {
+func processCFIPBlockAllAccounts(ctx context.Context, manager cloudflareAccountIPBlockManager, targets []AccountTarget, action string, values []string) cfIPBlockBatchResult {
 	result := cfIPBlockBatchResult{
 		Action: action,
 		Values: append([]string(nil), values...),
 	}
-	ch := make(chan cfIPBlockAccountResult, len(accounts))
+	ch := make(chan cfIPBlockAccountResult, len(targets))
 	var wg sync.WaitGroup
-	for _, account := range accounts {
-		account := account
-		if strings.TrimSpace(account.Label) == "" {
+	for _, target := range targets {
+		target := target
+		if strings.TrimSpace(target.Account.Label) == "" {
 			continue
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			ch <- processCFIPBlockAccount(ctx, manager, account, action, values)
+			ch <- processCFIPBlockAccount(ctx, manager, target, action, values)
 		}()
 	}
 	go func() {
@@ -337,7 +393,8 @@ func processCFIPBlockAllAccounts(ctx context.Context, manager cloudflareAccountI
 	return result
 }
 
-func processCFIPBlockAccount(ctx context.Context, manager cloudflareAccountIPBlockManager, account config.CF, action string, values []string) cfIPBlockAccountResult {
+func processCFIPBlockAccount(ctx context.Context, manager cloudflareAccountIPBlockManager, target AccountTarget, action string, values []string) cfIPBlockAccountResult {
+	account := target.Account
 	result := cfIPBlockAccountResult{AccountLabel: account.Label}
 	zones, err := manager.ListZones(ctx, account)
 	if err != nil {
@@ -345,7 +402,7 @@ func processCFIPBlockAccount(ctx context.Context, manager cloudflareAccountIPBlo
 		return result
 	}
 	pacer := newBatchAPIPacerWithInterval(cfIPBlockPerAccountInterval)
-	for _, zone := range zones {
+	for _, zone := range target.FilterZones(zones) {
 		zoneID := strings.TrimSpace(zone.ID)
 		name := strings.TrimSpace(zone.Name)
 		if zoneID == "" {
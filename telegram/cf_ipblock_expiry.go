@@ -0,0 +1,347 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+const (
+	cfIPBlockMinTTL = time.Minute
+	cfIPBlockMaxTTL = 365 * 24 * time.Hour
+)
+
+// IPBlockExpiry 记录一条带 TTL 的临时 IP 封禁，Accounts 为尚未完成解封的账号标签。
+type IPBlockExpiry struct {
+	Value     string    `json:"value"`
+	Accounts  []string  `json:"accounts"`
+	Operator  string    `json:"operator,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// MissingReported 是已提醒过“账号不在配置中”的账号标签，避免每轮重复提醒。
+	MissingReported []string `json:"missing_reported,omitempty"`
+}
+
+type ipBlockExpiryCache struct {
+	Version int                      `json:"version"`
+	Entries map[string]IPBlockExpiry `json:"entries"`
+}
+
+var ipBlockExpiryMu sync.Mutex
+
+// parseCFIPBlockTTL 解析 ttl，支持 Go duration（30m/24h）以及按天的 7d 写法。
+func parseCFIPBlockTTL(raw string) (time.Duration, error) {
+	ttl, err := parseHumanDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("ttl %v", err)
+	}
+	if ttl < cfIPBlockMinTTL || ttl > cfIPBlockMaxTTL {
+		return 0, fmt.Errorf("ttl 需在 1m 到 365d 之间")
+	}
+	return ttl, nil
+}
+
+// extractCFIPBlockTTL 从参数中取出 ttl=xxx，返回剩余参数。
+func extractCFIPBlockTTL(args []string) ([]string, time.Duration, error) {
+	var rest []string
+	var ttl time.Duration
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "ttl") {
+			rest = append(rest, arg)
+			continue
+		}
+		parsed, err := parseCFIPBlockTTL(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		ttl = parsed
+	}
+	return rest, ttl, nil
+}
+
+func loadIPBlockExpiries(path string) (map[string]IPBlockExpiry, error) {
+	var cache ipBlockExpiryCache
+	if err := loadJSONStateFile(path, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]IPBlockExpiry{}
+	}
+	return cache.Entries, nil
+}
+
+func saveIPBlockExpiries(path string, entries map[string]IPBlockExpiry) error {
+	return saveJSONStateFile(path, ipBlockExpiryCache{Version: 1, Entries: entries})
+}
+
+func updateIPBlockExpiries(path string, fn func(entries map[string]IPBlockExpiry)) error {
+	ipBlockExpiryMu.Lock()
+	defer ipBlockExpiryMu.Unlock()
+	entries, err := loadIPBlockExpiries(path)
+	if err != nil {
+		return err
+	}
+	fn(entries)
+	return saveIPBlockExpiries(path, entries)
+}
+
+// ListIPBlockExpiries 返回全部临时封禁，按到期时间升序。
+func ListIPBlockExpiries(path string) ([]IPBlockExpiry, error) {
+	ipBlockExpiryMu.Lock()
+	entries, err := loadIPBlockExpiries(path)
+	ipBlockExpiryMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]IPBlockExpiry, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ExpiresAt.Equal(out[j].ExpiresAt) {
+			return out[i].Value < out[j].Value
+		}
+		return out[i].ExpiresAt.Before(out[j].ExpiresAt)
+	})
+	return out, nil
+}
+
+// recordCFIPBlockExpiries 同步本地到期记录：带 ttl 的 add 写入/延长到期时间，
+// 永久 add 与 delete 移除对应记录，clear 清空全部记录。
+func recordCFIPBlockExpiries(path string, action string, values []string, accounts []config.CF, ttl time.Duration, operator string, now time.Time) error {
+	labels := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if label := strings.TrimSpace(account.Label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return updateIPBlockExpiries(path, func(entries map[string]IPBlockExpiry) {
+		if action == "clear" {
+			for key := range entries {
+				delete(entries, key)
+			}
+			return
+		}
+		for _, value := range values {
+			if action != "add" || ttl <= 0 {
+				delete(entries, value)
+				continue
+			}
+			entry, ok := entries[value]
+			if !ok {
+				entry = IPBlockExpiry{Value: value, CreatedAt: now}
+			}
+			entry.Accounts = mergeIPAccessLabels(entry.Accounts, labels)
+			entry.Operator = operator
+			entry.ExpiresAt = now.Add(ttl)
+			entries[value] = entry
+		}
+	})
+}
+
+func mergeIPAccessLabels(existing []string, add []string) []string {
+	seen := make(map[string]struct{}, len(existing)+len(add))
+	var out []string
+	for _, label := range append(append([]string(nil), existing...), add...) {
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		out = append(out, label)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type ipBlockSweepResult struct {
+	Values   []string
+	Accounts []cfIPBlockAccountResult
+	Failed   []string
+	// Missing 是本轮首次发现账号已不在配置中的记录，记录保留，账号恢复后继续解封。
+	Missing []string
+}
+
+// RunIPBlockExpirySweeper 定期删除已到期的临时 IP 封禁，失败的账号保留记录等待下一轮重试。
+// accounts 每轮调用一次，配置热加载后的账号列表在下一轮生效。
+func RunIPBlockExpirySweeper(ctx context.Context, client cfclient.Client, accounts func() []config.CF, sender Sender, interval time.Duration) {
+	manager, ok := client.(cloudflareAccountIPBlockManager)
+	if !ok {
+		log.Printf("Cloudflare 客户端不支持 WAF IP 黑名单管理，跳过临时封禁清理")
+		return
+	}
+	if interval <= 0 {
+		interval = config.IPBlockSweepInterval()
+	}
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if DryRunEnabled() {
+			// 全局 dry-run 期间不做真实解封，到期记录保留到关闭 dry-run 后的下一轮。
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			continue
+		}
+		result, err := sweepIPBlockExpiries(ctx, manager, accounts(), config.IPBlockExpiryFile(), time.Now())
+		if err != nil {
+			log.Printf("临时 IP 封禁清理失败: %v", err)
+		} else if len(result.Values) > 0 {
+			_ = sender.Send(ctx, result.Summary())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sweepIPBlockExpiries(ctx context.Context, manager cloudflareAccountIPBlockManager, accounts []config.CF, path string, now time.Time) (ipBlockSweepResult, error) {
+	var result ipBlockSweepResult
+	ipBlockExpiryMu.Lock()
+	entries, err := loadIPBlockExpiries(path)
+	ipBlockExpiryMu.Unlock()
+	if err != nil {
+		return result, err
+	}
+
+	byLabel := make(map[string]config.CF, len(accounts))
+	for _, account := range accounts {
+		byLabel[strings.TrimSpace(account.Label)] = account
+	}
+	valuesByLabel := map[string][]string{}
+	missingByLabel := map[string][]string{}
+	for value, entry := range entries {
+		if entry.ExpiresAt.After(now) {
+			continue
+		}
+		pending := false
+		for _, label := range entry.Accounts {
+			if _, ok := byLabel[label]; ok {
+				valuesByLabel[label] = append(valuesByLabel[label], value)
+				pending = true
+			} else if !containsLabel(entry.MissingReported, label) {
+				missingByLabel[label] = append(missingByLabel[label], value)
+				pending = true
+			}
+		}
+		// 只剩已提醒过的缺失账号时不再处理，等账号重新加入配置或手动清理。
+		if pending {
+			result.Values = append(result.Values, value)
+		}
+	}
+	if len(result.Values) == 0 {
+		return result, nil
+	}
+	sort.Strings(result.Values)
+	for label, values := range missingByLabel {
+		sort.Strings(values)
+		result.Missing = append(result.Missing, fmt.Sprintf("%s: %s", label, strings.Join(values, ", ")))
+	}
+	sort.Strings(result.Missing)
+
+	done := map[string]bool{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for label, values := range valuesByLabel {
+		account := byLabel[label]
+		sort.Strings(values)
+		wg.Add(1)
+		go func(label string, account config.CF, values []string) {
+			defer wg.Done()
+			accountResult := processCFIPBlockAccount(ctx, manager, AccountTarget{Account: account}, "delete", values)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Accounts = append(result.Accounts, accountResult)
+			for _, item := range accountResult.Failed {
+				result.Failed = append(result.Failed, label+": "+item)
+			}
+			done[label] = len(accountResult.Failed) == 0
+		}(label, account, values)
+	}
+	wg.Wait()
+	sort.Slice(result.Accounts, func(i, j int) bool { return result.Accounts[i].AccountLabel < result.Accounts[j].AccountLabel })
+	sort.Strings(result.Failed)
+
+	err = updateIPBlockExpiries(path, func(current map[string]IPBlockExpiry) {
+		for _, value := range result.Values {
+			entry, ok := current[value]
+			// 清理期间被重新 add 延长的记录保持不动。
+			if !ok || entry.ExpiresAt.After(now) {
+				continue
+			}
+			var remaining, reported []string
+			for _, label := range entry.Accounts {
+				if done[label] {
+					continue
+				}
+				remaining = append(remaining, label)
+				if _, ok := byLabel[label]; !ok {
+					reported = append(reported, label)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(current, value)
+				continue
+			}
+			entry.Accounts = remaining
+			entry.MissingReported = reported
+			current[value] = entry
+		}
+	})
+	return result, err
+}
+
+func (r ipBlockSweepResult) Summary() string {
+	var deleted, notFound int
+	for _, account := range r.Accounts {
+		deleted += account.Deleted
+		notFound += account.NotFound
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("临时 IP 封禁已到期，自动解封完成\n目标: %s\n账号: %d\ndeleted:%d not_found:%d failed:%d",
+		strings.Join(r.Values, ", "), len(r.Accounts), deleted, notFound, len(r.Failed)))
+	if len(r.Missing) > 0 {
+		sb.WriteString("\n\n账号不在配置中，到期记录已保留（只提醒一次，账号重新加入配置后自动解封，也可在 Cloudflare 手动删除）:")
+		for _, item := range r.Missing {
+			sb.WriteString("\n- " + item)
+		}
+	}
+	if len(r.Failed) > 0 {
+		sb.WriteString("\n\n失败明细（下一轮自动重试）:")
+		limit := len(r.Failed)
+		if limit > cfIPBlockMaxFailureLines {
+			limit = cfIPBlockMaxFailureLines
+		}
+		for _, item := range r.Failed[:limit] {
+			sb.WriteString("\n- " + item)
+		}
+		if len(r.Failed) > limit {
+			sb.WriteString(fmt.Sprintf("\n- 还有 %d 条失败明细未显示。", len(r.Failed)-limit))
+		}
+	}
+	return sb.String()
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, item := range labels {
+		if item == label {
+			return true
+		}
+	}
+	return false
+}
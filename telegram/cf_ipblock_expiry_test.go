@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeIPBlockManager struct {
+	cloudflareAccountIPBlockManager
+	mu      sync.Mutex
+	fail    map[string]bool
+	deleted map[string][]string
+}
+
+func (f *fakeIPBlockManager) ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error) {
+	return []cfclient.ZoneDetail{{ID: account.Label + "-zone", Name: account.Label + ".example"}}, nil
+}
+
+func (f *fakeIPBlockManager) DeleteIPBlockRule(ctx context.Context, account config.CF, zoneID string, values []string) (string, error) {
+	if f.fail[account.Label] {
+		return "", errors.New("api unavailable")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.deleted == nil {
+		f.deleted = map[string][]string{}
+	}
+	f.deleted[account.Label] = append(f.deleted[account.Label], values...)
+	return "deleted", nil
+}
+
+func TestParseCFIPBlockTTL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{raw: "30m", want: 30 * time.Minute},
+		{raw: "24h", want: 24 * time.Hour},
+		{raw: "7d", want: 7 * 24 * time.Hour},
+		{raw: "30s", wantErr: true},
+		{raw: "400d", wantErr: true},
+		{raw: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCFIPBlockTTL(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseCFIPBlockTTL(%q) = %v, %v; want %v, err=%v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	rest, ttl, err := extractCFIPBlockTTL([]string{"add", "203.0.113.1", "TTL=2h"})
+	if err != nil || ttl != 2*time.Hour || strings.Join(rest, " ") != "add 203.0.113.1" {
+		t.Fatalf("extractCFIPBlockTTL = %v, %v, %v", rest, ttl, err)
+	}
+}
+
+func TestSweepIPBlockExpiries(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Minute)
+	accounts := []config.CF{{Label: "main"}, {Label: "backup"}}
+
+	tests := []struct {
+		name        string
+		entries     map[string]IPBlockExpiry
+		fail        map[string]bool
+		wantValues  []string
+		wantDeleted map[string][]string
+		wantMissing int
+		wantLeft    map[string][]string
+	}{
+		{
+			name:     "not expired yet",
+			entries:  map[string]IPBlockExpiry{"203.0.113.1": {Value: "203.0.113.1", Accounts: []string{"main"}, ExpiresAt: now.Add(time.Hour)}},
+			wantLeft: map[string][]string{"203.0.113.1": {"main"}},
+		},
+		{
+			name:        "expired entries are removed after every account succeeds",
+			entries:     map[string]IPBlockExpiry{"203.0.113.1": {Value: "203.0.113.1", Accounts: []string{"backup", "main"}, ExpiresAt: expired}},
+			wantValues:  []string{"203.0.113.1"},
+			wantDeleted: map[string][]string{"main": {"203.0.113.1"}, "backup": {"203.0.113.1"}},
+			wantLeft:    map[string][]string{},
+		},
+		{
+			name:        "failed accounts are kept for the next round",
+			entries:     map[string]IPBlockExpiry{"203.0.113.1": {Value: "203.0.113.1", Accounts: []string{"backup", "main"}, ExpiresAt: expired}},
+			fail:        map[string]bool{"backup": true},
+			wantValues:  []string{"203.0.113.1"},
+			wantDeleted: map[string][]string{"main": {"203.0.113.1"}},
+			wantLeft:    map[string][]string{"203.0.113.1": {"backup"}},
+		},
+		{
+			name:        "accounts missing from config are kept and reported",
+			entries:     map[string]IPBlockExpiry{"203.0.113.1": {Value: "203.0.113.1", Accounts: []string{"gone", "main"}, ExpiresAt: expired}},
+			wantValues:  []string{"203.0.113.1"},
+			wantDeleted: map[string][]string{"main": {"203.0.113.1"}},
+			wantMissing: 1,
+			wantLeft:    map[string][]string{"203.0.113.1": {"gone"}},
+		},
+		{
+			name:     "already reported missing accounts stay quiet",
+			entries:  map[string]IPBlockExpiry{"203.0.113.1": {Value: "203.0.113.1", Accounts: []string{"gone"}, MissingReported: []string{"gone"}, ExpiresAt: expired}},
+			wantLeft: map[string][]string{"203.0.113.1": {"gone"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ipblock_expiry.json")
+			if err := saveIPBlockExpiries(path, tt.entries); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			manager := &fakeIPBlockManager{fail: tt.fail}
+			result, err := sweepIPBlockExpiries(context.Background(), manager, accounts, path, now)
+			if err != nil {
+				t.Fatalf("sweep: %v", err)
+			}
+			if strings.Join(result.Values, ",") != strings.Join(tt.wantValues, ",") {
+				t.Fatalf("values = %v, want %v", result.Values, tt.wantValues)
+			}
+			if len(result.Missing) != tt.wantMissing {
+				t.Fatalf("missing = %v, want %d", result.Missing, tt.wantMissing)
+			}
+			if len(manager.deleted) != len(tt.wantDeleted) {
+				t.Fatalf("deleted = %v, want %v", manager.deleted, tt.wantDeleted)
+			}
+			for label, values := range tt.wantDeleted {
+				if strings.Join(manager.deleted[label], ",") != strings.Join(values, ",") {
+					t.Fatalf("deleted[%s] = %v, want %v", label, manager.deleted[label], values)
+				}
+			}
+			left, err := loadIPBlockExpiries(path)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(left) != len(tt.wantLeft) {
+				t.Fatalf("remaining entries = %+v, want %v", left, tt.wantLeft)
+			}
+			for value, labels := range tt.wantLeft {
+				got := append([]string(nil), left[value].Accounts...)
+				sort.Strings(got)
+				if strings.Join(got, ",") != strings.Join(labels, ",") {
+					t.Fatalf("entry %s accounts = %v, want %v", value, got, labels)
+				}
+			}
+		})
+	}
+}
+
+func TestSweepIPBlockExpiriesReportsMissingAccountOnce(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "ipblock_expiry.json")
+	entries := map[string]IPBlockExpiry{"198.51.100.7": {Value: "198.51.100.7", Accounts: []string{"gone"}, ExpiresAt: now.Add(-time.Hour)}}
+	if err := saveIPBlockExpiries(path, entries); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	manager := &fakeIPBlockManager{}
+	first, err := sweepIPBlockExpiries(context.Background(), manager, []config.CF{{Label: "main"}}, path, now)
+	if err != nil || len(first.Missing) != 1 || !strings.Contains(first.Summary(), "gone: 198.51.100.7") {
+		t.Fatalf("first sweep should report missing account, result=%+v err=%v", first, err)
+	}
+	second, err := sweepIPBlockExpiries(context.Background(), manager, []config.CF{{Label: "main"}}, path, now)
+	if err != nil || len(second.Values) != 0 {
+		t.Fatalf("second sweep should stay quiet, result=%+v err=%v", second, err)
+	}
+
+	// 账号重新加入配置后继续解封并删除记录。
+	third, err := sweepIPBlockExpiries(context.Background(), manager, []config.CF{{Label: "gone"}}, path, now)
+	if err != nil || len(third.Values) != 1 || len(manager.deleted["gone"]) != 1 {
+		t.Fatalf("restored account should be swept, result=%+v deleted=%v err=%v", third, manager.deleted, err)
+	}
+	if left, _ := loadIPBlockExpiries(path); len(left) != 0 {
+		t.Fatalf("entry should be removed after restored account succeeds: %+v", left)
+	}
+}
@@ -54,6 +54,7 @@ type CFRulesBatchResult struct {
 	AccountLabel string
 	Action       string
 	Feature      string
+	RateLimit    cfclient.RateLimitRuleOptions
 	Success      []cfclient.FeatureManageResult
 	Failed       []string
 }
@@ -62,6 +63,7 @@ type CFRulesAllAccountsResult struct {
 	Action         string
 	Feature        string
 	BlockCountries []string
+	RateLimit      cfclient.RateLimitRuleOptions
 	Accounts       []CFRulesBatchResult
 	Failed         []string
 }
@@ -86,13 +88,14 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 		h.sendText("未配置可用的 Cloudflare 账号，无法检查规则。")
 		return
 	}
+	args, dryRun := extractDryRunArg(args)
 	if len(args) == 0 {
 		h.sendCFRulesAccountSelector()
 		return
 	}
 
-	if isCFRulesAllAccountsArg(args[0]) {
-		action, feature, blockCountries, err := parseCFRulesAllAccountsArgs(args[1:])
+	if isCFRulesAllAccountsArg(args[0]) || config.IsMultiAccountSelector(args[0]) {
+		action, feature, blockCountries, rateLimit, err := parseCFRulesAllAccountsArgs(args[1:])
 		if err != nil {
 			h.sendText(err.Error())
 			return
@@ -101,13 +104,37 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 			h.sendText("启用国家/地区安全规则需要代码。SQL 拦截示例：/cf_rules all sql；国家拦截示例：/cf_rules all security block=AM,HK")
 			return
 		}
-		accounts := append([]config.CF(nil), h.Accounts...)
+		if rateLimit, err = validateCFRulesRateLimit(action, feature, rateLimit); err != nil {
+			h.sendText(err.Error() + "\n\n" + cfRulesRateLimitUsage())
+			return
+		}
+		selector := args[0]
+		if isCFRulesAllAccountsArg(selector) {
+			selector = "all"
+		}
+		targets, err := h.resolveAccountTargets(selector)
+		if err != nil {
+			h.sendText(err.Error() + "\n\n" + h.accountSelectorHelp())
+			return
+		}
+		client, recorder := BeginDryRun(h.CFClient, dryRun)
+		if action == "disable" && ApprovalRequired(ApprovalActionCFRulesAllDisable, recorder) {
+			description := fmt.Sprintf("/cf_rules %s action=disable feature=%s（账号 %d 个）", selector, feature, len(targets))
+			_, err := RequestApproval(context.Background(), h.Sender, ApprovalActionCFRulesAllDisable, description, h.operator, func(requester, approver string) {
+				result := ProcessCFRulesTargets(context.Background(), client, targets, action, feature, blockCountries, rateLimit)
+				FinishDryRun(context.Background(), h.Sender, nil, "", result.Summary()+"\n\n"+FormatApprovalFooter(requester, approver))
+			})
+			if err != nil {
+				h.sendText(fmt.Sprintf("发送审批请求失败: %v", err))
+			}
+			return
+		}
 		go func() {
-			result := ProcessCFRulesAllAccounts(context.Background(), h.CFClient, accounts, action, feature, blockCountries)
-			h.sendText(result.Summary())
+			result := ProcessCFRulesTargets(context.Background(), client, targets, action, feature, blockCountries, rateLimit)
+			FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_rules %s %s %s", selector, action, feature), result.Summary())
 		}()
-		h.sendText(fmt.Sprintf("Cloudflare 全账号规则检查任务已提交：账号 %d，动作 %s，功能 %s，国家拦截 %s。账号之间并发执行，每个账号内部限速 %s/域名。",
-			len(accounts), action, feature, formatCFRulesBlockCountries(feature, blockCountries), cfRulesPerAccountInterval))
+		h.sendText(fmt.Sprintf("Cloudflare 批量规则检查任务已提交：%s（账号 %d），动作 %s，功能 %s，国家拦截 %s。账号之间并发执行，每个账号内部限速 %s/域名。",
+			DescribeTargets(targets), len(targets), action, feature, formatCFRulesBlockCountries(feature, blockCountries), cfRulesPerAccountInterval))
 		return
 	}
 
@@ -132,6 +159,7 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 	action := "enable"
 	feature := "all"
 	blockCountries := config.DefaultBlockCountries()
+	var rateLimit cfclient.RateLimitRuleOptions
 	for _, arg := range args[2:] {
 		key, value, ok := strings.Cut(arg, "=")
 		if !ok {
@@ -146,17 +174,24 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 			h.sendText("无法识别参数：" + arg)
 			return
 		}
+		if handled, err := applyCFRulesRateLimitArg(&rateLimit, key, value); handled {
+			if err != nil {
+				h.sendText(err.Error())
+				return
+			}
+			continue
+		}
 		switch strings.ToLower(strings.TrimSpace(key)) {
 		case "action":
 			action = normalizeCFRulesAction(value)
 			if action == "" {
-				h.sendText("action 参数必须是 enable/update/on 或 disable/delete/off")
+				h.sendText("action 参数必须是 enable/update/on 或 disable/delete/off；限速动作可用 block/managed_challenge/js_challenge/challenge/log")
 				return
 			}
 		case "feature":
 			feature = normalizeCFRulesFeature(value)
 			if feature == "" {
-				h.sendText("feature 参数必须是 all/security/sql/speed/cache")
+				h.sendText("feature 参数必须是 all/security/sql/speed/cache/ratelimit")
 				return
 			}
 		case "block", "blocks", "country", "countries":
@@ -176,6 +211,11 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 		h.sendText("启用国家/地区安全规则需要代码。请使用 block=CN,RU，或配置 CF_DEFAULT_BLOCK_COUNTRIES。SQL 拦截可直接使用 feature=sql。")
 		return
 	}
+	rateLimit, err = validateCFRulesRateLimit(action, feature, rateLimit)
+	if err != nil {
+		h.sendText(err.Error() + "\n\n" + cfRulesRateLimitUsage())
+		return
+	}
 
 	zones, err := h.CFClient.ListZones(context.Background(), *account)
 	if err != nil {
@@ -191,10 +231,15 @@ func (h *CommandHandler) handleCFRulesCommand(args []string) {
 		return
 	}
 	go func() {
-		result := ProcessCFRulesItems(context.Background(), h.CFClient, *account, items, action, feature, blockCountries)
-		h.sendText(result.Summary())
+		client, recorder := BeginDryRun(h.CFClient, dryRun)
+		result := ProcessCFRulesItems(context.Background(), client, *account, items, action, feature, blockCountries, rateLimit)
+		FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_rules %s %s %s", account.Label, action, feature), result.Summary())
 	}()
-	h.sendText(fmt.Sprintf("Cloudflare 规则检查任务已提交：账号 %s，域名 %d，动作 %s，功能 %s", account.Label, len(items), action, feature))
+	msg := fmt.Sprintf("Cloudflare 规则检查任务已提交：账号 %s，域名 %d，动作 %s，功能 %s", account.Label, len(items), action, feature)
+	if feature == "ratelimit" {
+		msg += "，限速 " + formatCFRulesRateLimit(action, rateLimit)
+	}
+	h.sendText(msg)
 }
 
 func (h *CommandHandler) sendCFRulesAccountSelector() {
@@ -210,7 +255,7 @@ func (h *CommandHandler) sendCFRulesAccountSelector() {
 			CallbackData: fmt.Sprintf("cfrules_account|%s", token),
 		}})
 	}
-	msg := "请选择要检查规则的 Cloudflare 账号：\n\n批量所有账号 SQL 拦截示例：\n/cf_rules all sql\n\n批量所有账号国家拦截示例：\n/cf_rules all security block=AM,HK"
+	msg := "请选择要检查规则的 Cloudflare 账号：\n\n批量所有账号 SQL 拦截示例：\n/cf_rules all sql\n\n批量所有账号国家拦截示例：\n/cf_rules all security block=AM,HK\n\n按分组/标签批量示例：\n/cf_rules group:prod sql\n\n" + h.accountSelectorHelp()
 	if err := h.Sender.SendWithButtons(context.Background(), msg, buttons); err != nil {
 		h.sendText(fmt.Sprintf("发送账号选择失败: %v", err))
 	}
@@ -518,6 +563,10 @@ func BuildCFRulesActionView(sessionID string, items []CFRulesDomainItem) IPListP
 				{Text: "开启/更新SQL拦截", CallbackData: fmt.Sprintf("cfrules_run|%s", token("enable", "sql"))},
 				{Text: "关闭SQL拦截", CallbackData: fmt.Sprintf("cfrules_run|%s", token("disable", "sql"))},
 			},
+			{
+				{Text: "开启/更新限速", CallbackData: fmt.Sprintf("cfrules_run|%s", token("enable", "ratelimit"))},
+				{Text: "关闭限速", CallbackData: fmt.Sprintf("cfrules_run|%s", token("disable", "ratelimit"))},
+			},
 			{
 				{Text: "返回选择", CallbackData: fmt.Sprintf("cfrules_back|%s", token("", ""))},
 				{Text: "取消", CallbackData: fmt.Sprintf("cfrules_cancel|%s", token("", ""))},
@@ -556,6 +605,9 @@ func (h *CommandHandler) handlePendingCFRulesInput(msgText string, userID int64)
 		h.sendText("已取消 Cloudflare 规则检查。")
 		return true
 	}
+	if req.Feature == "ratelimit" {
+		return h.handlePendingCFRulesRateLimitInput(req, input, userID)
+	}
 
 	var countries []string
 	var err error
@@ -590,17 +642,18 @@ func (h *CommandHandler) handlePendingCFRulesInput(msgText string, userID int64)
 
 	ClearPendingCFRulesInput(userID)
 	go func() {
-		result := ProcessCFRulesItems(context.Background(), h.CFClient, *account, items, req.Action, req.Feature, countries)
+		client, recorder := BeginDryRun(h.CFClient, false)
+		result := ProcessCFRulesItems(context.Background(), client, *account, items, req.Action, req.Feature, countries, cfclient.RateLimitRuleOptions{})
 		ClearCFRulesSelection(req.SessionID)
-		h.sendText(result.Summary())
+		FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_rules %s %s %s", account.Label, req.Action, req.Feature), result.Summary())
 	}()
 	h.sendText(fmt.Sprintf("Cloudflare 规则检查任务已提交：账号 %s，域名 %d，动作 %s，功能 %s，国家拦截 %s",
 		account.Label, len(items), req.Action, req.Feature, formatCFRulesBlockCountries(req.Feature, countries)))
 	return true
 }
 
-func ProcessCFRulesItems(ctx context.Context, client cfclient.Client, account config.CF, items []CFRulesDomainItem, action string, feature string, blockCountries []string) CFRulesBatchResult {
-	result := CFRulesBatchResult{AccountLabel: account.Label, Action: action, Feature: feature}
+func ProcessCFRulesItems(ctx context.Context, client cfclient.Client, account config.CF, items []CFRulesDomainItem, action string, feature string, blockCountries []string, rateLimit cfclient.RateLimitRuleOptions) CFRulesBatchResult {
+	result := CFRulesBatchResult{AccountLabel: account.Label, Action: action, Feature: feature, RateLimit: rateLimit}
 	manager, ok := client.(cloudflareFeatureManager)
 	if !ok {
 		result.Failed = append(result.Failed, "当前 Cloudflare 客户端不支持规则管理")
@@ -610,6 +663,7 @@ func ProcessCFRulesItems(ctx context.Context, client cfclient.Client, account co
 	sqli := feature == "all" || feature == "sql"
 	speed := feature == "all" || feature == "speed"
 	cache := feature == "all" || feature == "cache"
+	ratelimit := feature == "ratelimit"
 	pacer := newBatchAPIPacerWithInterval(cfRulesPerAccountInterval)
 	for _, item := range items {
 		if err := pacer.Wait(ctx); err != nil {
@@ -629,6 +683,8 @@ func ProcessCFRulesItems(ctx context.Context, client cfclient.Client, account co
 			SQLi:           sqli,
 			Speed:          speed,
 			Cache:          cache,
+			RateLimit:      ratelimit,
+			RateLimitRule:  rateLimit,
 		})
 		if len(managed.Errors) > 0 {
 			result.Failed = append(result.Failed, fmt.Sprintf("%s: %s", item.Name, strings.Join(managed.Errors, "; ")))
@@ -640,40 +696,47 @@ func ProcessCFRulesItems(ctx context.Context, client cfclient.Client, account co
 	return result
 }
 
-func ProcessCFRulesAllAccounts(ctx context.Context, client cfclient.Client, accounts []config.CF, action string, feature string, blockCountries []string) CFRulesAllAccountsResult {
+func ProcessCFRulesAllAccounts(ctx context.Context, client cfclient.Client, accounts []config.CF, action string, feature string, blockCountries []string, rateLimit cfclient.RateLimitRuleOptions) CFRulesAllAccountsResult {
+	return ProcessCFRulesTargets(ctx, client, WholeAccountTargets(accounts), action, feature, blockCountries, rateLimit)
+}
+
+// ProcessCFRulesTargets 按账号并发处理选择器命中的域名；目标带 Domains 时只处理这些域名。
+func ProcessCFRulesTargets(ctx context.Context, client cfclient.Client, targets []AccountTarget, action string, feature string, blockCountries []string, rateLimit cfclient.RateLimitRuleOptions) CFRulesAllAccountsResult {
 	result := CFRulesAllAccountsResult{
 		Action:         action,
 		Feature:        feature,
 		BlockCountries: append([]string(nil), blockCountries...),
+		RateLimit:      rateLimit,
 	}
-	if len(accounts) == 0 {
+	if len(targets) == 0 {
 		result.Failed = append(result.Failed, "未配置可用的 Cloudflare 账号")
 		return result
 	}
 
-	ch := make(chan CFRulesBatchResult, len(accounts))
+	ch := make(chan CFRulesBatchResult, len(targets))
 	var wg sync.WaitGroup
-	for _, account := range accounts {
-		account := account
+	for _, target := range targets {
+		target := target
+		account := target.Account
 		if strings.TrimSpace(account.Label) == "" {
 			continue
 		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			accountResult := CFRulesBatchResult{AccountLabel: account.Label, Action: action, Feature: feature}
+			accountResult := CFRulesBatchResult{AccountLabel: account.Label, Action: action, Feature: feature, RateLimit: rateLimit}
 			zones, err := client.ListZones(ctx, account)
 			if err != nil {
 				accountResult.Failed = append(accountResult.Failed, fmt.Sprintf("读取域名失败: %v", err))
 				ch <- accountResult
 				return
 			}
-			items := buildCFRulesDomainItemsFromZones(account.Label, zones)
+			items := buildCFRulesDomainItemsFromZones(account.Label, target.FilterZones(zones))
 			if len(items) == 0 {
 				ch <- accountResult
 				return
 			}
-			ch <- ProcessCFRulesItems(ctx, client, account, items, action, feature, blockCountries)
+			ch <- ProcessCFRulesItems(ctx, client, account, items, action, feature, blockCountries, rateLimit)
 		}()
 	}
 	go func() {
@@ -695,13 +758,17 @@ func ProcessCFRulesAllAccounts(ctx context.Context, client cfclient.Client, acco
 func (r CFRulesBatchResult) Summary() string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Cloudflare 规则检查完成\n账号: %s\n动作: %s\n功能: %s\n已处理: %d", r.AccountLabel, r.Action, r.Feature, len(r.Success)))
+	if r.Feature == "ratelimit" {
+		sb.WriteString("\n限速: " + formatCFRulesRateLimit(r.Action, r.RateLimit))
+	}
 	for _, item := range r.Success {
-		sb.WriteString(fmt.Sprintf("\n- %s | 国家:%s | SQL:%s | 速度:%s | 缓存:%s",
+		sb.WriteString(fmt.Sprintf("\n- %s | 国家:%s | SQL:%s | 速度:%s | 缓存:%s | 限速:%s",
 			item.Domain,
 			normalizeDisplayValue(item.SecurityRuleStatus),
 			normalizeDisplayValue(item.SQLiRuleStatus),
 			compactSpeedStatus(item.SpeedStatus),
 			normalizeDisplayValue(item.CacheRuleStatus),
+			normalizeDisplayValue(item.RateLimitStatus),
 		))
 	}
 	if len(r.Failed) > 0 {
@@ -721,6 +788,9 @@ func (r CFRulesAllAccountsResult) Summary() string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Cloudflare 全账号规则检查完成\n动作: %s\n功能: %s\n国家拦截: %s\n账号: %d\n已处理域名: %d\n失败: %d",
 		r.Action, r.Feature, formatCFRulesBlockCountries(r.Feature, r.BlockCountries), len(r.Accounts), processed, len(r.Failed)))
+	if r.Feature == "ratelimit" {
+		sb.WriteString("\n限速: " + formatCFRulesRateLimit(r.Action, r.RateLimit))
+	}
 	for _, account := range r.Accounts {
 		sb.WriteString(fmt.Sprintf("\n- %s | 已处理:%d | 失败:%d", account.AccountLabel, len(account.Success), len(account.Failed)))
 	}
@@ -751,10 +821,11 @@ func formatCFRulesBlockCountries(feature string, countries []string) string {
 	return strings.Join(countries, ",")
 }
 
-func parseCFRulesAllAccountsArgs(args []string) (string, string, []string, error) {
+func parseCFRulesAllAccountsArgs(args []string) (string, string, []string, cfclient.RateLimitRuleOptions, error) {
 	action := "enable"
 	feature := "security"
 	blockCountries := config.DefaultBlockCountries()
+	var rateLimit cfclient.RateLimitRuleOptions
 	start := 0
 	if len(args) > 0 && !strings.Contains(args[0], "=") {
 		if isCFRulesAllDomainsArg(args[0]) {
@@ -778,19 +849,25 @@ func parseCFRulesAllAccountsArgs(args []string) (string, string, []string, error
 				action = parsedAction
 				continue
 			}
-			return action, feature, blockCountries, fmt.Errorf("无法识别参数：%s", arg)
+			return action, feature, blockCountries, rateLimit, fmt.Errorf("无法识别参数：%s", arg)
+		}
+		if handled, err := applyCFRulesRateLimitArg(&rateLimit, key, value); handled {
+			if err != nil {
+				return action, feature, blockCountries, rateLimit, err
+			}
+			continue
 		}
 		switch strings.ToLower(strings.TrimSpace(key)) {
 		case "action":
 			parsed := normalizeCFRulesAction(value)
 			if parsed == "" {
-				return action, feature, blockCountries, fmt.Errorf("action 参数必须是 enable/update/on 或 disable/delete/off")
+				return action, feature, blockCountries, rateLimit, fmt.Errorf("action 参数必须是 enable/update/on 或 disable/delete/off；限速动作可用 block/managed_challenge/js_challenge/challenge/log")
 			}
 			action = parsed
 		case "feature":
 			parsed := normalizeCFRulesFeature(value)
 			if parsed == "" {
-				return action, feature, blockCountries, fmt.Errorf("feature 参数必须是 all/security/sql/speed/cache")
+				return action, feature, blockCountries, rateLimit, fmt.Errorf("feature 参数必须是 all/security/sql/speed/cache/ratelimit")
 			}
 			feature = parsed
 		case "block", "blocks", "country", "countries":
@@ -800,14 +877,14 @@ func parseCFRulesAllAccountsArgs(args []string) (string, string, []string, error
 			}
 			countries, err := cfclient.NormalizeCountryCodes([]string{value})
 			if err != nil {
-				return action, feature, blockCountries, fmt.Errorf("block 参数错误：%v", err)
+				return action, feature, blockCountries, rateLimit, fmt.Errorf("block 参数错误：%v", err)
 			}
 			blockCountries = countries
 		default:
-			return action, feature, blockCountries, fmt.Errorf("未知参数 %s", key)
+			return action, feature, blockCountries, rateLimit, fmt.Errorf("未知参数 %s", key)
 		}
 	}
-	return action, feature, blockCountries, nil
+	return action, feature, blockCountries, rateLimit, nil
 }
 
 func isCFRulesAllAccountsArg(raw string) bool {
@@ -851,6 +928,8 @@ func normalizeCFRulesFeature(raw string) string {
 		return "speed"
 	case "cache":
 		return "cache"
+	case "ratelimit", "rate_limit", "rate-limit", "ratelimiting", "rl", "限速":
+		return "ratelimit"
 	default:
 		return ""
 	}
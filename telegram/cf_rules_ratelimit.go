@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"DomainC/cfclient"
+)
+
+// applyCFRulesRateLimitArg 解析 /cf_rules 中的限速参数；返回 false 表示不是限速参数。
+func applyCFRulesRateLimitArg(opts *cfclient.RateLimitRuleOptions, key string, value string) (bool, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	parseInt := func(name string) (int, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s 参数必须是非负整数: %s", name, value)
+		}
+		return n, nil
+	}
+	var err error
+	switch key {
+	case "path", "uri":
+		opts.Path = value
+	case "method":
+		opts.Method = value
+	case "rps":
+		opts.RequestsPerSecond, err = parseInt("rps")
+	case "requests", "requests_per_period", "limit":
+		opts.RequestsPerPeriod, err = parseInt("requests")
+	case "period":
+		opts.Period, err = parseInt("period")
+	case "timeout", "mitigation_timeout":
+		opts.MitigationTimeout, err = parseInt("timeout")
+	case "action":
+		// action=enable/disable 仍表示规则开关，只有限速动作才在这里处理。
+		if normalizeCFRulesAction(value) != "" || !cfclient.IsRateLimitAction(value) {
+			return false, nil
+		}
+		opts.Action = strings.ToLower(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// validateCFRulesRateLimit 在提交任务前校验限速参数；关闭时 path 可省略，表示删除全部自动限速规则。
+func validateCFRulesRateLimit(action string, feature string, opts cfclient.RateLimitRuleOptions) (cfclient.RateLimitRuleOptions, error) {
+	if feature != "ratelimit" {
+		return opts, nil
+	}
+	if action == "disable" {
+		opts.Path = strings.TrimSpace(opts.Path)
+		opts.Method = strings.ToUpper(strings.TrimSpace(opts.Method))
+		return opts, nil
+	}
+	return cfclient.NormalizeRateLimitRuleOptions(opts)
+}
+
+func formatCFRulesRateLimit(action string, opts cfclient.RateLimitRuleOptions) string {
+	if action == "disable" {
+		if strings.TrimSpace(opts.Path) == "" {
+			return "删除全部自动限速规则"
+		}
+		return "删除 " + cfclient.RateLimitRuleDescription(opts.Path, opts.Method)
+	}
+	return cfclient.FormatRateLimitRule(opts)
+}
+
+func cfRulesRateLimitUsage() string {
+	return "限速用法：\n/cf_rules 账号标签 all ratelimit path=/login rps=10 period=60 action=block\n/cf_rules all ratelimit path=/api/* requests=100 period=10 action=managed_challenge method=POST\n/cf_rules all ratelimit action=disable [path=/login]\n\n说明：rps 为每秒请求数（会乘以 period 换算），也可直接用 requests 指定每个周期的请求数；period 可选 10/60/120/300/600/3600 秒；action 可选 block/managed_challenge/js_challenge/challenge/log；timeout 为 block 的封禁时长，默认等于 period。"
+}
+
+// BuildCFRulesRateLimitPrompt 提示在选择域名后输入限速参数。
+func BuildCFRulesRateLimitPrompt(req CFRulesInputRequest, errText string) string {
+	var sb strings.Builder
+	if strings.TrimSpace(errText) != "" {
+		sb.WriteString("限速参数错误: " + errText + "\n\n")
+	}
+	sb.WriteString("请输入限速参数，例如：\npath=/login rps=10 period=60 action=block\n")
+	sb.WriteString("也可以直接发送路径（如 /login），其余参数使用默认值 period=60 action=block，此时需要补充 rps 或 requests。\n")
+	sb.WriteString("发送 cancel 取消本次规则检查。")
+	return sb.String()
+}
+
+func parseCFRulesRateLimitInput(input string) (cfclient.RateLimitRuleOptions, error) {
+	var opts cfclient.RateLimitRuleOptions
+	for _, field := range strings.Fields(input) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			if strings.HasPrefix(field, "/") && opts.Path == "" {
+				opts.Path = field
+				continue
+			}
+			return opts, fmt.Errorf("无法识别参数：%s", field)
+		}
+		handled, err := applyCFRulesRateLimitArg(&opts, key, value)
+		if err != nil {
+			return opts, err
+		}
+		if !handled {
+			return opts, fmt.Errorf("未知参数 %s", key)
+		}
+	}
+	return cfclient.NormalizeRateLimitRuleOptions(opts)
+}
+
+func (h *CommandHandler) handlePendingCFRulesRateLimitInput(req CFRulesInputRequest, input string, userID int64) bool {
+	opts, err := parseCFRulesRateLimitInput(input)
+	if err != nil {
+		h.sendText(BuildCFRulesRateLimitPrompt(req, err.Error()))
+		return true
+	}
+	items, ok := SelectedCFRulesDomainItems(req.SessionID)
+	if !ok || len(items) == 0 {
+		ClearPendingCFRulesInput(userID)
+		h.sendText("Cloudflare 规则检查选择已过期，请重新执行 /cf_rules。")
+		return true
+	}
+	account := h.getAccountByLabel(req.AccountLabel)
+	if account == nil {
+		ClearPendingCFRulesInput(userID)
+		h.sendText("未找到 Cloudflare 账号：" + req.AccountLabel)
+		return true
+	}
+
+	ClearPendingCFRulesInput(userID)
+	go func() {
+		client, recorder := BeginDryRun(h.CFClient, false)
+		result := ProcessCFRulesItems(context.Background(), client, *account, items, req.Action, req.Feature, nil, opts)
+		ClearCFRulesSelection(req.SessionID)
+		FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("cf_rules %s %s %s", account.Label, req.Action, req.Feature), result.Summary())
+	}()
+	h.sendText(fmt.Sprintf("Cloudflare 限速规则任务已提交：账号 %s，域名 %d，规则 %s",
+		account.Label, len(items), cfclient.FormatRateLimitRule(opts)))
+	return true
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-
-	"DomainC/config"
 )
 
 func (h *CommandHandler) handleCheckCFCommand(args []string) {
@@ -20,32 +18,28 @@ func (h *CommandHandler) handleCheckCFCommand(args []string) {
 		return
 	}
 
-	var targets []config.CF
-	if strings.EqualFold(selector, "all") {
-		targets = append(targets, h.Accounts...)
-		if len(targets) == 0 {
-			h.sendText("未配置可用的 Cloudflare 账号，无法检测。")
-			return
-		}
-	} else {
-		acc := h.getAccountByLabel(selector)
-		if acc == nil {
-			h.sendText(fmt.Sprintf("未找到账号 %s。\n\n%s", selector, h.checkCFPromptText()))
-			return
-		}
-		targets = []config.CF{*acc}
+	if len(h.Accounts) == 0 {
+		h.sendText("未配置可用的 Cloudflare 账号，无法检测。")
+		return
+	}
+	targets, err := h.resolveAccountTargets(selector)
+	if err != nil {
+		h.sendText(fmt.Sprintf("%v。\n\n%s", err, h.checkCFPromptText()))
+		return
 	}
 
 	ctx := context.Background()
 	var sb strings.Builder
 	sb.WriteString("Cloudflare 账号检测结果：\n")
 
-	for _, acc := range targets {
+	for _, target := range targets {
+		acc := target.Account
 		zones, err := h.CFClient.ListZones(ctx, acc)
 		if err != nil {
 			h.sendText(fmt.Sprintf("列出账号 %s 的域名失败: %v", acc.Label, err))
 			return
 		}
+		zones = target.FilterZones(zones)
 
 		inactive := 0
 		sb.WriteString(fmt.Sprintf("\n账号: %s\n", acc.Label))
@@ -94,6 +88,7 @@ func (h *CommandHandler) checkCFPromptText() string {
 		}
 		sb.WriteString("- " + a.Label + "\n")
 	}
-	sb.WriteString("- all\n\n请输入：\n/checkcf all\n或者：\n/checkcf 账号标签")
+	sb.WriteString("- all\n\n请输入：\n/checkcf all\n或者：\n/checkcf 账号标签\n\n")
+	sb.WriteString(h.accountSelectorHelp())
 	return sb.String()
 }
@@ -38,5 +38,6 @@ func (h *CommandHandler) handleCLSCommand(args []string) {
 	}
 
 	operator := formatOperator(h.operator)
+	RecordOperation(OperationEntry{Operation: "cls", Account: account.Label, Zone: zone.Name, Operator: operator})
 	h.sendText(fmt.Sprintf("✅ 已清理缓存：%s (账号: %s，操作人: %s)", zone.Name, account.Label, operator))
 }
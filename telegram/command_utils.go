@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"strconv"
 	"strings"
+	"time"
 
 	"DomainC/cfclient"
 	"DomainC/config"
@@ -133,3 +135,23 @@ func deriveDomainFromName(name string) string {
 	}
 	return ""
 }
+
+// parseHumanDuration 解析时长参数，支持 Go duration（30m/24h）以及按天的 7d 写法。
+func parseHumanDuration(raw string) (time.Duration, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if value == "" {
+		return 0, fmt.Errorf("不能为空")
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("格式错误: %s（示例: 30m、24h、7d）", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("格式错误: %s（示例: 30m、24h、7d）", raw)
+	}
+	return parsed, nil
+}
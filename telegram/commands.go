@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"strings"
+	"sync"
 
 	"DomainC/cfclient"
 	"DomainC/config"
@@ -16,7 +17,15 @@ type CommandHandler struct {
 	Accounts         []config.CF
 	Sender           Sender
 	ChatID           int64
+	AbuseReports     AbuseReportQuerier
 	operator         *tgbotapi.User
+	// live 保存热加载后的账号列表；每条消息处理前复制一份 handler，进行中的流程继续使用旧账号。
+	live *liveAccounts
+}
+
+type liveAccounts struct {
+	mu       sync.RWMutex
+	accounts []config.CF
 }
 
 func NewCommandHandler(cf cfclient.Client, registrarManager *registrarclient.Manager, sender Sender, accounts []config.CF, chatID int64) *CommandHandler {
@@ -26,7 +35,35 @@ func NewCommandHandler(cf cfclient.Client, registrarManager *registrarclient.Man
 	if sender == nil {
 		sender = DefaultSender()
 	}
-	return &CommandHandler{CFClient: cf, RegistrarManager: registrarManager, Accounts: accounts, Sender: sender, ChatID: chatID}
+	return &CommandHandler{
+		CFClient:         cf,
+		RegistrarManager: registrarManager,
+		Accounts:         accounts,
+		Sender:           sender,
+		ChatID:           chatID,
+		live:             &liveAccounts{accounts: accounts},
+	}
+}
+
+// SetAccounts 在配置热加载后替换账号列表，对之后收到的消息生效。
+func (h *CommandHandler) SetAccounts(accounts []config.CF) {
+	if h.live == nil {
+		return
+	}
+	h.live.mu.Lock()
+	h.live.accounts = append([]config.CF(nil), accounts...)
+	h.live.mu.Unlock()
+}
+
+// forMessage 返回带有当前账号列表的副本，避免并发消息之间共享 operator 和账号切片。
+func (h *CommandHandler) forMessage() *CommandHandler {
+	c := *h
+	if h.live != nil {
+		h.live.mu.RLock()
+		c.Accounts = h.live.accounts
+		h.live.mu.RUnlock()
+	}
+	return &c
 }
 
 func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
@@ -36,7 +73,13 @@ func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
 	if msg.Chat != nil && !config.IsTelegramChatAllowed(msg.Chat.ID) {
 		return
 	}
+	h = h.forMessage()
 	if !msg.IsCommand() {
+		if msg.From != nil && msg.Document != nil {
+			if h.handlePendingIPListSyncDocument(msg.Document, msg.From.ID) {
+				return
+			}
+		}
 		if msg.From != nil && msg.Text != "" {
 			if h.handlePendingOriginSSLInput(msg.Text, msg.From.ID) {
 				return
@@ -53,6 +96,9 @@ func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
 			if h.handlePendingCFRulesInput(msg.Text, msg.From.ID) {
 				return
 			}
+			if h.handlePendingIPListSyncInput(msg.Text, msg.From.ID) {
+				return
+			}
 			if h.handlePendingIPListInput(msg.Text, msg.From.ID) {
 				return
 			}
@@ -96,6 +142,28 @@ func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
 		go h.handleCFRulesCommand(args)
 	case "cf_ipblock", "ipblock":
 		go h.handleCFIPBlockCommand(args)
+	case "ipaccess":
+		go h.handleIPAccessCommand(args)
+	case "attack":
+		go h.handleAttackCommand(args)
+	case "waf_events":
+		go h.handleWAFEventsCommand(args)
+	case "move":
+		go h.handleMoveCommand(args)
+	case "snapshot":
+		go h.handleSnapshotCommand(args)
+	case "dryrun":
+		go h.handleDryRunCommand(args)
+	case "approvals":
+		go h.handleApprovalsCommand()
+	case "config":
+		go h.handleConfigCommand(args)
+	case "tokens":
+		go h.handleTokensCommand(args)
+	case "tag":
+		go h.handleTagCommand(args)
+	case "abuse":
+		go h.handleAbuseCommand(args)
 	}
 
 }
@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	"DomainC/config"
+)
+
+func (h *CommandHandler) handleConfigCommand(args []string) {
+	if len(args) == 0 || !strings.EqualFold(args[0], "validate") {
+		h.sendText("用法: /config validate —— 校验磁盘上的配置文件（不会输出 token 等敏感值）")
+		return
+	}
+	path := config.LoadedPath()
+	parsed, err := config.Parse(path)
+	if err != nil {
+		h.sendText(fmt.Sprintf("❌ 配置文件 %s 无法解析: %v", path, err))
+		return
+	}
+	h.sendText(BuildConfigValidationReport(path, &parsed, config.Validate(&parsed)))
+}
+
+// BuildConfigValidationReport 汇总账号数量和校验问题，只展示 label 与字段路径。
+func BuildConfigValidationReport(path string, c *config.Config, problems []config.Problem) string {
+	var sb strings.Builder
+	if config.HasErrors(problems) {
+		sb.WriteString(fmt.Sprintf("❌ 配置文件 %s 存在错误，热加载会被拒绝\n", path))
+	} else {
+		sb.WriteString(fmt.Sprintf("✅ 配置文件 %s 校验通过\n", path))
+	}
+	sb.WriteString(fmt.Sprintf("Cloudflare 账号 %d 个，注册商 %d 个，AWS 目标 %d 个，API token %d 个\n",
+		len(c.CloudflareAccounts), len(c.Registrars), len(c.AWSTargets), len(c.API.Tokens)))
+	sb.WriteString(FormatConfigProblems(problems))
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatConfigProblems 按错误、警告分组列出问题。
+func FormatConfigProblems(problems []config.Problem) string {
+	if len(problems) == 0 {
+		return "未发现问题。"
+	}
+	var errs, warns []string
+	for _, p := range problems {
+		line := fmt.Sprintf("- %s: %s", p.Field, p.Message)
+		if p.Level == config.ProblemError {
+			errs = append(errs, line)
+		} else {
+			warns = append(warns, line)
+		}
+	}
+	var sb strings.Builder
+	if len(errs) > 0 {
+		sb.WriteString(fmt.Sprintf("错误 %d 项:\n%s\n", len(errs), strings.Join(errs, "\n")))
+	}
+	if len(warns) > 0 {
+		sb.WriteString(fmt.Sprintf("警告 %d 项:\n%s\n", len(warns), strings.Join(warns, "\n")))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
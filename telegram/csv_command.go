@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"DomainC/cfclient"
 	"DomainC/config"
 )
 
@@ -26,26 +27,20 @@ func (h *CommandHandler) handleCSVCommand(args []string) {
 		return
 	}
 
-	// 2) 选择账号
-	var targets []config.CF
-	if strings.EqualFold(selector, "all") {
-		targets = append(targets, h.Accounts...)
-		if len(targets) == 0 {
-			h.sendText("未配置可用的 Cloudflare 账号，无法导出。")
-			return
-		}
-	} else {
-		acc := h.getAccountByLabel(selector)
-		if acc == nil {
-			h.sendText(fmt.Sprintf("未找到账号 %s。\n\n%s", selector, h.csvPromptText()))
-			return
-		}
-		targets = []config.CF{*acc}
+	// 2) 选择账号（支持 all、账号标签、group:、tag:）
+	if len(h.Accounts) == 0 {
+		h.sendText("未配置可用的 Cloudflare 账号，无法导出。")
+		return
+	}
+	targets, err := h.resolveAccountTargets(selector)
+	if err != nil {
+		h.sendText(fmt.Sprintf("%v。\n\n%s", err, h.csvPromptText()))
+		return
 	}
 	h.sendText("要遍历所有账号的所有解析记录，且要控制查询速度，避免被 Cloudflare 限制，因此过程较慢，请耐心等待...")
 	// 3) 拉取数据并生成 CSV
 	ctx := context.Background()
-	csvBytes, filename, err := h.buildDNSExportCSV(ctx, targets)
+	csvBytes, filename, err := BuildDNSExportCSVForTargets(ctx, h.CFClient, targets)
 	if err != nil {
 		h.sendText(fmt.Sprintf("导出失败: %v", err))
 		return
@@ -98,7 +93,8 @@ func (h *CommandHandler) csvPromptText() string {
 		}
 		sb.WriteString("- " + a.Label + "\n")
 	}
-	sb.WriteString("- all\n\n请输入：\n/csv all\n或者：\n/csv 账号标签")
+	sb.WriteString("- all\n\n请输入：\n/csv all\n或者：\n/csv 账号标签\n\n")
+	sb.WriteString(h.accountSelectorHelp())
 	return sb.String()
 }
 
@@ -112,7 +108,13 @@ func (h *CommandHandler) getAccountByLabel(label string) *config.CF {
 	return nil
 }
 
-func (h *CommandHandler) buildDNSExportCSV(ctx context.Context, accounts []config.CF) ([]byte, string, error) {
+// BuildDNSExportCSV 导出账号下全部 Zone 的 DNS 记录。
+func BuildDNSExportCSV(ctx context.Context, client cfclient.Client, accounts []config.CF) ([]byte, string, error) {
+	return BuildDNSExportCSVForTargets(ctx, client, WholeAccountTargets(accounts))
+}
+
+// BuildDNSExportCSVForTargets 导出选择器命中的 Zone 的 DNS 记录，/csv 和命令行 dns export 共用。
+func BuildDNSExportCSVForTargets(ctx context.Context, client cfclient.Client, targets []AccountTarget) ([]byte, string, error) {
 	// 文件名：dns-export-YYYYMMDD-HHMMSS.csv
 	filename := fmt.Sprintf("dns-export-%s.csv", time.Now().Format("20060102-150405"))
 
@@ -134,11 +136,13 @@ func (h *CommandHandler) buildDNSExportCSV(ctx context.Context, accounts []confi
 		return nil, "", err
 	}
 
-	for _, acc := range accounts {
-		zones, err := h.CFClient.ListZones(ctx, acc)
+	for _, target := range targets {
+		acc := target.Account
+		zones, err := client.ListZones(ctx, acc)
 		if err != nil {
 			return nil, "", fmt.Errorf("列出账号 %s 的域名失败: %w", acc.Label, err)
 		}
+		zones = target.FilterZones(zones)
 
 		for _, z := range zones {
 			zonePaused := "否"
@@ -146,7 +150,7 @@ func (h *CommandHandler) buildDNSExportCSV(ctx context.Context, accounts []confi
 				zonePaused = "是"
 			}
 
-			records, err := h.CFClient.ListDNSRecords(ctx, acc, z.Name)
+			records, err := client.ListDNSRecords(ctx, acc, z.Name)
 			if err != nil {
 				return nil, "", fmt.Errorf("获取 %s(%s) DNS 失败: %w", z.Name, acc.Label, err)
 			}
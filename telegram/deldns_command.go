@@ -4,21 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 
 	"DomainC/cfclient"
 )
 
 func (h *CommandHandler) handleDelDNSCommand(args []string) {
+	args, dryRunArg := extractDryRunArg(args)
 	if len(args) < 1 {
-		h.sendText("用法: /deldns <sub.domain.com | domain.com | URL>")
+		h.sendText("用法: /deldns <sub.domain.com | domain.com | URL> [dryrun]")
 		return
 	}
 
 	raw := strings.TrimSpace(args[0])
 	q, err := extractDomainOrHost(raw)
 	if err != nil {
-		h.sendText(fmt.Sprintf("参数不合法：%v\n用法: /deldns <sub.domain.com | domain.com | URL>", err))
+		h.sendText(fmt.Sprintf("参数不合法：%v\n用法: /deldns <sub.domain.com | domain.com | URL> [dryrun]", err))
 		return
 	}
 
@@ -32,11 +34,23 @@ func (h *CommandHandler) handleDelDNSCommand(args []string) {
 		return
 	}
 
-	deleted, err := h.CFClient.DeleteDNSRecord(context.Background(), *account, zone.Name, q)
+	// 删除前保存原记录，用于“撤销”按钮重新创建。
+	before, beforeErr := listDNSRecordsByName(context.Background(), h.CFClient, *account, zone, q)
+	if beforeErr != nil {
+		log.Printf("[deldns] snapshot_before_delete_failed name=%s zone=%s err=%v", q, zone.Name, beforeErr)
+	}
+
+	client, recorder := BeginDryRun(h.CFClient, dryRunArg)
+	deleted, err := client.DeleteDNSRecord(context.Background(), *account, zone.Name, q)
 	if err != nil {
 		h.sendText(fmt.Sprintf("删除解析记录失败: %v", err))
 		return
 	}
+	if recorder != nil {
+		FinishDryRun(context.Background(), h.Sender, recorder, "deldns "+q,
+			fmt.Sprintf("删除 %s 的解析记录（账号: %s，Zone: %s，读取到 %d 条）", q, account.Label, zone.Name, len(before)))
+		return
+	}
 
 	if deleted == 0 {
 		h.sendText(fmt.Sprintf("未找到 %s 的解析记录（账号: %s，Zone: %s）。", q, account.Label, zone.Name))
@@ -44,5 +58,14 @@ func (h *CommandHandler) handleDelDNSCommand(args []string) {
 	}
 
 	operator := formatOperator(h.operator)
-	h.sendText(fmt.Sprintf("✅ 已删除 %d 条解析记录：%s (账号: %s，Zone: %s，操作人: %s)", deleted, q, account.Label, zone.Name, operator))
+	msg := fmt.Sprintf("✅ 已删除 %d 条解析记录：%s (账号: %s，Zone: %s，操作人: %s)", deleted, q, account.Label, zone.Name, operator)
+	if beforeErr != nil {
+		h.sendText(msg + "\n\n⚠️ 删除前未能读取原记录，本次操作无法撤销。")
+		return
+	}
+	changes := make([]DNSRecordChange, 0, len(before))
+	for _, record := range before {
+		changes = append(changes, DNSRecordChange{ZoneName: zone.Name, Before: record})
+	}
+	h.sendDNSMutationResult(msg, "deldns", *account, changes)
 }
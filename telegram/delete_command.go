@@ -9,6 +9,8 @@ import (
 	"DomainC/cfclient"
 	"DomainC/config"
 	"DomainC/reminder"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type DeleteBatchResult struct {
@@ -17,6 +19,7 @@ type DeleteBatchResult struct {
 	ParseErrors   []string
 	Missing       []string
 	Failed        []string
+	Snapshots     []string
 }
 
 func (r DeleteBatchResult) HasErrors() bool {
@@ -27,6 +30,13 @@ func (r DeleteBatchResult) Summary() string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("✅ /delete 处理完成\n搜索范围: %s\n成功: %d", r.TargetAccount, len(r.Deleted)))
 
+	if len(r.Snapshots) > 0 {
+		sb.WriteString("\n\n删除前快照（可用 /snapshot restore 恢复）:")
+		for _, item := range r.Snapshots {
+			sb.WriteString("\n- " + item)
+		}
+	}
+
 	if len(r.ParseErrors) > 0 {
 		sb.WriteString("\n\n格式错误:")
 		for _, item := range r.ParseErrors {
@@ -69,6 +79,7 @@ func ProcessDeleteBatch(client cfclient.Client, accounts []config.CF, domains []
 	}
 
 	ctx := context.Background()
+	dryRun := isDryRunClient(client)
 	pacer := newBatchAPIPacer()
 	for _, domain := range domains {
 		if err := pacer.Wait(ctx); err != nil {
@@ -76,6 +87,17 @@ func ProcessDeleteBatch(client cfclient.Client, accounts []config.CF, domains []
 			continue
 		}
 
+		if !dryRun {
+			snapshotID, err := snapshotZoneBeforeDelete(ctx, client, accounts, domain)
+			if err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("%s: 删除前快照失败，未删除: %v", domain, err))
+				continue
+			}
+			if snapshotID != "" {
+				result.Snapshots = append(result.Snapshots, fmt.Sprintf("%s %s", domain, snapshotID))
+			}
+		}
+
 		deletedAccount, err := deleteDomainAcrossAccounts(ctx, client, accounts, domain)
 		if err != nil {
 			if errors.Is(err, cfclient.ErrZoneNotFound) || strings.Contains(strings.ToLower(err.Error()), "zone not found") {
@@ -87,7 +109,7 @@ func ProcessDeleteBatch(client cfclient.Client, accounts []config.CF, domains []
 		}
 
 		result.Deleted = append(result.Deleted, domain)
-		if rt := reminder.DefaultRuntime(); rt != nil {
+		if rt := reminder.DefaultRuntime(); rt != nil && !dryRun {
 			if deletedAccount != nil {
 				rt.RecordDomainDeletion(ctx, domain, deletedAccount.Label)
 			} else {
@@ -99,6 +121,32 @@ func ProcessDeleteBatch(client cfclient.Client, accounts []config.CF, domains []
 	return result
 }
 
+// ConfirmDeleteZone 执行到期提醒“删除域名”按钮的确认：与 /delete 相同，按配置先请求第二人批准，
+// 执行时先快照再删除。返回的错误只表示审批请求发送失败。
+func ConfirmDeleteZone(ctx context.Context, client cfclient.Client, sender Sender, account config.CF, domain string, user *tgbotapi.User) error {
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	runClient, recorder := BeginDryRun(client, false)
+	run := func(footer string) {
+		result := ProcessDeleteBatch(runClient, []config.CF{account}, []string{domain})
+		result.TargetAccount = account.Label
+		if len(result.Deleted) > 0 && recorder == nil {
+			RecordOperation(OperationEntry{Operation: "delete", Account: account.Label, Zone: domain, Operator: formatOperator(user)})
+		}
+		FinishDryRun(ctx, sender, recorder, "delete "+domain, result.Summary()+footer)
+	}
+	if ApprovalRequired(ApprovalActionDelete, recorder) {
+		description := fmt.Sprintf("删除域名 %s（账号 %s）", domain, account.Label)
+		_, err := RequestApproval(ctx, sender, ApprovalActionDelete, description, user, func(requester, approver string) {
+			run("\n\n" + FormatApprovalFooter(requester, approver))
+		})
+		return err
+	}
+	run("")
+	return nil
+}
+
 func (h *CommandHandler) handleDeleteCommand(args []string) {
 	if len(h.Accounts) == 0 {
 		h.sendText("未配置可用的 Cloudflare 账号，无法删除域名。")
@@ -113,12 +161,24 @@ func (h *CommandHandler) handleDeleteCommand(args []string) {
 		return
 	}
 
+	args, dryRunArg := extractDryRunArg(args)
 	domains, parseErrors := parseGetNSDomainsInput(strings.Join(args, "\n"))
 	if len(domains) == 0 {
 		h.sendText(h.deleteRetryPrompt("", parseErrors))
 		return
 	}
 
+	if dryRunArg {
+		// dry-run 不会删除任何 Zone，无需二次确认。
+		go func() {
+			client, recorder := BeginDryRun(h.CFClient, true)
+			result := ProcessDeleteBatch(client, h.Accounts, domains)
+			result.ParseErrors = append(result.ParseErrors, parseErrors...)
+			FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("delete %d domains", len(domains)), result.Summary())
+		}()
+		return
+	}
+
 	h.sendDeleteBatchConfirm(domains, parseErrors)
 }
 
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type fakeZoneDeleter struct {
+	*fakeZoneMover
+}
+
+func (f fakeZoneDeleter) DeleteDomain(ctx context.Context, account config.CF, domain string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, account.Label+"/"+domain)
+	return nil
+}
+
+// 到期提醒的“删除域名”按钮（delete_confirm 回调）与 /delete 一样需要审批，批准后先快照再删除。
+func TestConfirmDeleteZoneRequestsApprovalAndSnapshotsBeforeDelete(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	dir := t.TempDir()
+	cfg := prev
+	cfg.ZoneSnapshot.Dir = filepath.Join(dir, "snapshots")
+	cfg.OperationLog.File = filepath.Join(dir, "operation_log.json")
+	cfg.Approval = config.Approval{Actions: []string{ApprovalActionDelete}}
+	config.Set(cfg)
+
+	client := fakeZoneDeleter{&fakeZoneMover{}}
+	sender := &recordingSender{}
+	requester := &tgbotapi.User{ID: 1, UserName: "ops"}
+	if err := ConfirmDeleteZone(context.Background(), client, sender, config.CF{Label: "main"}, "expiring.com", requester); err != nil {
+		t.Fatalf("ConfirmDeleteZone: %v", err)
+	}
+	if got := client.deletedZones(); len(got) != 0 {
+		t.Fatalf("zone deleted before approval: %v", got)
+	}
+	var token string
+	for _, item := range ListPendingApprovals() {
+		if item.Action == ApprovalActionDelete && strings.Contains(item.Description, "expiring.com") {
+			token = item.Token
+		}
+	}
+	if token == "" || !strings.Contains(sender.text(), "approval_approve|"+token) {
+		t.Fatalf("expected approval request, got %q", sender.text())
+	}
+
+	if _, err := ApproveApproval(token, &tgbotapi.User{ID: 2, UserName: "lead"}); err != nil {
+		t.Fatalf("ApproveApproval: %v", err)
+	}
+	waitFor(t, "zone deletion", func() bool { return len(client.deletedZones()) == 1 })
+	waitFor(t, "result message", func() bool { return strings.Contains(sender.text(), "批准人") })
+	if got := client.deletedZones(); got[0] != "main/expiring.com" {
+		t.Fatalf("unexpected deletion: %v", got)
+	}
+	if text := sender.text(); !strings.Contains(text, "删除前快照") || !strings.Contains(text, "expiring.com") {
+		t.Fatalf("result should list the pre-delete snapshot: %s", text)
+	}
+	if ops, _ := RecentOperations("expiring.com", time.Time{}, 0); len(ops) != 1 || ops[0].Operation != "delete" {
+		t.Fatalf("expected delete operation to be logged, got %+v", ops)
+	}
+}
@@ -0,0 +1,305 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// DNSRecordChange 记录一次 DNS 变更前后的状态；After 为 nil 表示记录被删除。
+type DNSRecordChange struct {
+	ZoneName string                      `json:"zone_name"`
+	Before   cfclient.SnapshotDNSRecord  `json:"before"`
+	After    *cfclient.SnapshotDNSRecord `json:"after,omitempty"`
+}
+
+// DNSUndoEntry 是一次可撤销的 DNS 操作，过期或已撤销后按钮失效。
+type DNSUndoEntry struct {
+	Token        string            `json:"token"`
+	Operation    string            `json:"operation"`
+	AccountLabel string            `json:"account_label"`
+	Operator     string            `json:"operator,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	Changes      []DNSRecordChange `json:"changes"`
+	UndoneAt     *time.Time        `json:"undone_at,omitempty"`
+	UndoneBy     string            `json:"undone_by,omitempty"`
+}
+
+type dnsUndoCache struct {
+	Version int                     `json:"version"`
+	Entries map[string]DNSUndoEntry `json:"entries"`
+}
+
+type cloudflareDNSUndoer interface {
+	GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error)
+	ListZoneDNSRecords(ctx context.Context, account config.CF, zoneID string) ([]cfclient.SnapshotDNSRecord, error)
+	CreateZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, record cfclient.SnapshotDNSRecord) (cfclient.SnapshotDNSRecord, error)
+	PatchZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, recordID string, record cfclient.SnapshotDNSRecord) (cfclient.SnapshotDNSRecord, error)
+}
+
+var dnsUndoMu sync.Mutex
+
+func dnsRecordSnapshot(record cloudflare.DNSRecord) cfclient.SnapshotDNSRecord {
+	out := cfclient.SnapshotDNSRecord{
+		ID:      record.ID,
+		Type:    record.Type,
+		Name:    record.Name,
+		Content: record.Content,
+		TTL:     record.TTL,
+		Proxied: record.Proxied,
+		Comment: record.Comment,
+	}
+	if record.Priority != nil {
+		priority := int(*record.Priority)
+		out.Priority = &priority
+	}
+	return out
+}
+
+func updateDNSUndoEntries(path string, fn func(entries map[string]DNSUndoEntry)) error {
+	dnsUndoMu.Lock()
+	defer dnsUndoMu.Unlock()
+	var cache dnsUndoCache
+	if err := loadJSONStateFile(path, &cache); err != nil {
+		return err
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]DNSUndoEntry{}
+	}
+	fn(cache.Entries)
+	return saveJSONStateFile(path, dnsUndoCache{Version: 1, Entries: cache.Entries})
+}
+
+// RecordDNSUndo 保存一次 DNS 变更的前置状态，返回撤销按钮使用的 token；没有变更时返回空字符串。
+func RecordDNSUndo(operation string, account config.CF, operator string, changes []DNSRecordChange) (string, error) {
+	if len(changes) == 0 {
+		return "", nil
+	}
+	now := time.Now().UTC()
+	entry := DNSUndoEntry{
+		Token:        newInteractionToken(),
+		Operation:    operation,
+		AccountLabel: account.Label,
+		Operator:     operator,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(config.DNSUndoWindow()),
+		Changes:      changes,
+	}
+	err := updateDNSUndoEntries(config.DNSUndoStateFile(), func(entries map[string]DNSUndoEntry) {
+		for token, existing := range entries {
+			if now.After(existing.ExpiresAt) {
+				delete(entries, token)
+			}
+		}
+		entries[entry.Token] = entry
+	})
+	if err != nil {
+		return "", err
+	}
+	return entry.Token, nil
+}
+
+// DNSUndoButtons 返回“撤销”按钮；token 为空时不带按钮。
+func DNSUndoButtons(token string) [][]Button {
+	if token == "" {
+		return nil
+	}
+	return [][]Button{{{Text: fmt.Sprintf("↩️ 撤销（%s 内有效）", config.DNSUndoWindow()), CallbackData: "dnsundo|" + token}}}
+}
+
+// sendDNSMutationResult 记录撤销信息并发送带“撤销”按钮的结果消息；记录失败时退化为普通消息。
+func (h *CommandHandler) sendDNSMutationResult(msg, operation string, account config.CF, changes []DNSRecordChange) {
+	recordDNSChangeOperations(operation, account, formatOperator(h.operator), changes)
+	token, err := RecordDNSUndo(operation, account, formatOperator(h.operator), changes)
+	if err != nil {
+		log.Printf("[dns_undo] record_failed op=%s account=%s err=%v", operation, account.Label, err)
+		h.sendText(msg + "\n\n⚠️ 撤销信息保存失败，本次操作无法通过按钮撤销。")
+		return
+	}
+	buttons := DNSUndoButtons(token)
+	if len(buttons) == 0 {
+		h.sendText(msg)
+		return
+	}
+	if err := h.Sender.SendWithButtons(context.Background(), msg, buttons); err != nil {
+		h.sendText(msg)
+	}
+}
+
+// UndoDNSMutation 把一次 DNS 操作恢复到操作前状态；记录在操作之后又被修改过时拒绝恢复该条记录。
+func UndoDNSMutation(ctx context.Context, client cfclient.Client, token, operator string) string {
+	if client == nil {
+		client = cfclient.NewClient()
+	}
+	undoer, ok := client.(cloudflareDNSUndoer)
+	if !ok {
+		return "当前 Cloudflare 客户端不支持撤销 DNS 操作。"
+	}
+	path := config.DNSUndoStateFile()
+	dryRun := isDryRunClient(client)
+	var entry DNSUndoEntry
+	var found bool
+	now := time.Now().UTC()
+	// 先占用条目，避免重复点击导致两次恢复；dry-run 只读取不占用。
+	err := updateDNSUndoEntries(path, func(entries map[string]DNSUndoEntry) {
+		entry, found = entries[token]
+		if dryRun || !found || entry.UndoneAt != nil || now.After(entry.ExpiresAt) {
+			return
+		}
+		claimed := entry
+		claimed.UndoneAt = &now
+		claimed.UndoneBy = operator
+		entries[token] = claimed
+	})
+	if err != nil {
+		return fmt.Sprintf("读取撤销记录失败: %v", err)
+	}
+	switch {
+	case !found:
+		return "撤销记录不存在或已过期清理。"
+	case entry.UndoneAt != nil:
+		return fmt.Sprintf("该操作已于 %s 由 %s 撤销。", entry.UndoneAt.Local().Format("2006-01-02 15:04:05"), entry.UndoneBy)
+	case now.After(entry.ExpiresAt):
+		return fmt.Sprintf("撤销窗口已过（截止 %s）。", entry.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	account := cfclient.GetAccountByLabel(entry.AccountLabel)
+	if account == nil {
+		return fmt.Sprintf("未找到账号 %s，无法撤销。", entry.AccountLabel)
+	}
+
+	var restored, refused, failed []string
+	byZone := make(map[string][]DNSRecordChange)
+	for _, change := range entry.Changes {
+		byZone[change.ZoneName] = append(byZone[change.ZoneName], change)
+	}
+	zoneNames := make([]string, 0, len(byZone))
+	for zoneName := range byZone {
+		zoneNames = append(zoneNames, zoneName)
+	}
+	sort.Strings(zoneNames)
+	for _, zoneName := range zoneNames {
+		restoredBefore := len(restored)
+		changes := byZone[zoneName]
+		zone, err := undoer.GetZoneDetails(ctx, *account, zoneName)
+		if err != nil {
+			for _, change := range changes {
+				failed = append(failed, fmt.Sprintf("%s %s: 查询 Zone 失败: %v", change.Before.Type, change.Before.Name, err))
+			}
+			continue
+		}
+		current, err := undoer.ListZoneDNSRecords(ctx, *account, zone.ID)
+		if err != nil {
+			for _, change := range changes {
+				failed = append(failed, fmt.Sprintf("%s %s: 读取当前记录失败: %v", change.Before.Type, change.Before.Name, err))
+			}
+			continue
+		}
+		byID := make(map[string]cfclient.SnapshotDNSRecord, len(current))
+		byKey := make(map[string]bool, len(current))
+		for _, record := range current {
+			byID[record.ID] = record
+			byKey[record.Key()] = true
+		}
+		for _, change := range changes {
+			label := fmt.Sprintf("%s %s", change.Before.Type, change.Before.Name)
+			if change.After == nil {
+				if byKey[change.Before.Key()] {
+					refused = append(refused, label+": 已存在相同记录，可能已被重新创建")
+					continue
+				}
+				if _, err := undoer.CreateZoneDNSRecord(ctx, *account, zone.ID, change.Before); err != nil {
+					failed = append(failed, fmt.Sprintf("%s: 重新创建失败: %v", label, err))
+					continue
+				}
+				restored = append(restored, fmt.Sprintf("%s: 已重新创建 → %s", label, change.Before.Content))
+				continue
+			}
+			live, ok := byID[change.After.ID]
+			if !ok {
+				refused = append(refused, label+": 记录已被删除")
+				continue
+			}
+			if !cfclient.SameDNSRecordState(live, *change.After) {
+				refused = append(refused, fmt.Sprintf("%s: 记录在操作后又被修改（当前 %s）", label, live.Content))
+				continue
+			}
+			if _, err := undoer.PatchZoneDNSRecord(ctx, *account, zone.ID, change.After.ID, change.Before); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: 恢复失败: %v", label, err))
+				continue
+			}
+			restored = append(restored, fmt.Sprintf("%s: %s → %s", label, change.After.Content, change.Before.Content))
+		}
+		if n := len(restored) - restoredBefore; n > 0 && !dryRun {
+			RecordOperation(OperationEntry{Operation: "dnsundo", Account: entry.AccountLabel, Zone: zoneName, Operator: operator, Detail: fmt.Sprintf("撤销 %s，恢复 %d 条记录", entry.Operation, n)})
+		}
+	}
+	if !dryRun && len(restored) == 0 && len(refused) == 0 && len(failed) > 0 {
+		// 全部因 API 错误失败时释放占用，允许在窗口内重试。
+		if err := updateDNSUndoEntries(path, func(entries map[string]DNSUndoEntry) {
+			if item, ok := entries[token]; ok {
+				item.UndoneAt = nil
+				item.UndoneBy = ""
+				entries[token] = item
+			}
+		}); err != nil {
+			log.Printf("[dns_undo] release_failed token=%s err=%v", token, err)
+		}
+	}
+	return BuildDNSUndoSummary(entry, operator, restored, refused, failed)
+}
+
+// BuildDNSUndoSummary 渲染撤销结果。
+func BuildDNSUndoSummary(entry DNSUndoEntry, operator string, restored, refused, failed []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("↩️ 已处理 %s 撤销\n账号: %s\n原操作人: %s\n撤销人: %s\n恢复: %d", entry.Operation, entry.AccountLabel, entry.Operator, operator, len(restored)))
+	for _, item := range restored {
+		sb.WriteString("\n- " + item)
+	}
+	if len(refused) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n拒绝恢复: %d", len(refused)))
+		for _, item := range refused {
+			sb.WriteString("\n- " + item)
+		}
+	}
+	if len(failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n失败: %d", len(failed)))
+		for _, item := range failed {
+			sb.WriteString("\n- " + item)
+		}
+	}
+	return sb.String()
+}
+
+var errDNSUndoUnsupported = errors.New("当前 Cloudflare 客户端不支持按 Zone 读取 DNS 记录")
+
+// listDNSRecordsByName 在删除前读取名称等于 name 的全部记录，用于撤销时重新创建。
+func listDNSRecordsByName(ctx context.Context, client cfclient.Client, account config.CF, zone cfclient.ZoneDetail, name string) ([]cfclient.SnapshotDNSRecord, error) {
+	undoer, ok := client.(cloudflareDNSUndoer)
+	if !ok {
+		return nil, errDNSUndoUnsupported
+	}
+	records, err := undoer.ListZoneDNSRecords(ctx, account, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	var out []cfclient.SnapshotDNSRecord
+	for _, record := range records {
+		if strings.ToLower(strings.TrimSuffix(record.Name, ".")) == name {
+			out = append(out, record)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type fakeDNSUndoer struct {
+	cfclient.Client
+	records map[string]cfclient.SnapshotDNSRecord
+	fail    bool
+	nextID  int
+}
+
+func (f *fakeDNSUndoer) GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	return cfclient.ZoneDetail{ID: "zone-" + domain, Name: domain}, nil
+}
+
+func (f *fakeDNSUndoer) ListZoneDNSRecords(ctx context.Context, account config.CF, zoneID string) ([]cfclient.SnapshotDNSRecord, error) {
+	out := make([]cfclient.SnapshotDNSRecord, 0, len(f.records))
+	for _, record := range f.records {
+		out = append(out, record)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (f *fakeDNSUndoer) CreateZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, record cfclient.SnapshotDNSRecord) (cfclient.SnapshotDNSRecord, error) {
+	if f.fail {
+		return cfclient.SnapshotDNSRecord{}, errors.New("api unavailable")
+	}
+	f.nextID++
+	record.ID = fmt.Sprintf("new-%d", f.nextID)
+	f.records[record.ID] = record
+	return record, nil
+}
+
+func (f *fakeDNSUndoer) PatchZoneDNSRecord(ctx context.Context, account config.CF, zoneID string, recordID string, record cfclient.SnapshotDNSRecord) (cfclient.SnapshotDNSRecord, error) {
+	if f.fail {
+		return cfclient.SnapshotDNSRecord{}, errors.New("api unavailable")
+	}
+	record.ID = recordID
+	f.records[recordID] = record
+	return record, nil
+}
+
+func setupDNSUndoTest(t *testing.T) {
+	t.Helper()
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	dir := t.TempDir()
+	cfg := prev
+	cfg.CloudflareAccounts = []config.CF{{Label: "main"}}
+	cfg.DNSUndo = config.DNSUndo{StateFile: filepath.Join(dir, "dns_undo.json")}
+	cfg.OperationLog.File = filepath.Join(dir, "operation_log.json")
+	config.Set(cfg)
+}
+
+func dnsRecord(id, name, content string) cfclient.SnapshotDNSRecord {
+	return cfclient.SnapshotDNSRecord{ID: id, Type: "A", Name: name, Content: content, TTL: 300}
+}
+
+// recordUpdate 模拟一次 setdns：r1 从 1.1.1.1 改为 2.2.2.2，并删除了 r2。
+func recordUpdate(t *testing.T) (string, *fakeDNSUndoer) {
+	t.Helper()
+	after := dnsRecord("r1", "www.example.com", "2.2.2.2")
+	changes := []DNSRecordChange{
+		{ZoneName: "example.com", Before: dnsRecord("r1", "www.example.com", "1.1.1.1"), After: &after},
+		{ZoneName: "example.com", Before: dnsRecord("r2", "api.example.com", "3.3.3.3")},
+	}
+	token, err := RecordDNSUndo("setdns", config.CF{Label: "main"}, "@alice", changes)
+	if err != nil || token == "" {
+		t.Fatalf("RecordDNSUndo: token=%q err=%v", token, err)
+	}
+	undoer := &fakeDNSUndoer{records: map[string]cfclient.SnapshotDNSRecord{"r1": after}}
+	return token, undoer
+}
+
+func loadDNSUndoEntry(t *testing.T, token string) DNSUndoEntry {
+	t.Helper()
+	var entry DNSUndoEntry
+	if err := updateDNSUndoEntries(config.DNSUndoStateFile(), func(entries map[string]DNSUndoEntry) {
+		entry = entries[token]
+	}); err != nil {
+		t.Fatalf("read undo entries: %v", err)
+	}
+	return entry
+}
+
+func TestUndoDNSMutationRestoresPriorRecords(t *testing.T) {
+	setupDNSUndoTest(t)
+	token, undoer := recordUpdate(t)
+
+	summary := UndoDNSMutation(context.Background(), undoer, token, "@bob")
+	if !strings.Contains(summary, "恢复: 2") {
+		t.Fatalf("expected both records restored:\n%s", summary)
+	}
+	if got := undoer.records["r1"].Content; got != "1.1.1.1" {
+		t.Fatalf("r1 content = %s, want 1.1.1.1", got)
+	}
+	var recreated bool
+	for _, record := range undoer.records {
+		if record.Name == "api.example.com" && record.Content == "3.3.3.3" {
+			recreated = true
+		}
+	}
+	if !recreated {
+		t.Fatalf("deleted record should be recreated: %+v", undoer.records)
+	}
+	if entry := loadDNSUndoEntry(t, token); entry.UndoneAt == nil || entry.UndoneBy != "@bob" {
+		t.Fatalf("entry should be marked undone: %+v", entry)
+	}
+	if again := UndoDNSMutation(context.Background(), undoer, token, "@carol"); !strings.Contains(again, "@bob") {
+		t.Fatalf("second undo should be rejected, got:\n%s", again)
+	}
+}
+
+func TestUndoDNSMutationRefusesRecordsChangedAfterwards(t *testing.T) {
+	setupDNSUndoTest(t)
+	token, undoer := recordUpdate(t)
+	undoer.records["r1"] = dnsRecord("r1", "www.example.com", "9.9.9.9")
+	undoer.records["r3"] = dnsRecord("r3", "api.example.com", "3.3.3.3")
+
+	summary := UndoDNSMutation(context.Background(), undoer, token, "@bob")
+	if !strings.Contains(summary, "拒绝恢复: 2") || !strings.Contains(summary, "又被修改") || !strings.Contains(summary, "已存在相同记录") {
+		t.Fatalf("expected both changes refused:\n%s", summary)
+	}
+	if got := undoer.records["r1"].Content; got != "9.9.9.9" {
+		t.Fatalf("changed record must not be overwritten, got %s", got)
+	}
+}
+
+func TestUndoDNSMutationRejectsExpiredToken(t *testing.T) {
+	setupDNSUndoTest(t)
+	token, undoer := recordUpdate(t)
+	if err := updateDNSUndoEntries(config.DNSUndoStateFile(), func(entries map[string]DNSUndoEntry) {
+		entry := entries[token]
+		entry.ExpiresAt = time.Now().Add(-time.Minute)
+		entries[token] = entry
+	}); err != nil {
+		t.Fatalf("expire entry: %v", err)
+	}
+
+	summary := UndoDNSMutation(context.Background(), undoer, token, "@bob")
+	if !strings.Contains(summary, "撤销窗口已过") {
+		t.Fatalf("expected expiry message, got:\n%s", summary)
+	}
+	if got := undoer.records["r1"].Content; got != "2.2.2.2" {
+		t.Fatalf("expired undo must not touch records, got %s", got)
+	}
+	if missing := UndoDNSMutation(context.Background(), undoer, "unknown-token", "@bob"); !strings.Contains(missing, "不存在") {
+		t.Fatalf("expected unknown token message, got:\n%s", missing)
+	}
+}
+
+func TestUndoDNSMutationReleasesClaimWhenEveryRestoreFails(t *testing.T) {
+	setupDNSUndoTest(t)
+	token, undoer := recordUpdate(t)
+	undoer.fail = true
+
+	summary := UndoDNSMutation(context.Background(), undoer, token, "@bob")
+	if !strings.Contains(summary, "失败: 2") {
+		t.Fatalf("expected both restores to fail:\n%s", summary)
+	}
+	if entry := loadDNSUndoEntry(t, token); entry.UndoneAt != nil || entry.UndoneBy != "" {
+		t.Fatalf("claim should be released after every restore failed: %+v", entry)
+	}
+
+	undoer.fail = false
+	retry := UndoDNSMutation(context.Background(), undoer, token, "@bob")
+	if !strings.Contains(retry, "恢复: 2") {
+		t.Fatalf("retry within the window should succeed:\n%s", retry)
+	}
+}
@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+// dryRunOverride 保存 /dryrun on|off 的运行时覆盖；未设置时使用配置 dryRun.enabled。
+var dryRunOverride atomic.Pointer[bool]
+
+var dryRunSlugPattern = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// DryRunEnabled 返回当前全局 dry-run 开关。
+func DryRunEnabled() bool {
+	if v := dryRunOverride.Load(); v != nil {
+		return *v
+	}
+	return config.DryRunEnabled()
+}
+
+func SetDryRunEnabled(enabled bool) {
+	dryRunOverride.Store(&enabled)
+}
+
+// extractDryRunArg 去掉参数中的 dryrun / dry-run / --dry-run。
+func extractDryRunArg(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		switch strings.ToLower(strings.TrimSpace(arg)) {
+		case "dryrun", "dry-run", "--dry-run", "--dryrun":
+			found = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, found
+}
+
+// BeginDryRun 在请求了 dryrun 或全局开关开启时返回 client 的 dry-run 副本；否则原样返回 client。
+// client 已是 dry-run 客户端时沿用它的 recorder。
+func BeginDryRun(client cfclient.Client, requested bool) (cfclient.Client, *cfclient.DryRunRecorder) {
+	if recorder, ok := cfclient.DryRunRecorderOf(client); ok {
+		return client, recorder
+	}
+	if !requested && !DryRunEnabled() {
+		return client, nil
+	}
+	if client == nil {
+		client = cfclient.NewClient()
+	}
+	recorder := cfclient.NewDryRunRecorder()
+	return cfclient.WithDryRun(client, recorder), recorder
+}
+
+// dryRunPrefix 标注需要二次确认的 dry-run 操作，recorder 为 nil 时返回空字符串。
+func dryRunPrefix(recorder *cfclient.DryRunRecorder) string {
+	if recorder == nil {
+		return ""
+	}
+	return "🧪 DRY-RUN：确认后只输出计划，不执行写操作。\n"
+}
+
+// isDryRunClient 用于在 dry-run 时跳过本地状态（资产缓存、撤销记录、快照、到期记录）的写入。
+func isDryRunClient(client any) bool {
+	_, ok := cfclient.DryRunRecorderOf(client)
+	return ok
+}
+
+// WriteDryRunPlan 把计划写入 config.DryRunPlanDir()，返回文件路径。
+func WriteDryRunPlan(recorder *cfclient.DryRunRecorder, title, summary string) (string, cfclient.DryRunPlan, error) {
+	plan := recorder.Plan(title)
+	plan.Summary = summary
+	slug := strings.Trim(dryRunSlugPattern.ReplaceAllString(title, "_"), "_")
+	if slug == "" {
+		slug = "plan"
+	}
+	path := filepath.Join(config.DryRunPlanDir(), fmt.Sprintf("%s_%s.json", time.Now().UTC().Format("20060102-150405"), slug))
+	if err := saveJSONStateFile(path, plan); err != nil {
+		return "", plan, err
+	}
+	return path, plan, nil
+}
+
+// FinishDryRun 写出计划文件并发送给 Telegram；recorder 为 nil 时只发送 summary。
+func FinishDryRun(ctx context.Context, sender Sender, recorder *cfclient.DryRunRecorder, title, summary string) {
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	if recorder == nil {
+		if err := sender.Send(ctx, summary); err != nil {
+			log.Printf("发送结果失败: %v", err)
+		}
+		return
+	}
+	path, plan, err := WriteDryRunPlan(recorder, title, summary)
+	msg := BuildDryRunMessage(title, summary, plan)
+	if err != nil {
+		msg += fmt.Sprintf("\n\n⚠️ 写入计划文件失败: %v", err)
+	}
+	if sendErr := sender.Send(ctx, msg); sendErr != nil {
+		log.Printf("发送 dry-run 结果失败: %v", sendErr)
+	}
+	if err != nil {
+		return
+	}
+	if sendErr := sender.SendDocumentPath(ctx, path, fmt.Sprintf("dry-run 计划：%s（%d 个写请求）", title, len(plan.Writes))); sendErr != nil {
+		log.Printf("发送 dry-run 计划文件失败: %v", sendErr)
+	}
+}
+
+// BuildDryRunMessage 渲染 dry-run 结果，按 方法+路径 前缀汇总写请求。
+func BuildDryRunMessage(title, summary string, plan cfclient.DryRunPlan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧪 DRY-RUN：%s\n未执行任何写操作。读请求 %d 个，计划写请求 %d 个。\n", title, plan.Reads, len(plan.Writes)))
+	for i, write := range plan.Writes {
+		if i >= 15 {
+			sb.WriteString(fmt.Sprintf("... 另有 %d 个写请求，见计划文件\n", len(plan.Writes)-i))
+			break
+		}
+		path := write.Path
+		if idx := strings.Index(path, "?"); idx >= 0 {
+			path = path[:idx]
+		}
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s %s\n", write.Seq, write.Account, write.Method, path))
+	}
+	if strings.TrimSpace(summary) != "" {
+		sb.WriteString("\n按计划执行后的结果预估:\n")
+		sb.WriteString(summary)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (h *CommandHandler) handleDryRunCommand(args []string) {
+	if len(args) == 0 || strings.EqualFold(args[0], "status") {
+		state := "关闭"
+		if DryRunEnabled() {
+			state = "开启"
+		}
+		h.sendText(fmt.Sprintf("全局 dry-run：%s\n计划文件目录：%s\n\n%s", state, config.DryRunPlanDir(), dryRunUsage()))
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "on", "enable", "true":
+		SetDryRunEnabled(true)
+		h.sendText(fmt.Sprintf("✅ 已开启全局 dry-run（操作人: %s）。批量命令只读取 Cloudflare 并输出计划文件，不会执行写操作。", formatOperator(h.operator)))
+	case "off", "disable", "false":
+		SetDryRunEnabled(false)
+		h.sendText(fmt.Sprintf("✅ 已关闭全局 dry-run（操作人: %s），批量命令恢复真实执行。", formatOperator(h.operator)))
+	default:
+		h.sendText(dryRunUsage())
+	}
+}
+
+func dryRunUsage() string {
+	return "用法: /dryrun on|off|status\n" +
+		"也可以在单条命令后追加 dryrun，例如: /cf_rules all sql dryrun、/cf_ipblock add 1.2.3.4 dryrun、/delete dryrun a.com b.com、/deldns www.a.com dryrun、/attack all on 2h dryrun、/move a.com old new dryrun；/setdns 输入新目标时追加 dryrun。\n" +
+		"dry-run 会执行相同的处理流程：读请求照常访问 Cloudflare，写请求只被记录并以计划文件（JSON）返回。"
+}
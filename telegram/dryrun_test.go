@@ -0,0 +1,33 @@
+package telegram
+
+import (
+	"testing"
+
+	"DomainC/cfclient"
+)
+
+func TestBeginDryRunFollowsGlobalSwitchAndReusesRecorder(t *testing.T) {
+	prev := DryRunEnabled()
+	t.Cleanup(func() { SetDryRunEnabled(prev) })
+	base := cfclient.NewClient()
+
+	SetDryRunEnabled(false)
+	if client, recorder := BeginDryRun(base, false); client != base || recorder != nil {
+		t.Fatalf("dry-run off should return the shared client unchanged")
+	}
+
+	SetDryRunEnabled(true)
+	client, recorder := BeginDryRun(base, false)
+	if recorder == nil || client == base || !isDryRunClient(client) {
+		t.Fatalf("global dry-run should wrap the shared client")
+	}
+	if isDryRunClient(base) {
+		t.Fatalf("wrapping must not turn the shared client into a dry-run client")
+	}
+
+	SetDryRunEnabled(false)
+	again, reused := BeginDryRun(client, false)
+	if again != client || reused != recorder {
+		t.Fatalf("an existing dry-run client should keep its recorder")
+	}
+}
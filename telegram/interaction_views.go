@@ -24,7 +24,7 @@ func BuildIPListDeleteSelectionView(sessionID string, selection IPListDeleteSele
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("【IP 删除选择】\n账号: %s\n页码: %d/%d\n已选择: %d/%d\n\n",
+	sb.WriteString(fmt.Sprintf("【名单条目删除选择】\n账号: %s\n页码: %d/%d\n已选择: %d/%d\n\n",
 		selection.AccountLabel, page+1, totalPages, countSelected(selection.Selected), len(selection.Items)))
 	for i := start; i < end; i++ {
 		item := selection.Items[i]
@@ -37,7 +37,7 @@ func BuildIPListDeleteSelectionView(sessionID string, selection IPListDeleteSele
 			listName = item.ListID
 		}
 		sb.WriteString(fmt.Sprintf("%d. [%s] %s | 备注: %s\n",
-			i+1, truncateDisplay(listName, 18), item.IP, truncateDisplay(comment, 60)))
+			i+1, truncateDisplay(listName, 18), item.Value, truncateDisplay(comment, 60)))
 	}
 
 	var buttons [][]Button
@@ -54,7 +54,7 @@ func BuildIPListDeleteSelectionView(sessionID string, selection IPListDeleteSele
 			Page:         page,
 		})
 		buttons = append(buttons, []Button{{
-			Text:         fmt.Sprintf("%s %d. %s", mark, i+1, truncateDisplay(item.IP, 34)),
+			Text:         fmt.Sprintf("%s %d. %s", mark, i+1, truncateDisplay(item.Value, 34)),
 			CallbackData: fmt.Sprintf("iplist_select_toggle|%s", token),
 		}})
 	}
@@ -100,7 +100,7 @@ func BuildIPListDeleteConfirmView(sessionID string, items []IPListDeleteItem) IP
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("⚠️【IP 删除汇总确认】\n账号: %s\n待删除: %d\n", accountLabel, len(items)))
+	sb.WriteString(fmt.Sprintf("⚠️【名单条目删除汇总确认】\n账号: %s\n待删除: %d\n", accountLabel, len(items)))
 	for i, item := range items {
 		if i >= 20 {
 			sb.WriteString(fmt.Sprintf("- ... 其余 %d 条\n", len(items)-i))
@@ -114,7 +114,7 @@ func BuildIPListDeleteConfirmView(sessionID string, items []IPListDeleteItem) IP
 		if listName == "" {
 			listName = item.ListID
 		}
-		sb.WriteString(fmt.Sprintf("- [%s] %s | 备注: %s\n", truncateDisplay(listName, 18), item.IP, truncateDisplay(comment, 50)))
+		sb.WriteString(fmt.Sprintf("- [%s] %s | 备注: %s\n", truncateDisplay(listName, 18), item.Value, truncateDisplay(comment, 50)))
 	}
 	sb.WriteString("\n此操作不可逆，确认执行删除吗？")
 
@@ -391,7 +391,7 @@ func BuildOriginSSLAWSSelectionView(sessionID string, selection OriginSSLDomainS
 		if selection.AWSAliases != nil && selection.AWSAliases[alias] {
 			mark = "☑"
 		}
-		target := config.Cfg.AWSTargets[alias]
+		target := config.Cfg().AWSTargets[alias]
 		token := SetOriginSSLCallbackPayload(OriginSSLCallbackPayload{
 			AccountLabel: selection.AccountLabel,
 			SessionID:    sessionID,
@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+const ipAccessMaxRuleLines = 60
+
+type cloudflareIPAccessRuleLister interface {
+	ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error)
+	ListAccountIPAccessRules(ctx context.Context, account config.CF) ([]cfclient.IPAccessRule, error)
+	ListZoneIPAccessRules(ctx context.Context, account config.CF, zoneID string) ([]cfclient.IPAccessRule, error)
+}
+
+func (h *CommandHandler) handleIPAccessCommand(args []string) {
+	if len(args) < 2 || !strings.EqualFold(args[0], "list") {
+		h.sendText(ipAccessUsage())
+		return
+	}
+	lister, ok := h.CFClient.(cloudflareIPAccessRuleLister)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持查询 IP 访问规则。")
+		return
+	}
+	account := h.getAccountByLabel(args[1])
+	if account == nil {
+		h.sendText(fmt.Sprintf("未找到账号标签: %s", args[1]))
+		return
+	}
+	zoneName := ""
+	if len(args) >= 3 {
+		zoneName = strings.ToLower(strings.TrimSpace(args[2]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var rules []cfclient.IPAccessRule
+	var err error
+	title := "账号级"
+	if zoneName == "" {
+		rules, err = lister.ListAccountIPAccessRules(ctx, *account)
+	} else {
+		var zone cfclient.ZoneDetail
+		zone, err = findIPAccessZone(ctx, lister, *account, zoneName)
+		if err == nil {
+			title = "Zone " + zone.Name
+			rules, err = lister.ListZoneIPAccessRules(ctx, *account, zone.ID)
+		}
+	}
+	if err != nil {
+		h.sendText(fmt.Sprintf("查询 IP 访问规则失败: %s", formatCFIPAccessPermissionError(err)))
+		return
+	}
+
+	expiries, err := ListIPBlockExpiries(config.IPBlockExpiryFile())
+	if err != nil {
+		expiries = nil
+	}
+	h.sendText(BuildIPAccessRuleList(account.Label, title, rules, filterIPBlockExpiries(expiries, account.Label)))
+}
+
+func findIPAccessZone(ctx context.Context, lister cloudflareIPAccessRuleLister, account config.CF, name string) (cfclient.ZoneDetail, error) {
+	zones, err := lister.ListZones(ctx, account)
+	if err != nil {
+		return cfclient.ZoneDetail{}, err
+	}
+	for _, zone := range zones {
+		if strings.EqualFold(zone.Name, name) {
+			return zone, nil
+		}
+	}
+	return cfclient.ZoneDetail{}, fmt.Errorf("账号 %s 下未找到域名 %s", account.Label, name)
+}
+
+func filterIPBlockExpiries(expiries []IPBlockExpiry, label string) []IPBlockExpiry {
+	var out []IPBlockExpiry
+	for _, entry := range expiries {
+		for _, item := range entry.Accounts {
+			if item == label {
+				out = append(out, entry)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// BuildIPAccessRuleList 渲染 IP 访问规则列表，按创建时间倒序，并附带本账号的临时封禁到期信息。
+func BuildIPAccessRuleList(accountLabel string, title string, rules []cfclient.IPAccessRule, expiries []IPBlockExpiry) string {
+	sorted := append([]cfclient.IPAccessRule(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedOn.After(sorted[j].CreatedOn) })
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【IP 访问规则】\n账号: %s\n范围: %s\n规则数: %d\n", accountLabel, title, len(sorted)))
+	if len(sorted) == 0 {
+		sb.WriteString("\n暂无 IP 访问规则。\n")
+	}
+	limit := len(sorted)
+	if limit > ipAccessMaxRuleLines {
+		limit = ipAccessMaxRuleLines
+	}
+	for i, rule := range sorted[:limit] {
+		notes := strings.TrimSpace(rule.Notes)
+		if notes == "" {
+			notes = "无"
+		}
+		created := "-"
+		if !rule.CreatedOn.IsZero() {
+			created = rule.CreatedOn.Local().Format("2006-01-02 15:04")
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s %s=%s | %s | 创建: %s\n   备注: %s",
+			i+1, rule.Mode, rule.Target, rule.Value, rule.Scope, created, truncateDisplay(notes, 80)))
+	}
+	if len(sorted) > limit {
+		sb.WriteString(fmt.Sprintf("\n\n还有 %d 条规则未显示。", len(sorted)-limit))
+	}
+
+	if len(expiries) > 0 {
+		sb.WriteString("\n\n临时封禁（WAF 规则 telegram-auto-block-ips）:")
+		for _, entry := range expiries {
+			sb.WriteString(fmt.Sprintf("\n- %s 到期: %s 操作人: %s",
+				entry.Value, entry.ExpiresAt.Local().Format("2006-01-02 15:04"), entry.Operator))
+		}
+	}
+	return sb.String()
+}
+
+func ipAccessUsage() string {
+	return "用法：\n/ipaccess list 账号标签\n/ipaccess list 账号标签 example.com\n\n说明：不带域名时列出账号级 IP 访问规则；带域名时列出该 Zone 的规则（包含继承自账号的规则，范围列显示 account）。"
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 
+	"DomainC/cfclient"
 	"DomainC/config"
 
 	cloudflare "github.com/cloudflare/cloudflare-go"
 )
 
 type ipListBatchEntry struct {
-	IP      string
+	Value   string
+	Key     string
 	Comment string
+	Item    cloudflare.ListItemCreateRequest
 }
 
 type ipListBatchResult struct {
@@ -50,7 +54,7 @@ func (r ipListBatchResult) Summary() string {
 	}
 
 	if len(r.Missing) > 0 {
-		sb.WriteString("\n\n未找到的地址:")
+		sb.WriteString("\n\n未找到的条目:")
 		for _, item := range r.Missing {
 			sb.WriteString("\n- " + item)
 		}
@@ -82,20 +86,18 @@ func (h *CommandHandler) handleIPListCommand(args []string) {
 	}
 
 	selector := strings.TrimSpace(args[0])
-	if strings.EqualFold(selector, "all") {
-		for _, acc := range h.Accounts {
-			h.sendIPListListSelector(acc)
-		}
+	if strings.EqualFold(selector, "sync") {
+		h.handleIPListSyncCommand(args[1:])
 		return
 	}
-
-	acc := h.getAccountByLabel(selector)
-	if acc == nil {
-		h.sendText(fmt.Sprintf("未找到账号 %s。\n\n%s", selector, h.ipListPromptText()))
+	targets, err := h.resolveAccountTargets(selector)
+	if err != nil {
+		h.sendText(fmt.Sprintf("%v\n\n%s", err, h.ipListPromptText()))
 		return
 	}
-
-	h.sendIPListListSelector(*acc)
+	for _, acc := range TargetAccounts(targets) {
+		h.sendIPListListSelector(acc)
+	}
 }
 
 func (h *CommandHandler) sendIPListAccountSelector() {
@@ -129,19 +131,24 @@ func (h *CommandHandler) sendIPListListSelector(account config.CF) {
 		return
 	}
 	if len(lists) == 0 {
-		h.sendText(fmt.Sprintf("账号 %s 暂无 IP 白名单。", account.Label))
+		h.sendText(fmt.Sprintf("账号 %s 暂无 Custom List。", account.Label))
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("【IP 白名单】\n账号: %s\n请选择要操作的名单，或直接进入全部 IP 删除选择：\n", account.Label))
-	for i, list := range lists {
-		sb.WriteString(fmt.Sprintf("%d. %s (%d)\n", i+1, list.Name, list.NumItems))
+	page := BuildIPListListSelector(account.Label, lists)
+	if err := h.Sender.SendWithButtons(context.Background(), page.Message, page.Buttons); err != nil {
+		h.sendText(fmt.Sprintf("发送账号 %s 白名单列表失败: %v", account.Label, err))
 	}
+}
 
-	if err := h.Sender.SendWithButtons(context.Background(), sb.String(), buildIPListListButtons(account.Label, lists)); err != nil {
-		h.sendText(fmt.Sprintf("发送账号 %s 白名单列表失败: %v", account.Label, err))
+// BuildIPListListSelector 生成账号下全部 Custom List 的选择视图，名单后标注类型。
+func BuildIPListListSelector(accountLabel string, lists []cloudflare.List) IPListPage {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【Custom List 名单】\n账号: %s\n请选择要操作的名单，或直接进入全部条目删除选择：\n", accountLabel))
+	for i, list := range lists {
+		sb.WriteString(fmt.Sprintf("%d. %s [%s] (%d)\n", i+1, list.Name, cfclient.CustomListKindLabel(list.Kind), list.NumItems))
 	}
+	return IPListPage{Message: sb.String(), Buttons: buildIPListListButtons(accountLabel, lists)}
 }
 
 func buildIPListListButtons(accountLabel string, lists []cloudflare.List) [][]Button {
@@ -150,7 +157,7 @@ func buildIPListListButtons(accountLabel string, lists []cloudflare.List) [][]Bu
 		AccountLabel: accountLabel,
 	})
 	buttons = append(buttons, []Button{{
-		Text:         "删除IP（全部名单）",
+		Text:         "删除条目（全部名单）",
 		CallbackData: fmt.Sprintf("iplist_delete_account|%s", accountToken),
 	}})
 	for _, list := range lists {
@@ -158,6 +165,7 @@ func buildIPListListButtons(accountLabel string, lists []cloudflare.List) [][]Bu
 			AccountLabel: accountLabel,
 			ListID:       list.ID,
 			ListName:     list.Name,
+			ListKind:     list.Kind,
 		})
 		buttons = append(buttons, []Button{
 			{Text: "添加 " + list.Name, CallbackData: fmt.Sprintf("iplist_add|%s", token)},
@@ -181,20 +189,46 @@ func (h *CommandHandler) ipListPromptText() string {
 		sb.WriteString("- " + a.Label + "\n")
 	}
 	sb.WriteString("\n也可以直接输入：/iplist 账号标签")
+	sb.WriteString("\n按文件整体同步名单：/iplist sync 账号标签 名单名称")
 	return sb.String()
 }
 
 func (h *CommandHandler) ipListInputPrompt(req IPListInputRequest) string {
+	return BuildIPListInputPrompt(req)
+}
+
+// BuildIPListInputPrompt 按名单类型生成添加/删除条目的输入提示。
+func BuildIPListInputPrompt(req IPListInputRequest) string {
 	listName := req.ListName
 	if strings.TrimSpace(listName) == "" {
 		listName = req.ListID
 	}
+	kind := cfclient.NormalizeCustomListKind(req.ListKind)
+	header := fmt.Sprintf("已选择%s名单 %s（账号: %s）。", cfclient.CustomListKindLabel(kind), listName, req.AccountLabel)
+
+	if req.Action == IPListActionDelete {
+		switch kind {
+		case cloudflare.ListTypeHostname:
+			return header + "\n请直接发送要删除的主机名，每行一条。\n示例：\nexample.com\n*.cdn.example.com"
+		case cloudflare.ListTypeASN:
+			return header + "\n请直接发送要删除的 ASN，每行一条，可带 AS 前缀。\n示例：\nAS13335\n15169"
+		case cloudflare.ListTypeRedirect:
+			return header + "\n请直接发送要删除的重定向 source URL，每行一条。\n示例：\nold.example.com/promo\nhttps://example.com/old"
+		default:
+			return header + "\n请直接发送要删除的地址，每行一条，只需填写 IP 或 CIDR。\n示例：\n1.2.3.4\n2407:cdc0:b010::/112"
+		}
+	}
 
-	switch req.Action {
-	case IPListActionDelete:
-		return fmt.Sprintf("已选择白名单 %s（账号: %s）。\n请直接发送要删除的地址，每行一条，只需填写 IP 或 CIDR。\n示例：\n1.2.3.4\n2407:cdc0:b010::/112", listName, req.AccountLabel)
+	inherit := "\n同一批次里未填写备注的行，会自动继承本批第一条非空备注。"
+	switch kind {
+	case cloudflare.ListTypeHostname:
+		return header + "\n请直接发送要添加的主机名，每行一条，格式：主机名 [备注]，支持最左侧 *. 通配。" + inherit + "\n示例：\nexample.com 主站\n*.cdn.example.com"
+	case cloudflare.ListTypeASN:
+		return header + "\n请直接发送要添加的 ASN，每行一条，格式：ASN [备注]，可带 AS 前缀。" + inherit + "\n示例：\nAS13335 Cloudflare\n15169"
+	case cloudflare.ListTypeRedirect:
+		return header + "\n请直接发送要添加的重定向，每行一条，格式：source target [301|302|307|308] [备注]，状态码默认 301。\nsource 可省略协议，不能带查询参数；target 必须是完整的 http/https 地址。" + inherit + "\n示例：\nold.example.com/promo https://www.example.com/sale 302 活动\nexample.net https://example.com"
 	default:
-		return fmt.Sprintf("已选择白名单 %s（账号: %s）。\n请直接发送要添加的地址，每行一条，格式：IP 或 CIDR，备注可选。\n同一批次里未填写备注的行，会自动继承本批第一条非空备注。\n示例：\n1.2.3.4 办公网\n2407:cdc0:b010::/112\n8.8.8.8", listName, req.AccountLabel)
+		return header + "\n请直接发送要添加的地址，每行一条，格式：IP 或 CIDR，备注可选。" + inherit + "\n示例：\n1.2.3.4 办公网\n2407:cdc0:b010::/112\n8.8.8.8"
 	}
 }
 
@@ -217,7 +251,7 @@ func (h *CommandHandler) handlePendingIPListInput(msgText string, userID int64)
 		return false
 	}
 
-	entries, parseErrors := parseIPListBatchEntries(msgText)
+	entries, parseErrors := parseIPListBatchEntries(req.ListKind, req.Action, msgText)
 	if len(entries) == 0 {
 		h.sendText(h.ipListRetryPrompt(req, parseErrors))
 		return true
@@ -254,9 +288,24 @@ func (h *CommandHandler) handlePendingIPListInput(msgText string, userID int64)
 func (h *CommandHandler) processIPListAddBatch(acc config.CF, req IPListInputRequest, entries []ipListBatchEntry) ipListBatchResult {
 	result := ipListBatchResult{Request: req}
 	entries = fillIPListBatchComments(entries)
+
+	// 多条时优先走异步批量接口，一次请求提交全部条目；失败再逐条添加以定位问题条目。
+	if bulk, ok := h.CFClient.(cloudflareCustomListBulkManager); ok && len(entries) > 1 {
+		items := make([]cloudflare.ListItemCreateRequest, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, entry.Item)
+		}
+		_, err := bulk.AppendCustomListItems(context.Background(), acc, req.ListID, items)
+		if err == nil {
+			result.Success = len(entries)
+			return result
+		}
+		h.sendText(fmt.Sprintf("批量添加失败，改为逐条添加: %v", err))
+	}
+
 	for _, entry := range entries {
-		if _, err := h.CFClient.CreateCustomListItem(context.Background(), acc, req.ListID, entry.IP, entry.Comment); err != nil {
-			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", entry.IP, err))
+		if _, err := h.CFClient.CreateCustomListItem(context.Background(), acc, req.ListID, entry.Item); err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", entry.Value, err))
 			continue
 		}
 		result.Success++
@@ -273,12 +322,13 @@ func (h *CommandHandler) processIPListDeleteBatch(acc config.CF, req IPListInput
 		return result
 	}
 
+	kind := cfclient.NormalizeCustomListKind(req.ListKind)
 	index := make(map[string][]string)
 	for _, item := range items {
-		if item.ID == "" || item.IP == nil {
+		if item.ID == "" {
 			continue
 		}
-		keys, err := buildIPListMatchKeys(*item.IP)
+		keys, err := buildIPListMatchKeys(kind, cfclient.CustomListItemMatchKey(item))
 		if err != nil || len(keys) == 0 {
 			continue
 		}
@@ -288,16 +338,16 @@ func (h *CommandHandler) processIPListDeleteBatch(acc config.CF, req IPListInput
 	}
 
 	for _, entry := range entries {
-		itemIDs := lookupIPListItemIDs(index, entry.IP)
+		itemIDs := lookupIPListItemIDs(index, kind, entry.Key)
 		if len(itemIDs) == 0 {
-			result.Missing = append(result.Missing, entry.IP)
+			result.Missing = append(result.Missing, entry.Value)
 			continue
 		}
 
 		failed := false
 		for _, itemID := range itemIDs {
 			if _, err := h.CFClient.DeleteCustomListItem(context.Background(), acc, req.ListID, itemID); err != nil {
-				result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", entry.IP, err))
+				result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", entry.Value, err))
 				failed = true
 				break
 			}
@@ -307,13 +357,13 @@ func (h *CommandHandler) processIPListDeleteBatch(acc config.CF, req IPListInput
 		}
 
 		result.Success++
-		removeIPListItemIDs(index, entry.IP, itemIDs)
+		removeIPListItemIDs(index, kind, entry.Key, itemIDs)
 	}
 
 	return result
 }
 
-func parseIPListBatchEntries(input string) ([]ipListBatchEntry, []string) {
+func parseIPListBatchEntries(kind string, action IPListAction, input string) ([]ipListBatchEntry, []string) {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 	entries := make([]ipListBatchEntry, 0, len(lines))
 	var errs []string
@@ -324,16 +374,13 @@ func parseIPListBatchEntries(input string) ([]ipListBatchEntry, []string) {
 			continue
 		}
 
-		ip, comment, err := parseIPListInput(line)
+		entry, err := parseIPListInput(kind, action, line)
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("第 %d 行: %v", idx+1, err))
 			continue
 		}
 
-		entries = append(entries, ipListBatchEntry{
-			IP:      ip,
-			Comment: comment,
-		})
+		entries = append(entries, entry)
 	}
 
 	if len(entries) == 0 && len(errs) == 0 {
@@ -343,19 +390,32 @@ func parseIPListBatchEntries(input string) ([]ipListBatchEntry, []string) {
 	return entries, errs
 }
 
-func parseIPListInput(input string) (string, string, error) {
+// parseIPListInput 解析一行输入。添加时按名单类型完整校验（重定向需要 target 和状态码），
+// 删除时只取第一个字段作为匹配值。
+func parseIPListInput(kind string, action IPListAction, input string) (ipListBatchEntry, error) {
 	fields := strings.Fields(strings.TrimSpace(input))
 	if len(fields) == 0 {
-		return "", "", fmt.Errorf("输入为空")
+		return ipListBatchEntry{}, fmt.Errorf("输入为空")
 	}
 
-	ipStr, err := normalizeIPListValue(fields[0])
-	if err != nil {
-		return "", "", err
+	if action == IPListActionDelete {
+		value, err := normalizeIPListValue(kind, fields[0])
+		if err != nil {
+			return ipListBatchEntry{}, err
+		}
+		return ipListBatchEntry{Value: fields[0], Key: value}, nil
 	}
 
-	comment := strings.TrimSpace(strings.Join(fields[1:], " "))
-	return ipStr, comment, nil
+	item, err := cfclient.ParseCustomListItem(kind, input)
+	if err != nil {
+		return ipListBatchEntry{}, err
+	}
+	return ipListBatchEntry{
+		Value:   cfclient.CustomListCreateValue(item),
+		Key:     cfclient.CustomListCreateMatchKey(item),
+		Comment: item.Comment,
+		Item:    item,
+	}, nil
 }
 
 func fillIPListBatchComments(entries []ipListBatchEntry) []ipListBatchEntry {
@@ -379,34 +439,46 @@ func fillIPListBatchComments(entries []ipListBatchEntry) []ipListBatchEntry {
 	for _, entry := range entries {
 		if strings.TrimSpace(entry.Comment) == "" {
 			entry.Comment = inherited
+			entry.Item.Comment = inherited
 		}
 		filled = append(filled, entry)
 	}
 	return filled
 }
 
-func normalizeIPListValue(input string) (string, error) {
-	ipStr := strings.TrimSpace(input)
-	if ipStr == "" {
-		return "", fmt.Errorf("IP 不能为空")
-	}
-
-	if strings.Contains(ipStr, "/") {
-		_, network, err := net.ParseCIDR(ipStr)
+// normalizeIPListValue 把单个值规范为与 cfclient.CustomListItemMatchKey 一致的匹配键。
+func normalizeIPListValue(kind string, input string) (string, error) {
+	switch cfclient.NormalizeCustomListKind(kind) {
+	case cloudflare.ListTypeIP:
+		return cfclient.NormalizeCustomListIP(input)
+	case cloudflare.ListTypeHostname:
+		return cfclient.NormalizeCustomListHostname(input)
+	case cloudflare.ListTypeASN:
+		asn, err := cfclient.NormalizeCustomListASN(input)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(uint64(asn), 10), nil
+	case cloudflare.ListTypeRedirect:
+		source, err := cfclient.NormalizeCustomListRedirectSource(input)
 		if err != nil {
 			return "", err
 		}
-		return network.String(), nil
+		return cfclient.CustomListRedirectKey(source), nil
+	default:
+		return "", fmt.Errorf("不支持的名单类型 %q", kind)
 	}
+}
 
-	ip := net.ParseIP(ipStr)
-	if ip == nil {
-		return "", fmt.Errorf("IP 无法解析")
+func buildIPListMatchKeys(kind string, input string) ([]string, error) {
+	if cfclient.NormalizeCustomListKind(kind) != cloudflare.ListTypeIP {
+		value := strings.TrimSpace(input)
+		if value == "" {
+			return nil, fmt.Errorf("条目不能为空")
+		}
+		return []string{value}, nil
 	}
-	return ip.String(), nil
-}
 
-func buildIPListMatchKeys(input string) ([]string, error) {
 	ipStr := strings.TrimSpace(input)
 	if ipStr == "" {
 		return nil, fmt.Errorf("IP 不能为空")
@@ -450,8 +522,8 @@ func buildIPListMatchKeys(input string) ([]string, error) {
 	return keys, nil
 }
 
-func lookupIPListItemIDs(index map[string][]string, value string) []string {
-	keys, err := buildIPListMatchKeys(value)
+func lookupIPListItemIDs(index map[string][]string, kind string, value string) []string {
+	keys, err := buildIPListMatchKeys(kind, value)
 	if err != nil {
 		return nil
 	}
@@ -470,8 +542,8 @@ func lookupIPListItemIDs(index map[string][]string, value string) []string {
 	return itemIDs
 }
 
-func removeIPListItemIDs(index map[string][]string, value string, itemIDs []string) {
-	keys, err := buildIPListMatchKeys(value)
+func removeIPListItemIDs(index map[string][]string, kind string, value string, itemIDs []string) {
+	keys, err := buildIPListMatchKeys(kind, value)
 	if err != nil {
 		return
 	}
@@ -18,7 +18,7 @@ type IPListDeleteResult struct {
 
 func (r IPListDeleteResult) Summary() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("✅ IP 白名单删除完成\n账号: %s\n成功: %d", r.AccountLabel, len(r.Success)))
+	sb.WriteString(fmt.Sprintf("✅ 名单条目删除完成\n账号: %s\n成功: %d", r.AccountLabel, len(r.Success)))
 	if len(r.Failed) > 0 {
 		sb.WriteString(fmt.Sprintf("\n失败: %d", len(r.Failed)))
 		for _, item := range r.Failed {
@@ -37,12 +37,12 @@ func ProcessIPListDeleteItems(ctx context.Context, client cfclient.Client, accou
 	pacer := newBatchAPIPacerWithInterval(1200 * time.Millisecond)
 	for _, item := range items {
 		if err := pacer.Wait(ctx); err != nil {
-			result.Failed = append(result.Failed, fmt.Sprintf("%s: 等待执行失败: %v", item.IP, err))
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: 等待执行失败: %v", item.Value, err))
 			continue
 		}
 
 		if err := deleteCustomListItemWithRetry(ctx, client, account, item); err != nil {
-			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", item.IP, err))
+			result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", item.Value, err))
 			continue
 		}
 		result.Success = append(result.Success, item)
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"DomainC/cfclient"
+
 	cloudflare "github.com/cloudflare/cloudflare-go"
 )
 
@@ -15,11 +17,13 @@ type IPListPage struct {
 	Buttons [][]Button
 }
 
-func BuildIPListPages(accountLabel string, listName string, listID string, items []cloudflare.ListItem, includeAdd bool) []IPListPage {
-	header := fmt.Sprintf("【IP Custom List】\n账号: %s\n列表: %s\n\n", accountLabel, listName)
+// BuildIPListPages 分页展示名单条目，IP/主机名/ASN/重定向条目都按所属类型渲染。
+func BuildIPListPages(accountLabel string, listName string, listID string, listKind string, items []cloudflare.ListItem, includeAdd bool) []IPListPage {
+	kindLabel := cfclient.CustomListKindLabel(listKind)
 	if listName == "" {
-		header = fmt.Sprintf("【IP Custom List】\n账号: %s\n列表: %s\n\n", accountLabel, listID)
+		listName = listID
 	}
+	header := fmt.Sprintf("【%s Custom List】\n账号: %s\n列表: %s\n\n", kindLabel, accountLabel, listName)
 
 	if len(items) == 0 {
 		buttons := [][]Button{}
@@ -27,11 +31,12 @@ func BuildIPListPages(accountLabel string, listName string, listID string, items
 			token := SetIPListCallbackPayload(IPListCallbackPayload{
 				AccountLabel: accountLabel,
 				ListID:       listID,
+				ListKind:     listKind,
 			})
 			buttons = append(buttons, []Button{{Text: "添加", CallbackData: fmt.Sprintf("iplist_add|%s", token)}})
 		}
 		return []IPListPage{{
-			Message: header + "暂无 IP 记录。",
+			Message: header + fmt.Sprintf("暂无 %s 记录。", kindLabel),
 			Buttons: buttons,
 		}}
 	}
@@ -47,23 +52,23 @@ func BuildIPListPages(accountLabel string, listName string, listID string, items
 		sb.WriteString(header)
 		for i := start; i < end; i++ {
 			item := items[i]
-			ip := "-"
-			if item.IP != nil {
-				ip = *item.IP
+			value := cfclient.CustomListItemValue(item)
+			if value == "" {
+				value = "-"
 			}
 			comment := strings.TrimSpace(item.Comment)
 			if comment == "" {
 				comment = "无"
 			}
-			sb.WriteString(fmt.Sprintf("%d. %s | 备注: %s\n", i+1, ip, comment))
+			sb.WriteString(fmt.Sprintf("%d. %s | 备注: %s\n", i+1, value, comment))
 		}
 
 		var buttons [][]Button
 		for i := start; i < end; i++ {
 			item := items[i]
-			ip := "IP"
-			if item.IP != nil && *item.IP != "" {
-				ip = *item.IP
+			value := cfclient.CustomListItemValue(item)
+			if value == "" {
+				value = kindLabel
 			}
 			if item.ID == "" {
 				continue
@@ -71,14 +76,16 @@ func BuildIPListPages(accountLabel string, listName string, listID string, items
 			token := SetIPListCallbackPayload(IPListCallbackPayload{
 				AccountLabel: accountLabel,
 				ListID:       listID,
+				ListKind:     listKind,
 				ItemID:       item.ID,
 			})
-			buttons = append(buttons, []Button{{Text: "删除 " + ip, CallbackData: fmt.Sprintf("iplist_delete|%s", token)}})
+			buttons = append(buttons, []Button{{Text: "删除 " + truncateDisplay(value, 40), CallbackData: fmt.Sprintf("iplist_delete|%s", token)}})
 		}
 		if includeAdd && end == len(items) {
 			token := SetIPListCallbackPayload(IPListCallbackPayload{
 				AccountLabel: accountLabel,
 				ListID:       listID,
+				ListKind:     listKind,
 			})
 			buttons = append(buttons, []Button{{Text: "添加", CallbackData: fmt.Sprintf("iplist_add|%s", token)}})
 		}
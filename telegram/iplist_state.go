@@ -18,6 +18,7 @@ type IPListInputRequest struct {
 	AccountLabel string
 	ListID       string
 	ListName     string
+	ListKind     string
 	Action       IPListAction
 }
 
@@ -81,6 +82,7 @@ type IPListCallbackPayload struct {
 	AccountLabel string
 	ListID       string
 	ListName     string
+	ListKind     string
 	ItemID       string
 	SessionID    string
 	ItemKey      string
@@ -181,7 +183,8 @@ type IPListDeleteItem struct {
 	ListID       string
 	ListName     string
 	ItemID       string
-	IP           string
+	Kind         string
+	Value        string
 	Comment      string
 }
 
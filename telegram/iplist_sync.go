@@ -0,0 +1,451 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	ipListSyncMaxFileBytes = 5 << 20
+	ipListSyncPreviewLines = 20
+	// ipListSyncPlanTTL 之后确认按钮失效，避免按很久以前读取的名单整体替换。
+	ipListSyncPlanTTL = 15 * time.Minute
+)
+
+type cloudflareCustomListBulkManager interface {
+	AppendCustomListItems(ctx context.Context, account config.CF, listID string, items []cloudflare.ListItemCreateRequest) (cfclient.CustomListBulkResult, error)
+	ReplaceCustomListItems(ctx context.Context, account config.CF, listID string, items []cloudflare.ListItemCreateRequest) (cfclient.CustomListBulkResult, error)
+}
+
+// IPListSyncPlan 是 /iplist sync 计算出的差异，确认后用 Items 整体替换名单。
+type IPListSyncPlan struct {
+	AccountLabel string
+	ListID       string
+	ListName     string
+	ListKind     string
+	Items        []cloudflare.ListItemCreateRequest
+	Added        []string
+	Removed      []string
+	Updated      []string
+	Unchanged    int
+	CreatedAt    time.Time
+}
+
+func (p IPListSyncPlan) HasChanges() bool {
+	return len(p.Added) > 0 || len(p.Removed) > 0 || len(p.Updated) > 0
+}
+
+var ipListSyncState = struct {
+	mu      sync.Mutex
+	pending map[int64]IPListInputRequest
+	plans   map[string]IPListSyncPlan
+}{
+	pending: make(map[int64]IPListInputRequest),
+	plans:   make(map[string]IPListSyncPlan),
+}
+
+func SetPendingIPListSync(userID int64, req IPListInputRequest) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	ipListSyncState.pending[userID] = req
+}
+
+func GetPendingIPListSync(userID int64) (IPListInputRequest, bool) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	req, ok := ipListSyncState.pending[userID]
+	return req, ok
+}
+
+func ClearPendingIPListSync(userID int64) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	delete(ipListSyncState.pending, userID)
+}
+
+func SetIPListSyncPlan(plan IPListSyncPlan) string {
+	planID := newInteractionToken()
+	now := time.Now()
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = now
+	}
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	pruneIPListSyncPlansLocked(now)
+	ipListSyncState.plans[planID] = cloneIPListSyncPlan(plan)
+	return planID
+}
+
+// GetIPListSyncPlan 返回未过期的同步计划。
+func GetIPListSyncPlan(planID string) (IPListSyncPlan, bool) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	pruneIPListSyncPlansLocked(time.Now())
+	plan, ok := ipListSyncState.plans[planID]
+	if !ok {
+		return IPListSyncPlan{}, false
+	}
+	return cloneIPListSyncPlan(plan), true
+}
+
+// TakeIPListSyncPlan 取出并删除未过期的同步计划，重复确认只有第一次生效。
+func TakeIPListSyncPlan(planID string) (IPListSyncPlan, bool) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	pruneIPListSyncPlansLocked(time.Now())
+	plan, ok := ipListSyncState.plans[planID]
+	if !ok {
+		return IPListSyncPlan{}, false
+	}
+	delete(ipListSyncState.plans, planID)
+	return cloneIPListSyncPlan(plan), true
+}
+
+func pruneIPListSyncPlansLocked(now time.Time) {
+	for planID, plan := range ipListSyncState.plans {
+		if now.Sub(plan.CreatedAt) > ipListSyncPlanTTL {
+			delete(ipListSyncState.plans, planID)
+		}
+	}
+}
+
+func ClearIPListSyncPlan(planID string) {
+	ipListSyncState.mu.Lock()
+	defer ipListSyncState.mu.Unlock()
+	delete(ipListSyncState.plans, planID)
+}
+
+func cloneIPListSyncPlan(plan IPListSyncPlan) IPListSyncPlan {
+	plan.Items = append([]cloudflare.ListItemCreateRequest(nil), plan.Items...)
+	plan.Added = append([]string(nil), plan.Added...)
+	plan.Removed = append([]string(nil), plan.Removed...)
+	plan.Updated = append([]string(nil), plan.Updated...)
+	return plan
+}
+
+// handleIPListSyncCommand 处理 /iplist sync <账号> <名单>，名单可以是名称或 ID。
+func (h *CommandHandler) handleIPListSyncCommand(args []string) {
+	if len(args) < 2 {
+		h.sendText("用法：/iplist sync 账号标签 名单名称\n随后上传 txt 文件（每行一条，格式同添加条目），确认后名单将与文件完全一致。")
+		return
+	}
+	if h.operator == nil {
+		h.sendText("无法识别操作人，/iplist sync 已取消。")
+		return
+	}
+
+	acc := h.getAccountByLabel(args[0])
+	if acc == nil {
+		h.sendText(fmt.Sprintf("未找到账号 %s。\n\n%s", args[0], h.ipListPromptText()))
+		return
+	}
+	if _, ok := h.CFClient.(cloudflareCustomListBulkManager); !ok {
+		h.sendText("当前 Cloudflare 客户端不支持名单批量替换。")
+		return
+	}
+
+	lists, err := h.CFClient.ListCustomLists(context.Background(), *acc)
+	if err != nil {
+		h.sendText(fmt.Sprintf("查询账号 %s 白名单失败: %v", acc.Label, err))
+		return
+	}
+	selector := strings.TrimSpace(strings.Join(args[1:], " "))
+	var target *cloudflare.List
+	for i := range lists {
+		if lists[i].ID == selector || strings.EqualFold(lists[i].Name, selector) {
+			target = &lists[i]
+			break
+		}
+	}
+	if target == nil {
+		h.sendText(fmt.Sprintf("账号 %s 下未找到名单 %s。", acc.Label, selector))
+		return
+	}
+
+	req := IPListInputRequest{
+		AccountLabel: acc.Label,
+		ListID:       target.ID,
+		ListName:     target.Name,
+		ListKind:     target.Kind,
+	}
+	ClearPendingIPListInput(h.operator.ID)
+	SetPendingIPListSync(h.operator.ID, req)
+	h.sendText(BuildIPListSyncPrompt(req))
+}
+
+// BuildIPListSyncPrompt 生成等待上传同步文件的提示。
+func BuildIPListSyncPrompt(req IPListInputRequest) string {
+	listName := req.ListName
+	if strings.TrimSpace(listName) == "" {
+		listName = req.ListID
+	}
+	return fmt.Sprintf("已选择%s名单 %s（账号: %s）进行同步。\n请上传 txt 文件，或直接发送文本，每行一条，格式同添加条目；空行和 # 开头的行会被忽略。\n同步后名单内容将与文件完全一致：文件中没有的条目会被删除。\n已有条目若文件中未写备注，会保留原备注。\n发送 取消 结束本次同步。",
+		cfclient.CustomListKindLabel(req.ListKind), listName, req.AccountLabel)
+}
+
+func (h *CommandHandler) handlePendingIPListSyncInput(msgText string, userID int64) bool {
+	req, ok := GetPendingIPListSync(userID)
+	if !ok {
+		return false
+	}
+	if isIPListSyncCancelInput(msgText) {
+		ClearPendingIPListSync(userID)
+		h.sendText("已取消名单同步。")
+		return true
+	}
+	h.buildAndSendIPListSyncPlan(req, userID, msgText)
+	return true
+}
+
+func (h *CommandHandler) handlePendingIPListSyncDocument(doc *tgbotapi.Document, userID int64) bool {
+	req, ok := GetPendingIPListSync(userID)
+	if !ok || doc == nil {
+		return false
+	}
+	if doc.FileSize > ipListSyncMaxFileBytes {
+		h.sendText(fmt.Sprintf("文件过大（%d 字节），上限 %d 字节。", doc.FileSize, ipListSyncMaxFileBytes))
+		return true
+	}
+	downloader, ok := h.Sender.(FileDownloader)
+	if !ok {
+		h.sendText("当前 Telegram 发送器不支持下载文件，请直接发送文本。")
+		return true
+	}
+	data, err := downloader.DownloadFile(context.Background(), doc.FileID, ipListSyncMaxFileBytes)
+	if err != nil {
+		h.sendText(fmt.Sprintf("下载同步文件失败: %v", err))
+		return true
+	}
+	h.buildAndSendIPListSyncPlan(req, userID, string(data))
+	return true
+}
+
+func (h *CommandHandler) buildAndSendIPListSyncPlan(req IPListInputRequest, userID int64, content string) {
+	desired, parseErrors := parseIPListSyncContent(req.ListKind, content)
+	if len(parseErrors) > 0 {
+		var sb strings.Builder
+		sb.WriteString("同步文件存在格式错误，未做任何修改：\n")
+		for i, item := range parseErrors {
+			if i >= ipListSyncPreviewLines {
+				sb.WriteString(fmt.Sprintf("- ... 其余 %d 条\n", len(parseErrors)-i))
+				break
+			}
+			sb.WriteString("- " + item + "\n")
+		}
+		sb.WriteString("\n请修正后重新上传，或发送 取消 结束。")
+		h.sendText(sb.String())
+		return
+	}
+
+	acc := h.getAccountByLabel(req.AccountLabel)
+	if acc == nil {
+		ClearPendingIPListSync(userID)
+		h.sendText(fmt.Sprintf("未找到账号 %s，已取消本次名单同步。", req.AccountLabel))
+		return
+	}
+	current, err := h.CFClient.ListCustomListItems(context.Background(), *acc, req.ListID)
+	if err != nil {
+		h.sendText(fmt.Sprintf("读取名单失败: %v", err))
+		return
+	}
+
+	plan := BuildIPListSyncPlan(req, current, desired)
+	ClearPendingIPListSync(userID)
+	if !plan.HasChanges() {
+		h.sendText(fmt.Sprintf("名单 %s 已与文件一致（%d 条），无需同步。", req.ListName, plan.Unchanged))
+		return
+	}
+
+	planID := SetIPListSyncPlan(plan)
+	page := BuildIPListSyncConfirmView(planID, plan)
+	if err := h.Sender.SendWithButtons(context.Background(), page.Message, page.Buttons); err != nil {
+		h.sendText(fmt.Sprintf("发送名单同步确认失败: %v", err))
+	}
+}
+
+// parseIPListSyncContent 解析同步文件，重复条目只保留第一条。
+func parseIPListSyncContent(kind string, content string) ([]cloudflare.ListItemCreateRequest, []string) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	seen := make(map[string]struct{}, len(lines))
+	items := make([]cloudflare.ListItemCreateRequest, 0, len(lines))
+	var errs []string
+	for idx, raw := range lines {
+		line := strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		item, err := cfclient.ParseCustomListItem(kind, line)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("第 %d 行: %v", idx+1, err))
+			continue
+		}
+		key := ipListSyncKey(kind, cfclient.CustomListCreateMatchKey(item))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		items = append(items, item)
+	}
+	return items, errs
+}
+
+// BuildIPListSyncPlan 对比现有条目和目标条目，生成新增/删除/更新明细。
+func BuildIPListSyncPlan(req IPListInputRequest, current []cloudflare.ListItem, desired []cloudflare.ListItemCreateRequest) IPListSyncPlan {
+	plan := IPListSyncPlan{
+		AccountLabel: req.AccountLabel,
+		ListID:       req.ListID,
+		ListName:     req.ListName,
+		ListKind:     req.ListKind,
+	}
+
+	existing := make(map[string]cloudflare.ListItem, len(current))
+	for _, item := range current {
+		existing[ipListSyncKey(req.ListKind, cfclient.CustomListItemMatchKey(item))] = item
+	}
+
+	wanted := make(map[string]struct{}, len(desired))
+	for _, item := range desired {
+		key := ipListSyncKey(req.ListKind, cfclient.CustomListCreateMatchKey(item))
+		wanted[key] = struct{}{}
+		value := cfclient.CustomListCreateValue(item)
+
+		old, ok := existing[key]
+		if !ok {
+			plan.Added = append(plan.Added, value)
+			plan.Items = append(plan.Items, item)
+			continue
+		}
+		// 以现有条目为准，只覆盖文件能表达的字段（重定向目标和状态码、非空备注），
+		// 避免整体替换时丢掉重定向选项等文件里没有的设置。
+		merged := cfclient.CustomListCreateFromItem(old)
+		if item.Redirect != nil && merged.Redirect != nil {
+			merged.Redirect.TargetUrl = item.Redirect.TargetUrl
+			merged.Redirect.StatusCode = item.Redirect.StatusCode
+		}
+		if strings.TrimSpace(item.Comment) != "" {
+			merged.Comment = item.Comment
+		}
+		mergedValue := cfclient.CustomListCreateValue(merged)
+		if cfclient.CustomListItemValue(old) != mergedValue || strings.TrimSpace(old.Comment) != strings.TrimSpace(merged.Comment) {
+			plan.Updated = append(plan.Updated, mergedValue)
+		} else {
+			plan.Unchanged++
+		}
+		plan.Items = append(plan.Items, merged)
+	}
+
+	for key, item := range existing {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		plan.Removed = append(plan.Removed, cfclient.CustomListItemValue(item))
+	}
+	sort.Strings(plan.Removed)
+	return plan
+}
+
+// ipListSyncKey 在匹配键基础上把单主机 CIDR 折叠为 IP，避免 1.2.3.4 与 1.2.3.4/32 被视为不同条目。
+func ipListSyncKey(kind string, key string) string {
+	if cfclient.NormalizeCustomListKind(kind) != cloudflare.ListTypeIP {
+		return key
+	}
+	if ip, network, err := net.ParseCIDR(key); err == nil {
+		if ones, bits := network.Mask.Size(); ones == bits {
+			return normalizeParsedIP(ip)
+		}
+		return network.String()
+	}
+	if ip := net.ParseIP(key); ip != nil {
+		return normalizeParsedIP(ip)
+	}
+	return key
+}
+
+func BuildIPListSyncConfirmView(planID string, plan IPListSyncPlan) IPListPage {
+	listName := plan.ListName
+	if strings.TrimSpace(listName) == "" {
+		listName = plan.ListID
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️【名单同步确认】\n账号: %s\n名单: %s [%s]\n同步后条目: %d\n新增: %d | 删除: %d | 更新: %d | 不变: %d\n",
+		plan.AccountLabel, listName, cfclient.CustomListKindLabel(plan.ListKind), len(plan.Items),
+		len(plan.Added), len(plan.Removed), len(plan.Updated), plan.Unchanged))
+	writeIPListSyncSection(&sb, "新增", "+", plan.Added)
+	writeIPListSyncSection(&sb, "删除", "-", plan.Removed)
+	writeIPListSyncSection(&sb, "更新", "~", plan.Updated)
+	sb.WriteString(fmt.Sprintf("\n确认后将通过批量接口整体替换名单。计划 %s 内有效，过期需重新执行 /iplist sync。", ipListSyncPlanTTL))
+
+	token := SetIPListCallbackPayload(IPListCallbackPayload{
+		AccountLabel: plan.AccountLabel,
+		ListID:       plan.ListID,
+		ListName:     plan.ListName,
+		ListKind:     plan.ListKind,
+		SessionID:    planID,
+	})
+	return IPListPage{
+		Message: sb.String(),
+		Buttons: [][]Button{{
+			{Text: "确认同步", CallbackData: fmt.Sprintf("iplist_sync_confirm|%s", token)},
+			{Text: "取消", CallbackData: fmt.Sprintf("iplist_sync_cancel|%s", token)},
+		}},
+	}
+}
+
+func writeIPListSyncSection(sb *strings.Builder, title string, mark string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s:\n", title))
+	for i, value := range values {
+		if i >= ipListSyncPreviewLines {
+			sb.WriteString(fmt.Sprintf("%s ... 其余 %d 条\n", mark, len(values)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", mark, truncateDisplay(value, 80)))
+	}
+}
+
+// ProcessIPListSyncPlan 执行同步计划：一次 PUT 替换全部条目，并轮询批量操作直到完成。
+func ProcessIPListSyncPlan(ctx context.Context, client cfclient.Client, account config.CF, plan IPListSyncPlan) string {
+	if client == nil {
+		client = cfclient.NewClient()
+	}
+	listName := plan.ListName
+	if strings.TrimSpace(listName) == "" {
+		listName = plan.ListID
+	}
+
+	bulk, ok := client.(cloudflareCustomListBulkManager)
+	if !ok {
+		return "❌ 当前 Cloudflare 客户端不支持名单批量替换。"
+	}
+	started := time.Now()
+	result, err := bulk.ReplaceCustomListItems(ctx, account, plan.ListID, plan.Items)
+	if err != nil {
+		return fmt.Sprintf("❌ 名单同步失败\n账号: %s\n名单: %s\n错误: %v", plan.AccountLabel, listName, err)
+	}
+	return fmt.Sprintf("✅ 名单同步完成\n账号: %s\n名单: %s\n当前条目: %d\n新增: %d | 删除: %d | 更新: %d\n操作 ID: %s\n耗时: %s",
+		plan.AccountLabel, listName, len(plan.Items), len(plan.Added), len(plan.Removed), len(plan.Updated),
+		result.OperationID, time.Since(started).Round(time.Second))
+}
+
+func isIPListSyncCancelInput(input string) bool {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "取消", "关闭", "结束", "cancel", "exit", "stop":
+		return true
+	default:
+		return false
+	}
+}
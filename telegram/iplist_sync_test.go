@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+func TestIPListSyncPlanExpiresAndIsTakenOnce(t *testing.T) {
+	planID := SetIPListSyncPlan(IPListSyncPlan{AccountLabel: "main", ListID: "l1", Added: []string{"203.0.113.1"}})
+	if _, ok := TakeIPListSyncPlan(planID); !ok {
+		t.Fatalf("fresh plan should be confirmable")
+	}
+	if _, ok := TakeIPListSyncPlan(planID); ok {
+		t.Fatalf("plan must only be confirmed once")
+	}
+
+	stale := SetIPListSyncPlan(IPListSyncPlan{AccountLabel: "main", ListID: "l1", CreatedAt: time.Now().Add(-ipListSyncPlanTTL - time.Minute)})
+	if _, ok := GetIPListSyncPlan(stale); ok {
+		t.Fatalf("expired plan should not be returned")
+	}
+	if _, ok := TakeIPListSyncPlan(stale); ok {
+		t.Fatalf("expired plan should be rejected on confirm")
+	}
+}
+
+func TestStripRequestURLHidesBotToken(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://api.telegram.org/file/bot123456:secret-token/documents/file.txt", Err: errors.New("connection reset by peer")}
+	msg := stripRequestURL(err).Error()
+	if strings.Contains(msg, "secret-token") || !strings.Contains(msg, "connection reset by peer") {
+		t.Fatalf("unexpected sanitized error: %q", msg)
+	}
+	plain := errors.New("boom")
+	if stripRequestURL(plain) != plain {
+		t.Fatalf("non-url errors should be returned unchanged")
+	}
+}
+
+func TestBuildIPListSyncPlanKeepsRedirectOptions(t *testing.T) {
+	yes, status302 := true, 302
+	current := []cloudflare.ListItem{
+		{ID: "1", Comment: "promo", Redirect: &cloudflare.Redirect{
+			SourceUrl: "example.com/promo", TargetUrl: "https://example.com/new", StatusCode: &status302,
+			IncludeSubdomains: &yes, SubpathMatching: &yes, PreserveQueryString: &yes, PreservePathSuffix: &yes,
+		}},
+		{ID: "2", Redirect: &cloudflare.Redirect{SourceUrl: "example.com/old", TargetUrl: "https://example.com/a", SubpathMatching: &yes}},
+	}
+	desired, errs := parseIPListSyncContent(cloudflare.ListTypeRedirect,
+		"example.com/promo https://example.com/new 302\nexample.com/old https://example.com/b\n")
+	if len(errs) > 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+
+	plan := BuildIPListSyncPlan(IPListInputRequest{AccountLabel: "main", ListID: "l1", ListKind: cloudflare.ListTypeRedirect}, current, desired)
+	if plan.Unchanged != 1 || len(plan.Updated) != 1 || len(plan.Added) != 0 || len(plan.Removed) != 0 {
+		t.Fatalf("unexpected plan counts: %+v", plan)
+	}
+	if !strings.Contains(plan.Updated[0], "https://example.com/b") {
+		t.Fatalf("changed target should be shown as update: %v", plan.Updated)
+	}
+
+	unchanged := plan.Items[0]
+	if unchanged.Comment != "promo" || unchanged.Redirect.IncludeSubdomains == nil || unchanged.Redirect.PreserveQueryString == nil ||
+		unchanged.Redirect.PreservePathSuffix == nil || unchanged.Redirect.SubpathMatching == nil || *unchanged.Redirect.StatusCode != 302 {
+		t.Fatalf("unchanged item lost its options: %+v", *unchanged.Redirect)
+	}
+	updated := plan.Items[1]
+	if updated.Redirect.TargetUrl != "https://example.com/b" || updated.Redirect.SubpathMatching == nil || !*updated.Redirect.SubpathMatching {
+		t.Fatalf("updated item should keep options and take the new target: %+v", *updated.Redirect)
+	}
+}
@@ -0,0 +1,6 @@
+package telegram
+
+import "DomainC/metrics"
+
+var telegramSendFailures = metrics.NewCounterVec("domainc_telegram_send_failures_total",
+	"重试耗尽后仍失败的 Telegram 调用次数，kind 为 message、document 或 request，reason 为 timeout 或 error", "kind", "reason")
@@ -0,0 +1,669 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/registrarclient"
+	"DomainC/reminder"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	zoneMoveActivationTimeout = 48 * time.Hour
+	zoneMovePollInterval      = 5 * time.Minute
+)
+
+type cloudflareZoneMover interface {
+	GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error)
+	CreateZone(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error)
+	SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (cfclient.ZoneSnapshot, error)
+	ApplyZoneSnapshot(ctx context.Context, account config.CF, zoneID string, snap cfclient.ZoneSnapshot, opts cfclient.ZoneApplyOptions) (cfclient.ZoneApplyResult, error)
+	DeleteZoneByID(ctx context.Context, account config.CF, zoneID string) error
+}
+
+// zoneMoveJob 保存一次 /move 从确认到删除旧 Zone 期间需要的上下文。
+type zoneMoveJob struct {
+	Domain       string
+	From         config.CF
+	To           config.CF
+	SourceZoneID string
+	TargetZoneID string
+	Operator     string
+	// StartedAt 是回放完成、开始等待激活的时间，重启后按它计算剩余等待时长。
+	StartedAt time.Time
+	// TimedOutAt 非零表示等待激活超时，此时按钮处理的是目标账号中未激活的新 Zone。
+	TimedOutAt time.Time
+
+	// token 是持久化记录的键，新 Zone 激活后也用作删除/保留按钮的回调参数。
+	token     string
+	mover     cloudflareZoneMover
+	registrar *registrarclient.Manager
+	sender    Sender
+	// recorder 不为空表示 dry-run：只输出计划，不同步注册商、不更新资产缓存、不等待激活。
+	recorder *cfclient.DryRunRecorder
+}
+
+var zoneMoveState = struct {
+	mu      sync.Mutex
+	jobs    map[string]*zoneMoveJob
+	waiting map[string]bool
+	// ctx 是当前 leader 任期的上下文，由 ResumeZoneMoves 登记；确认迁移后启动的等待也随降级结束。
+	ctx context.Context
+}{
+	jobs:    make(map[string]*zoneMoveJob),
+	waiting: make(map[string]bool),
+}
+
+// zoneMoveRecord 是持久化的迁移任务；只保存账号标签，恢复时从当前配置查找账号，避免把 API Token 写进状态文件。
+type zoneMoveRecord struct {
+	Domain       string    `json:"domain"`
+	FromLabel    string    `json:"from_label"`
+	ToLabel      string    `json:"to_label"`
+	SourceZoneID string    `json:"source_zone_id"`
+	TargetZoneID string    `json:"target_zone_id"`
+	Operator     string    `json:"operator,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	ActivatedAt  time.Time `json:"activated_at,omitempty"`
+	TimedOutAt   time.Time `json:"timed_out_at,omitempty"`
+}
+
+type zoneMoveStateFile struct {
+	Version int                       `json:"version"`
+	Jobs    map[string]zoneMoveRecord `json:"jobs"`
+}
+
+var zoneMoveFileMu sync.Mutex
+
+func (h *CommandHandler) handleMoveCommand(args []string) {
+	args, dryRunArg := extractDryRunArg(args)
+	if len(args) != 3 {
+		h.sendText(moveUsage())
+		return
+	}
+	client, recorder := BeginDryRun(h.CFClient, dryRunArg)
+	mover, ok := client.(cloudflareZoneMover)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持 Zone 迁移。")
+		return
+	}
+	domain, err := extractDomainOrHost(args[0])
+	if err != nil {
+		h.sendText(fmt.Sprintf("域名格式错误: %v", err))
+		return
+	}
+	from := h.getAccountByLabel(args[1])
+	to := h.getAccountByLabel(args[2])
+	if from == nil || to == nil {
+		h.sendText(fmt.Sprintf("未找到账号标签: %s / %s", args[1], args[2]))
+		return
+	}
+	if strings.EqualFold(from.Label, to.Label) {
+		h.sendText("源账号和目标账号不能相同。")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	source, err := mover.GetZoneDetails(ctx, *from, domain)
+	if err != nil {
+		h.sendText(fmt.Sprintf("在账号 %s 中查找 %s 失败: %v", from.Label, domain, err))
+		return
+	}
+	if existing, err := mover.GetZoneDetails(ctx, *to, domain); err == nil {
+		h.sendText(fmt.Sprintf("目标账号 %s 已存在 %s（状态: %s），请先确认后手动处理。", to.Label, domain, existing.Status))
+		return
+	} else if !errors.Is(err, cfclient.ErrZoneNotFound) {
+		h.sendText(fmt.Sprintf("检查目标账号 %s 失败: %v", to.Label, err))
+		return
+	}
+
+	job := &zoneMoveJob{
+		Domain:       source.Name,
+		From:         *from,
+		To:           *to,
+		SourceZoneID: source.ID,
+		Operator:     formatOperator(h.operator),
+		mover:        mover,
+		registrar:    h.RegistrarManager,
+		sender:       h.Sender,
+		recorder:     recorder,
+	}
+	token := setZoneMoveJob(job)
+	msg := fmt.Sprintf("%s【Zone 迁移确认】\n域名: %s\n源账号: %s（zone_id: %s，状态: %s）\n目标账号: %s\n\n确认后将依次执行:\n1. 快照源 Zone 的 DNS、防火墙/缓存/限速规则集和关键设置\n2. 在目标账号创建 Zone 并回放快照\n3. 同步注册商 NS 到新 Zone\n4. 等待新 Zone 激活后，再询问是否删除旧 Zone",
+		dryRunPrefix(recorder), source.Name, from.Label, source.ID, source.Status, to.Label)
+	buttons := [][]Button{{
+		{Text: "确认迁移", CallbackData: "move_confirm|" + token},
+		{Text: "取消", CallbackData: "move_cancel|" + token},
+	}}
+	if err := h.Sender.SendWithButtons(context.Background(), msg, buttons); err != nil {
+		h.sendText(fmt.Sprintf("发送迁移确认失败: %v", err))
+	}
+}
+
+func setZoneMoveJob(job *zoneMoveJob) string {
+	token := newInteractionToken()
+	zoneMoveState.mu.Lock()
+	defer zoneMoveState.mu.Unlock()
+	zoneMoveState.jobs[token] = job
+	return token
+}
+
+func registerZoneMoveJob(job *zoneMoveJob) {
+	zoneMoveState.mu.Lock()
+	defer zoneMoveState.mu.Unlock()
+	zoneMoveState.jobs[job.token] = job
+}
+
+func peekZoneMoveJob(token string) (*zoneMoveJob, bool) {
+	zoneMoveState.mu.Lock()
+	defer zoneMoveState.mu.Unlock()
+	job, ok := zoneMoveState.jobs[token]
+	return job, ok
+}
+
+// zoneMoveContext 返回等待激活使用的 leader 上下文；尚未登记时（如测试中）不会被取消。
+func zoneMoveContext() context.Context {
+	zoneMoveState.mu.Lock()
+	defer zoneMoveState.mu.Unlock()
+	if zoneMoveState.ctx == nil {
+		return context.Background()
+	}
+	return zoneMoveState.ctx
+}
+
+func takeZoneMoveJob(token string) (*zoneMoveJob, bool) {
+	zoneMoveState.mu.Lock()
+	defer zoneMoveState.mu.Unlock()
+	job, ok := zoneMoveState.jobs[token]
+	if ok {
+		delete(zoneMoveState.jobs, token)
+	}
+	return job, ok
+}
+
+// ConfirmZoneMove 执行迁移：快照、创建、回放、同步 NS、更新资产缓存，然后后台等待新 Zone 激活。
+func ConfirmZoneMove(token string, operator string) {
+	job, ok := takeZoneMoveJob(token)
+	if !ok {
+		SendTelegramAlert("迁移操作已过期，请重新执行 /move。")
+		return
+	}
+	if strings.TrimSpace(operator) != "" {
+		job.Operator = operator
+	}
+	ctx := context.Background()
+	job.send(fmt.Sprintf("开始迁移 %s: %s → %s（操作人: %s）", job.Domain, job.From.Label, job.To.Label, job.Operator))
+
+	snap, err := job.mover.SnapshotZone(ctx, job.From, job.SourceZoneID, job.Domain)
+	if err != nil {
+		job.send(fmt.Sprintf("❌ 迁移中止：快照源 Zone 失败: %v", err))
+		return
+	}
+	job.sendSnapshotFile(ctx, snap)
+
+	target, err := job.mover.CreateZone(ctx, job.To, job.Domain)
+	if err != nil {
+		job.send(fmt.Sprintf("❌ 迁移中止：在账号 %s 创建 Zone 失败: %v\n源 Zone 未做任何修改。", job.To.Label, err))
+		return
+	}
+	job.TargetZoneID = target.ID
+
+	applied, err := job.mover.ApplyZoneSnapshot(ctx, job.To, target.ID, snap, cfclient.ZoneApplyOptions{})
+	if err != nil {
+		applied.Failed = append(applied.Failed, err.Error())
+	}
+	if job.recorder != nil {
+		summary := BuildZoneMoveSummary(job.Domain, job.From.Label, job.To.Label, snap, target, applied, "dry-run，未同步")
+		FinishDryRun(ctx, job.sender, job.recorder, fmt.Sprintf("move %s %s %s", job.Domain, job.From.Label, job.To.Label), summary)
+		return
+	}
+
+	nsStatus := "未配置注册商，需手动修改 NS"
+	if job.registrar != nil && len(target.NameServers) > 0 {
+		registrar, err := job.registrar.SetNameServersForDomain(ctx, job.Domain, target.NameServers)
+		if err != nil {
+			nsStatus = fmt.Sprintf("同步失败: %v", err)
+		} else {
+			nsStatus = fmt.Sprintf("已同步到 %s (%s)", registrar.Label, registrar.Type)
+		}
+	}
+
+	if rt := reminder.DefaultRuntime(); rt != nil {
+		rt.RecordDomainChange(ctx, reminder.DomainChange{Domain: job.Domain, Source: job.To.Label, IsCF: true, ZoneID: target.ID, Status: target.Status})
+	}
+
+	job.send(BuildZoneMoveSummary(job.Domain, job.From.Label, job.To.Label, snap, target, applied, nsStatus))
+	job.token = newInteractionToken()
+	job.StartedAt = time.Now()
+	job.save(time.Time{})
+	go job.waitForActivation(zoneMoveContext())
+}
+
+// ResumeZoneMoves 在启动后读取持久化的迁移任务：未激活的继续等待，已激活或已超时的恢复按钮。
+// accounts 是当前配置中的账号，记录里的账号标签找不到时保留记录并提醒。
+// ctx 应为 leader 上下文，之后确认的迁移也在它下面等待激活，失去租约后停止轮询，由接管的实例继续。
+func ResumeZoneMoves(ctx context.Context, client cfclient.Client, accounts func() []config.CF, sender Sender) {
+	zoneMoveState.mu.Lock()
+	zoneMoveState.ctx = ctx
+	zoneMoveState.mu.Unlock()
+	mover, ok := client.(cloudflareZoneMover)
+	if !ok {
+		log.Printf("Cloudflare 客户端不支持 Zone 迁移，跳过恢复迁移任务")
+		return
+	}
+	if sender == nil {
+		sender = DefaultSender()
+	}
+	state, err := loadZoneMoveState(config.ZoneMoveStateFile())
+	if err != nil {
+		log.Printf("读取迁移任务状态失败: %v", err)
+		return
+	}
+	byLabel := map[string]config.CF{}
+	for _, account := range accounts() {
+		byLabel[account.Label] = account
+	}
+	for token, record := range state.Jobs {
+		from, fromOK := byLabel[record.FromLabel]
+		to, toOK := byLabel[record.ToLabel]
+		if !fromOK || !toOK {
+			msg := fmt.Sprintf("⚠️ 无法恢复 %s 的迁移任务（%s → %s）：账号不在配置中，记录已保留。", record.Domain, record.FromLabel, record.ToLabel)
+			if err := sender.Send(ctx, msg); err != nil {
+				log.Printf("发送迁移恢复提醒失败: %v", err)
+			}
+			continue
+		}
+		job := &zoneMoveJob{
+			Domain:       record.Domain,
+			From:         from,
+			To:           to,
+			SourceZoneID: record.SourceZoneID,
+			TargetZoneID: record.TargetZoneID,
+			Operator:     record.Operator,
+			StartedAt:    record.StartedAt,
+			TimedOutAt:   record.TimedOutAt,
+			token:        token,
+			mover:        mover,
+			sender:       sender,
+		}
+		if !record.ActivatedAt.IsZero() || !record.TimedOutAt.IsZero() {
+			if _, exists := peekZoneMoveJob(token); !exists {
+				registerZoneMoveJob(job)
+			}
+			continue
+		}
+		go job.waitForActivation(ctx)
+	}
+}
+
+// CancelZoneMove 放弃尚未确认的迁移。
+func CancelZoneMove(token string) bool {
+	_, ok := takeZoneMoveJob(token)
+	return ok
+}
+
+// BuildZoneMoveSummary 渲染迁移回放结果。
+func BuildZoneMoveSummary(domain, fromLabel, toLabel string, snap cfclient.ZoneSnapshot, target cfclient.ZoneDetail, applied cfclient.ZoneApplyResult, nsStatus string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【Zone 迁移】%s\n%s → %s\n新 zone_id: %s\n新 NS: %s\n", domain, fromLabel, toLabel, target.ID, strings.Join(target.NameServers, ", ")))
+	sb.WriteString(fmt.Sprintf("DNS: 快照 %d 条，新建 %d，更新 %d，未变 %d\n", len(snap.DNSRecords), applied.DNSCreated, applied.DNSUpdated, applied.DNSUnchanged))
+	rulesets := "无"
+	if len(applied.RulesetsApplied) > 0 {
+		rulesets = strings.Join(applied.RulesetsApplied, ", ")
+	}
+	sb.WriteString(fmt.Sprintf("规则集: %s\n", rulesets))
+	sb.WriteString(fmt.Sprintf("设置: 快照 %d 项，写入 %d 项\n", len(snap.Settings), len(applied.SettingsApplied)))
+	sb.WriteString(fmt.Sprintf("注册商 NS: %s\n", nsStatus))
+	if len(applied.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n失败 %d 项:\n", len(applied.Failed)))
+		for _, item := range applied.Failed {
+			sb.WriteString("- " + item + "\n")
+		}
+	}
+	sb.WriteString("\n旧 Zone 暂时保留，新 Zone 激活后会再提示是否删除。")
+	return sb.String()
+}
+
+func (j *zoneMoveJob) send(msg string) {
+	if j.sender == nil {
+		SendTelegramAlert(msg)
+		return
+	}
+	if err := j.sender.Send(context.Background(), msg); err != nil {
+		log.Printf("发送迁移消息失败: %v", err)
+	}
+}
+
+func (j *zoneMoveJob) sendSnapshotFile(ctx context.Context, snap cfclient.ZoneSnapshot) {
+	if j.sender == nil {
+		return
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		log.Printf("序列化迁移快照失败: %v", err)
+		return
+	}
+	file, err := os.CreateTemp("", fmt.Sprintf("zone_move_%s_*.json", strings.ReplaceAll(j.Domain, ".", "_")))
+	if err != nil {
+		log.Printf("创建迁移快照文件失败: %v", err)
+		return
+	}
+	path := file.Name()
+	defer os.Remove(path)
+	_, writeErr := file.Write(data)
+	closeErr := file.Close()
+	if writeErr != nil || closeErr != nil {
+		log.Printf("写入迁移快照文件失败: %v %v", writeErr, closeErr)
+		return
+	}
+	caption := fmt.Sprintf("%s 迁移前快照（账号 %s）", j.Domain, j.From.Label)
+	if err := j.sender.SendDocumentPath(ctx, path, caption); err != nil {
+		log.Printf("发送迁移快照失败: %v", err)
+	}
+}
+
+func (j *zoneMoveJob) waitForActivation(ctx context.Context) {
+	zoneMoveState.mu.Lock()
+	if zoneMoveState.waiting[j.token] {
+		zoneMoveState.mu.Unlock()
+		return
+	}
+	zoneMoveState.waiting[j.token] = true
+	zoneMoveState.mu.Unlock()
+	defer func() {
+		zoneMoveState.mu.Lock()
+		delete(zoneMoveState.waiting, j.token)
+		zoneMoveState.mu.Unlock()
+	}()
+
+	deadline := j.StartedAt.Add(zoneMoveActivationTimeout)
+	ticker := time.NewTicker(zoneMovePollInterval)
+	defer ticker.Stop()
+	for {
+		zone, err := j.mover.GetZoneDetails(ctx, j.To, j.Domain)
+		if err == nil && strings.EqualFold(zone.Status, "active") {
+			if rt := reminder.DefaultRuntime(); rt != nil {
+				rt.RecordDomainChange(ctx, reminder.DomainChange{Domain: j.Domain, Source: j.To.Label, IsCF: true, ZoneID: zone.ID, Status: zone.Status})
+			}
+			j.save(time.Now())
+			j.promptDeleteOld(ctx, fmt.Sprintf("✅ %s 已在账号 %s 激活。", j.Domain, j.To.Label))
+			return
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[move] check_active_failed domain=%s account=%s err=%v", j.Domain, j.To.Label, err)
+		}
+		if time.Now().After(deadline) {
+			j.TimedOutAt = time.Now()
+			j.save(time.Time{})
+			j.promptDeleteTarget(ctx, fmt.Sprintf("⚠️ %s 在账号 %s 等待 %s 仍未激活，请检查注册商 NS；旧 Zone 未删除。", j.Domain, j.To.Label, zoneMoveActivationTimeout))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// promptDeleteOld 登记任务并发送删除/保留旧 Zone 的按钮。
+func (j *zoneMoveJob) promptDeleteOld(ctx context.Context, headline string) {
+	registerZoneMoveJob(j)
+	msg := fmt.Sprintf("%s\n是否删除账号 %s 中的旧 Zone（zone_id: %s）？删除前会先保存一份快照。", headline, j.From.Label, j.SourceZoneID)
+	buttons := [][]Button{{
+		{Text: "删除旧 Zone", CallbackData: "move_delete_old|" + j.token},
+		{Text: "保留旧 Zone", CallbackData: "move_keep_old|" + j.token},
+	}}
+	if j.sender == nil {
+		SendTelegramAlertWithButtons(msg, buttons)
+	} else if err := j.sender.SendWithButtons(ctx, msg, buttons); err != nil {
+		log.Printf("发送迁移激活提示失败: %v", err)
+	}
+}
+
+// promptDeleteTarget 在等待激活超时后登记任务，并发送删除/保留目标账号中未激活新 Zone 的按钮。
+func (j *zoneMoveJob) promptDeleteTarget(ctx context.Context, headline string) {
+	registerZoneMoveJob(j)
+	msg := fmt.Sprintf("%s\n新 Zone（zone_id: %s）仍留在账号 %s 中，不再使用可点击删除，删除前会先保存一份快照。", headline, j.TargetZoneID, j.To.Label)
+	buttons := [][]Button{{
+		{Text: "删除新 Zone", CallbackData: "move_delete_target|" + j.token},
+		{Text: "保留新 Zone", CallbackData: "move_keep_target|" + j.token},
+	}}
+	if j.sender == nil {
+		SendTelegramAlertWithButtons(msg, buttons)
+	} else if err := j.sender.SendWithButtons(ctx, msg, buttons); err != nil {
+		log.Printf("发送迁移超时提示失败: %v", err)
+	}
+}
+
+// beginDryRun 按全局 dry-run 开关包装删除使用的客户端。
+func (j *zoneMoveJob) beginDryRun() (cloudflareZoneMover, *cfclient.DryRunRecorder) {
+	if client, ok := j.mover.(cfclient.Client); ok {
+		wrapped, recorder := BeginDryRun(client, false)
+		if mover, ok := wrapped.(cloudflareZoneMover); ok {
+			return mover, recorder
+		}
+	}
+	return j.mover, nil
+}
+
+// DeleteOldZoneAfterMove 在新 Zone 激活后删除源账号中的旧 Zone，并更新资产缓存归属。
+// 删除前保存旧 Zone 快照；配置了 approval.actions 包含 delete 时需要第二人批准。
+func DeleteOldZoneAfterMove(token string, user *tgbotapi.User) {
+	job, ok := peekZoneMoveJob(token)
+	if !ok || !job.TimedOutAt.IsZero() {
+		SendTelegramAlert("操作已过期，请到 Cloudflare 控制台手动删除旧 Zone。")
+		return
+	}
+	mover, recorder := job.beginDryRun()
+	operator := formatOperator(user)
+	if ApprovalRequired(ApprovalActionDelete, recorder) {
+		description := fmt.Sprintf("迁移完成后删除账号 %s 中的旧 Zone %s（zone_id: %s）", job.From.Label, job.Domain, job.SourceZoneID)
+		_, err := RequestApproval(context.Background(), job.sender, ApprovalActionDelete, description, user, func(requester, approver string) {
+			deleteOldZoneAfterMove(token, mover, recorder, FormatApprovalFooter(requester, approver))
+		})
+		if err != nil {
+			job.send(fmt.Sprintf("发送审批请求失败: %v", err))
+		}
+		return
+	}
+	deleteOldZoneAfterMove(token, mover, recorder, "操作人: "+operator)
+}
+
+func deleteOldZoneAfterMove(token string, mover cloudflareZoneMover, recorder *cfclient.DryRunRecorder, footer string) {
+	job, ok := takeZoneMoveJob(token)
+	if !ok {
+		SendTelegramAlert("该迁移任务已处理，请勿重复操作。")
+		return
+	}
+	ctx := context.Background()
+	zone := cfclient.ZoneDetail{ID: job.SourceZoneID, Name: job.Domain}
+	if recorder != nil {
+		err := mover.DeleteZoneByID(ctx, job.From, job.SourceZoneID)
+		registerZoneMoveJob(job)
+		summary := fmt.Sprintf("删除账号 %s 中的旧 Zone %s（zone_id: %s）", job.From.Label, job.Domain, job.SourceZoneID)
+		if err != nil {
+			summary += fmt.Sprintf("\n失败: %v", err)
+		}
+		FinishDryRun(ctx, job.sender, recorder, "move delete old "+job.Domain, summary)
+		return
+	}
+	info, err := TakeZoneSnapshot(ctx, mover, job.From, zone, "删除迁移前的旧 Zone")
+	if err != nil {
+		job.promptDeleteOld(ctx, fmt.Sprintf("❌ 保存旧 Zone %s 的快照失败，未删除: %v", job.Domain, err))
+		return
+	}
+	if err := mover.DeleteZoneByID(ctx, job.From, job.SourceZoneID); err != nil {
+		job.promptDeleteOld(ctx, fmt.Sprintf("❌ 删除账号 %s 中的旧 Zone %s 失败: %v", job.From.Label, job.Domain, err))
+		return
+	}
+	job.remove()
+	if rt := reminder.DefaultRuntime(); rt != nil {
+		rt.RecordDomainDeletion(ctx, job.Domain, job.From.Label)
+	}
+	job.send(fmt.Sprintf("✅ 已删除账号 %s 中的旧 Zone %s，迁移完成。\n删除前快照: %s\n%s", job.From.Label, job.Domain, info.ID, footer))
+}
+
+// KeepOldZoneAfterMove 保留旧 Zone，只结束本次迁移。
+func KeepOldZoneAfterMove(token string, operator string) {
+	job, ok := takeZoneMoveJob(token)
+	if !ok {
+		return
+	}
+	job.remove()
+	job.send(fmt.Sprintf("已保留账号 %s 中的旧 Zone %s（操作人: %s）。", job.From.Label, job.Domain, operator))
+}
+
+// DeleteTargetZoneAfterMove 在等待激活超时后删除目标账号中未激活的新 Zone，资产缓存归属改回源账号。
+// 删除前保存新 Zone 快照；配置了 approval.actions 包含 delete 时需要第二人批准。
+func DeleteTargetZoneAfterMove(token string, user *tgbotapi.User) {
+	job, ok := peekZoneMoveJob(token)
+	if !ok || job.TimedOutAt.IsZero() {
+		SendTelegramAlert("操作已过期，请到 Cloudflare 控制台手动删除未激活的新 Zone。")
+		return
+	}
+	mover, recorder := job.beginDryRun()
+	operator := formatOperator(user)
+	if ApprovalRequired(ApprovalActionDelete, recorder) {
+		description := fmt.Sprintf("迁移超时后删除账号 %s 中未激活的新 Zone %s（zone_id: %s）", job.To.Label, job.Domain, job.TargetZoneID)
+		_, err := RequestApproval(context.Background(), job.sender, ApprovalActionDelete, description, user, func(requester, approver string) {
+			deleteTargetZoneAfterMove(token, mover, recorder, FormatApprovalFooter(requester, approver))
+		})
+		if err != nil {
+			job.send(fmt.Sprintf("发送审批请求失败: %v", err))
+		}
+		return
+	}
+	deleteTargetZoneAfterMove(token, mover, recorder, "操作人: "+operator)
+}
+
+func deleteTargetZoneAfterMove(token string, mover cloudflareZoneMover, recorder *cfclient.DryRunRecorder, footer string) {
+	job, ok := takeZoneMoveJob(token)
+	if !ok {
+		SendTelegramAlert("该迁移任务已处理，请勿重复操作。")
+		return
+	}
+	ctx := context.Background()
+	zone := cfclient.ZoneDetail{ID: job.TargetZoneID, Name: job.Domain}
+	if recorder != nil {
+		err := mover.DeleteZoneByID(ctx, job.To, job.TargetZoneID)
+		registerZoneMoveJob(job)
+		summary := fmt.Sprintf("删除账号 %s 中未激活的新 Zone %s（zone_id: %s）", job.To.Label, job.Domain, job.TargetZoneID)
+		if err != nil {
+			summary += fmt.Sprintf("\n失败: %v", err)
+		}
+		FinishDryRun(ctx, job.sender, recorder, "move delete target "+job.Domain, summary)
+		return
+	}
+	info, err := TakeZoneSnapshot(ctx, mover, job.To, zone, "删除迁移超时的新 Zone")
+	if err != nil {
+		job.promptDeleteTarget(ctx, fmt.Sprintf("❌ 保存新 Zone %s 的快照失败，未删除: %v", job.Domain, err))
+		return
+	}
+	if err := mover.DeleteZoneByID(ctx, job.To, job.TargetZoneID); err != nil {
+		job.promptDeleteTarget(ctx, fmt.Sprintf("❌ 删除账号 %s 中的新 Zone %s 失败: %v", job.To.Label, job.Domain, err))
+		return
+	}
+	job.remove()
+	msg := fmt.Sprintf("✅ 已删除账号 %s 中未激活的新 Zone %s（zone_id: %s），域名仍由账号 %s 中的旧 Zone 承载。\n删除前快照: %s\n%s",
+		job.To.Label, job.Domain, job.TargetZoneID, job.From.Label, info.ID, footer)
+	source, err := mover.GetZoneDetails(ctx, job.From, job.Domain)
+	if err == nil && len(source.NameServers) > 0 {
+		msg += fmt.Sprintf("\n如注册商 NS 已改为新 Zone，请改回旧 Zone 的 NS: %s", strings.Join(source.NameServers, ", "))
+	}
+	if rt := reminder.DefaultRuntime(); rt != nil {
+		rt.RecordDomainDeletion(ctx, job.Domain, job.To.Label)
+		if err == nil {
+			rt.RecordDomainChange(ctx, reminder.DomainChange{Domain: job.Domain, Source: job.From.Label, IsCF: true, ZoneID: source.ID, Status: source.Status})
+		}
+	}
+	job.send(msg)
+}
+
+// KeepTargetZoneAfterMove 保留超时未激活的新 Zone，只结束本次迁移。
+func KeepTargetZoneAfterMove(token string, operator string) {
+	job, ok := takeZoneMoveJob(token)
+	if !ok {
+		return
+	}
+	job.remove()
+	job.send(fmt.Sprintf("已保留账号 %s 中未激活的新 Zone %s（zone_id: %s，操作人: %s），请稍后手动处理。", job.To.Label, job.Domain, job.TargetZoneID, operator))
+}
+
+// save 写入或更新持久化记录；activatedAt 非零表示已经发出删除/保留按钮。
+func (j *zoneMoveJob) save(activatedAt time.Time) {
+	record := zoneMoveRecord{
+		Domain:       j.Domain,
+		FromLabel:    j.From.Label,
+		ToLabel:      j.To.Label,
+		SourceZoneID: j.SourceZoneID,
+		TargetZoneID: j.TargetZoneID,
+		Operator:     j.Operator,
+		StartedAt:    j.StartedAt,
+		ActivatedAt:  activatedAt,
+		TimedOutAt:   j.TimedOutAt,
+	}
+	err := updateZoneMoveState(config.ZoneMoveStateFile(), func(jobs map[string]zoneMoveRecord) {
+		jobs[j.token] = record
+	})
+	if err != nil {
+		log.Printf("保存迁移任务状态失败: domain=%s err=%v", j.Domain, err)
+	}
+}
+
+func (j *zoneMoveJob) remove() {
+	err := updateZoneMoveState(config.ZoneMoveStateFile(), func(jobs map[string]zoneMoveRecord) {
+		delete(jobs, j.token)
+	})
+	if err != nil {
+		log.Printf("删除迁移任务状态失败: domain=%s err=%v", j.Domain, err)
+	}
+}
+
+func loadZoneMoveState(path string) (zoneMoveStateFile, error) {
+	zoneMoveFileMu.Lock()
+	defer zoneMoveFileMu.Unlock()
+	return readZoneMoveState(path)
+}
+
+func readZoneMoveState(path string) (zoneMoveStateFile, error) {
+	var state zoneMoveStateFile
+	if err := loadJSONStateFile(path, &state); err != nil {
+		return state, err
+	}
+	if state.Jobs == nil {
+		state.Jobs = map[string]zoneMoveRecord{}
+	}
+	return state, nil
+}
+
+func updateZoneMoveState(path string, fn func(jobs map[string]zoneMoveRecord)) error {
+	zoneMoveFileMu.Lock()
+	defer zoneMoveFileMu.Unlock()
+	state, err := readZoneMoveState(path)
+	if err != nil {
+		return err
+	}
+	fn(state.Jobs)
+	state.Version = 1
+	return saveJSONStateFile(path, state)
+}
+
+func moveUsage() string {
+	return "用法: /move <domain> <源账号标签> <目标账号标签> [dryrun]\n示例: /move example.com old new\n" +
+		"会复制 DNS、防火墙/缓存/限速规则集和关键设置到目标账号，同步注册商 NS，新 Zone 激活后再确认是否删除旧 Zone。"
+}
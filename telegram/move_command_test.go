@@ -0,0 +1,270 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type fakeZoneMover struct {
+	cfclient.Client
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (f *fakeZoneMover) GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	return cfclient.ZoneDetail{ID: account.Label + "-zone", Name: domain, Status: "active"}, nil
+}
+
+func (f *fakeZoneMover) SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (cfclient.ZoneSnapshot, error) {
+	return cfclient.ZoneSnapshot{Domain: domain, ZoneID: zoneID, AccountLabel: account.Label}, nil
+}
+
+func (f *fakeZoneMover) CreateZone(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	return cfclient.ZoneDetail{ID: account.Label + "-zone", Name: domain}, nil
+}
+
+func (f *fakeZoneMover) ApplyZoneSnapshot(ctx context.Context, account config.CF, zoneID string, snap cfclient.ZoneSnapshot, opts cfclient.ZoneApplyOptions) (cfclient.ZoneApplyResult, error) {
+	return cfclient.ZoneApplyResult{}, nil
+}
+
+func (f *fakeZoneMover) DeleteZoneByID(ctx context.Context, account config.CF, zoneID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, account.Label+"/"+zoneID)
+	return nil
+}
+
+func (f *fakeZoneMover) deletedZones() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+type recordingSender struct {
+	NoopSender
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recordingSender) Send(ctx context.Context, msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recordingSender) SendWithButtons(ctx context.Context, msg string, buttons [][]Button) error {
+	for _, row := range buttons {
+		for _, b := range row {
+			msg += "\n[" + b.CallbackData + "]"
+		}
+	}
+	return r.Send(ctx, msg)
+}
+
+func (r *recordingSender) text() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.messages, "\n---\n")
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResumeZoneMovesAndDeleteOldZoneWithApproval(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	dir := t.TempDir()
+	cfg := prev
+	cfg.ZoneMove.StateFile = filepath.Join(dir, "zone_move_state.json")
+	cfg.ZoneSnapshot.Dir = filepath.Join(dir, "snapshots")
+	cfg.OperationLog.File = filepath.Join(dir, "operation_log.json")
+	cfg.Approval = config.Approval{Actions: []string{ApprovalActionDelete}}
+	config.Set(cfg)
+
+	startedAt := time.Now().Add(-time.Hour)
+	err := updateZoneMoveState(config.ZoneMoveStateFile(), func(jobs map[string]zoneMoveRecord) {
+		jobs["move-activated"] = zoneMoveRecord{Domain: "done.example", FromLabel: "old", ToLabel: "new", SourceZoneID: "src-1", StartedAt: startedAt, ActivatedAt: startedAt}
+		jobs["move-waiting"] = zoneMoveRecord{Domain: "wait.example", FromLabel: "old", ToLabel: "new", SourceZoneID: "src-2", StartedAt: startedAt}
+		jobs["move-orphan"] = zoneMoveRecord{Domain: "orphan.example", FromLabel: "gone", ToLabel: "new", SourceZoneID: "src-3", StartedAt: startedAt}
+	})
+	if err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	mover := &fakeZoneMover{}
+	sender := &recordingSender{}
+	accounts := func() []config.CF { return []config.CF{{Label: "old"}, {Label: "new"}} }
+	ResumeZoneMoves(context.Background(), mover, accounts, sender)
+	t.Cleanup(func() {
+		for _, token := range []string{"move-activated", "move-waiting", "move-orphan"} {
+			takeZoneMoveJob(token)
+		}
+	})
+
+	if _, ok := peekZoneMoveJob("move-activated"); !ok {
+		t.Fatalf("activated job should be registered so its buttons keep working")
+	}
+	waitFor(t, "waiting job to be prompted", func() bool { return strings.Contains(sender.text(), "move_delete_old|move-waiting") })
+	if !strings.Contains(sender.text(), "orphan.example") {
+		t.Fatalf("missing account should be reported: %s", sender.text())
+	}
+	state, err := loadZoneMoveState(config.ZoneMoveStateFile())
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if state.Jobs["move-waiting"].ActivatedAt.IsZero() {
+		t.Fatalf("activation should be persisted: %+v", state.Jobs["move-waiting"])
+	}
+	if _, ok := state.Jobs["move-orphan"]; !ok {
+		t.Fatalf("record of missing account should be kept")
+	}
+
+	requester := &tgbotapi.User{ID: 1, UserName: "alice"}
+	DeleteOldZoneAfterMove("move-activated", requester)
+	if len(mover.deletedZones()) != 0 {
+		t.Fatalf("old zone must not be deleted before approval")
+	}
+	var approvalToken string
+	for _, item := range ListPendingApprovals() {
+		if strings.Contains(item.Description, "done.example") {
+			approvalToken = item.Token
+		}
+	}
+	if approvalToken == "" {
+		t.Fatalf("expected a pending delete approval")
+	}
+	if _, err := ApproveApproval(approvalToken, &tgbotapi.User{ID: 2, UserName: "bob"}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	waitFor(t, "old zone deletion", func() bool { return len(mover.deletedZones()) == 1 })
+	if got := mover.deletedZones()[0]; got != "old/src-1" {
+		t.Fatalf("deleted %s, want old/src-1", got)
+	}
+	waitFor(t, "completion message", func() bool { return strings.Contains(sender.text(), "迁移完成") })
+
+	items, err := zoneSnapshotStore().List("done.example")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected a snapshot before deletion, got %+v err=%v", items, err)
+	}
+	state, _ = loadZoneMoveState(config.ZoneMoveStateFile())
+	if _, ok := state.Jobs["move-activated"]; ok {
+		t.Fatalf("finished move should be removed from state")
+	}
+	if _, ok := peekZoneMoveJob("move-activated"); ok {
+		t.Fatalf("finished move should not stay registered")
+	}
+}
+
+// pendingZoneMover 模拟目标账号中一直未激活的新 Zone。
+type pendingZoneMover struct {
+	*fakeZoneMover
+}
+
+func (f *pendingZoneMover) GetZoneDetails(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
+	if account.Label == "new" {
+		return cfclient.ZoneDetail{ID: "tgt-1", Name: domain, Status: "pending"}, nil
+	}
+	return cfclient.ZoneDetail{ID: "src-1", Name: domain, Status: "active", NameServers: []string{"a.ns.cloudflare.com", "b.ns.cloudflare.com"}}, nil
+}
+
+func TestZoneMoveTimeoutOffersDeletingTargetZoneAndStopsWithLeaderContext(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	dir := t.TempDir()
+	cfg := prev
+	cfg.ZoneMove.StateFile = filepath.Join(dir, "zone_move_state.json")
+	cfg.ZoneSnapshot.Dir = filepath.Join(dir, "snapshots")
+	cfg.OperationLog.File = filepath.Join(dir, "operation_log.json")
+	cfg.Approval = config.Approval{}
+	config.Set(cfg)
+
+	err := updateZoneMoveState(config.ZoneMoveStateFile(), func(jobs map[string]zoneMoveRecord) {
+		jobs["move-expired"] = zoneMoveRecord{Domain: "slow.example", FromLabel: "old", ToLabel: "new", SourceZoneID: "src-1", TargetZoneID: "tgt-1", StartedAt: time.Now().Add(-zoneMoveActivationTimeout - time.Hour)}
+		jobs["move-pending"] = zoneMoveRecord{Domain: "pending.example", FromLabel: "old", ToLabel: "new", SourceZoneID: "src-2", TargetZoneID: "tgt-2", StartedAt: time.Now().Add(-time.Hour)}
+	})
+	if err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	mover := &pendingZoneMover{fakeZoneMover: &fakeZoneMover{}}
+	sender := &recordingSender{}
+	accounts := func() []config.CF { return []config.CF{{Label: "old"}, {Label: "new"}} }
+	leaderCtx, demote := context.WithCancel(context.Background())
+	ResumeZoneMoves(leaderCtx, mover, accounts, sender)
+	t.Cleanup(func() {
+		demote()
+		takeZoneMoveJob("move-expired")
+		zoneMoveState.mu.Lock()
+		zoneMoveState.ctx = nil
+		zoneMoveState.mu.Unlock()
+	})
+
+	waitFor(t, "timeout prompt", func() bool { return strings.Contains(sender.text(), "move_delete_target|move-expired") })
+	if !strings.Contains(sender.text(), "tgt-1") {
+		t.Fatalf("timeout message should name the target zone: %s", sender.text())
+	}
+	state, err := loadZoneMoveState(config.ZoneMoveStateFile())
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if state.Jobs["move-expired"].TimedOutAt.IsZero() {
+		t.Fatalf("timeout should be persisted so the button survives a restart: %+v", state.Jobs["move-expired"])
+	}
+
+	waitFor(t, "pending waiter to start", func() bool {
+		zoneMoveState.mu.Lock()
+		defer zoneMoveState.mu.Unlock()
+		return zoneMoveState.waiting["move-pending"]
+	})
+	demote()
+	if zoneMoveContext().Err() == nil {
+		t.Fatalf("waiters started after confirmation should use the leader context")
+	}
+	waitFor(t, "pending waiter to stop after demotion", func() bool {
+		zoneMoveState.mu.Lock()
+		defer zoneMoveState.mu.Unlock()
+		return !zoneMoveState.waiting["move-pending"]
+	})
+	state, _ = loadZoneMoveState(config.ZoneMoveStateFile())
+	if record, ok := state.Jobs["move-pending"]; !ok || !record.TimedOutAt.IsZero() {
+		t.Fatalf("demoted waiter should leave the record for the next leader: %+v", record)
+	}
+
+	DeleteOldZoneAfterMove("move-expired", &tgbotapi.User{ID: 1, UserName: "alice"})
+	if len(mover.deletedZones()) != 0 {
+		t.Fatalf("a timed out move must not delete the old zone: %v", mover.deletedZones())
+	}
+	DeleteTargetZoneAfterMove("move-expired", &tgbotapi.User{ID: 1, UserName: "alice"})
+	if got := mover.deletedZones(); len(got) != 1 || got[0] != "new/tgt-1" {
+		t.Fatalf("deleted %v, want new/tgt-1", got)
+	}
+	if !strings.Contains(sender.text(), "a.ns.cloudflare.com") {
+		t.Fatalf("completion should list the old zone NS: %s", sender.text())
+	}
+	items, err := zoneSnapshotStore().List("slow.example")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected a snapshot before deleting the target zone, got %+v err=%v", items, err)
+	}
+	state, _ = loadZoneMoveState(config.ZoneMoveStateFile())
+	if _, ok := state.Jobs["move-expired"]; ok {
+		t.Fatalf("finished move should be removed from state")
+	}
+}
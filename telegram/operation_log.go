@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+)
+
+// operationLogMaxEntries 限制记录文件大小，超出后丢弃最早的记录。
+const operationLogMaxEntries = 5000
+
+// OperationEntry 是机器人对某个 Zone 执行的一次写操作。
+type OperationEntry struct {
+	At        time.Time `json:"at"`
+	Operation string    `json:"operation"`
+	Account   string    `json:"account,omitempty"`
+	Zone      string    `json:"zone"`
+	Operator  string    `json:"operator,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+type operationLogFile struct {
+	Version int              `json:"version"`
+	Entries []OperationEntry `json:"entries"`
+}
+
+var operationLogMu sync.Mutex
+
+// RecordOperation 追加一条操作记录；写入失败只记日志，不影响操作本身。
+func RecordOperation(entry OperationEntry) {
+	entry.Zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry.Zone)), ".")
+	if entry.Zone == "" {
+		return
+	}
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+	operationLogMu.Lock()
+	defer operationLogMu.Unlock()
+	path := config.OperationLogFile()
+	var file operationLogFile
+	if err := loadJSONStateFile(path, &file); err != nil {
+		log.Printf("[operation_log] load_failed err=%v", err)
+		return
+	}
+	cutoff := time.Now().Add(-config.OperationLogRetention())
+	kept := make([]OperationEntry, 0, len(file.Entries)+1)
+	for _, existing := range file.Entries {
+		if existing.At.After(cutoff) {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, entry)
+	if len(kept) > operationLogMaxEntries {
+		kept = kept[len(kept)-operationLogMaxEntries:]
+	}
+	if err := saveJSONStateFile(path, operationLogFile{Version: 1, Entries: kept}); err != nil {
+		log.Printf("[operation_log] save_failed err=%v", err)
+	}
+}
+
+// RecentOperations 返回 since 之后针对 zone 的操作，最新的在前，最多 limit 条（<=0 不限制）。
+func RecentOperations(zone string, since time.Time, limit int) ([]OperationEntry, error) {
+	zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+	operationLogMu.Lock()
+	var file operationLogFile
+	err := loadJSONStateFile(config.OperationLogFile(), &file)
+	operationLogMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var out []OperationEntry
+	for _, entry := range file.Entries {
+		if entry.Zone == zone && !entry.At.Before(since) {
+			out = append(out, entry)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.After(out[j].At) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// recordDNSChangeOperations 按 Zone 汇总一次 DNS 变更并写入操作记录。
+func recordDNSChangeOperations(operation string, account config.CF, operator string, changes []DNSRecordChange) {
+	counts := make(map[string]int)
+	var zones []string
+	for _, change := range changes {
+		if counts[change.ZoneName] == 0 {
+			zones = append(zones, change.ZoneName)
+		}
+		counts[change.ZoneName]++
+	}
+	for _, zone := range zones {
+		RecordOperation(OperationEntry{
+			Operation: operation,
+			Account:   account.Label,
+			Zone:      zone,
+			Operator:  operator,
+			Detail:    fmt.Sprintf("%d 条记录", counts[zone]),
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"DomainC/config"
+)
+
+func TestRecentOperationsWindowAndRetention(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	cfg := prev
+	cfg.OperationLog.File = filepath.Join(t.TempDir(), "operation_log.json")
+	cfg.OperationLog.RetentionDays = 7
+	config.Set(cfg)
+
+	now := time.Now().UTC()
+	RecordOperation(OperationEntry{At: now.Add(-8 * 24 * time.Hour), Operation: "cls", Zone: "example.com"})
+	RecordOperation(OperationEntry{At: now.Add(-3 * time.Hour), Operation: "setdns", Zone: "example.com"})
+	RecordOperation(OperationEntry{At: now.Add(-time.Hour), Operation: "pause", Zone: "Example.COM."})
+	RecordOperation(OperationEntry{At: now.Add(-30 * time.Minute), Operation: "cls", Zone: "other.com"})
+	RecordOperation(OperationEntry{Operation: "ignored", Zone: " "})
+
+	ops, err := RecentOperations("example.com", now.Add(-2*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("RecentOperations: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Operation != "pause" {
+		t.Fatalf("expected only the operation inside the window, got %+v", ops)
+	}
+
+	ops, _ = RecentOperations("EXAMPLE.com", now.Add(-30*24*time.Hour), 0)
+	if len(ops) != 2 || ops[0].Operation != "pause" || ops[1].Operation != "setdns" {
+		t.Fatalf("expected newest first and expired entry pruned on write, got %+v", ops)
+	}
+	if ops, _ = RecentOperations("example.com", time.Time{}, 1); len(ops) != 1 || ops[0].Operation != "pause" {
+		t.Fatalf("limit should keep the newest entry, got %+v", ops)
+	}
+
+	var file operationLogFile
+	if err := loadJSONStateFile(cfg.OperationLog.File, &file); err != nil {
+		t.Fatalf("load operation log: %v", err)
+	}
+	if len(file.Entries) != 3 {
+		t.Fatalf("expected expired and empty-zone entries to be dropped, got %+v", file.Entries)
+	}
+}
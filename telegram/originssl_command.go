@@ -326,7 +326,7 @@ func BuildOriginSSLAWSButtons(selection OriginSSLSelection) [][]Button {
 		if selection.AWSAliases[alias] {
 			mark = "☑"
 		}
-		target := config.Cfg.AWSTargets[alias]
+		target := config.Cfg().AWSTargets[alias]
 		token := SetOriginSSLCallbackPayload(OriginSSLCallbackPayload{Value: alias})
 		flat = append(flat, Button{
 			Text:         fmt.Sprintf("%s %s (%s)", mark, alias, target.Region),
@@ -365,7 +365,7 @@ func OriginSSLTargetPromptText() string {
 	sb.WriteString("Cloudflare 账号会按域名自动识别。\n")
 	sb.WriteString("请选择要导入证书的 AWS 目标（可多选，可不选）：\n")
 	for _, alias := range sortedAWSTargetAliases() {
-		target := config.Cfg.AWSTargets[alias]
+		target := config.Cfg().AWSTargets[alias]
 		sb.WriteString(fmt.Sprintf("- %s (%s)\n", alias, target.Region))
 	}
 	sb.WriteString("\n完成后直接输入一个或多个主域名。")
@@ -507,6 +507,16 @@ func (h *CommandHandler) handlePendingOriginSSLBlockCountries(msgText string, us
 	}
 }
 
+func BuildOriginSSLBlockCountriesPrompt(req OriginSSLInputRequest, errText string) string {
+	var sb strings.Builder
+	if strings.TrimSpace(errText) != "" {
+		sb.WriteString("输入错误: " + errText + "\n\n")
+	}
+	sb.WriteString("/ssl 不再安装国家/地区拦截规则，如需拦截请使用 /cf_rules。\n")
+	sb.WriteString("发送任意内容进入执行确认，或发送 关闭/结束/exit/stop 结束本次 /ssl。")
+	return sb.String()
+}
+
 func (h *CommandHandler) handlePendingOriginSSLDNSTarget(msgText string, userID int64, req OriginSSLInputRequest) {
 	if isOriginSSLStopInput(msgText) {
 		ClearPendingOriginSSLInput(userID)
@@ -677,7 +687,7 @@ func splitOriginSSLDirectArgs(args []string) ([]string, []string) {
 		if token == "" {
 			continue
 		}
-		if _, ok := config.Cfg.AWSTargets[token]; ok {
+		if _, ok := config.Cfg().AWSTargets[token]; ok {
 			if seenAlias[token] {
 				continue
 			}
@@ -1219,7 +1229,7 @@ func processOriginSSLDomainItem(ctx context.Context, client cfclient.Client, acc
 	}
 
 	for _, awsAlias := range awsAliases {
-		target, ok := config.Cfg.AWSTargets[awsAlias]
+		target, ok := config.Cfg().AWSTargets[awsAlias]
 		if !ok {
 			domainResult.Imports = append(domainResult.Imports, OriginSSLAWSImportResult{
 				Alias: awsAlias,
@@ -1612,7 +1622,7 @@ func (h *CommandHandler) processOriginSSLDirectDomain(ctx context.Context, req O
 	}
 
 	for _, awsAlias := range req.AWSAliases {
-		target, ok := config.Cfg.AWSTargets[awsAlias]
+		target, ok := config.Cfg().AWSTargets[awsAlias]
 		if !ok {
 			domainResult.Imports = append(domainResult.Imports, originSSLImportResult{
 				Alias: awsAlias,
@@ -1723,8 +1733,8 @@ func (h *CommandHandler) findAccountByDomainInAccounts(ctx context.Context, doma
 }
 
 func sortedAWSTargetAliases() []string {
-	aliases := make([]string, 0, len(config.Cfg.AWSTargets))
-	for alias := range config.Cfg.AWSTargets {
+	aliases := make([]string, 0, len(config.Cfg().AWSTargets))
+	for alias := range config.Cfg().AWSTargets {
 		if strings.TrimSpace(alias) == "" {
 			continue
 		}
@@ -1737,7 +1747,7 @@ func sortedAWSTargetAliases() []string {
 func formatAWSTargets(aliases []string) []string {
 	out := make([]string, 0, len(aliases))
 	for _, alias := range aliases {
-		target, ok := config.Cfg.AWSTargets[alias]
+		target, ok := config.Cfg().AWSTargets[alias]
 		if !ok {
 			out = append(out, alias)
 			continue
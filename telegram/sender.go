@@ -4,9 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"DomainC/config"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -26,6 +32,11 @@ type HTMLSender interface {
 	SendHTML(ctx context.Context, msg string) error
 }
 
+// FileDownloader 下载用户上传到 Telegram 的文件内容。
+type FileDownloader interface {
+	DownloadFile(ctx context.Context, fileID string, maxBytes int64) ([]byte, error)
+}
+
 type NoopSender struct{}
 
 func (NoopSender) SendDocumentPath(ctx context.Context, filepath string, caption string) error {
@@ -165,7 +176,10 @@ func splitTelegramText(s string, limit int) []string {
 	}
 	return out
 }
+
+// sendWithMarkup 发送前对正文脱敏，避免错误信息中带出已加载的密钥。
 func (s *BotSender) sendWithMarkup(ctx context.Context, msg tgbotapi.MessageConfig) error {
+	msg.Text = config.Redact(msg.Text)
 
 	for attempt := 0; attempt <= s.retryTimes; attempt++ {
 		select {
@@ -188,6 +202,7 @@ func (s *BotSender) sendWithMarkup(ctx context.Context, msg tgbotapi.MessageConf
 			case <-sendCtx.Done():
 				cancel()
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("message", "timeout")
 					return fmt.Errorf("发送 Telegram 超时: %w", sendCtx.Err())
 				}
 				continue
@@ -197,6 +212,7 @@ func (s *BotSender) sendWithMarkup(ctx context.Context, msg tgbotapi.MessageConf
 					return nil
 				}
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("message", "error")
 					return fmt.Errorf("发送 Telegram 失败: %w", err)
 				}
 				time.Sleep(time.Duration(attempt+1) * 200 * time.Millisecond)
@@ -206,27 +222,77 @@ func (s *BotSender) sendWithMarkup(ctx context.Context, msg tgbotapi.MessageConf
 	return nil
 }
 
+// StartListener 在配置了 telegram.webhook 时以 webhook 模式接收更新，注册或监听失败时回退到长轮询。
 func (s *BotSender) StartListener(ctx context.Context, handleCallback func(cb *tgbotapi.CallbackQuery), handleMessage func(msg *tgbotapi.Message)) error {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := s.bot.GetUpdatesChan(u)
-	for {
-		select {
-		case <-ctx.Done():
+	if config.TelegramWebhookEnabled() {
+		err := s.startWebhook(ctx, handleCallback, handleMessage)
+		if ctx.Err() != nil {
 			return ctx.Err()
+		}
+		log.Printf("Telegram webhook 模式不可用，回退到长轮询: %v", err)
+		if _, delErr := s.bot.Request(tgbotapi.DeleteWebhookConfig{}); delErr != nil {
+			log.Printf("删除 Telegram webhook 失败: %v", delErr)
+		}
+	}
+	return s.startPolling(ctx, handleCallback, handleMessage)
+}
 
-		case up := <-updates:
-			if up.CallbackQuery != nil && handleCallback != nil {
-				handleCallback(up.CallbackQuery)
-				_ = s.AnswerCallback(ctx, up.CallbackQuery.ID, "操作已收到")
+// startPolling 自行循环 getUpdates 而不使用 GetUpdatesChan：后者停止后无法再次启动，
+// 而实例失去并重新获得 leader 租约时需要重新开始监听。ctx 结束后收到的更新不会确认，
+// 由接管的实例重新拉取。
+func (s *BotSender) startPolling(ctx context.Context, handleCallback func(cb *tgbotapi.CallbackQuery), handleMessage func(msg *tgbotapi.Message)) error {
+	offset := 0
+	defer func() {
+		// 退出前确认已处理的更新，避免接管的实例重复处理。
+		if offset > 0 {
+			_, _ = s.bot.GetUpdates(tgbotapi.UpdateConfig{Offset: offset, Limit: 1})
+		}
+	}()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		u := tgbotapi.NewUpdate(offset)
+		u.Timeout = 60
+		updates, err := s.bot.GetUpdates(u)
+		if err != nil {
+			log.Printf("拉取 Telegram 更新失败，3 秒后重试: %v", err)
+			timer := time.NewTimer(3 * time.Second)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
 			}
-
-			if up.Message != nil && handleMessage != nil {
-				handleMessage(up.Message)
+			continue
+		}
+		for _, up := range updates {
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
+			if up.UpdateID >= offset {
+				offset = up.UpdateID + 1
+			}
+			dispatchUpdate(up, handleCallback, handleMessage, func(id string) {
+				_ = s.AnswerCallback(ctx, id, "操作已收到")
+			})
 		}
 	}
 }
+
+// dispatchUpdate 是轮询和 webhook 共用的分发逻辑。
+func dispatchUpdate(up tgbotapi.Update, handleCallback func(cb *tgbotapi.CallbackQuery), handleMessage func(msg *tgbotapi.Message), answer func(callbackID string)) {
+	if up.CallbackQuery != nil && handleCallback != nil {
+		handleCallback(up.CallbackQuery)
+		if answer != nil {
+			answer(up.CallbackQuery.ID)
+		}
+	}
+
+	if up.Message != nil && handleMessage != nil {
+		handleMessage(up.Message)
+	}
+}
 func (s *BotSender) SendDocumentPath(ctx context.Context, filepath string, caption string) error {
 	if filepath == "" {
 		return errors.New("filepath is empty")
@@ -257,7 +323,7 @@ func (s *BotSender) sendDocumentTo(ctx context.Context, chatID int64, filepath s
 			go func() {
 				doc := tgbotapi.NewDocument(chatID, tgbotapi.FilePath(filepath))
 				if caption != "" {
-					doc.Caption = caption
+					doc.Caption = config.Redact(caption)
 				}
 				_, err := s.bot.Send(doc)
 				result <- err
@@ -267,6 +333,7 @@ func (s *BotSender) sendDocumentTo(ctx context.Context, chatID int64, filepath s
 			case <-sendCtx.Done():
 				cancel()
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("document", "timeout")
 					return fmt.Errorf("发送文件超时: %w", sendCtx.Err())
 				}
 				continue
@@ -276,6 +343,7 @@ func (s *BotSender) sendDocumentTo(ctx context.Context, chatID int64, filepath s
 					return nil
 				}
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("document", "error")
 					return fmt.Errorf("发送文件失败: %w", err)
 				}
 				time.Sleep(time.Duration(attempt+1) * 200 * time.Millisecond)
@@ -307,6 +375,7 @@ func (s *BotSender) requestWithRetry(ctx context.Context, cfg tgbotapi.Chattable
 			case <-reqCtx.Done():
 				cancel()
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("request", "timeout")
 					return fmt.Errorf("telegram request 超时: %w", reqCtx.Err())
 				}
 				continue
@@ -316,6 +385,7 @@ func (s *BotSender) requestWithRetry(ctx context.Context, cfg tgbotapi.Chattable
 					return nil
 				}
 				if attempt == s.retryTimes {
+					telegramSendFailures.Inc("request", "error")
 					return fmt.Errorf("telegram request 失败: %w", err)
 				}
 				time.Sleep(time.Duration(attempt+1) * 200 * time.Millisecond)
@@ -333,7 +403,7 @@ func (s *BotSender) EditButtons(ctx context.Context, chatID int64, messageID int
 
 func (s *BotSender) EditMessageWithButtons(ctx context.Context, chatID int64, messageID int, msg string, buttons [][]Button) error {
 	markup := buildInlineKeyboardMarkup(buttons)
-	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, msg, markup)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, config.Redact(msg), markup)
 	return s.requestWithRetry(ctx, edit)
 }
 
@@ -359,3 +429,50 @@ func (s *BotSender) AnswerCallback(ctx context.Context, callbackID, text string)
 	cfg.ShowAlert = false
 	return s.requestWithRetry(ctx, cfg)
 }
+
+func (s *BotSender) DownloadFile(ctx context.Context, fileID string, maxBytes int64) ([]byte, error) {
+	if strings.TrimSpace(fileID) == "" {
+		return nil, errors.New("fileID is empty")
+	}
+	fileURL, err := s.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件地址失败: %w", stripRequestURL(err))
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, errors.New("构造下载请求失败")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %w", stripRequestURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载文件失败: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("文件超过 %d 字节上限", maxBytes)
+	}
+	return data, nil
+}
+
+// stripRequestURL 去掉 *url.Error 中的请求地址：Telegram 文件地址和 Bot API 地址都包含 bot token，
+// 错误会被原样发回聊天。
+func stripRequestURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s 请求失败: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
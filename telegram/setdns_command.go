@@ -13,6 +13,8 @@ import (
 
 	"DomainC/cfclient"
 	"DomainC/config"
+
+	"github.com/cloudflare/cloudflare-go"
 )
 
 const (
@@ -33,16 +35,24 @@ func (h *CommandHandler) handleSetDNSCommand(args []string) {
 	}
 
 	if len(args) == 0 {
-		h.sendSetDNSAccountSelector()
+		h.sendSetDNSAccountSelector(h.Accounts)
 		return
 	}
 
 	if len(args) == 1 {
-		selector := strings.TrimSpace(args[0])
-		if acc := h.getAccountByLabel(selector); acc != nil {
-			h.beginSetDNSKeywordInput(*acc)
+		// 关键词筛选按单个账号进行；选择器命中多个账号时只在命中的账号中再选一次。
+		targets, err := h.resolveAccountTargets(args[0])
+		if err != nil {
+			h.sendText(err.Error() + "\n\n" + h.accountSelectorHelp())
+			return
+		}
+		accounts := TargetAccounts(targets)
+		if len(accounts) == 1 {
+			h.beginSetDNSKeywordInput(accounts[0])
 			return
 		}
+		h.sendSetDNSAccountSelector(accounts)
+		return
 	}
 
 	h.sendText("交互用法: /setdns 后选择账号，再发送一个或多个关键词。\n旧用法仍可用: /setdns <domain.com> <type> <name> <content> [proxied:yes/no]")
@@ -109,9 +119,9 @@ func (h *CommandHandler) handleSetDNSDirect(args []string) {
 	}
 }
 
-func (h *CommandHandler) sendSetDNSAccountSelector() {
+func (h *CommandHandler) sendSetDNSAccountSelector(accounts []config.CF) {
 	var buttons [][]Button
-	for _, acc := range h.Accounts {
+	for _, acc := range accounts {
 		label := strings.TrimSpace(acc.Label)
 		if label == "" {
 			continue
@@ -126,7 +136,7 @@ func (h *CommandHandler) sendSetDNSAccountSelector() {
 		h.sendText("未配置可用的 Cloudflare 账号，无法修改解析。")
 		return
 	}
-	if err := h.Sender.SendWithButtons(context.Background(), h.setDNSPromptText(), buttons); err != nil {
+	if err := h.Sender.SendWithButtons(context.Background(), h.setDNSPromptText(accounts), buttons); err != nil {
 		h.sendText(fmt.Sprintf("发送 setdns 账号选择失败: %v", err))
 	}
 }
@@ -141,10 +151,10 @@ func (h *CommandHandler) beginSetDNSKeywordInput(account config.CF) {
 	h.sendText(BuildSetDNSKeywordPrompt(account.Label))
 }
 
-func (h *CommandHandler) setDNSPromptText() string {
+func (h *CommandHandler) setDNSPromptText(accounts []config.CF) string {
 	var sb strings.Builder
 	sb.WriteString("请选择要修改解析的 Cloudflare 账号：\n")
-	for _, acc := range h.Accounts {
+	for _, acc := range accounts {
 		if strings.TrimSpace(acc.Label) == "" {
 			continue
 		}
@@ -230,7 +240,8 @@ func (h *CommandHandler) runSetDNSKeywordScan(acc config.CF, keywords []string)
 }
 
 func (h *CommandHandler) handlePendingSetDNSNewTarget(msgText string, userID int64, req SetDNSInputRequest) {
-	newTarget := normalizeSetDNSNewTarget(msgText)
+	fields, dryRunArg := extractDryRunArg(strings.Fields(msgText))
+	newTarget := normalizeSetDNSNewTarget(strings.Join(fields, " "))
 	if newTarget == "" {
 		h.sendText(BuildSetDNSNewTargetPrompt(req.AccountLabel, 0))
 		return
@@ -255,6 +266,18 @@ func (h *CommandHandler) handlePendingSetDNSNewTarget(msgText string, userID int
 		return
 	}
 
+	client, recorder := BeginDryRun(h.CFClient, dryRunArg)
+	if recorder != nil {
+		// dry-run 不改动筛选会话，确认计划后可直接再次选择并真实执行。
+		ClearPendingSetDNSInput(userID)
+		go func() {
+			result := ProcessSetDNSUpdateTargets(context.Background(), client, *acc, targets, newTarget)
+			FinishDryRun(context.Background(), h.Sender, recorder, fmt.Sprintf("setdns %s %s", acc.Label, newTarget), result.Summary())
+		}()
+		h.sendText(fmt.Sprintf("🧪 dry-run：正在模拟把 %d 条解析记录改为 %s，不会执行写操作。", len(targets), newTarget))
+		return
+	}
+
 	keys := make([]string, 0, len(targets))
 	for _, target := range targets {
 		keys = append(keys, target.Key)
@@ -264,7 +287,7 @@ func (h *CommandHandler) handlePendingSetDNSNewTarget(msgText string, userID int
 
 	go func() {
 		result := ProcessSetDNSUpdateTargets(context.Background(), h.CFClient, *acc, targets, newTarget)
-		h.sendText(result.Summary())
+		h.sendDNSMutationResult(result.Summary(), "setdns", *acc, result.Changes)
 	}()
 
 	if len(remaining.Candidates) == 0 {
@@ -579,6 +602,7 @@ type SetDNSUpdateResult struct {
 	NewTarget    string
 	Success      []SetDNSRecordTarget
 	Failed       []string
+	Changes      []DNSRecordChange
 }
 
 func (r SetDNSUpdateResult) Summary() string {
@@ -608,19 +632,41 @@ func ProcessSetDNSUpdateTargets(ctx context.Context, client cfclient.Client, acc
 			result.Failed = append(result.Failed, fmt.Sprintf("%s: 等待执行失败: %v", target.Name, err))
 			continue
 		}
-		if err := updateSetDNSRecordWithRetry(ctx, client, account, target, newTarget); err != nil {
+		updated, err := updateSetDNSRecordWithRetry(ctx, client, account, target, newTarget)
+		if err != nil {
 			result.Failed = append(result.Failed, fmt.Sprintf("%s %s: %v", target.Type, target.Name, err))
 			continue
 		}
 		result.Success = append(result.Success, target)
+		result.Changes = append(result.Changes, setDNSRecordChange(target, newTarget, updated))
 	}
 	return result
 }
 
-func updateSetDNSRecordWithRetry(ctx context.Context, client cfclient.Client, account config.CF, target SetDNSRecordTarget, newTarget string) error {
+// setDNSRecordChange 组装撤销所需的前后状态；Cloudflare 未返回记录时按请求参数推断更新后的状态。
+func setDNSRecordChange(target SetDNSRecordTarget, newTarget string, updated cloudflare.DNSRecord) DNSRecordChange {
+	before := cfclient.SnapshotDNSRecord{
+		ID:      target.RecordID,
+		Type:    target.Type,
+		Name:    target.Name,
+		Content: target.Content,
+		TTL:     target.TTL,
+		Proxied: target.Proxied,
+	}
+	after := before
+	after.Content = newTarget
+	if updated.ID != "" {
+		after = dnsRecordSnapshot(updated)
+		before.Comment = after.Comment
+		before.Priority = after.Priority
+	}
+	return DNSRecordChange{ZoneName: target.ZoneName, Before: before, After: &after}
+}
+
+func updateSetDNSRecordWithRetry(ctx context.Context, client cfclient.Client, account config.CF, target SetDNSRecordTarget, newTarget string) (cloudflare.DNSRecord, error) {
 	var lastErr error
 	for attempt := 0; attempt < 3; attempt++ {
-		_, err := client.UpdateDNSRecord(ctx, account, target.ZoneName, cfclient.DNSRecordUpdateParams{
+		updated, err := client.UpdateDNSRecord(ctx, account, target.ZoneName, cfclient.DNSRecordUpdateParams{
 			ID:      target.RecordID,
 			Type:    target.Type,
 			Name:    target.Name,
@@ -629,7 +675,7 @@ func updateSetDNSRecordWithRetry(ctx context.Context, client cfclient.Client, ac
 			TTL:     target.TTL,
 		})
 		if err == nil {
-			return nil
+			return updated, nil
 		}
 		lastErr = err
 		if !isRetryableCloudflareError(err) || attempt == 2 {
@@ -640,9 +686,9 @@ func updateSetDNSRecordWithRetry(ctx context.Context, client cfclient.Client, ac
 		select {
 		case <-ctx.Done():
 			timer.Stop()
-			return ctx.Err()
+			return cloudflare.DNSRecord{}, ctx.Err()
 		case <-timer.C:
 		}
 	}
-	return lastErr
+	return cloudflare.DNSRecord{}, lastErr
 }
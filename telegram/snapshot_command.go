@@ -0,0 +1,441 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/registrarclient"
+	"DomainC/reminder"
+)
+
+const (
+	zoneSnapshotListLimit = 20
+	// zoneRestoreJobTTL 之后恢复确认按钮失效，避免很久以后误按把 Zone 回滚到过时的快照。
+	zoneRestoreJobTTL = 15 * time.Minute
+)
+
+type cloudflareZoneSnapshotter interface {
+	SnapshotZone(ctx context.Context, account config.CF, zoneID string, domain string) (cfclient.ZoneSnapshot, error)
+	ApplyZoneSnapshot(ctx context.Context, account config.CF, zoneID string, snap cfclient.ZoneSnapshot, opts cfclient.ZoneApplyOptions) (cfclient.ZoneApplyResult, error)
+}
+
+type cloudflareZoneCreator interface {
+	CreateZone(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error)
+}
+
+// zoneRestoreJob 保存 /snapshot restore 确认前解析出的目标；ZoneID 为空表示 Zone 已删除，需要重新创建。
+type zoneRestoreJob struct {
+	Domain     string
+	SnapshotID string
+	Account    config.CF
+	ZoneID     string
+
+	client    cloudflareZoneSnapshotter
+	registrar *registrarclient.Manager
+	sender    Sender
+	// recorder 不为空表示 dry-run：不保存恢复前快照、不同步注册商、不更新资产缓存。
+	recorder  *cfclient.DryRunRecorder
+	createdAt time.Time
+}
+
+var zoneRestoreState = struct {
+	mu   sync.Mutex
+	jobs map[string]*zoneRestoreJob
+}{
+	jobs: make(map[string]*zoneRestoreJob),
+}
+
+func zoneSnapshotStore() *cfclient.ZoneSnapshotStore {
+	return cfclient.NewZoneSnapshotStore(config.ZoneSnapshotDir(), config.ZoneSnapshotRetention(), config.ZoneSnapshotMaxPerZone())
+}
+
+// TakeZoneSnapshot 快照一个 Zone 并写入本地快照目录；keep 中的快照 ID 不会被这次保存触发的清理删除。
+func TakeZoneSnapshot(ctx context.Context, client cloudflareZoneSnapshotter, account config.CF, zone cfclient.ZoneDetail, reason string, keep ...string) (cfclient.ZoneSnapshotInfo, error) {
+	snap, err := client.SnapshotZone(ctx, account, zone.ID, zone.Name)
+	if err != nil {
+		return cfclient.ZoneSnapshotInfo{}, err
+	}
+	snap.Reason = reason
+	return zoneSnapshotStore().Save(snap, keep...)
+}
+
+func (h *CommandHandler) handleSnapshotCommand(args []string) {
+	args, dryRunArg := extractDryRunArg(args)
+	if len(args) < 2 {
+		h.sendText(snapshotUsage())
+		return
+	}
+	domain, err := extractDomainOrHost(args[1])
+	if err != nil {
+		h.sendText(fmt.Sprintf("域名格式错误: %v", err))
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "list":
+		h.handleSnapshotList(domain)
+	case "diff":
+		if len(args) < 3 {
+			h.sendText(snapshotUsage())
+			return
+		}
+		h.handleSnapshotDiff(domain, args[2])
+	case "restore":
+		if len(args) < 3 {
+			h.sendText(snapshotUsage())
+			return
+		}
+		accountArg := ""
+		if len(args) >= 4 {
+			accountArg = args[3]
+		}
+		h.handleSnapshotRestore(domain, args[2], accountArg, dryRunArg)
+	default:
+		h.sendText(snapshotUsage())
+	}
+}
+
+func (h *CommandHandler) handleSnapshotList(domain string) {
+	items, err := zoneSnapshotStore().List(domain)
+	if err != nil {
+		h.sendText(fmt.Sprintf("读取 %s 的快照失败: %v", domain, err))
+		return
+	}
+	h.sendText(BuildZoneSnapshotList(domain, items))
+}
+
+// BuildZoneSnapshotList 渲染快照列表，最多展示最近 zoneSnapshotListLimit 份。
+func BuildZoneSnapshotList(domain string, items []cfclient.ZoneSnapshotInfo) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("%s 暂无快照。", domain)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【Zone 快照】%s（共 %d 份）\n", domain, len(items)))
+	for i, item := range items {
+		if i >= zoneSnapshotListLimit {
+			sb.WriteString(fmt.Sprintf("... 还有 %d 份更早的快照\n", len(items)-i))
+			break
+		}
+		reason := item.Reason
+		if reason == "" {
+			reason = "-"
+		}
+		sb.WriteString(fmt.Sprintf("- %s  %s  账号:%s  原因:%s  DNS:%d 规则:%d 设置:%d\n",
+			item.ID, item.TakenAt.Local().Format("2006-01-02 15:04"), item.AccountLabel, reason, item.DNSRecords, item.Rules, item.Settings))
+	}
+	sb.WriteString("\n对比: /snapshot diff <domain> <id>\n恢复: /snapshot restore <domain> <id>")
+	return sb.String()
+}
+
+func (h *CommandHandler) handleSnapshotDiff(domain, id string) {
+	snapper, ok := h.CFClient.(cloudflareZoneSnapshotter)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持 Zone 快照。")
+		return
+	}
+	stored, info, err := zoneSnapshotStore().Load(domain, id)
+	if err != nil {
+		h.sendText(fmt.Sprintf("读取快照失败: %v", err))
+		return
+	}
+	account, zone, err := h.findZone(domain)
+	if err != nil {
+		if errors.Is(err, cfclient.ErrZoneNotFound) {
+			h.sendText(fmt.Sprintf("%s 当前不在任何已配置账号中，恢复快照 %s 会在账号 %s 重新创建 Zone。", domain, info.ID, info.AccountLabel))
+			return
+		}
+		h.sendText(fmt.Sprintf("查找 %s 失败: %v", domain, err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	live, err := snapper.SnapshotZone(ctx, *account, zone.ID, zone.Name)
+	if err != nil {
+		h.sendText(fmt.Sprintf("读取 %s 当前配置失败: %v", zone.Name, err))
+		return
+	}
+	h.sendText(BuildZoneSnapshotDiff(info, account.Label, cfclient.DiffZoneSnapshots(stored, live)))
+}
+
+// BuildZoneSnapshotDiff 渲染“当前 Zone 相对快照”的变化。
+func BuildZoneSnapshotDiff(info cfclient.ZoneSnapshotInfo, currentAccount string, diff cfclient.ZoneSnapshotDiff) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【快照对比】%s\n快照: %s（%s，账号 %s）\n当前账号: %s\n",
+		info.Domain, info.ID, info.TakenAt.Local().Format("2006-01-02 15:04"), info.AccountLabel, currentAccount))
+	if diff.Empty() {
+		sb.WriteString("\n当前配置与快照一致。")
+		return sb.String()
+	}
+	writeRecords := func(title string, records []cfclient.SnapshotDNSRecord) {
+		if len(records) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %d 条:\n", title, len(records)))
+		for i, record := range records {
+			if i >= zoneSnapshotListLimit {
+				sb.WriteString(fmt.Sprintf("... 省略 %d 条\n", len(records)-i))
+				break
+			}
+			proxied := ""
+			if record.Proxied != nil && *record.Proxied {
+				proxied = " (proxied)"
+			}
+			sb.WriteString(fmt.Sprintf("- %s %s → %s%s\n", record.Type, record.Name, truncateDisplay(record.Content, 60), proxied))
+		}
+	}
+	writeRecords("快照之后新增的 DNS", diff.DNSAdded)
+	writeRecords("快照之后删除的 DNS", diff.DNSRemoved)
+	writeRecords("TTL/代理/备注有变化的 DNS", diff.DNSChanged)
+	if len(diff.RulesetsChanged) > 0 {
+		sb.WriteString("\n规则集有变化: " + strings.Join(diff.RulesetsChanged, ", ") + "\n")
+	}
+	if len(diff.SettingsChanged) > 0 {
+		sb.WriteString("\n设置有变化: " + strings.Join(diff.SettingsChanged, ", ") + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n恢复: /snapshot restore %s %s", info.Domain, info.ID))
+	return sb.String()
+}
+
+// handleSnapshotRestore 的 accountArg 为空时，Zone 已删除的情况下在快照所属账号重建。
+func (h *CommandHandler) handleSnapshotRestore(domain, id, accountArg string, dryRun bool) {
+	client, recorder := BeginDryRun(h.CFClient, dryRun)
+	snapper, ok := client.(cloudflareZoneSnapshotter)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持 Zone 快照。")
+		return
+	}
+	_, info, err := zoneSnapshotStore().Load(domain, id)
+	if err != nil {
+		h.sendText(fmt.Sprintf("读取快照失败: %v", err))
+		return
+	}
+
+	job := &zoneRestoreJob{
+		Domain:     info.Domain,
+		SnapshotID: info.ID,
+		client:     snapper,
+		registrar:  h.RegistrarManager,
+		sender:     h.Sender,
+		recorder:   recorder,
+	}
+	var target string
+	account, zone, err := h.findZone(info.Domain)
+	switch {
+	case err == nil && strings.EqualFold(zone.Name, info.Domain):
+		if accountArg != "" {
+			if chosen, chooseErr := h.resolveSingleAccount(accountArg); chooseErr != nil || !strings.EqualFold(chosen.Label, account.Label) {
+				h.sendText(fmt.Sprintf("%s 仍在账号 %s 中，只能恢复到现有 Zone；迁移到其他账号请使用 /move。", info.Domain, account.Label))
+				return
+			}
+		}
+		job.Account = *account
+		job.ZoneID = zone.ID
+		target = fmt.Sprintf("账号 %s 中的现有 Zone（zone_id: %s）。恢复前会先保存当前配置的快照，快照中没有的 DNS 记录会被删除。", account.Label, zone.ID)
+	case err == nil || errors.Is(err, cfclient.ErrZoneNotFound):
+		selector := info.AccountLabel
+		if accountArg != "" {
+			selector = accountArg
+		}
+		origin, err := h.resolveSingleAccount(selector)
+		if err != nil {
+			if accountArg == "" {
+				h.sendText(fmt.Sprintf("%s 当前不存在，且快照所属账号 %s 已不在配置中，可在命令末尾指定重建账号：/snapshot restore %s %s <账号>", info.Domain, info.AccountLabel, info.Domain, info.ID))
+				return
+			}
+			h.sendText(err.Error() + "\n\n" + h.accountSelectorHelp())
+			return
+		}
+		if _, ok := h.CFClient.(cloudflareZoneCreator); !ok {
+			h.sendText("当前 Cloudflare 客户端不支持创建 Zone。")
+			return
+		}
+		job.Account = *origin
+		target = fmt.Sprintf("Zone 已不存在，将在账号 %s 重新创建后回放，并尝试同步注册商 NS。", origin.Label)
+	default:
+		h.sendText(fmt.Sprintf("查找 %s 失败: %v", info.Domain, err))
+		return
+	}
+
+	token := setZoneRestoreJob(job)
+	msg := fmt.Sprintf("%s【快照恢复确认】\n域名: %s\n快照: %s（%s，DNS %d 条，规则 %d 条，设置 %d 项）\n目标: %s\n确认按钮 %s 内有效，过期需重新执行 /snapshot restore。",
+		dryRunPrefix(recorder), info.Domain, info.ID, info.TakenAt.Local().Format("2006-01-02 15:04"), info.DNSRecords, info.Rules, info.Settings, target, zoneRestoreJobTTL)
+	buttons := [][]Button{{
+		{Text: "确认恢复", CallbackData: "snapshot_restore|" + token},
+		{Text: "取消", CallbackData: "snapshot_cancel|" + token},
+	}}
+	if err := h.Sender.SendWithButtons(context.Background(), msg, buttons); err != nil {
+		h.sendText(fmt.Sprintf("发送恢复确认失败: %v", err))
+	}
+}
+
+func setZoneRestoreJob(job *zoneRestoreJob) string {
+	token := newInteractionToken()
+	now := time.Now()
+	if job.createdAt.IsZero() {
+		job.createdAt = now
+	}
+	zoneRestoreState.mu.Lock()
+	defer zoneRestoreState.mu.Unlock()
+	pruneZoneRestoreJobsLocked(now)
+	zoneRestoreState.jobs[token] = job
+	return token
+}
+
+// takeZoneRestoreJob 取出并删除未过期的恢复任务，重复确认只有第一次生效。
+func takeZoneRestoreJob(token string) (*zoneRestoreJob, bool) {
+	zoneRestoreState.mu.Lock()
+	defer zoneRestoreState.mu.Unlock()
+	pruneZoneRestoreJobsLocked(time.Now())
+	job, ok := zoneRestoreState.jobs[token]
+	if ok {
+		delete(zoneRestoreState.jobs, token)
+	}
+	return job, ok
+}
+
+func pruneZoneRestoreJobsLocked(now time.Time) {
+	for token, job := range zoneRestoreState.jobs {
+		if now.Sub(job.createdAt) > zoneRestoreJobTTL {
+			delete(zoneRestoreState.jobs, token)
+		}
+	}
+}
+
+// CancelZoneRestore 放弃尚未确认的快照恢复。
+func CancelZoneRestore(token string) bool {
+	_, ok := takeZoneRestoreJob(token)
+	return ok
+}
+
+// ConfirmZoneRestore 把快照回放到目标 Zone；Zone 不存在时先重新创建。
+func ConfirmZoneRestore(token string, operator string) {
+	job, ok := takeZoneRestoreJob(token)
+	if !ok {
+		SendTelegramAlert(fmt.Sprintf("快照恢复确认已过期（有效期 %s）或已处理，未做任何修改，请重新执行 /snapshot restore。", zoneRestoreJobTTL))
+		return
+	}
+	ctx := context.Background()
+	send := func(msg string) {
+		if job.sender == nil {
+			SendTelegramAlert(msg)
+			return
+		}
+		if err := job.sender.Send(ctx, msg); err != nil {
+			log.Printf("发送快照恢复消息失败: %v", err)
+		}
+	}
+
+	store := zoneSnapshotStore()
+	snap, _, err := store.Load(job.Domain, job.SnapshotID)
+	if err != nil {
+		send(fmt.Sprintf("❌ 恢复中止：读取快照失败: %v", err))
+		return
+	}
+
+	var notes []string
+	zoneID := job.ZoneID
+	if zoneID != "" && job.recorder != nil {
+		notes = append(notes, "dry-run：未保存恢复前快照")
+	} else if zoneID != "" {
+		// 保存恢复前快照会触发按份数清理，正在恢复的快照不能被清理掉。
+		before, err := TakeZoneSnapshot(ctx, job.client, job.Account, cfclient.ZoneDetail{ID: zoneID, Name: job.Domain}, "pre-restore", job.SnapshotID)
+		if err != nil {
+			send(fmt.Sprintf("❌ 恢复中止：恢复前快照失败，未做任何修改: %v", err))
+			return
+		}
+		notes = append(notes, fmt.Sprintf("恢复前快照: %s", before.ID))
+	} else {
+		creator, ok := job.client.(cloudflareZoneCreator)
+		if !ok {
+			send("❌ 恢复中止：当前 Cloudflare 客户端不支持创建 Zone。")
+			return
+		}
+		zone, err := creator.CreateZone(ctx, job.Account, job.Domain)
+		if err != nil {
+			send(fmt.Sprintf("❌ 恢复中止：在账号 %s 重新创建 Zone 失败: %v", job.Account.Label, err))
+			return
+		}
+		zoneID = zone.ID
+		notes = append(notes, fmt.Sprintf("已重新创建 Zone: %s，NS: %s", zone.ID, strings.Join(zone.NameServers, ", ")))
+		if job.recorder != nil {
+			notes = append(notes, "dry-run：未同步注册商 NS")
+		} else if job.registrar != nil && len(zone.NameServers) > 0 {
+			if registrar, err := job.registrar.SetNameServersForDomain(ctx, job.Domain, zone.NameServers); err != nil {
+				notes = append(notes, fmt.Sprintf("注册商 NS 同步失败: %v", err))
+			} else {
+				notes = append(notes, fmt.Sprintf("注册商 NS 已同步到 %s (%s)", registrar.Label, registrar.Type))
+			}
+		} else {
+			notes = append(notes, "未配置注册商，需手动修改 NS")
+		}
+		if rt := reminder.DefaultRuntime(); rt != nil && job.recorder == nil {
+			rt.RecordDomainChange(ctx, reminder.DomainChange{Domain: job.Domain, Source: job.Account.Label, IsCF: true, ZoneID: zone.ID, Status: zone.Status})
+		}
+	}
+
+	result, err := job.client.ApplyZoneSnapshot(ctx, job.Account, zoneID, snap, cfclient.ZoneApplyOptions{PruneDNS: true})
+	if err != nil {
+		result.Failed = append(result.Failed, err.Error())
+	}
+	summary := BuildZoneRestoreSummary(job.Domain, job.SnapshotID, job.Account.Label, operator, result, notes)
+	if job.recorder != nil {
+		FinishDryRun(ctx, job.sender, job.recorder, fmt.Sprintf("snapshot restore %s %s", job.Domain, job.SnapshotID), summary)
+		return
+	}
+	send(summary)
+}
+
+// BuildZoneRestoreSummary 渲染快照恢复结果。
+func BuildZoneRestoreSummary(domain, snapshotID, accountLabel, operator string, result cfclient.ZoneApplyResult, notes []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【快照恢复】%s\n快照: %s\n账号: %s\n操作人: %s\n", domain, snapshotID, accountLabel, operator))
+	for _, note := range notes {
+		sb.WriteString(note + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("DNS: 新建 %d，更新 %d，删除 %d，未变 %d\n", result.DNSCreated, result.DNSUpdated, result.DNSDeleted, result.DNSUnchanged))
+	if len(result.RulesetsApplied) > 0 {
+		sb.WriteString("规则集: " + strings.Join(result.RulesetsApplied, ", ") + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("设置: 写入 %d 项\n", len(result.SettingsApplied)))
+	if len(result.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n失败 %d 项:\n", len(result.Failed)))
+		for _, item := range result.Failed {
+			sb.WriteString("- " + item + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// snapshotZoneBeforeDelete 在删除前找到域名所在账号并保存快照；域名不在任何账号中时返回空 ID。
+func snapshotZoneBeforeDelete(ctx context.Context, client cfclient.Client, accounts []config.CF, domain string) (string, error) {
+	snapper, ok := client.(cloudflareZoneSnapshotter)
+	if !ok {
+		return "", nil
+	}
+	for i := range accounts {
+		zone, err := client.GetZoneDetails(ctx, accounts[i], domain)
+		if err != nil {
+			if errors.Is(err, cfclient.ErrZoneNotFound) {
+				continue
+			}
+			return "", err
+		}
+		info, err := TakeZoneSnapshot(ctx, snapper, accounts[i], zone, "pre-delete")
+		if err != nil {
+			return "", err
+		}
+		return info.ID, nil
+	}
+	return "", nil
+}
+
+func snapshotUsage() string {
+	return "用法:\n/snapshot list <domain>\n/snapshot diff <domain> <id|latest>\n/snapshot restore <domain> <id|latest> [账号] [dryrun]\n" +
+		"Zone 已删除时默认在快照所属账号重建，也可以指定一个账号标签或只命中一个账号的选择器。\n快照包含 DNS 记录、防火墙/缓存/限速规则集和关键设置；/delete 删除前会自动保存快照。"
+}
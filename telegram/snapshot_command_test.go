@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+type countingZoneRestorer struct {
+	*fakeZoneMover
+	applied atomic.Int32
+}
+
+func (f *countingZoneRestorer) ApplyZoneSnapshot(ctx context.Context, account config.CF, zoneID string, snap cfclient.ZoneSnapshot, opts cfclient.ZoneApplyOptions) (cfclient.ZoneApplyResult, error) {
+	f.applied.Add(1)
+	return cfclient.ZoneApplyResult{}, nil
+}
+
+func TestConfirmZoneRestoreKeepsSourceSnapshotWhenPruning(t *testing.T) {
+	prev := *config.Cfg()
+	t.Cleanup(func() { config.Set(prev) })
+	next := prev
+	next.ZoneSnapshot = config.ZoneSnapshot{Dir: t.TempDir(), MaxPerZone: 2}
+	config.Set(next)
+
+	store := zoneSnapshotStore()
+	now := time.Now().UTC()
+	source, err := store.Save(cfclient.ZoneSnapshot{Domain: "example.com", TakenAt: now.Add(-2 * time.Hour), Reason: "scheduled"})
+	if err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if _, err := store.Save(cfclient.ZoneSnapshot{Domain: "example.com", TakenAt: now.Add(-time.Hour), Reason: "scheduled"}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	client := &countingZoneRestorer{fakeZoneMover: &fakeZoneMover{}}
+	sender := &recordingSender{}
+	token := setZoneRestoreJob(&zoneRestoreJob{
+		Domain:     "example.com",
+		SnapshotID: source.ID,
+		Account:    config.CF{Label: "main"},
+		ZoneID:     "zone-1",
+		client:     client,
+		sender:     sender,
+	})
+	ConfirmZoneRestore(token, "@ops")
+
+	if client.applied.Load() != 1 {
+		t.Fatalf("expected snapshot to be applied once, got %d", client.applied.Load())
+	}
+	if !strings.Contains(sender.text(), "恢复前快照") {
+		t.Fatalf("expected pre-restore snapshot in summary, got %q", sender.text())
+	}
+	if _, _, err := store.Load("example.com", source.ID); err != nil {
+		t.Fatalf("expected restored snapshot to survive pruning: %v", err)
+	}
+}
+
+func TestZoneRestoreConfirmationExpires(t *testing.T) {
+	client := &countingZoneRestorer{fakeZoneMover: &fakeZoneMover{}}
+	sender := &recordingSender{}
+	token := setZoneRestoreJob(&zoneRestoreJob{
+		Domain:     "example.com",
+		SnapshotID: "20260101-000000",
+		Account:    config.CF{Label: "main"},
+		ZoneID:     "zone-1",
+		client:     client,
+		sender:     sender,
+		createdAt:  time.Now().Add(-zoneRestoreJobTTL - time.Minute),
+	})
+
+	if _, ok := takeZoneRestoreJob(token); ok {
+		t.Fatalf("expected expired restore confirmation to be rejected")
+	}
+	if client.applied.Load() != 0 || sender.text() != "" {
+		t.Fatalf("expected nothing to run for an expired confirmation, applied=%d sent=%q", client.applied.Load(), sender.text())
+	}
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadJSONStateFile 读取本地 JSON 状态文件；文件不存在或为空时保持 out 不变并返回 nil。
+func loadJSONStateFile(path string, out any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("读取状态文件 %s 失败: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析状态文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// saveJSONStateFile 先写临时文件再重命名，避免进程中断时留下半截文件。
+func saveJSONStateFile(path string, value any) error {
+	dir := filepath.Dir(path)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建状态目录 %s 失败: %w", dir, err)
+		}
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态文件 %s 失败: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入状态文件 %s 失败: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("替换状态文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"DomainC/reminder"
+)
+
+func (h *CommandHandler) handleTagCommand(args []string) {
+	rt := reminder.DefaultRuntime()
+	if rt == nil || rt.Store() == nil {
+		h.sendText("资产缓存未初始化，无法管理 Zone 标签。")
+		return
+	}
+	store := rt.Store()
+	if len(args) == 0 {
+		h.sendText(tagUsage())
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "add", "remove", "rm", "del":
+		if len(args) < 3 {
+			h.sendText(tagUsage())
+			return
+		}
+		var add, remove []string
+		if strings.EqualFold(args[0], "add") {
+			add = args[2:]
+		} else {
+			remove = args[2:]
+		}
+		rec, err := store.SetDomainTags(args[1], add, remove)
+		if err != nil {
+			h.sendText(fmt.Sprintf("更新标签失败: %v", err))
+			return
+		}
+		tags := "无"
+		if len(rec.Tags) > 0 {
+			tags = strings.Join(rec.Tags, ", ")
+		}
+		h.sendText(fmt.Sprintf("✅ %s 的标签: %s", rec.Domain, tags))
+	case "list", "ls":
+		if len(args) >= 2 {
+			records, err := store.ListByTag(args[1])
+			if err != nil {
+				h.sendText(fmt.Sprintf("读取资产缓存失败: %v", err))
+				return
+			}
+			h.sendText(BuildTagDomainList(args[1], records))
+			return
+		}
+		records, err := store.ListActive()
+		if err != nil {
+			h.sendText(fmt.Sprintf("读取资产缓存失败: %v", err))
+			return
+		}
+		h.sendText(BuildTagSummary(records))
+	default:
+		h.sendText(tagUsage())
+	}
+}
+
+// BuildTagDomainList 列出带有指定标签的域名及其所属账号。
+func BuildTagDomainList(tag string, records []reminder.Record) string {
+	if len(records) == 0 {
+		return fmt.Sprintf("没有域名带有标签 %s。", tag)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Domain < records[j].Domain })
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("标签 %s 共 %d 个域名：\n", strings.ToLower(strings.TrimSpace(tag)), len(records)))
+	for _, rec := range records {
+		owners := make([]string, 0, len(rec.Accounts))
+		for _, owner := range reminder.RecordAccounts(rec) {
+			owners = append(owners, reminder.NormalizeSource(owner.Source))
+		}
+		line := "- " + rec.Domain
+		if len(owners) > 0 {
+			line += "（" + strings.Join(owners, ", ") + "）"
+		}
+		sb.WriteString(line + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// BuildTagSummary 统计资产缓存中每个标签下的域名数量。
+func BuildTagSummary(records []reminder.Record) string {
+	counts := make(map[string]int)
+	for _, rec := range records {
+		for _, tag := range rec.Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		return "资产缓存中还没有 Zone 标签，可用 /tag add <域名> <标签...> 添加。"
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	var sb strings.Builder
+	sb.WriteString("Zone 标签：\n")
+	for _, tag := range tags {
+		sb.WriteString(fmt.Sprintf("- %s: %d 个域名\n", tag, counts[tag]))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func tagUsage() string {
+	return "用法:\n" +
+		"/tag add <域名> <标签...> —— 给 Zone 添加标签\n" +
+		"/tag remove <域名> <标签...> —— 移除 Zone 标签\n" +
+		"/tag list [标签] —— 查看标签统计或某个标签下的域名\n" +
+		"批量命令可用 tag:<标签> 只处理带该标签的域名，例如 /csv tag:brand。"
+}
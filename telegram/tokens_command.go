@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+func (h *CommandHandler) handleTokensCommand(args []string) {
+	if len(args) < 1 || !strings.EqualFold(args[0], "check") {
+		h.sendText(tokensUsage())
+		return
+	}
+	checker, ok := h.CFClient.(cfclient.TokenChecker)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持 token 权限检查。")
+		return
+	}
+	accounts := h.Accounts
+	if len(args) >= 2 {
+		targets, err := h.resolveAccountTargets(args[1])
+		if err != nil {
+			h.sendText(err.Error())
+			return
+		}
+		accounts = TargetAccounts(targets)
+	}
+	if len(accounts) == 0 {
+		h.sendText("未配置 Cloudflare 账号。")
+		return
+	}
+
+	h.sendText(fmt.Sprintf("正在检查 %d 个账号的 API token 权限...", len(accounts)))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	results := CheckTokens(ctx, checker, accounts)
+	h.sendText(BuildTokenCheckReport(results, time.Now(), config.TokenExpiryWarnDays()))
+}
+
+// CheckTokens 依次检查各账号的 token；单个账号失败不影响其他账号。
+func CheckTokens(ctx context.Context, checker cfclient.TokenChecker, accounts []config.CF) []cfclient.TokenCheckResult {
+	results := make([]cfclient.TokenCheckResult, 0, len(accounts))
+	for _, account := range accounts {
+		result, err := checker.CheckToken(ctx, account)
+		if err != nil {
+			result = cfclient.TokenCheckResult{AccountLabel: account.Label, VerifyError: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// TokenCheckNeedsAttention 判断结果中是否存在无效 token、权限缺失、探测失败或即将过期。
+func TokenCheckNeedsAttention(result cfclient.TokenCheckResult, now time.Time, warnDays int) bool {
+	if !result.Valid() || tokenExpiresSoon(result, now, warnDays) {
+		return true
+	}
+	for _, probe := range result.Probes {
+		if probe.Status == cfclient.TokenProbeDenied || probe.Status == cfclient.TokenProbeError {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenExpiresSoon(result cfclient.TokenCheckResult, now time.Time, warnDays int) bool {
+	return !result.ExpiresOn.IsZero() && result.ExpiresOn.Before(now.AddDate(0, 0, warnDays))
+}
+
+// BuildTokenCheckReport 渲染每个账号的能力矩阵，并列出因权限缺失而无法使用的命令。
+func BuildTokenCheckReport(results []cfclient.TokenCheckResult, now time.Time, warnDays int) string {
+	var sb strings.Builder
+	sb.WriteString("【Cloudflare Token 权限检查】\n")
+	for _, result := range results {
+		sb.WriteString("\n")
+		sb.WriteString(buildTokenCheckSection(result, now, warnDays))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func buildTokenCheckSection(result cfclient.TokenCheckResult, now time.Time, warnDays int) string {
+	var sb strings.Builder
+	if result.VerifyError != "" {
+		sb.WriteString(fmt.Sprintf("❌ 账号 %s: token 校验失败: %s\n", result.AccountLabel, truncateDisplay(result.VerifyError, 200)))
+		return sb.String()
+	}
+	if !result.Valid() {
+		sb.WriteString(fmt.Sprintf("❌ 账号 %s: token 状态为 %s，所有命令都无法使用\n", result.AccountLabel, result.TokenStatus))
+		return sb.String()
+	}
+
+	expiry := "永不过期"
+	if !result.ExpiresOn.IsZero() {
+		days := int(result.ExpiresOn.Sub(now).Hours() / 24)
+		expiry = fmt.Sprintf("%s 到期（剩 %d 天）", result.ExpiresOn.Local().Format("2006-01-02"), days)
+		if tokenExpiresSoon(result, now, warnDays) {
+			expiry = "⚠️ " + expiry + "，请尽快轮换"
+		}
+	}
+	sb.WriteString(fmt.Sprintf("🔑 账号 %s: active，%s\n", result.AccountLabel, expiry))
+	if result.ProbeZone != "" {
+		sb.WriteString(fmt.Sprintf("探测 Zone: %s\n", result.ProbeZone))
+	}
+
+	unavailable := make(map[string]bool)
+	for _, probe := range result.Probes {
+		icon := "✅"
+		note := ""
+		switch probe.Status {
+		case cfclient.TokenProbeDenied:
+			icon, note = "❌", "无权限"
+		case cfclient.TokenProbeError:
+			icon, note = "⚠️", "探测失败: "+truncateDisplay(probe.Detail, 120)
+		case cfclient.TokenProbeSkipped:
+			icon, note = "➖", "跳过: "+probe.Detail
+		}
+		line := fmt.Sprintf("%s %s", icon, probe.Capability.Name)
+		if note != "" {
+			line += "（" + note + "）"
+		}
+		sb.WriteString(line + "\n")
+		if probe.Status == cfclient.TokenProbeDenied {
+			for _, cmd := range probe.Capability.Commands {
+				unavailable[cmd] = true
+			}
+		}
+	}
+	if len(unavailable) > 0 {
+		cmds := make([]string, 0, len(unavailable))
+		for cmd := range unavailable {
+			cmds = append(cmds, cmd)
+		}
+		sort.Strings(cmds)
+		sb.WriteString("不可用或部分不可用: " + strings.Join(cmds, ", ") + "\n")
+	}
+	return sb.String()
+}
+
+// RunStartupTokenCheck 在启动时检查全部账号，只在有问题时发送 Telegram 提醒，结果都写日志。
+// accounts 在检查开始时读取，取得 leader 租约前发生的热加载同样生效。
+func RunStartupTokenCheck(ctx context.Context, client cfclient.Client, accounts func() []config.CF, sender Sender, warnDays int) {
+	checker, ok := client.(cfclient.TokenChecker)
+	if !ok {
+		return
+	}
+	targets := accounts()
+	if len(targets) == 0 {
+		return
+	}
+	now := time.Now()
+	var flagged []cfclient.TokenCheckResult
+	for _, result := range CheckTokens(ctx, checker, targets) {
+		if !TokenCheckNeedsAttention(result, now, warnDays) {
+			log.Printf("Token 权限检查通过: account=%s", result.AccountLabel)
+			continue
+		}
+		log.Printf("Token 权限检查发现问题: account=%s status=%s verify_error=%s", result.AccountLabel, result.TokenStatus, result.VerifyError)
+		flagged = append(flagged, result)
+	}
+	if len(flagged) == 0 || sender == nil {
+		return
+	}
+	if err := sender.Send(ctx, BuildTokenCheckReport(flagged, now, warnDays)); err != nil {
+		log.Printf("发送 token 权限检查结果失败: %v", err)
+	}
+}
+
+func tokensUsage() string {
+	return "用法: /tokens check [账号选择器]\n" +
+		"调用 Cloudflare token verify 接口，并用只读请求探测 Zone、DNS、规则集、列表、SSL、滥用报告、RUM 等权限，列出每个账号可用的命令。"
+}
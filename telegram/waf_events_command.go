@@ -0,0 +1,475 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+)
+
+const (
+	wafEventsDefaultHours    = 24
+	wafEventsMaxHours        = 168
+	wafEventsTopLimit        = 10
+	wafEventsBlockButtons    = 5
+	wafEventsPerZoneInterval = time.Second
+)
+
+type cloudflareWAFEventsReader interface {
+	ListZones(ctx context.Context, account config.CF) ([]cfclient.ZoneDetail, error)
+	FetchWAFEvents(ctx context.Context, account config.CF, zoneID string, since, until time.Time, limit int) (cfclient.WAFEventsReport, error)
+}
+
+type cloudflareWAFEventsBlocker interface {
+	EnsureIPBlockRule(ctx context.Context, account config.CF, zoneID string, values []string) (string, error)
+	EnsureASNBlockRule(ctx context.Context, account config.CF, zoneID string, asns []uint32) (string, error)
+}
+
+// WAFEventsDigest 是一个或多个 Zone 的 WAF 事件汇总。
+type WAFEventsDigest struct {
+	AccountLabel string
+	Title        string
+	Hours        int
+	Zones        []cfclient.WAFEventsReport
+	Total        int
+	TopIPs       []cfclient.WAFEventCount
+	TopASNs      []cfclient.WAFEventCount
+	TopCountries []cfclient.WAFEventCount
+	TopPaths     []cfclient.WAFEventCount
+	Rules        []cfclient.WAFRuleHit
+	Failed       []string
+}
+
+// WAFEventsCallbackPayload 是“一键封禁”按钮的回调参数，ZoneIDs 为报表覆盖的 Zone。
+type WAFEventsCallbackPayload struct {
+	AccountLabel string
+	ZoneIDs      []string
+	ZoneNames    []string
+	Kind         string
+	Value        string
+}
+
+var wafEventsState = struct {
+	mu        sync.Mutex
+	callbacks map[string]WAFEventsCallbackPayload
+}{
+	callbacks: make(map[string]WAFEventsCallbackPayload),
+}
+
+func SetWAFEventsCallbackPayload(payload WAFEventsCallbackPayload) string {
+	token := newInteractionToken()
+	wafEventsState.mu.Lock()
+	defer wafEventsState.mu.Unlock()
+	wafEventsState.callbacks[token] = payload
+	return token
+}
+
+func GetWAFEventsCallbackPayload(token string) (WAFEventsCallbackPayload, bool) {
+	wafEventsState.mu.Lock()
+	defer wafEventsState.mu.Unlock()
+	payload, ok := wafEventsState.callbacks[token]
+	return payload, ok
+}
+
+func (h *CommandHandler) handleWAFEventsCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText(wafEventsUsage())
+		return
+	}
+	reader, ok := h.CFClient.(cloudflareWAFEventsReader)
+	if !ok {
+		h.sendText("当前 Cloudflare 客户端不支持查询 WAF 事件。")
+		return
+	}
+	hours := wafEventsDefaultHours
+	if len(args) >= 2 {
+		parsed, err := parseWAFEventsHours(args[1])
+		if err != nil {
+			h.sendText(err.Error() + "\n\n" + wafEventsUsage())
+			return
+		}
+		hours = parsed
+	}
+
+	target := strings.TrimSpace(args[0])
+	var targets []AccountTarget
+	var zones []cfclient.ZoneDetail
+	title := ""
+	if config.IsMultiAccountSelector(target) || h.getAccountByLabel(target) != nil {
+		resolved, err := h.resolveAccountTargets(target)
+		if err != nil {
+			h.sendText(err.Error() + "\n\n" + h.accountSelectorHelp())
+			return
+		}
+		targets = resolved
+		title = DescribeTargets(targets)
+	} else {
+		domain, err := extractDomainOrHost(target)
+		if err != nil {
+			h.sendText(fmt.Sprintf("未找到账号或域名 %s: %v", target, err))
+			return
+		}
+		acc, zone, err := h.findZone(domain)
+		if err != nil {
+			h.sendText(fmt.Sprintf("未找到账号或域名 %s: %v", target, err))
+			return
+		}
+		targets = []AccountTarget{{Account: *acc}}
+		zones = []cfclient.ZoneDetail{zone}
+		title = zone.Name
+	}
+
+	h.sendText(fmt.Sprintf("正在汇总 %s 最近 %d 小时的 WAF 事件，请稍候。", title, hours))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	// 选择器命中多个账号时每个账号单独发送一份报表。
+	for _, t := range targets {
+		account := t.Account
+		accountZones := zones
+		accountTitle := title
+		if accountZones == nil {
+			listed, err := reader.ListZones(ctx, account)
+			if err != nil {
+				h.sendText(fmt.Sprintf("读取账号 %s 域名失败: %v", account.Label, err))
+				continue
+			}
+			accountZones = t.FilterZones(listed)
+			accountTitle = "账号 " + account.Label + " 全部域名"
+			if len(t.Domains) > 0 {
+				accountTitle = fmt.Sprintf("账号 %s 中 %d 个域名", account.Label, len(accountZones))
+			}
+		}
+		digest := CollectWAFEvents(ctx, reader, account, accountZones, hours, time.Now())
+		digest.Title = accountTitle
+		page := BuildWAFEventsReport(digest)
+		if err := h.Sender.SendWithButtons(context.Background(), page.Message, page.Buttons); err != nil {
+			h.sendText(fmt.Sprintf("发送 WAF 事件报表失败: %v", err))
+		}
+	}
+}
+
+func parseWAFEventsHours(raw string) (int, error) {
+	value := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(raw)), "h")
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours < 1 || hours > wafEventsMaxHours {
+		return 0, fmt.Errorf("小时数无效: %s（范围 1-%d）", raw, wafEventsMaxHours)
+	}
+	return hours, nil
+}
+
+// CollectWAFEvents 逐个 Zone 查询 WAF 事件并合并为一份汇总，单个 Zone 失败不影响其他 Zone。
+func CollectWAFEvents(ctx context.Context, reader cloudflareWAFEventsReader, account config.CF, zones []cfclient.ZoneDetail, hours int, now time.Time) WAFEventsDigest {
+	digest := WAFEventsDigest{AccountLabel: account.Label, Hours: hours}
+	until := now.UTC()
+	since := until.Add(-time.Duration(hours) * time.Hour)
+	pacer := newBatchAPIPacerWithInterval(wafEventsPerZoneInterval)
+	for _, zone := range zones {
+		zoneID := strings.TrimSpace(zone.ID)
+		if zoneID == "" {
+			digest.Failed = append(digest.Failed, zone.Name+": 缺少 zone_id")
+			continue
+		}
+		if err := pacer.Wait(ctx); err != nil {
+			digest.Failed = append(digest.Failed, zone.Name+": 等待执行失败: "+err.Error())
+			continue
+		}
+		report, err := reader.FetchWAFEvents(ctx, account, zoneID, since, until, wafEventsTopLimit)
+		if err != nil {
+			digest.Failed = append(digest.Failed, zone.Name+": "+err.Error())
+			continue
+		}
+		report.ZoneName = zone.Name
+		digest.Zones = append(digest.Zones, report)
+	}
+	mergeWAFEventsDigest(&digest)
+	return digest
+}
+
+func mergeWAFEventsDigest(digest *WAFEventsDigest) {
+	ips := map[string]cfclient.WAFEventCount{}
+	asns := map[string]cfclient.WAFEventCount{}
+	countries := map[string]cfclient.WAFEventCount{}
+	paths := map[string]cfclient.WAFEventCount{}
+	rules := map[string]cfclient.WAFRuleHit{}
+	add := func(dst map[string]cfclient.WAFEventCount, items []cfclient.WAFEventCount) {
+		for _, item := range items {
+			current := dst[item.Value]
+			current.Value = item.Value
+			if current.Label == "" {
+				current.Label = item.Label
+			}
+			current.Count += item.Count
+			dst[item.Value] = current
+		}
+	}
+	for _, zone := range digest.Zones {
+		digest.Total += zone.Total
+		add(ips, zone.TopIPs)
+		add(asns, zone.TopASNs)
+		add(countries, zone.TopCountries)
+		add(paths, zone.TopPaths)
+		for _, hit := range zone.Rules {
+			key := hit.Description + "|" + hit.Action
+			if hit.Description == "" {
+				key = hit.RuleID + "|" + hit.Action
+			}
+			current := rules[key]
+			count := current.Count + hit.Count
+			current = hit
+			current.Count = count
+			rules[key] = current
+		}
+	}
+	digest.TopIPs = topWAFEventCounts(ips)
+	digest.TopASNs = topWAFEventCounts(asns)
+	digest.TopCountries = topWAFEventCounts(countries)
+	digest.TopPaths = topWAFEventCounts(paths)
+	digest.Rules = digest.Rules[:0]
+	for _, hit := range rules {
+		digest.Rules = append(digest.Rules, hit)
+	}
+	sort.Slice(digest.Rules, func(i, j int) bool {
+		if digest.Rules[i].Count != digest.Rules[j].Count {
+			return digest.Rules[i].Count > digest.Rules[j].Count
+		}
+		return digest.Rules[i].Description < digest.Rules[j].Description
+	})
+	if len(digest.Rules) > wafEventsTopLimit {
+		digest.Rules = digest.Rules[:wafEventsTopLimit]
+	}
+	sort.Strings(digest.Failed)
+}
+
+func topWAFEventCounts(items map[string]cfclient.WAFEventCount) []cfclient.WAFEventCount {
+	out := make([]cfclient.WAFEventCount, 0, len(items))
+	for _, item := range items {
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	if len(out) > wafEventsTopLimit {
+		out = out[:wafEventsTopLimit]
+	}
+	return out
+}
+
+// BuildWAFEventsReport 渲染 WAF 事件汇总，并为 Top IP/ASN 生成一键封禁按钮。
+func BuildWAFEventsReport(digest WAFEventsDigest) IPListPage {
+	var sb strings.Builder
+	title := digest.Title
+	if title == "" {
+		title = "账号 " + digest.AccountLabel
+	}
+	sb.WriteString(fmt.Sprintf("【WAF 事件汇总】\n范围: %s\n账号: %s\n时间窗口: 最近 %d 小时\n拦截/质询请求: %d\n", title, digest.AccountLabel, digest.Hours, digest.Total))
+
+	if len(digest.Zones) > 1 {
+		zones := append([]cfclient.WAFEventsReport(nil), digest.Zones...)
+		sort.SliceStable(zones, func(i, j int) bool { return zones[i].Total > zones[j].Total })
+		sb.WriteString("\n域名 Top:\n")
+		shown := 0
+		for _, zone := range zones {
+			if zone.Total == 0 || shown >= wafEventsTopLimit {
+				break
+			}
+			shown++
+			sb.WriteString(fmt.Sprintf("%d. %s: %d\n", shown, zone.ZoneName, zone.Total))
+		}
+		if shown == 0 {
+			sb.WriteString("无\n")
+		}
+	}
+
+	writeWAFEventCounts(&sb, "IP Top", digest.TopIPs, "")
+	writeWAFEventCounts(&sb, "ASN Top", digest.TopASNs, "AS")
+	writeWAFEventCounts(&sb, "国家/地区 Top", digest.TopCountries, "")
+	writeWAFEventCounts(&sb, "路径 Top", digest.TopPaths, "")
+
+	sb.WriteString("\n规则命中:\n")
+	if len(digest.Rules) == 0 {
+		sb.WriteString("无\n")
+	}
+	for i, hit := range digest.Rules {
+		name := hit.Description
+		if name == "" {
+			name = hit.RuleID
+		}
+		if name == "" {
+			name = hit.Source
+		}
+		owner := ""
+		if hit.Owner != "" {
+			owner = "【本工具: " + hit.Owner + "】"
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s%s | %s | %s: %d\n", i+1, owner, truncateDisplay(name, 60), hit.Source, hit.Action, hit.Count))
+	}
+
+	if len(digest.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n查询失败 %d 个:\n", len(digest.Failed)))
+		limit := len(digest.Failed)
+		if limit > cfIPBlockMaxFailureLines {
+			limit = cfIPBlockMaxFailureLines
+		}
+		for _, item := range digest.Failed[:limit] {
+			sb.WriteString("- " + item + "\n")
+		}
+		if len(digest.Failed) > limit {
+			sb.WriteString(fmt.Sprintf("... 另有 %d 个失败未展示\n", len(digest.Failed)-limit))
+		}
+	}
+
+	var zoneIDs, zoneNames []string
+	for _, zone := range digest.Zones {
+		zoneIDs = append(zoneIDs, zone.ZoneID)
+		zoneNames = append(zoneNames, zone.ZoneName)
+	}
+	var buttons [][]Button
+	addButtons := func(kind string, items []cfclient.WAFEventCount, text func(string) string) {
+		var row []Button
+		for i, item := range items {
+			if i >= wafEventsBlockButtons {
+				break
+			}
+			token := SetWAFEventsCallbackPayload(WAFEventsCallbackPayload{
+				AccountLabel: digest.AccountLabel,
+				ZoneIDs:      zoneIDs,
+				ZoneNames:    zoneNames,
+				Kind:         kind,
+				Value:        item.Value,
+			})
+			row = append(row, Button{Text: text(item.Value), CallbackData: fmt.Sprintf("wafevents_block|%s", token)})
+			if len(row) == 2 {
+				buttons = append(buttons, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			buttons = append(buttons, row)
+		}
+	}
+	if len(zoneIDs) > 0 {
+		addButtons("ip", digest.TopIPs, func(v string) string { return "封禁 IP " + v })
+		addButtons("asn", digest.TopASNs, func(v string) string { return "封禁 AS" + v })
+		if len(buttons) > 0 {
+			sb.WriteString(fmt.Sprintf("\n点击下方按钮可把 IP/ASN 加入 %d 个域名的封禁规则。", len(zoneIDs)))
+		}
+	}
+	return IPListPage{Message: strings.TrimRight(sb.String(), "\n"), Buttons: buttons}
+}
+
+func writeWAFEventCounts(sb *strings.Builder, title string, items []cfclient.WAFEventCount, prefix string) {
+	sb.WriteString("\n" + title + ":\n")
+	if len(items) == 0 {
+		sb.WriteString("无\n")
+		return
+	}
+	for i, item := range items {
+		line := fmt.Sprintf("%d. %s%s", i+1, prefix, truncateDisplay(item.Value, 80))
+		if item.Label != "" {
+			line += " (" + truncateDisplay(item.Label, 40) + ")"
+		}
+		sb.WriteString(fmt.Sprintf("%s: %d\n", line, item.Count))
+	}
+}
+
+// ApplyWAFEventsBlock 执行一键封禁：IP 写入 telegram-auto-block-ips，ASN 写入 telegram-auto-block-asn。
+func ApplyWAFEventsBlock(ctx context.Context, client cfclient.Client, account config.CF, payload WAFEventsCallbackPayload, operator string) string {
+	blocker, ok := client.(cloudflareWAFEventsBlocker)
+	if !ok {
+		return "当前 Cloudflare 客户端不支持一键封禁。"
+	}
+	var asn uint32
+	display := payload.Value
+	switch payload.Kind {
+	case "ip":
+		if _, err := cfclient.NormalizeIPAccessRuleValues([]string{payload.Value}); err != nil {
+			return fmt.Sprintf("IP 无效: %v", err)
+		}
+	case "asn":
+		parsed, err := cfclient.NormalizeCustomListASN(payload.Value)
+		if err != nil {
+			return fmt.Sprintf("ASN 无效: %v", err)
+		}
+		asn = parsed
+		display = "AS" + strconv.FormatUint(uint64(asn), 10)
+	default:
+		return fmt.Sprintf("不支持的封禁类型: %s", payload.Kind)
+	}
+
+	var succeeded, failed []string
+	pacer := newBatchAPIPacerWithInterval(cfIPBlockPerAccountInterval)
+	for i, zoneID := range payload.ZoneIDs {
+		name := zoneID
+		if i < len(payload.ZoneNames) && payload.ZoneNames[i] != "" {
+			name = payload.ZoneNames[i]
+		}
+		if err := pacer.Wait(ctx); err != nil {
+			failed = append(failed, name+": 等待执行失败: "+err.Error())
+			continue
+		}
+		var status string
+		var err error
+		if payload.Kind == "ip" {
+			status, err = blocker.EnsureIPBlockRule(ctx, account, zoneID, []string{payload.Value})
+		} else {
+			status, err = blocker.EnsureASNBlockRule(ctx, account, zoneID, []uint32{asn})
+		}
+		if err != nil {
+			failed = append(failed, name+": "+err.Error())
+			continue
+		}
+		succeeded = append(succeeded, name+": "+status)
+	}
+	log.Printf("WAF 事件一键封禁: account=%s kind=%s value=%s zones=%d failed=%d operator=%s", account.Label, payload.Kind, payload.Value, len(payload.ZoneIDs), len(failed), operator)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【WAF 一键封禁】\n账号: %s\n对象: %s\n操作人: %s\n成功: %d\n失败: %d\n", account.Label, display, operator, len(succeeded), len(failed)))
+	for _, item := range failed {
+		sb.WriteString("- " + item + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// SendWAFEventsDigests 为每个账号发送一份 WAF 事件汇总，没有拦截事件的账号跳过。
+func SendWAFEventsDigests(ctx context.Context, client cfclient.Client, accounts []config.CF, sender Sender, hours int) {
+	reader, ok := client.(cloudflareWAFEventsReader)
+	if !ok {
+		log.Printf("当前 Cloudflare 客户端不支持查询 WAF 事件，跳过每日汇总")
+		return
+	}
+	for _, account := range accounts {
+		if strings.TrimSpace(account.Label) == "" {
+			continue
+		}
+		zones, err := reader.ListZones(ctx, account)
+		if err != nil {
+			log.Printf("WAF 事件汇总读取账号 %s 域名失败: %v", account.Label, err)
+			continue
+		}
+		digest := CollectWAFEvents(ctx, reader, account, zones, hours, time.Now())
+		if digest.Total == 0 && len(digest.Failed) == 0 {
+			continue
+		}
+		page := BuildWAFEventsReport(digest)
+		if err := sender.SendWithButtons(ctx, page.Message, page.Buttons); err != nil {
+			log.Printf("发送账号 %s WAF 事件汇总失败: %v", account.Label, err)
+		}
+	}
+}
+
+func wafEventsUsage() string {
+	return "用法: /waf_events <账号选择器|域名> [小时数]\n" +
+		"示例:\n/waf_events main\n/waf_events group:prod 12\n/waf_events example.com 6\n" +
+		"账号选择器支持 all、账号标签、group:分组、tag:标签，命中多个账号时每个账号单独发送报表。\n" +
+		fmt.Sprintf("小时数默认 %d，范围 1-%d；按钮可把 Top IP/ASN 一键加入封禁规则。", wafEventsDefaultHours, wafEventsMaxHours)
+}
@@ -0,0 +1,214 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	webhookSecretHeader  = "X-Telegram-Bot-Api-Secret-Token"
+	webhookMaxUpdateSize = 1 << 20
+	// webhookQueueSize 是已确认但尚未处理的更新上限，队列满时返回 503 让 Telegram 稍后重发。
+	webhookQueueSize = 256
+	// webhookSeenUpdates 是用于去重的最近 update_id 数量。
+	webhookSeenUpdates = 1024
+)
+
+// startWebhook 注册 webhook 并启动内置 HTTP(S) 服务，直到 ctx 结束或服务异常退出。
+func (s *BotSender) startWebhook(ctx context.Context, handleCallback func(cb *tgbotapi.CallbackQuery), handleMessage func(msg *tgbotapi.Message)) error {
+	cfg := config.Cfg().Telegram.Webhook
+	path, err := webhookPath(cfg.URL)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(cfg.SecretToken) == "" {
+		// 没有 secret 时任何能访问监听地址的人都能伪造更新（包括审批和删除回调），拒绝启动 webhook。
+		return errors.New("telegram.webhook.secretToken 未配置，拒绝以 webhook 模式启动")
+	}
+
+	listener, err := net.Listen("tcp", config.TelegramWebhookListenAddr())
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", config.TelegramWebhookListenAddr(), err)
+	}
+	if err := s.registerWebhook(cfg); err != nil {
+		listener.Close()
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, newWebhookHandler(ctx, cfg.SecretToken, func(up tgbotapi.Update) {
+		dispatchUpdate(up, handleCallback, handleMessage, func(id string) {
+			_ = s.AnswerCallback(ctx, id, "操作已收到")
+		})
+	}))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Telegram webhook 模式已启动: listen=%s path=%s", listener.Addr(), path)
+	if strings.TrimSpace(cfg.CertFile) != "" && strings.TrimSpace(cfg.KeyFile) != "" {
+		err = server.ServeTLS(listener, cfg.CertFile, cfg.KeyFile)
+	} else {
+		err = server.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+	return fmt.Errorf("webhook 服务退出: %w", err)
+}
+
+// registerWebhook 调用 setWebhook；当前 SDK 的 WebhookConfig 不支持 secret_token，这里直接拼参数。
+func (s *BotSender) registerWebhook(cfg config.TelegramWebhook) error {
+	params := tgbotapi.Params{"url": strings.TrimSpace(cfg.URL)}
+	params.AddNonEmpty("secret_token", strings.TrimSpace(cfg.SecretToken))
+	params.AddNonZero("max_connections", cfg.MaxConnections)
+	params["allowed_updates"] = `["message","callback_query"]`
+
+	var resp *tgbotapi.APIResponse
+	var err error
+	if cfg.UploadCert && strings.TrimSpace(cfg.CertFile) != "" {
+		resp, err = s.bot.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{{
+			Name: "certificate",
+			Data: tgbotapi.FilePath(cfg.CertFile),
+		}})
+	} else {
+		resp, err = s.bot.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("注册 webhook 失败: %w", err)
+	}
+	if resp != nil && !resp.Ok {
+		return fmt.Errorf("注册 webhook 失败: %s", resp.Description)
+	}
+	return nil
+}
+
+// newWebhookHandler 校验 secret token 后解析 Update，放入队列后立即返回 200，由单个 goroutine 按顺序交给 dispatch，
+// 避免慢命令超过 Telegram 的 webhook 超时而被重发。Telegram 重发的同一 update_id 只处理一次。
+// 非 2xx 会让 Telegram 重试；secret 为空时拒绝所有请求。
+func newWebhookHandler(ctx context.Context, secret string, dispatch func(up tgbotapi.Update)) http.Handler {
+	secret = strings.TrimSpace(secret)
+	queue := newWebhookQueue()
+	go queue.run(ctx, dispatch)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if secret == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookSecretHeader)), []byte(secret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(r.Body, webhookMaxUpdateSize))
+		if err != nil {
+			http.Error(w, "read body failed", http.StatusBadRequest)
+			return
+		}
+		var up tgbotapi.Update
+		if err := json.Unmarshal(data, &up); err != nil {
+			// 格式错误的请求重试也无意义，直接丢弃。
+			log.Printf("忽略无法解析的 webhook 更新: %v", err)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		switch err := queue.enqueue(up); {
+		case errors.Is(err, errWebhookDuplicate):
+			log.Printf("忽略重复的 webhook 更新: update_id=%d", up.UpdateID)
+		case err != nil:
+			log.Printf("webhook 更新队列已满，稍后由 Telegram 重发: update_id=%d", up.UpdateID)
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+var (
+	errWebhookDuplicate = errors.New("重复的 webhook 更新")
+	errWebhookQueueFull = errors.New("webhook 更新队列已满")
+)
+
+// webhookQueue 保存已确认的更新，并记住最近的 update_id 用于去重。
+type webhookQueue struct {
+	updates chan tgbotapi.Update
+
+	mu    sync.Mutex
+	seen  map[int]struct{}
+	order []int
+}
+
+func newWebhookQueue() *webhookQueue {
+	return &webhookQueue{
+		updates: make(chan tgbotapi.Update, webhookQueueSize),
+		seen:    make(map[int]struct{}, webhookSeenUpdates),
+	}
+}
+
+func (q *webhookQueue) enqueue(up tgbotapi.Update) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.seen[up.UpdateID]; ok {
+		return errWebhookDuplicate
+	}
+	select {
+	case q.updates <- up:
+	default:
+		// 未入队的更新不记为已处理，Telegram 重发时仍可接收。
+		return errWebhookQueueFull
+	}
+	q.seen[up.UpdateID] = struct{}{}
+	q.order = append(q.order, up.UpdateID)
+	if len(q.order) > webhookSeenUpdates {
+		delete(q.seen, q.order[0])
+		q.order = q.order[1:]
+	}
+	return nil
+}
+
+func (q *webhookQueue) run(ctx context.Context, dispatch func(up tgbotapi.Update)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case up := <-q.updates:
+			dispatch(up)
+		}
+	}
+}
+
+func webhookPath(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("telegram.webhook.url 无效: %w", err)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("telegram.webhook.url 必须是 https 地址: %s", rawURL)
+	}
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func postWebhookUpdate(t *testing.T, server *httptest.Server, secret, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(webhookSecretHeader, secret)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("post update: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookHandlerDispatchesSyntheticUpdates(t *testing.T) {
+	var mu sync.Mutex
+	var messages []string
+	var callbacks []string
+	var answered []string
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := newWebhookHandler(ctx, "s3cret", func(up tgbotapi.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		dispatchUpdate(up,
+			func(cb *tgbotapi.CallbackQuery) { callbacks = append(callbacks, cb.Data) },
+			func(msg *tgbotapi.Message) { messages = append(messages, msg.Text) },
+			func(id string) { answered = append(answered, id) },
+		)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	post := func(secret, body string) int {
+		t.Helper()
+		return postWebhookUpdate(t, server, secret, body)
+	}
+
+	message := `{"update_id":1,"message":{"message_id":10,"text":"/dns example.com","chat":{"id":-100,"type":"group"},"from":{"id":7,"is_bot":false,"first_name":"a"}}}`
+	if status := post("wrong", message); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad secret, got %d", status)
+	}
+	if status := post("", message); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for missing secret, got %d", status)
+	}
+	mu.Lock()
+	unauthenticated := len(messages)
+	mu.Unlock()
+	if unauthenticated != 0 {
+		t.Fatalf("unauthenticated update must not be dispatched: %v", messages)
+	}
+
+	if status := post("s3cret", message); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	callback := `{"update_id":2,"callback_query":{"id":"cb1","data":"approval_approve|abc","from":{"id":8,"is_bot":false,"first_name":"b"}}}`
+	if status := post("s3cret", callback); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if status := post("s3cret", "not json"); status != http.StatusOK {
+		t.Fatalf("malformed update should be acknowledged, got %d", status)
+	}
+
+	waitFor(t, "dispatch", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(answered) == 1 && len(messages) == 1
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 1 || messages[0] != "/dns example.com" {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+	if len(callbacks) != 1 || callbacks[0] != "approval_approve|abc" {
+		t.Fatalf("unexpected callbacks: %v", callbacks)
+	}
+	if len(answered) != 1 || answered[0] != "cb1" {
+		t.Fatalf("expected callback to be answered: %v", answered)
+	}
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebhookHandlerRejectsAllRequestsWithoutSecret(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var dispatched atomic.Int32
+	handler := newWebhookHandler(ctx, "  ", func(up tgbotapi.Update) { dispatched.Add(1) })
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body := `{"update_id":1,"callback_query":{"id":"cb1","data":"deletecmd_confirm|abc","from":{"id":8,"is_bot":false,"first_name":"b"}}}`
+	for _, header := range []string{"", "anything"} {
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("build request: %v", err)
+		}
+		if header != "" {
+			req.Header.Set(webhookSecretHeader, header)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("post update: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected 401 with header %q, got %d", header, resp.StatusCode)
+		}
+	}
+	if n := dispatched.Load(); n != 0 {
+		t.Fatalf("no update may be dispatched without a configured secret, got %d", n)
+	}
+}
+
+func TestWebhookHandlerAcknowledgesBeforeDispatchAndDropsRedelivery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	var dispatched sync.Map
+	var count atomic.Int32
+	handler := newWebhookHandler(ctx, "s3cret", func(up tgbotapi.Update) {
+		count.Add(1)
+		dispatched.Store(up.UpdateID, true)
+		<-release
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	slow := `{"update_id":42,"message":{"message_id":10,"text":"/move example.com a b","chat":{"id":-100,"type":"group"},"from":{"id":7,"is_bot":false,"first_name":"a"}}}`
+	// 处理被阻塞时也应立即确认，Telegram 超时重发的同一 update_id 不会再次分发。
+	for i := 0; i < 3; i++ {
+		if status := postWebhookUpdate(t, server, "s3cret", slow); status != http.StatusOK {
+			t.Fatalf("delivery %d: expected 200, got %d", i+1, status)
+		}
+	}
+	next := strings.Replace(slow, `"update_id":42`, `"update_id":43`, 1)
+	if status := postWebhookUpdate(t, server, "s3cret", next); status != http.StatusOK {
+		t.Fatalf("expected 200 for next update, got %d", status)
+	}
+	close(release)
+	waitFor(t, "queued update", func() bool {
+		_, ok := dispatched.Load(43)
+		return ok
+	})
+	if n := count.Load(); n != 2 {
+		t.Fatalf("expected each update_id to be dispatched once, got %d dispatches", n)
+	}
+}
+
+func TestWebhookPathRequiresHTTPS(t *testing.T) {
+	if _, err := webhookPath("http://bot.example.com/hook"); err == nil {
+		t.Fatalf("expected error for http url")
+	}
+	path, err := webhookPath("https://bot.example.com/telegram/webhook")
+	if err != nil || path != "/telegram/webhook" {
+		t.Fatalf("unexpected path=%q err=%v", path, err)
+	}
+}